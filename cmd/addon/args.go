@@ -2,15 +2,22 @@ package addon
 
 var (
 	addonFlags = flags{
-		Values:       map[string]string{},
-		RemoveValues: []string{},
+		Values:          map[string]string{},
+		RemoveValues:    []string{},
+		Dependencies:    []string{},
+		ReadinessChecks: []string{},
 	}
 )
 
 type flags struct {
-	Name         string
-	Description  string
-	FromFile     string
-	Values       map[string]string
-	RemoveValues []string
+	Name            string
+	Description     string
+	FromFile        string
+	Values          map[string]string
+	RemoveValues    []string
+	Cluster         string
+	ValuesSchema    string
+	Output          string
+	Dependencies    []string
+	ReadinessChecks []string
 }
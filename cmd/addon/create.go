@@ -23,6 +23,9 @@ func init() {
 	createCmd.Flags().StringVar(&addonFlags.Description, "description", addonFlags.Description, "The description of add-on")
 	createCmd.Flags().StringVarP(&addonFlags.FromFile, "from", "f", addonFlags.FromFile, "The manifest file path of add-on")
 	createCmd.Flags().StringToStringVar(&addonFlags.Values, "set", addonFlags.Values, "Set value to replace parameters defined in manifest")
+	createCmd.Flags().StringVar(&addonFlags.ValuesSchema, "values-schema", addonFlags.ValuesSchema, "The JSON schema file path of add-on values")
+	createCmd.Flags().StringArrayVar(&addonFlags.Dependencies, "depends-on", addonFlags.Dependencies, "The add-ons which must be deployed and ready before this add-on, e.g. --depends-on cert-manager")
+	createCmd.Flags().StringArrayVar(&addonFlags.ReadinessChecks, "readiness-check", addonFlags.ReadinessChecks, "The resource to wait for after deploying the add-on with format <kind>/<namespace>/<name> or crd/<name>, the kind can be deployment, daemonset, statefulset, job and crd")
 }
 
 func CreateCmd() *cobra.Command {
@@ -37,6 +40,9 @@ func CreateCmd() *cobra.Command {
 		if addonFlags.FromFile != "" && !utils.IsFileExists(addonFlags.FromFile) {
 			return fmt.Errorf("manifest file %s is not exist", addonFlags.FromFile)
 		}
+		if addonFlags.ValuesSchema != "" && !utils.IsFileExists(addonFlags.ValuesSchema) {
+			return fmt.Errorf("values schema file %s is not exist", addonFlags.ValuesSchema)
+		}
 		return nil
 	}
 
@@ -46,11 +52,27 @@ func CreateCmd() *cobra.Command {
 		if err != nil {
 			logrus.Fatalln(err)
 		}
+		var schema []byte
+		if addonFlags.ValuesSchema != "" {
+			if schema, err = os.ReadFile(addonFlags.ValuesSchema); err != nil {
+				logrus.Fatalln(err)
+			}
+			if err := common.ValidateValuesSchema(schema); err != nil {
+				logrus.Fatalln(err)
+			}
+		}
+		checks, err := parseReadinessChecks(addonFlags.ReadinessChecks)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
 		addon := &common.Addon{
-			Name:        name,
-			Description: addonFlags.Description,
-			Manifest:    []byte(manifest),
-			Values:      addonFlags.Values,
+			Name:            name,
+			Description:     addonFlags.Description,
+			Manifest:        []byte(manifest),
+			Values:          addonFlags.Values,
+			ValuesSchema:    schema,
+			Dependencies:    addonFlags.Dependencies,
+			ReadinessChecks: checks,
 		}
 		if err := common.DefaultDB.SaveAddon(addon); err != nil {
 			logrus.Fatalln(err)
@@ -60,3 +82,15 @@ func CreateCmd() *cobra.Command {
 
 	return createCmd
 }
+
+func parseReadinessChecks(values []string) (common.ReadinessChecks, error) {
+	checks := common.ReadinessChecks{}
+	for _, value := range values {
+		check, err := common.ParseReadinessCheck(value)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
@@ -11,7 +11,7 @@ import (
 
 var (
 	getCmd = &cobra.Command{
-		Use:   "get <name>",
+		Use:   "get <name|repo/name[@version]>",
 		Short: "Get an add-on information.",
 		Args:  cobra.ExactArgs(1),
 	}
@@ -20,7 +20,7 @@ var (
 func GetCmd() *cobra.Command {
 	getCmd.Run = func(_ *cobra.Command, args []string) {
 		name := args[0]
-		addon, err := common.DefaultDB.GetAddon(name)
+		addon, version, err := common.DefaultDB.GetAddonByRef(name)
 		if err != nil {
 			logrus.Fatalln(err)
 		}
@@ -30,6 +30,18 @@ func GetCmd() *cobra.Command {
 			"Manifest":    string(addon.Manifest),
 			"Values":      addon.Values,
 		}
+		if version != "" {
+			addonMap["Version"] = version
+		}
+		if len(addon.Dependencies) > 0 {
+			addonMap["Dependencies"] = addon.Dependencies
+		}
+		if len(addon.ReadinessChecks) > 0 {
+			addonMap["ReadinessChecks"] = addon.ReadinessChecks
+		}
+		if len(addon.ValuesSchema) > 0 {
+			addonMap["ValuesSchema"] = string(addon.ValuesSchema)
+		}
 		data, _ := yaml.Marshal(addonMap)
 		fmt.Println(string(data))
 	}
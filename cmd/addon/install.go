@@ -0,0 +1,66 @@
+package addon
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	installCmd = &cobra.Command{
+		Use:   "install <name|repo/name[@version]>",
+		Short: "Install an add-on to an existing K3s cluster",
+		Args:  cobra.ExactArgs(1),
+	}
+)
+
+func init() {
+	installCmd.Flags().StringVar(&addonFlags.Cluster, "cluster", addonFlags.Cluster, "The context name of the cluster to install add-on")
+	installCmd.Flags().StringToStringVar(&addonFlags.Values, "set", addonFlags.Values, "Set value to replace parameters defined in manifest")
+}
+
+func InstallCmd() *cobra.Command {
+	installCmd.PreRunE = validateCluster
+	installCmd.Run = func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if _, _, err := common.DefaultDB.GetAddonByRef(name); err != nil {
+			logrus.Fatalf("failed to get add-on %s: %v", name, err)
+		}
+		provider, clusterName, err := getClusterProvider(addonFlags.Cluster)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		if err := provider.InstallAddon(clusterName, name, addonFlags.Values); err != nil {
+			logrus.Fatalf("failed to install add-on %s to cluster %s: %v", name, addonFlags.Cluster, err)
+		}
+		cmd.Printf("add-on %s installed to cluster %s\n", name, addonFlags.Cluster)
+	}
+
+	return installCmd
+}
+
+func validateCluster(_ *cobra.Command, _ []string) error {
+	if addonFlags.Cluster == "" {
+		return fmt.Errorf("`--cluster` must set to specify the context name of cluster")
+	}
+	return nil
+}
+
+// getClusterProvider returns the provider and the cluster name of the cluster context.
+func getClusterProvider(contextName string) (providers.Provider, string, error) {
+	state, err := common.DefaultDB.GetClusterByID(contextName)
+	if err != nil {
+		return nil, "", err
+	}
+	if state == nil {
+		return nil, "", fmt.Errorf("cluster %s is not found", contextName)
+	}
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		return nil, "", err
+	}
+	return provider, state.Name, nil
+}
@@ -38,6 +38,18 @@ func ListCmd() *cobra.Command {
 				strconv.Itoa(len(addon.Values)),
 			})
 		}
+		// the catalog add-ons are listed with the reference which can be used by --enable.
+		catalogAddons, err := common.DefaultDB.ListCatalogAddons("")
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		for _, addon := range catalogAddons {
+			table.Append([]string{
+				addon.GetID(),
+				addon.Description,
+				strconv.Itoa(len(addon.Values)),
+			})
+		}
 
 		table.Render()
 	}
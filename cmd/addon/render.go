@@ -0,0 +1,52 @@
+package addon
+
+import (
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderCmd = &cobra.Command{
+		Use:   "render <name|repo/name[@version]>",
+		Short: "Render the manifest of an add-on locally to preview it",
+		Long:  "Render the manifest of an add-on with the values, the values are validated with the values schema of the add-on. The providerTemplate values are rendered as <metaName> placeholders as they depend on the cluster nodes",
+		Args:  cobra.ExactArgs(1),
+	}
+)
+
+func init() {
+	renderCmd.Flags().StringToStringVar(&addonFlags.Values, "set", addonFlags.Values, "Set value to replace parameters defined in manifest")
+	renderCmd.Flags().StringVarP(&addonFlags.Output, "output", "o", addonFlags.Output, "The file path to write the rendered manifest, print to stdout if not set")
+}
+
+func RenderCmd() *cobra.Command {
+	renderCmd.Run = func(_ *cobra.Command, args []string) {
+		name := args[0]
+		addon, _, err := common.DefaultDB.GetAddonByRef(name)
+		if err != nil {
+			logrus.Fatalf("failed to get add-on %s: %v", name, err)
+		}
+		manifest, err := common.RenderAddon(addon, addonFlags.Values, template.FuncMap{
+			"providerTemplate": func(metaName string) string {
+				return fmt.Sprintf("<%s>", metaName)
+			},
+		})
+		if err != nil {
+			logrus.Fatalf("failed to render add-on %s: %v", name, err)
+		}
+		if addonFlags.Output == "" {
+			fmt.Print(string(manifest))
+			return
+		}
+		if err := os.WriteFile(addonFlags.Output, manifest, 0644); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return renderCmd
+}
@@ -0,0 +1,150 @@
+package addon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	pkgaddon "github.com/cnrancher/autok3s/pkg/addon"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repoCmd = &cobra.Command{
+		Use:   "repo",
+		Short: "Manage add-on repos",
+		Long:  "The add-on repo is a catalog of versioned add-ons from a git repository or a http server, the add-ons can be enabled by <repo>/<name>[@version]",
+	}
+	repoAddCmd = &cobra.Command{
+		Use:   "add <name> <url>",
+		Short: "Add an add-on repo and sync the add-ons of it",
+		Args:  cobra.ExactArgs(2),
+	}
+	repoUpdateCmd = &cobra.Command{
+		Use:   "update [name]",
+		Short: "Sync the add-ons of the add-on repo, all the repos are synced if name is not set",
+		Args:  cobra.MaximumNArgs(1),
+	}
+	repoListCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all add-on repos",
+	}
+	repoRemoveCmd = &cobra.Command{
+		Use:   "rm <name>",
+		Short: "Remove an add-on repo and the add-ons of it",
+		Args:  cobra.ExactArgs(1),
+	}
+
+	repoFlags = struct {
+		Type   string
+		Branch string
+	}{}
+)
+
+func init() {
+	repoAddCmd.Flags().StringVar(&repoFlags.Type, "type", repoFlags.Type, "The type of add-on repo, git or http, detected by url if not set")
+	repoAddCmd.Flags().StringVar(&repoFlags.Branch, "branch", repoFlags.Branch, "The branch or tag of git repo, the default branch is used if not set")
+}
+
+func RepoCmd() *cobra.Command {
+	repoAddCmd.Run = func(cmd *cobra.Command, args []string) {
+		if repoFlags.Type != "" && repoFlags.Type != common.AddonRepoTypeGit && repoFlags.Type != common.AddonRepoTypeHTTP {
+			logrus.Fatalf("add-on repo type %s is not supported, only git and http are supported", repoFlags.Type)
+		}
+		repo := &common.AddonRepo{
+			Name:   args[0],
+			URL:    args[1],
+			Type:   repoFlags.Type,
+			Branch: repoFlags.Branch,
+		}
+		count, err := pkgaddon.AddRepo(repo)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		cmd.Printf("add-on repo %s added with %d add-ons\n", repo.Name, count)
+	}
+
+	repoUpdateCmd.Run = func(cmd *cobra.Command, args []string) {
+		var repos []*common.AddonRepo
+		if len(args) > 0 {
+			repo, err := common.DefaultDB.GetAddonRepo(args[0])
+			if err != nil {
+				logrus.Fatalln(err)
+			}
+			if repo == nil {
+				logrus.Fatalf("add-on repo %s is not found", args[0])
+			}
+			repos = append(repos, repo)
+		} else {
+			var err error
+			if repos, err = common.DefaultDB.ListAddonRepos(); err != nil {
+				logrus.Fatalln(err)
+			}
+		}
+		failed := false
+		for _, repo := range repos {
+			count, err := pkgaddon.SyncRepo(repo)
+			if err != nil {
+				logrus.Errorln(err)
+				failed = true
+				continue
+			}
+			cmd.Printf("add-on repo %s synced with %d add-ons\n", repo.Name, count)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	}
+
+	repoListCmd.Run = func(_ *cobra.Command, _ []string) {
+		repos, err := common.DefaultDB.ListAddonRepos()
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetColumnSeparator("")
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetHeader([]string{"Name", "Type", "URL", "Add-ons", "Last Synced"})
+		for _, repo := range repos {
+			addons, err := common.DefaultDB.ListCatalogAddons(repo.Name)
+			if err != nil {
+				logrus.Fatalln(err)
+			}
+			table.Append([]string{
+				repo.Name,
+				repo.Type,
+				repo.URL,
+				strconv.Itoa(len(addons)),
+				repo.LastSynced.Format("2006-01-02 15:04:05"),
+			})
+		}
+		table.Render()
+	}
+
+	repoRemoveCmd.Run = func(_ *cobra.Command, args []string) {
+		name := args[0]
+		repo, err := common.DefaultDB.GetAddonRepo(name)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		if repo == nil {
+			logrus.Fatalf("add-on repo %s is not found", name)
+		}
+		if utils.AskForConfirmation(fmt.Sprintf("are you going to remove the add-on repo %s", name), false) {
+			if err := common.DefaultDB.DeleteAddonRepo(name); err != nil {
+				logrus.Fatalln(err)
+			}
+		}
+	}
+
+	repoCmd.AddCommand(repoAddCmd, repoUpdateCmd, repoListCmd, repoRemoveCmd)
+	return repoCmd
+}
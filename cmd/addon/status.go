@@ -0,0 +1,60 @@
+package addon
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusCmd = &cobra.Command{
+		Use:   "status [name]",
+		Short: "Show the status of installed add-ons of K3s cluster",
+		Args:  cobra.MaximumNArgs(1),
+	}
+)
+
+func init() {
+	statusCmd.Flags().StringVar(&addonFlags.Cluster, "cluster", addonFlags.Cluster, "The context name of the cluster")
+}
+
+func StatusCmd() *cobra.Command {
+	statusCmd.PreRunE = validateCluster
+	statusCmd.Run = func(_ *cobra.Command, args []string) {
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		provider, clusterName, err := getClusterProvider(addonFlags.Cluster)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		addons, err := provider.GetAddonStatus(clusterName, name)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetColumnSeparator("")
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetHeader([]string{"Name", "Version", "Status", "Readiness", "Values", "Updated"})
+		for _, addon := range addons {
+			table.Append([]string{
+				addon.Name,
+				addon.Version,
+				addon.Status,
+				addon.Readiness,
+				strconv.Itoa(len(addon.Values)),
+				addon.UpdatedAt.Format("2006-01-02 15:04:05"),
+			})
+		}
+		table.Render()
+	}
+
+	return statusCmd
+}
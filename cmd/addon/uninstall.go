@@ -0,0 +1,43 @@
+package addon
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	uninstallCmd = &cobra.Command{
+		Use:   "uninstall <name>",
+		Short: "Uninstall an add-on from K3s cluster, the resources deployed by the add-on will be removed",
+		Args:  cobra.ExactArgs(1),
+	}
+	uninstallForce = false
+)
+
+func init() {
+	uninstallCmd.Flags().StringVar(&addonFlags.Cluster, "cluster", addonFlags.Cluster, "The context name of the cluster to uninstall add-on")
+	uninstallCmd.Flags().BoolVarP(&uninstallForce, "force", "f", uninstallForce, "Force uninstall without confirmation")
+}
+
+func UninstallCmd() *cobra.Command {
+	uninstallCmd.PreRunE = validateCluster
+	uninstallCmd.Run = func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if !uninstallForce && !utils.AskForConfirmation(fmt.Sprintf("are you going to uninstall the add-on %s from cluster %s", name, addonFlags.Cluster), false) {
+			return
+		}
+		provider, clusterName, err := getClusterProvider(addonFlags.Cluster)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		if err := provider.UninstallAddon(clusterName, name); err != nil {
+			logrus.Fatalf("failed to uninstall add-on %s from cluster %s: %v", name, addonFlags.Cluster, err)
+		}
+		cmd.Printf("add-on %s uninstalled from cluster %s\n", name, addonFlags.Cluster)
+	}
+
+	return uninstallCmd
+}
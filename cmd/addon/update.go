@@ -24,6 +24,9 @@ func init() {
 	updateCmd.Flags().StringVar(&addonFlags.Description, "description", addonFlags.Description, "The description of add-on")
 	updateCmd.Flags().StringVarP(&addonFlags.FromFile, "from", "f", addonFlags.FromFile, "The manifest file path of add-on")
 	updateCmd.Flags().StringToStringVar(&addonFlags.Values, "set", addonFlags.Values, "Set value to replace parameters defined in manifest")
+	updateCmd.Flags().StringVar(&addonFlags.ValuesSchema, "values-schema", addonFlags.ValuesSchema, "The JSON schema file path of add-on values")
+	updateCmd.Flags().StringArrayVar(&addonFlags.Dependencies, "depends-on", addonFlags.Dependencies, "The add-ons which must be deployed and ready before this add-on, e.g. --depends-on cert-manager")
+	updateCmd.Flags().StringArrayVar(&addonFlags.ReadinessChecks, "readiness-check", addonFlags.ReadinessChecks, "The resource to wait for after deploying the add-on with format <kind>/<namespace>/<name> or crd/<name>, the kind can be deployment, daemonset, statefulset, job and crd")
 	updateCmd.Flags().StringArrayVar(&addonFlags.RemoveValues, "unset", addonFlags.RemoveValues, "The values of the add-on to unset, will ignore if the value is not present in the list")
 }
 
@@ -32,6 +35,9 @@ func UpdateCmd() *cobra.Command {
 		if addonFlags.FromFile != "" && !utils.IsFileExists(addonFlags.FromFile) {
 			return fmt.Errorf("manifest file %s is not exist", addonFlags.FromFile)
 		}
+		if addonFlags.ValuesSchema != "" && !utils.IsFileExists(addonFlags.ValuesSchema) {
+			return fmt.Errorf("values schema file %s is not exist", addonFlags.ValuesSchema)
+		}
 		return nil
 	}
 	updateCmd.Run = func(cmd *cobra.Command, args []string) {
@@ -45,10 +51,13 @@ func UpdateCmd() *cobra.Command {
 		}
 
 		newAddon := &common.Addon{
-			Name:        addon.Name,
-			Description: addon.Description,
-			Manifest:    addon.Manifest,
-			Values:      map[string]string{},
+			Name:            addon.Name,
+			Description:     addon.Description,
+			Manifest:        addon.Manifest,
+			Values:          map[string]string{},
+			ValuesSchema:    addon.ValuesSchema,
+			Dependencies:    addon.Dependencies,
+			ReadinessChecks: addon.ReadinessChecks,
 		}
 		for k, v := range addon.Values {
 			newAddon.Values[k] = v
@@ -63,6 +72,32 @@ func UpdateCmd() *cobra.Command {
 			newAddon.Manifest = []byte(manifestString)
 		}
 
+		if addonFlags.ValuesSchema != "" {
+			schema, err := os.ReadFile(addonFlags.ValuesSchema)
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+			if err := common.ValidateValuesSchema(schema); err != nil {
+				logrus.Error(err)
+				return
+			}
+			newAddon.ValuesSchema = schema
+		}
+
+		// the dependencies and readiness checks are replaced if set.
+		if len(addonFlags.Dependencies) > 0 {
+			newAddon.Dependencies = addonFlags.Dependencies
+		}
+		if len(addonFlags.ReadinessChecks) > 0 {
+			checks, err := parseReadinessChecks(addonFlags.ReadinessChecks)
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+			newAddon.ReadinessChecks = checks
+		}
+
 		if addonFlags.Description != "" {
 			newAddon.Description = addonFlags.Description
 		}
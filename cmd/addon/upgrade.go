@@ -0,0 +1,37 @@
+package addon
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeCmd = &cobra.Command{
+		Use:   "upgrade <name>",
+		Short: "Upgrade an installed add-on of K3s cluster with the latest add-on manifest and values",
+		Args:  cobra.ExactArgs(1),
+	}
+)
+
+func init() {
+	upgradeCmd.Flags().StringVar(&addonFlags.Cluster, "cluster", addonFlags.Cluster, "The context name of the cluster to upgrade add-on")
+	upgradeCmd.Flags().StringToStringVar(&addonFlags.Values, "set", addonFlags.Values, "Set value to replace parameters defined in manifest, the values set when installing are kept")
+	upgradeCmd.Flags().StringArrayVar(&addonFlags.RemoveValues, "unset", addonFlags.RemoveValues, "The values set when installing to unset, will ignore if the value is not present in the list")
+}
+
+func UpgradeCmd() *cobra.Command {
+	upgradeCmd.PreRunE = validateCluster
+	upgradeCmd.Run = func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		provider, clusterName, err := getClusterProvider(addonFlags.Cluster)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		if err := provider.UpgradeAddon(clusterName, name, addonFlags.Values, addonFlags.RemoveValues); err != nil {
+			logrus.Fatalf("failed to upgrade add-on %s of cluster %s: %v", name, addonFlags.Cluster, err)
+		}
+		cmd.Printf("add-on %s of cluster %s upgraded\n", name, addonFlags.Cluster)
+	}
+
+	return upgradeCmd
+}
@@ -21,6 +21,7 @@ func Command() *cobra.Command {
 		importCmd,
 		exportCmd,
 		updateScriptCmd,
+		keyCmd,
 	)
 	return airgap
 }
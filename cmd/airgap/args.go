@@ -15,10 +15,13 @@ var (
 )
 
 type flags struct {
-	isForce    bool
-	isJSON     bool
-	K3sVersion string
-	Archs      []string
+	isForce     bool
+	isJSON      bool
+	clearExtras bool
+	K3sVersion  string
+	Archs       []string
+	Images      []string
+	Charts      []string
 }
 
 func getArchSelect(def []string) *survey.MultiSelect {
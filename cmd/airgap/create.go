@@ -26,6 +26,8 @@ var createCmd = &cobra.Command{
 func init() {
 	createCmd.Flags().StringVarP(&airgapFlags.K3sVersion, "k3s-version", "v", airgapFlags.K3sVersion, "The version of k3s to store airgap resources.")
 	createCmd.Flags().StringArrayVar(&airgapFlags.Archs, "arch", airgapFlags.Archs, "The archs of the k3s version. Following archs are support: "+strings.Join(pkgairgap.GetValidatedArchs(), ",")+".")
+	createCmd.Flags().StringArrayVar(&airgapFlags.Images, "image", airgapFlags.Images, "The extra images bundled in the package, e.g. the images used by add-ons.")
+	createCmd.Flags().StringArrayVar(&airgapFlags.Charts, "chart", airgapFlags.Charts, "The extra helm chart archives bundled in the package, can be a http(s) url or a local file path.")
 }
 
 func create(cmd *cobra.Command, args []string) error {
@@ -70,6 +72,8 @@ func create(cmd *cobra.Command, args []string) error {
 		Name:       name,
 		K3sVersion: airgapFlags.K3sVersion,
 		Archs:      airgapFlags.Archs,
+		Images:     airgapFlags.Images,
+		Charts:     airgapFlags.Charts,
 		State:      common.PackageOutOfSync,
 	}
 
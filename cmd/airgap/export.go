@@ -22,8 +22,13 @@ var (
 		RunE:  export,
 	}
 	errPathInvalid = errors.New("path should be an existing directory or a file with .tar.gz/tgz suffix")
+	signKeyPath    string
 )
 
+func init() {
+	exportCmd.Flags().StringVar(&signKeyPath, "sign-key", "", "The ed25519 private key path to sign the package, generate one with `autok3s airgap key create`")
+}
+
 func export(cmd *cobra.Command, args []string) error {
 	name := args[0]
 	pkgs, err := common.DefaultDB.ListPackages(&name)
@@ -64,6 +69,22 @@ func export(cmd *cobra.Command, args []string) error {
 		path = filepath.Join(path, name+".tar.gz")
 	}
 
+	if signKeyPath != "" {
+		data, err := os.ReadFile(signKeyPath)
+		if err != nil {
+			return err
+		}
+		key, err := pkgairgap.ParsePrivateKey(data)
+		if err != nil {
+			return err
+		}
+		signature, err := pkgairgap.SignPackage(targetPackage.FilePath, key)
+		if err != nil {
+			return errors.Wrap(err, "failed to sign package")
+		}
+		cmd.Printf("package %s is signed with key %s\n", name, signature.KeyID)
+	}
+
 	if err := pkgairgap.TarAndGzip(targetPackage.FilePath, path); err != nil {
 		return err
 	}
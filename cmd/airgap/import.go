@@ -53,6 +53,9 @@ func importFunc(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := pkgairgap.VerifyPackageSignature(tmpPath); err != nil {
+		return err
+	}
 
 	toSave.Name = name
 	toSave.FilePath = pkgairgap.PackagePath(name)
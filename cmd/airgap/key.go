@@ -0,0 +1,160 @@
+package airgap
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	pkgairgap "github.com/cnrancher/autok3s/pkg/airgap"
+	"github.com/cnrancher/autok3s/pkg/settings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyCmd = &cobra.Command{
+		Use:   "key",
+		Short: "Manage the signing keys and the trusted keys of airgap packages.",
+	}
+	keyCreateCmd = &cobra.Command{
+		Use:   "create <name>",
+		Short: "Generate a new ed25519 key pair to sign airgap packages, will write to <name>.key and <name>.pub under the output path.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  createKey,
+	}
+	keyTrustCmd = &cobra.Command{
+		Use:   "trust <public-key-path>",
+		Short: "Add the public key to the trusted keys, the signature of airgap packages will be verified when any key is trusted.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  trustKey,
+	}
+	keyUntrustCmd = &cobra.Command{
+		Use:   "untrust <key-id>",
+		Short: "Remove the public key from the trusted keys.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  untrustKey,
+	}
+	keyListCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the trusted keys.",
+		RunE:  listKeys,
+	}
+	keyOutputPath string
+)
+
+func init() {
+	keyCreateCmd.Flags().StringVarP(&keyOutputPath, "output", "o", ".", "The path to write key pair files")
+	keyCmd.AddCommand(keyCreateCmd, keyTrustCmd, keyUntrustCmd, keyListCmd)
+}
+
+func createKey(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	privateKeyPath := filepath.Join(keyOutputPath, name+".key")
+	publicKeyPath := filepath.Join(keyOutputPath, name+".pub")
+	for _, p := range []string{privateKeyPath, publicKeyPath} {
+		if _, err := os.Lstat(p); err == nil {
+			return fmt.Errorf("file %s exists", p)
+		}
+	}
+	privateKey, publicKey, err := pkgairgap.GenerateSigningKey()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(privateKeyPath, privateKey, 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(publicKeyPath, publicKey, 0644); err != nil {
+		return err
+	}
+	cmd.Printf("signing key written to %s and public key written to %s\n", privateKeyPath, publicKeyPath)
+	return nil
+}
+
+func trustKey(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	keys, err := pkgairgap.ParsePublicKeys(data)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("no public key found in the file")
+	}
+	trusted, err := pkgairgap.GetTrustedKeys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		found := false
+		for _, t := range trusted {
+			if t.ID == key.ID {
+				found = true
+				break
+			}
+		}
+		if found {
+			cmd.Printf("key %s is already trusted\n", key.ID)
+			continue
+		}
+		trusted = append(trusted, key)
+		cmd.Printf("key %s is trusted\n", key.ID)
+	}
+	return saveTrustedKeys(trusted)
+}
+
+func untrustKey(cmd *cobra.Command, args []string) error {
+	id := args[0]
+	trusted, err := pkgairgap.GetTrustedKeys()
+	if err != nil {
+		return err
+	}
+	rtn := make([]pkgairgap.TrustedKey, 0, len(trusted))
+	for _, key := range trusted {
+		if key.ID != id {
+			rtn = append(rtn, key)
+		}
+	}
+	if len(rtn) == len(trusted) {
+		return fmt.Errorf("key %s is not trusted", id)
+	}
+	if err := saveTrustedKeys(rtn); err != nil {
+		return err
+	}
+	cmd.Printf("key %s is removed from trusted keys\n", id)
+	return nil
+}
+
+func listKeys(_ *cobra.Command, _ []string) error {
+	trusted, err := pkgairgap.GetTrustedKeys()
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"KeyID"})
+	for _, key := range trusted {
+		table.Append([]string{key.ID})
+	}
+	table.Render()
+	return nil
+}
+
+func saveTrustedKeys(keys []pkgairgap.TrustedKey) error {
+	buff := &bytes.Buffer{}
+	for _, key := range keys {
+		data, err := pkgairgap.EncodePublicKey(key.Key)
+		if err != nil {
+			return err
+		}
+		buff.Write(data)
+	}
+	return settings.PackageTrustedKeys.Set(buff.String())
+}
@@ -2,6 +2,7 @@ package airgap
 
 import (
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -28,6 +29,9 @@ var updateCmd = &cobra.Command{
 func init() {
 	updateCmd.Flags().StringVarP(&airgapFlags.K3sVersion, "k3s-version", "v", airgapFlags.K3sVersion, "The version of k3s to store airgap resources.")
 	updateCmd.Flags().StringArrayVar(&airgapFlags.Archs, "arch", airgapFlags.Archs, "The archs of the k3s version. Following archs are support: "+strings.Join(pkgairgap.GetValidatedArchs(), ",")+".")
+	updateCmd.Flags().StringArrayVar(&airgapFlags.Images, "image", airgapFlags.Images, "The extra images bundled in the package, e.g. the images used by add-ons.")
+	updateCmd.Flags().StringArrayVar(&airgapFlags.Charts, "chart", airgapFlags.Charts, "The extra helm chart archives bundled in the package, can be a http(s) url or a local file path.")
+	updateCmd.Flags().BoolVar(&airgapFlags.clearExtras, "clear-extras", false, "Remove all the extra images and charts from the package")
 	updateCmd.Flags().BoolVarP(&airgapFlags.isForce, "force", "f", false, "Force update without comfirm and skip state check")
 }
 
@@ -43,6 +47,18 @@ func update(cmd *cobra.Command, args []string) error {
 	toUpdate := pkgs[0]
 
 	versionChanged := airgapFlags.K3sVersion != "" && airgapFlags.K3sVersion != toUpdate.K3sVersion
+	images, charts := toUpdate.Images, toUpdate.Charts
+	if airgapFlags.clearExtras {
+		images, charts = nil, nil
+	}
+	if len(airgapFlags.Images) > 0 {
+		images = airgapFlags.Images
+	}
+	if len(airgapFlags.Charts) > 0 {
+		charts = airgapFlags.Charts
+	}
+	extrasChanged := !reflect.DeepEqual([]string(images), []string(toUpdate.Images)) ||
+		!reflect.DeepEqual([]string(charts), []string(toUpdate.Charts))
 
 	if len(airgapFlags.Archs) == 0 {
 		if !utils.IsTerm() {
@@ -73,13 +89,15 @@ func update(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 	}
-	if !versionChanged && len(add) == 0 && len(del) == 0 {
+	if !versionChanged && !extrasChanged && len(add) == 0 && len(del) == 0 {
 		if toUpdate.State == common.PackageActive && !airgapFlags.isForce {
 			cmd.Println("package not changed")
 			return nil
 		}
 	} else {
 		toUpdate.Archs = airgapFlags.Archs
+		toUpdate.Images = images
+		toUpdate.Charts = charts
 		toUpdate.State = common.PackageOutOfSync
 		if versionChanged {
 			toUpdate.K3sVersion = airgapFlags.K3sVersion
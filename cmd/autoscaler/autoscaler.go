@@ -0,0 +1,99 @@
+package autoscaler
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/autoscaler"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+const addonName = "cluster-autoscaler"
+
+var (
+	autoscalerCmd = &cobra.Command{
+		Use:   "autoscaler",
+		Short: "The cluster-autoscaler management of clusters.",
+		Long: `The autoscaler command deploys cluster-autoscaler to the cluster, which scales the workers through the autoscaler
+endpoint of "autok3s serve" within the autoscale bounds of cluster policy.`,
+	}
+
+	deployCmd = &cobra.Command{
+		Use:   "deploy <cluster>",
+		Short: "Deploy cluster-autoscaler to the cluster with the autoscaler endpoint of autok3s.",
+		Long: `Deploy cluster-autoscaler to the cluster with the autoscaler endpoint of autok3s, the client certificate of the
+cluster is issued by the CA of autoscaler endpoint. The endpoint must be reachable from the cluster, and "autok3s serve"
+should run with --autoscaler-listen and --autoscaler-tls-san of the endpoint host.
+The deployed cluster-autoscaler is upgraded if it's already deployed.`,
+		Example: `  autok3s policy set myk3s.ap-southeast-2.aws --autoscale-min 1 --autoscale-max 5
+  autok3s autoscaler deploy myk3s.ap-southeast-2.aws --endpoint 203.0.113.10:8086`,
+		Args: cobra.ExactArgs(1),
+		Run:  utils.CommandExitWithoutHelpInfo(deploy),
+	}
+
+	deployFlags = struct {
+		Endpoint string
+		Values   map[string]string
+	}{
+		Values: map[string]string{},
+	}
+)
+
+func init() {
+	deployCmd.Flags().StringVar(&deployFlags.Endpoint, "endpoint", "", "The autoscaler endpoint in host:port of autok3s which is reachable from the cluster")
+	deployCmd.Flags().StringToStringVar(&deployFlags.Values, "set", deployFlags.Values, "Set value of the cluster-autoscaler add-on, e.g. Version=v1.32.1, scaleDownUnneededTime=5m")
+	_ = deployCmd.MarkFlagRequired("endpoint")
+}
+
+func Command() *cobra.Command {
+	autoscalerCmd.AddCommand(deployCmd)
+	return autoscalerCmd
+}
+
+func deploy(cmd *cobra.Command, args []string) error {
+	state, err := common.DefaultDB.GetClusterByID(args[0])
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("cluster %s is not found", args[0])
+	}
+	if state.AutoscaleMax == 0 {
+		return fmt.Errorf("autoscaling of cluster %s is not enabled, please set the bounds by `autok3s policy set %s --autoscale-max <n>`",
+			state.ContextName, state.ContextName)
+	}
+	caCert, tlsCert, tlsKey, err := autoscaler.ClientCertificate(state.ContextName)
+	if err != nil {
+		return fmt.Errorf("failed to issue client certificate of cluster %s: %v", state.ContextName, err)
+	}
+	values := map[string]string{}
+	for k, v := range deployFlags.Values {
+		values[k] = v
+	}
+	values["endpoint"] = deployFlags.Endpoint
+	values["caCert"] = string(caCert)
+	values["tlsCert"] = string(tlsCert)
+	values["tlsKey"] = string(tlsKey)
+
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		return err
+	}
+	exist, err := common.DefaultDB.GetClusterAddon(state.ContextName, addonName)
+	if err != nil {
+		return err
+	}
+	if exist != nil {
+		err = provider.UpgradeAddon(state.Name, addonName, values, nil)
+	} else {
+		err = provider.InstallAddon(state.Name, addonName, values)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to deploy cluster-autoscaler to cluster %s: %v", state.ContextName, err)
+	}
+	cmd.Printf("cluster-autoscaler is deployed to cluster %s with endpoint %s\n", state.ContextName, deployFlags.Endpoint)
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/cnrancher/autok3s/cmd/common"
 	"github.com/cnrancher/autok3s/pkg/providers"
+	pkgtemplate "github.com/cnrancher/autok3s/pkg/template"
+	"github.com/cnrancher/autok3s/pkg/types"
 	"github.com/cnrancher/autok3s/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -18,11 +20,18 @@ var (
 	}
 
 	cProvider = ""
+	cTemplate = ""
 	cp        providers.Provider
+	// cFlags are the dynamic provider flags, the pointers of them are bound to the provider.
+	cFlags []types.Flag
+	// cTemplateParams are the values of template parameters.
+	cTemplateParams = map[string]string{}
 )
 
 func init() {
 	createCmd.Flags().StringVarP(&cProvider, "provider", "p", cProvider, "Provider is a module which provides an interface for managing cloud resources")
+	createCmd.Flags().StringVar(&cTemplate, "template", cTemplate, "The name of cluster template, the metadata, ssh and options of cluster are seeded from the template and can be overridden by flags")
+	createCmd.Flags().StringToStringVar(&cTemplateParams, "template-param", cTemplateParams, "Set the value of template parameter, e.g. --template-param region=cn-hangzhou")
 }
 
 // CreateCommand create command.
@@ -36,9 +45,10 @@ func CreateCommand() *cobra.Command {
 			cp = reg
 		}
 
-		createCmd.Flags().AddFlagSet(utils.ConvertFlags(createCmd, cp.GetCredentialFlags()))
-		createCmd.Flags().AddFlagSet(utils.ConvertFlags(createCmd, cp.GetOptionFlags()))
-		createCmd.Flags().AddFlagSet(utils.ConvertFlags(createCmd, cp.GetCreateFlags()))
+		cFlags = append(cFlags, cp.GetCredentialFlags()...)
+		cFlags = append(cFlags, cp.GetOptionFlags()...)
+		cFlags = append(cFlags, cp.GetCreateFlags()...)
+		createCmd.Flags().AddFlagSet(utils.ConvertFlags(createCmd, cFlags))
 		createCmd.Example = cp.GetUsageExample("create")
 		createCmd.Use = fmt.Sprintf("create -p %s", pStr)
 	}
@@ -48,6 +58,11 @@ func CreateCommand() *cobra.Command {
 			logrus.Fatalln("required flag(s) \"--provider\" not set")
 		}
 		common.BindEnvFlags(cmd)
+		if cTemplate != "" {
+			if err := applyTemplate(cmd); err != nil {
+				return err
+			}
+		}
 		if err := common.MakeSureCredentialFlag(cmd.Flags(), cp); err != nil {
 			return err
 		}
@@ -73,3 +88,24 @@ func CreateCommand() *cobra.Command {
 
 	return createCmd
 }
+
+// applyTemplate seeds the provider with the template, the flags set from command line are kept.
+func applyTemplate(cmd *cobra.Command) error {
+	t, err := pkgtemplate.Get(cTemplate, cProvider)
+	if err != nil {
+		return err
+	}
+	var overrides []types.Flag
+	seen := map[string]bool{}
+	for _, f := range cFlags {
+		// only the first flag of the same name is bound to command line.
+		if f.P == nil || seen[f.Name] {
+			continue
+		}
+		seen[f.Name] = true
+		if cmd.Flags().Changed(f.Name) {
+			overrides = append(overrides, f)
+		}
+	}
+	return pkgtemplate.ApplyWithOverrides(cp, t, cTemplateParams, overrides)
+}
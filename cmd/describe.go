@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/cnrancher/autok3s/pkg/common"
@@ -86,6 +87,24 @@ func describeCluster() {
 		}
 		_, _ = fmt.Fprintf(out, "Status: %s\n", info.Status)
 		_, _ = fmt.Fprintf(out, "Version: %s\n", info.Version)
+		if len(info.NodePools) > 0 {
+			_, _ = fmt.Fprintf(out, "NodePools:%s\n", "")
+			for _, pool := range info.NodePools {
+				_, _ = fmt.Fprintf(out, "  - name: %s\n", pool.Name)
+				if len(pool.Options) > 0 {
+					_, _ = fmt.Fprintf(out, "    options: %v\n", map[string]string(pool.Options))
+				}
+				if len(pool.Labels) > 0 {
+					_, _ = fmt.Fprintf(out, "    labels: %s\n", strings.Join(pool.Labels, ","))
+				}
+				if len(pool.Taints) > 0 {
+					_, _ = fmt.Fprintf(out, "    taints: %s\n", strings.Join(pool.Taints, ","))
+				}
+				if pool.ExtraArgs != "" {
+					_, _ = fmt.Fprintf(out, "    extra-args: %s\n", pool.ExtraArgs)
+				}
+			}
+		}
 		_, _ = fmt.Fprintf(out, "Nodes:%s\n", "")
 		for _, node := range info.Nodes {
 			_, _ = fmt.Fprintf(out, "  - internal-ip: %s\n", node.InternalIP)
@@ -97,6 +116,12 @@ func describeCluster() {
 			_, _ = fmt.Fprintf(out, "    hostname: %s\n", node.HostName)
 			_, _ = fmt.Fprintf(out, "    container-runtime: %s\n", node.ContainerRuntimeVersion)
 			_, _ = fmt.Fprintf(out, "    version: %s\n", node.Version)
+			if node.Pool != "" {
+				_, _ = fmt.Fprintf(out, "    pool: %s\n", node.Pool)
+			}
+			if node.Zone != "" {
+				_, _ = fmt.Fprintf(out, "    zone: %s\n", node.Zone)
+			}
 		}
 	}
 	for _, e := range allErr {
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importCmd = &cobra.Command{
+		Use:   "import",
+		Short: "Import an existing K3s cluster, the K3s configurations are discovered from the nodes via SSH",
+	}
+	importProvider = ""
+	importP        providers.Provider
+)
+
+func init() {
+	importCmd.Flags().StringVarP(&importProvider, "provider", "p", importProvider, "Provider is a module which provides an interface for managing cloud resources")
+}
+
+// ImportCommand import command.
+func ImportCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			importP = reg
+		}
+		importCmd.Flags().AddFlagSet(utils.ConvertFlags(importCmd, importP.GetCredentialFlags()))
+		importCmd.Flags().AddFlagSet(utils.ConvertFlags(importCmd, importP.GetImportFlags()))
+		importCmd.Example = importP.GetUsageExample("import")
+		importCmd.Use = fmt.Sprintf("import -p %s", pStr)
+	}
+
+	importCmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		if importProvider == "" {
+			logrus.Fatalln("required flag(s) \"--provider\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		if err := common.MakeSureCredentialFlag(cmd.Flags(), importP); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	importCmd.Run = func(_ *cobra.Command, _ []string) {
+		importP.GenerateClusterName()
+		if err := importP.ImportK3sCluster(); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return importCmd
+}
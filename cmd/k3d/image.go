@@ -0,0 +1,39 @@
+package k3d
+
+import (
+	"github.com/cnrancher/autok3s/pkg/providers/k3d"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	imageCmd = &cobra.Command{
+		Use:   "image",
+		Short: "The image management of k3d clusters.",
+	}
+
+	imageImportCmd = &cobra.Command{
+		Use:   "import <cluster> <image|tarball>...",
+		Short: "Import the images from docker or the image tarballs into the nodes of k3d cluster.",
+		Example: `  autok3s k3d image import k3d-mycluster myapp:dev
+  autok3s k3d image import k3d-mycluster ./images.tar --mode tools-node`,
+		Args: cobra.MinimumNArgs(2),
+		Run:  utils.CommandExitWithoutHelpInfo(importImages),
+	}
+
+	importMode = "auto"
+)
+
+func init() {
+	imageImportCmd.Flags().StringVarP(&importMode, "mode", "m", importMode, "The import mode of k3d, one of auto, direct and tools-node")
+	imageCmd.AddCommand(imageImportCmd)
+}
+
+func importImages(cmd *cobra.Command, args []string) error {
+	if err := k3d.ImportClusterImages(args[0], args[1:], importMode); err != nil {
+		return err
+	}
+	cmd.Printf("images are imported to cluster %s\n", args[0])
+	return nil
+}
@@ -0,0 +1,23 @@
+package k3d
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	k3dCmd = &cobra.Command{
+		Use:   "k3d",
+		Short: "The local development tools of k3d clusters, e.g. image import and k3d-managed registry.",
+		Long: `The k3d command imports the locally built images into the k3d clusters, and manages the k3d-managed registries
+which can be shared by the k3d clusters, the registry is added to the registry config of cluster with --registry-use when
+creating the cluster. The k3d clusters are stopped and started by "autok3s stop" and "autok3s start".`,
+	}
+)
+
+func Command() *cobra.Command {
+	k3dCmd.AddCommand(
+		imageCmd,
+		registryCmd,
+	)
+	return k3dCmd
+}
@@ -0,0 +1,105 @@
+package k3d
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/cnrancher/autok3s/pkg/providers/k3d"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	registryCmd = &cobra.Command{
+		Use:   "registry",
+		Short: "The k3d-managed registry management, the registry can be shared by the k3d clusters.",
+	}
+
+	registryCreateCmd = &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create and start a k3d-managed registry.",
+		Long: `Create and start a k3d-managed registry, the images pushed to the registry port on the docker host can be pulled
+by the k3d clusters which are created with --registry-use.`,
+		Example: `  autok3s k3d registry create myregistry --port 5050
+  autok3s create -p k3d --name mycluster --registry-use myregistry
+  docker tag myapp:dev localhost:5050/myapp:dev && docker push localhost:5050/myapp:dev`,
+		Args: cobra.ExactArgs(1),
+		Run:  utils.CommandExitWithoutHelpInfo(createRegistry),
+	}
+
+	registryListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the k3d-managed registries.",
+		Args:  cobra.NoArgs,
+		Run:   utils.CommandExitWithoutHelpInfo(listRegistries),
+	}
+
+	registryDeleteCmd = &cobra.Command{
+		Use:   "delete <name>...",
+		Short: "Delete the k3d-managed registries, the images pushed to the registry are removed with it.",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   utils.CommandExitWithoutHelpInfo(deleteRegistries),
+	}
+
+	registryFlags = apis.K3dRegistry{}
+	isJSON        bool
+)
+
+func init() {
+	registryCreateCmd.Flags().StringVar(&registryFlags.Port, "port", "", "The registry port exposed on the docker host in [HOST:]HOSTPORT, default to a random port")
+	registryCreateCmd.Flags().StringVar(&registryFlags.Image, "image", "", "The image of the registry, default to docker.io/library/registry:2")
+	registryCreateCmd.Flags().StringVar(&registryFlags.ProxyRemoteURL, "proxy-remote-url", "", "The url of the remote registry proxied by the registry, e.g. https://registry-1.docker.io")
+	registryCreateCmd.Flags().StringVar(&registryFlags.ProxyUsername, "proxy-username", "", "The username of the remote registry proxied by the registry")
+	registryCreateCmd.Flags().StringVar(&registryFlags.ProxyPassword, "proxy-password", "", "The password of the remote registry proxied by the registry")
+	registryListCmd.Flags().BoolVarP(&isJSON, "json", "j", isJSON, "json output")
+	registryCmd.AddCommand(registryCreateCmd, registryListCmd, registryDeleteCmd)
+}
+
+func createRegistry(cmd *cobra.Command, args []string) error {
+	registryFlags.Name = args[0]
+	reg, err := k3d.CreateRegistry(&registryFlags)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("registry %s is created on port %s, use it by `--registry-use %s` when creating k3d cluster\n", reg.Name, reg.Port, reg.Name)
+	return nil
+}
+
+func listRegistries(cmd *cobra.Command, _ []string) error {
+	list, err := k3d.ListRegistries()
+	if err != nil {
+		return err
+	}
+	if isJSON {
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("%s\n", string(data))
+		return nil
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"Name", "Host", "Port", "Image", "Proxy", "Status"})
+	for _, r := range list {
+		table.Append([]string{r.Name, r.Host, r.Port, r.Image, r.ProxyRemoteURL, r.Status})
+	}
+	table.Render()
+	return nil
+}
+
+func deleteRegistries(cmd *cobra.Command, args []string) error {
+	for _, name := range args {
+		if err := k3d.DeleteRegistry(name); err != nil {
+			return err
+		}
+		cmd.Printf("registry %s is deleted\n", name)
+	}
+	return nil
+}
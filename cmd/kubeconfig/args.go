@@ -0,0 +1,63 @@
+package kubeconfig
+
+import (
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	pkgkubeconfig "github.com/cnrancher/autok3s/pkg/kubeconfig"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+var (
+	kubeconfigFlags = flags{}
+)
+
+type flags struct {
+	Server      string
+	OutputPath  string
+	Kubeconfig  string
+	Name        string
+	Kind        string
+	ClusterRole string
+	Namespace   string
+	TTL         time.Duration
+
+	useTLSSan  bool
+	overwrite  bool
+	setCurrent bool
+	isJSON     bool
+	isForce    bool
+	dryRun     bool
+}
+
+// addServerFlags adds the flags to rewrite the server address of kubeconfig.
+func addServerFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&kubeconfigFlags.Server, "server", "", "Rewrite the server address of kubeconfig, can be a host, host:port or url, e.g. the LB endpoint")
+	cmd.Flags().BoolVar(&kubeconfigFlags.useTLSSan, "tls-san", false, "Rewrite the server address of kubeconfig to the first TLS SAN of cluster")
+}
+
+// loadConfig returns the kubeconfig of the cluster with the server address rewritten.
+func loadConfig(contextName string) (*api.Config, error) {
+	config, state, err := pkgkubeconfig.Get(contextName)
+	if err != nil {
+		return nil, err
+	}
+	if err := rewriteServer(config, state); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// rewriteServer rewrites the server address of the kubeconfig by the server flags.
+func rewriteServer(config *api.Config, state *common.ClusterState) error {
+	server := kubeconfigFlags.Server
+	if kubeconfigFlags.useTLSSan {
+		var err error
+		if server, err = pkgkubeconfig.TLSSanServer(state); err != nil {
+			return err
+		}
+	}
+	return pkgkubeconfig.RewriteServer(config, server)
+}
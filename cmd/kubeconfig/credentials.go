@@ -0,0 +1,71 @@
+package kubeconfig
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var credentialsCmd = &cobra.Command{
+	Use:   "credentials [cluster]",
+	Short: "List the credentials issued for the scoped kubeconfig, all clusters are listed if the cluster is not set.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   utils.CommandExitWithoutHelpInfo(credentials),
+}
+
+func init() {
+	credentialsCmd.Flags().BoolVarP(&kubeconfigFlags.isJSON, "json", "j", kubeconfigFlags.isJSON, "json output")
+}
+
+func credentials(cmd *cobra.Command, args []string) error {
+	cluster := ""
+	if len(args) > 0 {
+		cluster = args[0]
+	}
+	list, err := common.DefaultDB.ListKubeconfigCredentials(cluster)
+	if err != nil {
+		return err
+	}
+	if kubeconfigFlags.isJSON {
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("%s\n", string(data))
+		return nil
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"Cluster", "Name", "Kind", "ClusterRole", "Namespace", "Subject", "Expires", "Status"})
+	for _, c := range list {
+		status := "Active"
+		if c.Expired() {
+			status = "Expired"
+		}
+		namespace := c.Namespace
+		if namespace == "" {
+			namespace = "*"
+		}
+		table.Append([]string{
+			c.Cluster,
+			c.Name,
+			c.Kind,
+			c.ClusterRole,
+			namespace,
+			c.Subject,
+			c.ExpiresAt.Local().Format(time.RFC3339),
+			status,
+		})
+	}
+	table.Render()
+	return nil
+}
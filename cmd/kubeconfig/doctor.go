@@ -0,0 +1,73 @@
+package kubeconfig
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	pkgkubeconfig "github.com/cnrancher/autok3s/pkg/kubeconfig"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [cluster]",
+	Short: "Check the kubeconfig of clusters and repair the drifted ones.",
+	Long: `Check the context of clusters in the kubeconfig used by autok3s against the cluster state, e.g. the server address and
+the certificate authority. The kubeconfig of drifted cluster is re-fetched from a healthy master by ssh and repaired.
+All the running clusters are checked if the cluster is not set.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  utils.CommandExitWithoutHelpInfo(doctor),
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&kubeconfigFlags.dryRun, "dry-run", kubeconfigFlags.dryRun, "Only check the kubeconfig without repairing")
+	doctorCmd.Flags().BoolVarP(&kubeconfigFlags.isJSON, "json", "j", kubeconfigFlags.isJSON, "json output")
+}
+
+func doctor(cmd *cobra.Command, args []string) error {
+	contextName := ""
+	if len(args) > 0 {
+		contextName = args[0]
+	}
+	results, err := pkgkubeconfig.Doctor(contextName, !kubeconfigFlags.dryRun, logrus.StandardLogger())
+	if err != nil {
+		return err
+	}
+	if kubeconfigFlags.isJSON {
+		data, err := json.Marshal(results)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("%s\n", string(data))
+		return nil
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"Cluster", "Provider", "Status", "Problems"})
+	for _, result := range results {
+		status := "Healthy"
+		switch {
+		case result.Error != "":
+			status = "Repair Failed: " + result.Error
+		case result.Repaired:
+			status = "Repaired"
+		case len(result.Problems) > 0:
+			status = "Drifted"
+		}
+		table.Append([]string{
+			result.Cluster,
+			result.Provider,
+			status,
+			strings.Join(result.Problems, "; "),
+		})
+	}
+	table.Render()
+	return nil
+}
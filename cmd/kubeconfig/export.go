@@ -0,0 +1,44 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"os"
+
+	pkgkubeconfig "github.com/cnrancher/autok3s/pkg/kubeconfig"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <cluster>",
+	Short: "Export the standalone kubeconfig of the cluster to a file.",
+	Args:  cobra.ExactArgs(1),
+	Run:   utils.CommandExitWithoutHelpInfo(export),
+}
+
+func init() {
+	addServerFlags(exportCmd)
+	exportCmd.Flags().StringVarP(&kubeconfigFlags.OutputPath, "output", "o", "", "The file to write the kubeconfig, default to <cluster>.yaml under current directory")
+	exportCmd.Flags().BoolVar(&kubeconfigFlags.overwrite, "overwrite", false, "Overwrite the file if exists")
+}
+
+func export(cmd *cobra.Command, args []string) error {
+	config, err := loadConfig(args[0])
+	if err != nil {
+		return err
+	}
+	path := kubeconfigFlags.OutputPath
+	if path == "" {
+		path = fmt.Sprintf("%s.yaml", args[0])
+	}
+	path = utils.StripUserHome(path)
+	if _, err := os.Stat(path); err == nil && !kubeconfigFlags.overwrite {
+		return fmt.Errorf("file %s already exists, please use --overwrite to replace it", path)
+	}
+	if err := pkgkubeconfig.Write(config, path); err != nil {
+		return err
+	}
+	cmd.Printf("kubeconfig of cluster %s is written to file %s\n", args[0], path)
+	return nil
+}
@@ -0,0 +1,34 @@
+package kubeconfig
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get <cluster>",
+	Short: "Print the standalone kubeconfig of the cluster.",
+	Args:  cobra.ExactArgs(1),
+	Run:   utils.CommandExitWithoutHelpInfo(get),
+}
+
+func init() {
+	addServerFlags(getCmd)
+}
+
+func get(_ *cobra.Command, args []string) error {
+	config, err := loadConfig(args[0])
+	if err != nil {
+		return err
+	}
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
@@ -0,0 +1,78 @@
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	pkgkubeconfig "github.com/cnrancher/autok3s/pkg/kubeconfig"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var issueCmd = &cobra.Command{
+	Use:   "issue <cluster>",
+	Short: "Issue a scoped kubeconfig bound to a time-limited service account token or client certificate.",
+	Long: `Issue a scoped kubeconfig of the cluster, the credential is a service account token or a client certificate
+which is bound to the cluster role in the namespace (or the whole cluster if the namespace is not set) and expires after the ttl.
+The issued credentials can be listed by "autok3s kubeconfig credentials" and revoked by "autok3s kubeconfig revoke".`,
+	Example: `  autok3s kubeconfig issue myk3s.ap-southeast-2.aws -n dev --cluster-role edit --namespace dev --ttl 8h
+  autok3s kubeconfig issue myk3s.ap-southeast-2.aws -n ops --kind certificate --cluster-role view -o ops.yaml`,
+	Args: cobra.ExactArgs(1),
+	Run:  utils.CommandExitWithoutHelpInfo(issue),
+}
+
+func init() {
+	addServerFlags(issueCmd)
+	issueCmd.Flags().StringVarP(&kubeconfigFlags.Name, "name", "n", "", "The name of the credential, it must be unique in the cluster")
+	issueCmd.Flags().StringVar(&kubeconfigFlags.Kind, "kind", common.KubeconfigCredentialServiceAccount,
+		fmt.Sprintf("The kind of the credential, %s or %s", common.KubeconfigCredentialServiceAccount, common.KubeconfigCredentialCertificate))
+	issueCmd.Flags().StringVar(&kubeconfigFlags.ClusterRole, "cluster-role", "", "The cluster role bound to the credential, e.g. view, edit, admin")
+	issueCmd.Flags().StringVar(&kubeconfigFlags.Namespace, "namespace", "", "The namespace which the cluster role is bound in, the cluster role is bound to the whole cluster if not set")
+	issueCmd.Flags().DurationVar(&kubeconfigFlags.TTL, "ttl", 24*time.Hour, fmt.Sprintf("The duration before the credential expires, at least %s", pkgkubeconfig.MinCredentialTTL))
+	issueCmd.Flags().StringVarP(&kubeconfigFlags.OutputPath, "output", "o", "", "The file to write the kubeconfig, print to stdout if not set")
+	_ = issueCmd.MarkFlagRequired("name")
+	_ = issueCmd.MarkFlagRequired("cluster-role")
+}
+
+func issue(cmd *cobra.Command, args []string) error {
+	config, credential, err := pkgkubeconfig.Issue(context.Background(), args[0], pkgkubeconfig.IssueOptions{
+		Name:        kubeconfigFlags.Name,
+		Kind:        kubeconfigFlags.Kind,
+		ClusterRole: kubeconfigFlags.ClusterRole,
+		Namespace:   kubeconfigFlags.Namespace,
+		TTL:         kubeconfigFlags.TTL,
+	})
+	if err != nil {
+		return err
+	}
+	state, err := common.DefaultDB.GetClusterByID(args[0])
+	if err != nil {
+		return err
+	}
+	if err := rewriteServer(config, state); err != nil {
+		return err
+	}
+	if kubeconfigFlags.OutputPath == "" {
+		data, err := clientcmd.Write(*config)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+	path := utils.StripUserHome(kubeconfigFlags.OutputPath)
+	if _, err := os.Stat(path); err == nil {
+		cmd.Printf("file %s already exists and will be replaced\n", path)
+	}
+	if err := pkgkubeconfig.Write(config, path); err != nil {
+		return err
+	}
+	cmd.Printf("kubeconfig of credential %s which expires at %s is written to file %s\n", credential.Name,
+		credential.ExpiresAt.Local().Format(time.RFC3339), path)
+	return nil
+}
@@ -0,0 +1,26 @@
+package kubeconfig
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	kubeconfig = &cobra.Command{
+		Use:   "kubeconfig",
+		Short: "The kubeconfig management of clusters.",
+		Long:  "The kubeconfig command gets, exports and merges the standalone kubeconfig of the cluster, and issues the scoped kubeconfig with time-limited credentials.",
+	}
+)
+
+func Command() *cobra.Command {
+	kubeconfig.AddCommand(
+		getCmd,
+		exportCmd,
+		mergeCmd,
+		issueCmd,
+		credentialsCmd,
+		revokeCmd,
+		doctorCmd,
+	)
+	return kubeconfig
+}
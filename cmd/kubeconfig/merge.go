@@ -0,0 +1,35 @@
+package kubeconfig
+
+import (
+	pkgkubeconfig "github.com/cnrancher/autok3s/pkg/kubeconfig"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <cluster>",
+	Short: "Merge the kubeconfig of the cluster into the user's kubeconfig.",
+	Args:  cobra.ExactArgs(1),
+	Run:   utils.CommandExitWithoutHelpInfo(merge),
+}
+
+func init() {
+	addServerFlags(mergeCmd)
+	mergeCmd.Flags().StringVar(&kubeconfigFlags.Kubeconfig, "kubeconfig", clientcmd.RecommendedHomeFile, "The kubeconfig file to merge into")
+	mergeCmd.Flags().BoolVar(&kubeconfigFlags.overwrite, "overwrite", false, "Overwrite the context, cluster and user of the same name")
+	mergeCmd.Flags().BoolVar(&kubeconfigFlags.setCurrent, "set-current", false, "Set the cluster as the current context")
+}
+
+func merge(cmd *cobra.Command, args []string) error {
+	config, err := loadConfig(args[0])
+	if err != nil {
+		return err
+	}
+	if err := pkgkubeconfig.Merge(config, kubeconfigFlags.Kubeconfig, kubeconfigFlags.overwrite, kubeconfigFlags.setCurrent); err != nil {
+		return err
+	}
+	cmd.Printf("kubeconfig of cluster %s is merged into %s\n", args[0], kubeconfigFlags.Kubeconfig)
+	return nil
+}
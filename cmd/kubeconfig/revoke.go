@@ -0,0 +1,31 @@
+package kubeconfig
+
+import (
+	"context"
+
+	pkgkubeconfig "github.com/cnrancher/autok3s/pkg/kubeconfig"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var revokeCmd = &cobra.Command{
+	Use:   "revoke <cluster> <name>",
+	Short: "Revoke the credential issued for the scoped kubeconfig.",
+	Long: `Revoke the credential issued for the scoped kubeconfig, the role binding and the service account of the credential are removed.
+The client certificate cannot be revoked before it expires, but it has no permission after the role binding is removed.`,
+	Args: cobra.ExactArgs(2),
+	Run:  utils.CommandExitWithoutHelpInfo(revoke),
+}
+
+func init() {
+	revokeCmd.Flags().BoolVarP(&kubeconfigFlags.isForce, "force", "f", kubeconfigFlags.isForce, "Remove the record of credential even if the cluster is not reachable")
+}
+
+func revoke(cmd *cobra.Command, args []string) error {
+	if err := pkgkubeconfig.Revoke(context.Background(), args[0], args[1], kubeconfigFlags.isForce); err != nil {
+		return err
+	}
+	cmd.Printf("kubeconfig credential %s of cluster %s is revoked\n", args[1], args[0])
+	return nil
+}
@@ -20,10 +20,12 @@ var (
 		Example: `  autok3s list`,
 	}
 	jsonOut = false
+	refresh = false
 )
 
 func init() {
 	listCmd.Flags().BoolVarP(&jsonOut, "json", "j", jsonOut, "json output")
+	listCmd.Flags().BoolVar(&refresh, "refresh", refresh, "Refresh the status of clusters from the cloud providers and clusters instead of the cached status")
 }
 
 // ListCommand returns clusters as list.
@@ -42,7 +44,11 @@ func listCluster() {
 	table.SetAlignment(tablewriter.ALIGN_LEFT)
 	table.SetHeader([]string{"Name", "Region", "Provider", "Status", "Masters", "Workers", "Version", "IsHAMode", "DataStoreType"})
 
-	filters, err := cluster.ListClusters("")
+	listClusters := cluster.ListClusters
+	if refresh {
+		listClusters = cluster.RefreshClusters
+	}
+	filters, err := listClusters("")
 	if err != nil {
 		logrus.Fatalln(err)
 	}
@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	clearCmd = &cobra.Command{
+		Use:   "clear <cluster>",
+		Short: "Clear the policy of the cluster, the schedule, the TTL, the auto-heal and the autoscaling are all cleared if none is specified.",
+		Args:  cobra.ExactArgs(1),
+		Run:   utils.CommandExitWithoutHelpInfo(clearPolicy),
+	}
+
+	clearSchedule bool
+	clearTTL      bool
+	clearAutoHeal bool
+	clearScale    bool
+)
+
+func init() {
+	clearCmd.Flags().BoolVar(&clearSchedule, "schedule", clearSchedule, "Clear the scheduled start/stop window")
+	clearCmd.Flags().BoolVar(&clearTTL, "ttl", clearTTL, "Clear the TTL so that the cluster won't be deleted")
+	clearCmd.Flags().BoolVar(&clearAutoHeal, "auto-heal", clearAutoHeal, "Clear the auto-heal so that the failed workers won't be replaced")
+	clearCmd.Flags().BoolVar(&clearScale, "autoscale", clearScale, "Clear the autoscale bounds so that the workers won't be scaled by cluster-autoscaler")
+}
+
+func clearPolicy(cmd *cobra.Command, args []string) error {
+	state, err := getClusterState(args[0])
+	if err != nil {
+		return err
+	}
+	all := !clearSchedule && !clearTTL && !clearAutoHeal && !clearScale
+	if all || clearSchedule {
+		state.StopAt = ""
+		state.StartAt = ""
+		state.ScheduleDays = ""
+		state.Timezone = ""
+	}
+	if all || clearTTL {
+		state.ExpireAt = nil
+	}
+	if all || clearAutoHeal {
+		state.AutoHeal = false
+		state.AutoHealGracePeriod = ""
+	}
+	if all || clearScale {
+		state.AutoscaleMin = 0
+		state.AutoscaleMax = 0
+	}
+	if err = common.DefaultDB.SaveClusterPolicy(state); err != nil {
+		return err
+	}
+	cmd.Printf("policy of cluster %s is cleared\n", state.ContextName)
+	return nil
+}
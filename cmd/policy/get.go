@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/policy"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	getCmd = &cobra.Command{
+		Use:   "get [cluster]",
+		Short: "Show the policy of the cluster, all clusters with policy are listed if the cluster is not set.",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   utils.CommandExitWithoutHelpInfo(get),
+	}
+
+	isJSON bool
+)
+
+func init() {
+	getCmd.Flags().BoolVarP(&isJSON, "json", "j", isJSON, "json output")
+}
+
+func get(cmd *cobra.Command, args []string) error {
+	var states []*common.ClusterState
+	if len(args) > 0 {
+		state, err := getClusterState(args[0])
+		if err != nil {
+			return err
+		}
+		states = append(states, state)
+	} else {
+		list, err := common.DefaultDB.ListCluster("")
+		if err != nil {
+			return err
+		}
+		for _, state := range list {
+			if !state.ClusterPolicy.IsEmpty() {
+				states = append(states, state)
+			}
+		}
+	}
+	now := time.Now()
+	policies := make([]*apis.ClusterPolicy, 0, len(states))
+	for _, state := range states {
+		policies = append(policies, policy.ToAPIPolicy(state, now))
+	}
+	if isJSON {
+		data, err := json.Marshal(policies)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("%s\n", string(data))
+		return nil
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"Cluster", "Stop At", "Start At", "Days", "Timezone", "Expires", "Next Action", "Auto Heal", "Autoscale"})
+	for _, p := range policies {
+		days := p.ScheduleDays
+		if days == "" && (p.StopAt != "" || p.StartAt != "") {
+			days = "daily"
+		}
+		timezone := p.Timezone
+		if timezone == "" && (p.StopAt != "" || p.StartAt != "") {
+			timezone = "Local"
+		}
+		expires := ""
+		if p.ExpireAt != nil {
+			expires = p.ExpireAt.Local().Format(time.RFC3339)
+		}
+		next := ""
+		if p.NextActionAt != nil {
+			next = fmt.Sprintf("%s at %s", p.NextAction, p.NextActionAt.Local().Format(time.RFC3339))
+		}
+		autoHeal := ""
+		if p.AutoHeal {
+			grace := p.AutoHealGracePeriod
+			if grace == "" {
+				grace = policy.DefaultAutoHealGracePeriod.String()
+			}
+			autoHeal = fmt.Sprintf("enabled (grace %s)", grace)
+		}
+		autoscale := ""
+		if p.AutoscaleMax > 0 {
+			autoscale = fmt.Sprintf("%d-%d workers", p.AutoscaleMin, p.AutoscaleMax)
+		}
+		table.Append([]string{p.Cluster, p.StopAt, p.StartAt, days, timezone, expires, next, autoHeal, autoscale})
+	}
+	table.Render()
+	return nil
+}
+
+func getClusterState(contextName string) (*common.ClusterState, error) {
+	state, err := common.DefaultDB.GetClusterByID(contextName)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("cluster %s is not exist", contextName)
+	}
+	return state, nil
+}
@@ -0,0 +1,25 @@
+package policy
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	policyCmd = &cobra.Command{
+		Use:   "policy",
+		Short: "The scheduled start/stop window, TTL, auto-heal and autoscaling management of clusters.",
+		Long: `The policy command manages the policies of clusters, e.g. stop the cluster at 20:00 and start it at 08:00 on weekdays,
+or delete the cluster 48h later unless it's extended, replace the failed workers automatically, or scale the workers by
+cluster-autoscaler within the bounds.
+The policies are enforced by "autok3s serve".`,
+	}
+)
+
+func Command() *cobra.Command {
+	policyCmd.AddCommand(
+		getCmd,
+		setCmd,
+		clearCmd,
+	)
+	return policyCmd
+}
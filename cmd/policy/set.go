@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/policy"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	setCmd = &cobra.Command{
+		Use:   "set <cluster>",
+		Short: "Set the scheduled start/stop window, the TTL, the auto-heal or the autoscaling of the cluster, only the specified fields are changed.",
+		Long: `Set the scheduled start/stop window, the TTL, the auto-heal or the autoscaling of the cluster, only the specified fields are changed.
+The cluster is stopped at stop-at and started at start-at on the schedule days, and deleted when the TTL expires.
+Warnings are broadcast by "autok3s serve" before the cluster is stopped or deleted.
+With auto-heal, the failed workers are removed and replaced by "autok3s serve", the worker is failed if its instance
+is gone, or it's NotReady longer than the grace period.
+With autoscale bounds, the workers are scaled by cluster-autoscaler through the autoscaler endpoint of "autok3s serve".`,
+		Example: `  autok3s policy set myk3s.ap-southeast-2.aws --stop-at 20:00 --start-at 08:00 --days weekdays
+  autok3s policy set myk3s.ap-southeast-2.aws --ttl 48h
+  autok3s policy set myk3s.ap-southeast-2.aws --extend 24h
+  autok3s policy set myk3s.ap-southeast-2.aws --auto-heal --auto-heal-grace-period 15m
+  autok3s policy set myk3s.ap-southeast-2.aws --autoscale-min 1 --autoscale-max 5`,
+		Args: cobra.ExactArgs(1),
+		Run:  utils.CommandExitWithoutHelpInfo(set),
+	}
+
+	setFlags = struct {
+		StopAt   string
+		StartAt  string
+		Days     string
+		Timezone string
+		TTL      time.Duration
+		Extend   time.Duration
+
+		AutoHeal            bool
+		AutoHealGracePeriod time.Duration
+		AutoscaleMin        int
+		AutoscaleMax        int
+	}{}
+)
+
+func init() {
+	setCmd.Flags().StringVar(&setFlags.StopAt, "stop-at", "", "The time of day in format HH:MM to stop the cluster, set empty to disable")
+	setCmd.Flags().StringVar(&setFlags.StartAt, "start-at", "", "The time of day in format HH:MM to start the cluster, set empty to disable")
+	setCmd.Flags().StringVar(&setFlags.Days, "days", "", "The days of week the schedule applies to, e.g. daily, weekdays, weekends, sat,sun or mon-fri, default to daily")
+	setCmd.Flags().StringVar(&setFlags.Timezone, "timezone", "", "The IANA time zone of the schedule, e.g. Asia/Shanghai, default to the local time zone of autok3s")
+	setCmd.Flags().DurationVar(&setFlags.TTL, "ttl", 0, "Delete the cluster after the duration from now, e.g. 48h")
+	setCmd.Flags().DurationVar(&setFlags.Extend, "extend", 0, "Postpone the deletion of the cluster by the duration, e.g. 24h")
+	setCmd.Flags().BoolVar(&setFlags.AutoHeal, "auto-heal", false, "Remove and replace the failed workers of the cluster, set false to disable")
+	setCmd.Flags().DurationVar(&setFlags.AutoHealGracePeriod, "auto-heal-grace-period", 0, "How long a worker can be NotReady before it's replaced, default to 10m")
+	setCmd.Flags().IntVar(&setFlags.AutoscaleMin, "autoscale-min", 0, "The min number of workers scaled by cluster-autoscaler")
+	setCmd.Flags().IntVar(&setFlags.AutoscaleMax, "autoscale-max", 0, "The max number of workers scaled by cluster-autoscaler, set 0 to disable autoscaling")
+	setCmd.MarkFlagsMutuallyExclusive("ttl", "extend")
+}
+
+func set(cmd *cobra.Command, args []string) error {
+	state, err := getClusterState(args[0])
+	if err != nil {
+		return err
+	}
+	p := &state.ClusterPolicy
+	flags := cmd.Flags()
+	if flags.Changed("stop-at") {
+		p.StopAt = setFlags.StopAt
+	}
+	if flags.Changed("start-at") {
+		p.StartAt = setFlags.StartAt
+	}
+	if flags.Changed("days") {
+		p.ScheduleDays = setFlags.Days
+	}
+	if flags.Changed("timezone") {
+		p.Timezone = setFlags.Timezone
+	}
+	now := time.Now()
+	if flags.Changed("ttl") {
+		if err = policy.SetTTL(p, setFlags.TTL, now); err != nil {
+			return err
+		}
+	}
+	if flags.Changed("extend") {
+		if err = policy.Extend(p, setFlags.Extend, now); err != nil {
+			return err
+		}
+	}
+	if flags.Changed("auto-heal") {
+		p.AutoHeal = setFlags.AutoHeal
+		if !p.AutoHeal {
+			p.AutoHealGracePeriod = ""
+		}
+	}
+	if flags.Changed("auto-heal-grace-period") {
+		p.AutoHealGracePeriod = setFlags.AutoHealGracePeriod.String()
+	}
+	if flags.Changed("autoscale-min") {
+		p.AutoscaleMin = setFlags.AutoscaleMin
+	}
+	if flags.Changed("autoscale-max") {
+		p.AutoscaleMax = setFlags.AutoscaleMax
+		if p.AutoscaleMax == 0 {
+			p.AutoscaleMin = 0
+		}
+	}
+	if err = policy.Validate(p); err != nil {
+		return err
+	}
+	if err = policy.ValidateProvider(p, state.Provider); err != nil {
+		return err
+	}
+	if err = common.DefaultDB.SaveClusterPolicy(state); err != nil {
+		return err
+	}
+	cmd.Printf("policy of cluster %s is updated\n", state.ContextName)
+	if action, at := policy.NextAction(p, state.Status, now); action != "" {
+		cmd.Printf("next action: %s at %s\n", action, at.Local().Format(time.RFC3339))
+	}
+	return nil
+}
@@ -4,8 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/cnrancher/autok3s/pkg/autoscaler"
+	"github.com/cnrancher/autok3s/pkg/cluster"
 	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/health"
+	"github.com/cnrancher/autok3s/pkg/kubeconfig"
+	"github.com/cnrancher/autok3s/pkg/policy"
 	"github.com/cnrancher/autok3s/pkg/server"
 
 	"github.com/pkg/browser"
@@ -21,11 +27,48 @@ var (
 
 	bindPort    = "8080"
 	bindAddress = "127.0.0.1"
+
+	kubeconfigCheckInterval = 5 * time.Minute
+	policyCheckInterval     = time.Minute
+	policyWarnBefore        = 30 * time.Minute
+
+	statusRefreshInterval    = time.Minute
+	statusRefreshConcurrency = cluster.DefaultRefreshConcurrency
+	statusRefreshQPS         = cluster.DefaultRefreshQPS
+
+	healthCheckInterval    = time.Duration(0)
+	healthHistory          = health.DefaultHistory
+	healthLatencyThreshold = health.DefaultLatencyThreshold
+	healthWebhookURL       = ""
+	healthSMTP             = health.SMTPNotifier{}
+
+	autoHealMaxConcurrentRepairs = health.DefaultMaxConcurrentRepairs
+
+	autoscalerListen = ""
+	autoscalerTLSSAN = []string{}
 )
 
 func init() {
 	serveCmd.Flags().StringVar(&bindPort, "bind-port", bindPort, "HTTP/HTTPS bind port")
 	serveCmd.Flags().StringVar(&bindAddress, "bind-address", bindAddress, "HTTP/HTTPS bind address")
+	serveCmd.Flags().DurationVar(&kubeconfigCheckInterval, "kubeconfig-check-interval", kubeconfigCheckInterval, "The interval to check and repair the kubeconfig of clusters, set 0 to disable")
+	serveCmd.Flags().DurationVar(&policyCheckInterval, "policy-check-interval", policyCheckInterval, "The interval to enforce the scheduled start/stop window and TTL of clusters, set 0 to disable")
+	serveCmd.Flags().DurationVar(&policyWarnBefore, "policy-warn-before", policyWarnBefore, "The duration to broadcast warning before the cluster is stopped or deleted by policy")
+	serveCmd.Flags().DurationVar(&statusRefreshInterval, "status-refresh-interval", statusRefreshInterval, "The interval to refresh the cached status of clusters which is used to list clusters, set 0 to disable")
+	serveCmd.Flags().IntVar(&statusRefreshConcurrency, "status-refresh-concurrency", statusRefreshConcurrency, "The max number of clusters whose status is refreshed at the same time")
+	serveCmd.Flags().Float64Var(&statusRefreshQPS, "status-refresh-qps", statusRefreshQPS, "The max number of clusters of each provider refreshed per second, which limits the calls of the cloud API by the status refresh and the health checks")
+	serveCmd.Flags().DurationVar(&healthCheckInterval, "health-check-interval", healthCheckInterval, "The interval to check the health of running clusters which is required by auto-heal, the health monitoring is disabled by default")
+	serveCmd.Flags().IntVar(&healthHistory, "health-history", healthHistory, "The number of health records kept for each cluster")
+	serveCmd.Flags().DurationVar(&healthLatencyThreshold, "health-latency-threshold", healthLatencyThreshold, "The cluster is degraded if the latency of apiserver exceeds the threshold")
+	serveCmd.Flags().StringVar(&healthWebhookURL, "health-webhook-url", healthWebhookURL, "The webhook URL to post the health status transitions of clusters in JSON")
+	serveCmd.Flags().StringVar(&healthSMTP.Address, "health-smtp-address", healthSMTP.Address, "The SMTP server address in host:port to send the health status transitions of clusters by mail")
+	serveCmd.Flags().StringVar(&healthSMTP.From, "health-smtp-from", healthSMTP.From, "The sender of the health notification mail")
+	serveCmd.Flags().StringSliceVar(&healthSMTP.To, "health-smtp-to", healthSMTP.To, "The recipients of the health notification mail")
+	serveCmd.Flags().StringVar(&healthSMTP.Username, "health-smtp-username", healthSMTP.Username, "The username of the SMTP server, the plain authentication is used if it's set")
+	serveCmd.Flags().StringVar(&healthSMTP.Password, "health-smtp-password", healthSMTP.Password, "The password of the SMTP server")
+	serveCmd.Flags().IntVar(&autoHealMaxConcurrentRepairs, "auto-heal-max-concurrent-repairs", autoHealMaxConcurrentRepairs, "The max number of failed workers repaired at the same time for the clusters with auto-heal policy")
+	serveCmd.Flags().StringVar(&autoscalerListen, "autoscaler-listen", autoscalerListen, "The address in host:port to serve cluster-autoscaler as the external gRPC cloud provider, e.g. 0.0.0.0:8086, empty to disable")
+	serveCmd.Flags().StringSliceVar(&autoscalerTLSSAN, "autoscaler-tls-san", autoscalerTLSSAN, "The extra hostnames or IPs of the serving certificate of autoscaler endpoint, which are reachable from the clusters")
 }
 
 // ServeCommand serve command.
@@ -43,6 +86,55 @@ func ServeCommand() *cobra.Command {
 		go func(ctx context.Context) {
 			common.InitDashboard(ctx)
 		}(serveCmd.Context())
+		// check and repair the drifted kubeconfig of clusters
+		if kubeconfigCheckInterval > 0 {
+			go func(ctx context.Context) {
+				kubeconfig.StartDoctor(ctx, kubeconfigCheckInterval)
+			}(serveCmd.Context())
+		}
+
+		// enforce the scheduled start/stop window and TTL of clusters
+		if policyCheckInterval > 0 {
+			go func(ctx context.Context) {
+				policy.StartScheduler(ctx, policyCheckInterval, policyWarnBefore)
+			}(serveCmd.Context())
+		}
+
+		// refresh the cached status of clusters, the cloud API calls of the health checks share the rate limits
+		refresher := cluster.NewStatusRefresher(statusRefreshConcurrency, statusRefreshQPS)
+		if statusRefreshInterval > 0 {
+			go func(ctx context.Context) {
+				cluster.StartStatusRefresher(ctx, statusRefreshInterval, refresher)
+			}(serveCmd.Context())
+		}
+
+		// check the health of clusters, notify the transitions and repair the failed workers
+		if healthCheckInterval > 0 {
+			var notifiers []health.Notifier
+			if healthWebhookURL != "" {
+				notifiers = append(notifiers, health.NewWebhookNotifier(healthWebhookURL))
+			}
+			if healthSMTP.Address != "" {
+				if healthSMTP.From == "" || len(healthSMTP.To) == 0 {
+					logrus.Fatalln("the sender and recipients of health notification mail are required by --health-smtp-address")
+				}
+				notifiers = append(notifiers, &healthSMTP)
+			}
+			monitor := health.NewMonitor(&health.Checker{LatencyThreshold: healthLatencyThreshold, Limiter: refresher.Limiter}, healthHistory, notifiers...)
+			monitor.Healer = health.NewHealer(autoHealMaxConcurrentRepairs)
+			go func(ctx context.Context) {
+				health.StartMonitor(ctx, healthCheckInterval, monitor)
+			}(serveCmd.Context())
+		}
+
+		// scale the workers of clusters for cluster-autoscaler
+		if autoscalerListen != "" {
+			go func(ctx context.Context) {
+				if err := autoscaler.Serve(ctx, autoscalerListen, autoscalerTLSSAN); err != nil {
+					logrus.Errorf("failed to serve autoscaler endpoint: %v", err)
+				}
+			}(serveCmd.Context())
+		}
 
 		stopChan := make(chan struct{})
 		go func(c chan struct{}) {
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	startCmd = &cobra.Command{
+		Use:   "start",
+		Short: "Start a stopped K3s cluster",
+	}
+	startProvider = ""
+	startP        providers.Provider
+)
+
+func init() {
+	startCmd.Flags().StringVarP(&startProvider, "provider", "p", startProvider, "Provider is a module which provides an interface for managing cloud resources")
+}
+
+// StartCommand start command.
+func StartCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			startP = reg
+		}
+		startCmd.Flags().AddFlagSet(utils.ConvertFlags(startCmd, startP.GetCredentialFlags()))
+		startCmd.Flags().AddFlagSet(utils.ConvertFlags(startCmd, startP.GetDeleteFlags()))
+		startCmd.Example = fmt.Sprintf("  autok3s -d start --provider %s --name <cluster name>", pStr)
+		startCmd.Use = fmt.Sprintf("start -p %s", pStr)
+	}
+
+	startCmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		if startProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		err := startP.MergeClusterOptions()
+		if err != nil {
+			return err
+		}
+		if err = common.MakeSureCredentialFlag(cmd.Flags(), startP); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	startCmd.Run = func(_ *cobra.Command, _ []string) {
+		startP.GenerateClusterName()
+		if err := startP.StartK3sCluster(); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return startCmd
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "Stop a K3s cluster, the instances are stopped to save cost and can be started later",
+	}
+	stopProvider = ""
+	stopP        providers.Provider
+)
+
+func init() {
+	stopCmd.Flags().StringVarP(&stopProvider, "provider", "p", stopProvider, "Provider is a module which provides an interface for managing cloud resources")
+}
+
+// StopCommand stop command.
+func StopCommand() *cobra.Command {
+	pStr := common.FlagHackLookup("--provider")
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			stopP = reg
+		}
+		stopCmd.Flags().AddFlagSet(utils.ConvertFlags(stopCmd, stopP.GetCredentialFlags()))
+		stopCmd.Flags().AddFlagSet(utils.ConvertFlags(stopCmd, stopP.GetDeleteFlags()))
+		stopCmd.Example = fmt.Sprintf("  autok3s -d stop --provider %s --name <cluster name>", pStr)
+		stopCmd.Use = fmt.Sprintf("stop -p %s", pStr)
+	}
+
+	stopCmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		if stopProvider == "" {
+			logrus.Fatalln("required flag(s) \"[provider]\" not set")
+		}
+		common.BindEnvFlags(cmd)
+		err := stopP.MergeClusterOptions()
+		if err != nil {
+			return err
+		}
+		if err = common.MakeSureCredentialFlag(cmd.Flags(), stopP); err != nil {
+			return err
+		}
+		utils.ValidateRequiredFlags(cmd.Flags())
+		return nil
+	}
+
+	stopCmd.Run = func(_ *cobra.Command, _ []string) {
+		stopP.GenerateClusterName()
+		if err := stopP.StopK3sCluster(); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+
+	return stopCmd
+}
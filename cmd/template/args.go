@@ -0,0 +1,18 @@
+package template
+
+var (
+	templateFlags = flags{}
+)
+
+type flags struct {
+	Name       string
+	Provider   string
+	OutputPath string
+	Parent     string
+	Parameters []string
+	Values     map[string]string
+
+	isJSON    bool
+	isForce   bool
+	isResolve bool
+}
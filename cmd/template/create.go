@@ -0,0 +1,116 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/cmd/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	pkgtemplate "github.com/cnrancher/autok3s/pkg/template"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var (
+	createCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create a cluster template with the provider flags.",
+	}
+
+	cp     providers.Provider
+	cFlags []types.Flag
+)
+
+func init() {
+	createCmd.Flags().StringVarP(&templateFlags.Provider, "provider", "p", templateFlags.Provider, "Provider is a module which provides an interface for managing cloud resources")
+	createCmd.Flags().StringVarP(&templateFlags.Name, "name", "n", templateFlags.Name, "The name of cluster template")
+	createCmd.Flags().StringVar(&templateFlags.Parent, "parent", templateFlags.Parent, "The name of parent template of the same provider, the fields which are not set are inherited from the parent")
+	createCmd.Flags().StringArrayVar(&templateFlags.Parameters, "parameter", templateFlags.Parameters, "Declare the template parameter in format name[:type][=default] which can be referred by ${name}, the type is one of string, int and bool, the parameter is required if the default is not set")
+}
+
+// CreateCmd returns the template create command with the dynamic provider flags.
+func CreateCmd() *cobra.Command {
+	// load dynamic provider flags, the credential flags are not saved to template.
+	pStr := common.FlagHackLookup("--provider")
+	if pStr != "" {
+		if reg, err := providers.GetProvider(pStr); err != nil {
+			logrus.Fatalln(err)
+		} else {
+			cp = reg
+		}
+
+		cFlags = append(cFlags, cp.GetOptionFlags()...)
+		cFlags = append(cFlags, cp.GetCreateFlags()...)
+		fs := utils.ConvertFlags(createCmd, cFlags)
+		// all the flags are optional for template.
+		fs.VisitAll(func(f *pflag.Flag) {
+			delete(f.Annotations, cobra.BashCompOneRequiredFlag)
+		})
+		createCmd.Flags().AddFlagSet(fs)
+		createCmd.Use = fmt.Sprintf("create -p %s", pStr)
+	}
+
+	createCmd.PreRunE = func(_ *cobra.Command, _ []string) error {
+		if templateFlags.Provider == "" {
+			return fmt.Errorf("required flag(s) \"--provider\" not set")
+		}
+		if errs := validation.IsDNS1123Subdomain(templateFlags.Name); len(errs) > 0 {
+			return fmt.Errorf("template name %s is not valid, %v", templateFlags.Name, errs)
+		}
+		return nil
+	}
+
+	createCmd.Run = func(cmd *cobra.Command, _ []string) {
+		flags := cFlags
+		if templateFlags.Parent != "" {
+			// only the flags set from command line override the parent, the defaults of flags are inherited from the parent.
+			flags = nil
+			for _, f := range cFlags {
+				if cmd.Flags().Changed(f.Name) {
+					flags = append(flags, f)
+				}
+			}
+		}
+		t, err := pkgtemplate.FromFlags(templateFlags.Name, cp, flags)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		t.Parent = templateFlags.Parent
+		for _, p := range templateFlags.Parameters {
+			param, err := parseParameter(p)
+			if err != nil {
+				logrus.Fatalln(err)
+			}
+			t.Parameters = append(t.Parameters, param)
+		}
+		if err := pkgtemplate.Create(t); err != nil {
+			logrus.Fatalln(err)
+		}
+		cmd.Printf("template %s for provider %s created\n", t.Name, t.Provider)
+	}
+
+	return createCmd
+}
+
+// parseParameter parses the parameter in format name[:type][=default].
+func parseParameter(s string) (types.TemplateParameter, error) {
+	param := types.TemplateParameter{Required: true}
+	definition := s
+	if i := strings.Index(s, "="); i >= 0 {
+		definition, param.Default = s[:i], s[i+1:]
+		param.Required = false
+	}
+	param.Name = definition
+	if i := strings.Index(definition, ":"); i >= 0 {
+		param.Name, param.Type = definition[:i], definition[i+1:]
+	}
+	if param.Name == "" {
+		return param, fmt.Errorf("parameter %q is not valid, the format is name[:type][=default]", s)
+	}
+	return param, nil
+}
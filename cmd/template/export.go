@@ -0,0 +1,45 @@
+package template
+
+import (
+	"fmt"
+	"os"
+
+	pkgtemplate "github.com/cnrancher/autok3s/pkg/template"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <name>",
+	Short: "Export the cluster template to a portable YAML file.",
+	Long:  "Export the cluster template to a portable YAML file, the secrets such as token and ssh password are not exported.",
+	Args:  cobra.ExactArgs(1),
+	Run:   utils.CommandExitWithoutHelpInfo(export),
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&templateFlags.Provider, "provider", "p", templateFlags.Provider, "The provider of template, required if the template name exists for multiple providers")
+	exportCmd.Flags().StringVarP(&templateFlags.OutputPath, "output", "o", "", "The file to write the template, write to stdout if not set")
+}
+
+func export(cmd *cobra.Command, args []string) error {
+	t, err := pkgtemplate.Get(args[0], templateFlags.Provider)
+	if err != nil {
+		return err
+	}
+	data, err := pkgtemplate.Export(t)
+	if err != nil {
+		return err
+	}
+	if templateFlags.OutputPath == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+	path := utils.StripUserHome(templateFlags.OutputPath)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write template %s to file, %v", t.Name, err)
+	}
+	cmd.Printf("template %s is written to file %s\n", t.Name, path)
+	return nil
+}
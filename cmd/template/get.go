@@ -0,0 +1,41 @@
+package template
+
+import (
+	"fmt"
+
+	pkgtemplate "github.com/cnrancher/autok3s/pkg/template"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Show the cluster template in YAML format.",
+	Args:  cobra.ExactArgs(1),
+	Run:   utils.CommandExitWithoutHelpInfo(get),
+}
+
+func init() {
+	getCmd.Flags().BoolVar(&templateFlags.isResolve, "resolve", templateFlags.isResolve, "Show the template which is merged with the parents and the parameters are replaced")
+	getCmd.Flags().StringToStringVar(&templateFlags.Values, "param", templateFlags.Values, "Set the value of template parameter for the resolved template")
+	getCmd.Flags().StringVarP(&templateFlags.Provider, "provider", "p", templateFlags.Provider, "The provider of template, required if the template name exists for multiple providers")
+}
+
+func get(_ *cobra.Command, args []string) error {
+	t, err := pkgtemplate.Get(args[0], templateFlags.Provider)
+	if err != nil {
+		return err
+	}
+	if templateFlags.isResolve {
+		if t, err = pkgtemplate.Resolve(t, templateFlags.Values); err != nil {
+			return err
+		}
+	}
+	data, err := pkgtemplate.Export(t)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
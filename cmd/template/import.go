@@ -0,0 +1,40 @@
+package template
+
+import (
+	"fmt"
+
+	pkgtemplate "github.com/cnrancher/autok3s/pkg/template"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import the cluster template from a portable YAML file.",
+	Args:  cobra.ExactArgs(1),
+	Run:   utils.CommandExitWithoutHelpInfo(importTemplate),
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&templateFlags.Name, "name", "n", "", "The name of imported template, the name in file is used if not set")
+}
+
+func importTemplate(cmd *cobra.Command, args []string) error {
+	data, err := utils.GetFileContent(args[0])
+	if err != nil {
+		return err
+	}
+	if templateFlags.Name != "" {
+		if errs := validation.IsDNS1123Subdomain(templateFlags.Name); len(errs) > 0 {
+			return fmt.Errorf("template name %s is not valid, %v", templateFlags.Name, errs)
+		}
+	}
+	t, err := pkgtemplate.Import(data, templateFlags.Name)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("template %s for provider %s imported\n", t.Name, t.Provider)
+	return nil
+}
@@ -0,0 +1,60 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all cluster templates.",
+		Args:    cobra.NoArgs,
+		Run:     utils.CommandExitWithoutHelpInfo(list),
+	}
+)
+
+func init() {
+	listCmd.Flags().BoolVarP(&templateFlags.isJSON, "json", "j", templateFlags.isJSON, "json output")
+}
+
+func list(cmd *cobra.Command, _ []string) error {
+	list, err := common.DefaultDB.ListTemplates()
+	if err != nil {
+		return err
+	}
+	if templateFlags.isJSON {
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("%s\n", string(data))
+		return nil
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"Name", "Provider", "K3s Version", "Master", "Worker", "Default"})
+	for _, t := range list {
+		table.Append([]string{
+			t.Name,
+			t.Provider,
+			t.K3sVersion,
+			t.Master,
+			t.Worker,
+			fmt.Sprintf("%v", t.IsDefault),
+		})
+	}
+	table.Render()
+	return nil
+}
@@ -0,0 +1,44 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+
+	pkgtemplate "github.com/cnrancher/autok3s/pkg/template"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var removeCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"rm", "remove"},
+	Short:   "Delete a cluster template.",
+	Args:    cobra.ExactArgs(1),
+	Run:     utils.CommandExitWithoutHelpInfo(remove),
+}
+
+func init() {
+	removeCmd.Flags().StringVarP(&templateFlags.Provider, "provider", "p", templateFlags.Provider, "The provider of template, required if the template name exists for multiple providers")
+	removeCmd.Flags().BoolVarP(&templateFlags.isForce, "force", "f", false, "Force to delete a template.")
+}
+
+func remove(cmd *cobra.Command, args []string) error {
+	t, err := pkgtemplate.Get(args[0], templateFlags.Provider)
+	if err != nil {
+		return err
+	}
+	if !templateFlags.isForce {
+		if !utils.IsTerm() {
+			return errors.New("please using --force to delete a template")
+		}
+		if !utils.AskForConfirmation(fmt.Sprintf("are you going to remove template %s for provider %s", t.Name, t.Provider), false) {
+			return nil
+		}
+	}
+	if err := pkgtemplate.Delete(t.Name, t.Provider); err != nil {
+		return err
+	}
+	cmd.Printf("template %s for provider %s removed\n", t.Name, t.Provider)
+	return nil
+}
@@ -0,0 +1,25 @@
+package template
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	template = &cobra.Command{
+		Use:   "template",
+		Short: "The cluster template management.",
+		Long:  "The template command manages the cluster templates which seed the metadata, ssh and provider options of the cluster to create.",
+	}
+)
+
+func Command() *cobra.Command {
+	template.AddCommand(
+		CreateCmd(),
+		listCmd,
+		getCmd,
+		removeCmd,
+		exportCmd,
+		importCmd,
+	)
+	return template
+}
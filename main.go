@@ -9,7 +9,12 @@ import (
 	"github.com/cnrancher/autok3s/cmd"
 	"github.com/cnrancher/autok3s/cmd/addon"
 	"github.com/cnrancher/autok3s/cmd/airgap"
+	"github.com/cnrancher/autok3s/cmd/autoscaler"
+	"github.com/cnrancher/autok3s/cmd/k3d"
+	"github.com/cnrancher/autok3s/cmd/kubeconfig"
+	"github.com/cnrancher/autok3s/cmd/policy"
 	"github.com/cnrancher/autok3s/cmd/sshkey"
+	"github.com/cnrancher/autok3s/cmd/template"
 	"github.com/cnrancher/autok3s/pkg/cli/kubectl"
 	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/metrics"
@@ -40,9 +45,10 @@ func main() {
 
 	rootCmd := cmd.Command()
 	rootCmd.AddCommand(cmd.CompletionCommand(), cmd.VersionCommand(gitVersion, gitCommit, gitTreeState, buildDate),
-		cmd.ListCommand(), cmd.CreateCommand(), cmd.JoinCommand(), cmd.KubectlCommand(), cmd.DeleteCommand(),
+		cmd.ListCommand(), cmd.CreateCommand(), cmd.JoinCommand(), cmd.KubectlCommand(), cmd.DeleteCommand(), cmd.StopCommand(), cmd.StartCommand(), cmd.ImportCommand(),
 		cmd.SSHCommand(), cmd.DescribeCommand(), cmd.ServeCommand(), cmd.ExplorerCommand(), cmd.UpgradeCommand(),
-		cmd.TelemetryCommand(), airgap.Command(), sshkey.Command(), cmd.DashboardCommand(), addon.Command())
+		cmd.TelemetryCommand(), airgap.Command(), sshkey.Command(), cmd.DashboardCommand(), addon.Command(), template.Command(),
+		kubeconfig.Command(), policy.Command(), autoscaler.Command(), k3d.Command())
 
 	rootCmd.PersistentPreRun = func(c *cobra.Command, args []string) {
 		common.InitLogger(logrus.StandardLogger())
@@ -0,0 +1,283 @@
+package addon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	indexFilename   = "index.yaml"
+	gitCloneTimeout = 5 * time.Minute
+)
+
+var httpClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// catalogIndex is the index file of add-on repo, the paths in the index are relative to the index file.
+type catalogIndex struct {
+	Addons []catalogIndexEntry `json:"addons"`
+}
+
+type catalogIndexEntry struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Description  string            `json:"description,omitempty"`
+	Manifest     string            `json:"manifest"`
+	Values       map[string]string `json:"values,omitempty"`
+	ValuesSchema string            `json:"valuesSchema,omitempty"`
+	// Dependencies are the add-on references, the name without repo refers to the add-on of the same repo.
+	Dependencies    []string               `json:"dependencies,omitempty"`
+	ReadinessChecks common.ReadinessChecks `json:"readinessChecks,omitempty"`
+}
+
+// fetcher reads the files of the add-on repo.
+type fetcher interface {
+	read(path string) ([]byte, error)
+	close()
+}
+
+// DetectRepoType returns http for the http(s) url which is not ended with .git, otherwise returns git.
+func DetectRepoType(repoURL string) string {
+	if (strings.HasPrefix(repoURL, "http://") || strings.HasPrefix(repoURL, "https://")) &&
+		!strings.HasSuffix(strings.TrimSuffix(repoURL, "/"), ".git") {
+		return common.AddonRepoTypeHTTP
+	}
+	return common.AddonRepoTypeGit
+}
+
+// AddRepo validates and syncs the add-on repo, the repo is saved only if the sync succeed.
+func AddRepo(repo *common.AddonRepo) (int, error) {
+	if errs := validation.IsDNS1123Label(repo.Name); len(errs) > 0 {
+		return 0, fmt.Errorf("repo name %s is not valid, %v", repo.Name, errs)
+	}
+	exist, err := common.DefaultDB.GetAddonRepo(repo.Name)
+	if err != nil {
+		return 0, err
+	}
+	if exist != nil {
+		return 0, fmt.Errorf("add-on repo %s is already exist", repo.Name)
+	}
+	if repo.Type == "" {
+		repo.Type = DetectRepoType(repo.URL)
+	}
+	return SyncRepo(repo)
+}
+
+// SyncRepo fetches the index of the add-on repo and replaces the stored add-ons of the repo.
+func SyncRepo(repo *common.AddonRepo) (int, error) {
+	addons, err := fetchRepo(repo)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to sync add-on repo %s", repo.Name)
+	}
+	repo.LastSynced = time.Now()
+	if err := common.DefaultDB.SaveAddonRepo(repo); err != nil {
+		return 0, err
+	}
+	if err := common.DefaultDB.ReplaceCatalogAddons(repo.Name, addons); err != nil {
+		return 0, err
+	}
+	return len(addons), nil
+}
+
+func fetchRepo(repo *common.AddonRepo) ([]*common.CatalogAddon, error) {
+	var f fetcher
+	var err error
+	switch repo.Type {
+	case common.AddonRepoTypeGit:
+		f, err = newGitFetcher(repo.URL, repo.Branch)
+	case common.AddonRepoTypeHTTP:
+		f, err = newHTTPFetcher(repo.URL)
+	default:
+		err = fmt.Errorf("add-on repo type %s is not supported", repo.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.close()
+
+	data, err := f.read(indexFilename)
+	if err != nil {
+		return nil, err
+	}
+	index := &catalogIndex{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, errors.Wrap(err, "failed to decode add-on repo index")
+	}
+
+	rtn := make([]*common.CatalogAddon, 0, len(index.Addons))
+	exists := map[string]bool{}
+	for _, entry := range index.Addons {
+		if errs := validation.IsDNS1123Subdomain(entry.Name); len(errs) > 0 {
+			return nil, fmt.Errorf("add-on name %s is not valid, %v", entry.Name, errs)
+		}
+		if entry.Version == "" || strings.ContainsAny(entry.Version, "/@ ") {
+			return nil, fmt.Errorf("version %q of add-on %s is not valid", entry.Version, entry.Name)
+		}
+		if entry.Manifest == "" {
+			return nil, fmt.Errorf("manifest of add-on %s@%s is required", entry.Name, entry.Version)
+		}
+		id := entry.Name + "@" + entry.Version
+		if exists[id] {
+			return nil, fmt.Errorf("add-on %s is duplicated", id)
+		}
+		exists[id] = true
+
+		manifest, err := f.read(entry.Manifest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read manifest of add-on %s", id)
+		}
+		var schema []byte
+		if entry.ValuesSchema != "" {
+			if schema, err = f.read(entry.ValuesSchema); err != nil {
+				return nil, errors.Wrapf(err, "failed to read values schema of add-on %s", id)
+			}
+			if err := common.ValidateValuesSchema(schema); err != nil {
+				return nil, errors.Wrapf(err, "add-on %s", id)
+			}
+		}
+		if err := entry.ReadinessChecks.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "add-on %s", id)
+		}
+		dependencies := make([]string, 0, len(entry.Dependencies))
+		for _, dep := range entry.Dependencies {
+			if !strings.Contains(dep, "/") {
+				dep = repo.Name + "/" + dep
+			}
+			dependencies = append(dependencies, dep)
+		}
+		rtn = append(rtn, &common.CatalogAddon{
+			Repo:            repo.Name,
+			Name:            entry.Name,
+			Version:         entry.Version,
+			Description:     entry.Description,
+			Manifest:        manifest,
+			Values:          entry.Values,
+			ValuesSchema:    schema,
+			Dependencies:    dependencies,
+			ReadinessChecks: entry.ReadinessChecks,
+		})
+	}
+	return rtn, nil
+}
+
+type gitFetcher struct {
+	dir string
+}
+
+func newGitFetcher(repoURL, branch string) (fetcher, error) {
+	if err := common.CheckCommandExist("git"); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "autok3s-addon-repo-")
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"clone", "--depth", "1"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, "--", repoURL, dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), gitCloneTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to clone %s: %v, %s", repoURL, err, strings.TrimSpace(string(output)))
+	}
+	return &gitFetcher{dir: dir}, nil
+}
+
+func (g *gitFetcher) read(path string) ([]byte, error) {
+	// the symlinks are resolved, so that the files out of the repo can't be read through the symlinks in the repo.
+	root, err := filepath.EvalSymlinks(g.dir)
+	if err != nil {
+		return nil, err
+	}
+	full, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, err
+	}
+	if !isSubPath(root, full) {
+		return nil, fmt.Errorf("path %s is out of the repo", path)
+	}
+	return os.ReadFile(full)
+}
+
+func isSubPath(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (g *gitFetcher) close() {
+	_ = os.RemoveAll(g.dir)
+}
+
+type httpFetcher struct {
+	base *url.URL
+	// index is the index file name when the url is the index file.
+	index string
+}
+
+func newHTTPFetcher(repoURL string) (fetcher, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	// the url can be the index file or the directory of the index file.
+	index := ""
+	if strings.HasSuffix(u.Path, ".yaml") || strings.HasSuffix(u.Path, ".yml") {
+		i := strings.LastIndex(u.Path, "/") + 1
+		u.Path, index = u.Path[:i], u.Path[i:]
+	} else if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &httpFetcher{base: u, index: index}, nil
+}
+
+func (h *httpFetcher) read(path string) ([]byte, error) {
+	if path == indexFilename && h.index != "" {
+		path = h.index
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	target := h.base.ResolveReference(ref)
+	// the files must be served under the repo url, the absolute urls of other hosts in the index are rejected.
+	if target.Scheme != h.base.Scheme || target.Host != h.base.Host || !strings.HasPrefix(target.Path, h.base.Path) {
+		return nil, fmt.Errorf("path %s is out of the repo", path)
+	}
+	resp, err := httpClient.Get(target.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s, status code %d", target.String(), resp.StatusCode)
+	}
+	buff := &bytes.Buffer{}
+	if _, err := io.Copy(buff, resp.Body); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+func (h *httpFetcher) close() {}
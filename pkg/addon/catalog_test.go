@@ -0,0 +1,202 @@
+package addon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/common/commontest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testIndex = `addons:
+- name: nginx
+  version: 1.0.0
+  description: nginx web server
+  manifest: nginx/1.0.0.yaml
+  values:
+    replicas: "1"
+- name: nginx
+  version: 1.1.0
+  manifest: nginx/1.1.0.yaml
+  valuesSchema: nginx/schema.json
+`
+
+var testFiles = map[string]string{
+	"index.yaml":        testIndex,
+	"nginx/1.0.0.yaml":  "kind: Deployment # 1.0.0",
+	"nginx/1.1.0.yaml":  "kind: Deployment # 1.1.0",
+	"nginx/schema.json": `{"type": "object"}`,
+}
+
+func assertSyncedAddons(t *testing.T, repo string) {
+	addon, version, err := common.DefaultDB.GetAddonByRef(repo + "/nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "1.1.0", version)
+	assert.Equal(t, testFiles["nginx/1.1.0.yaml"], string(addon.Manifest))
+
+	catalogAddon, err := common.DefaultDB.GetCatalogAddon(repo, "nginx", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "nginx web server", catalogAddon.Description)
+	assert.Equal(t, "1", catalogAddon.Values["replicas"])
+
+	catalogAddon, err = common.DefaultDB.GetCatalogAddon(repo, "nginx", "1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testFiles["nginx/schema.json"], string(catalogAddon.ValuesSchema))
+}
+
+func TestSyncHTTPRepo(t *testing.T) {
+	commontest.InitStorage(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := testFiles[strings.TrimPrefix(r.URL.Path, "/catalog/")]
+		if !ok || !strings.HasPrefix(r.URL.Path, "/catalog/") {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	repo := &common.AddonRepo{Name: "web", URL: server.URL + "/catalog"}
+	count, err := AddRepo(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, common.AddonRepoTypeHTTP, repo.Type)
+	assertSyncedAddons(t, "web")
+
+	// the index url is also supported.
+	_, err = AddRepo(&common.AddonRepo{Name: "web-index", URL: server.URL + "/catalog/index.yaml"})
+	assert.NoError(t, err)
+
+	_, err = AddRepo(&common.AddonRepo{Name: "web", URL: server.URL + "/catalog"})
+	assert.Error(t, err)
+	_, err = AddRepo(&common.AddonRepo{Name: "missing", URL: server.URL + "/missing"})
+	assert.Error(t, err)
+	exist, err := common.DefaultDB.GetAddonRepo("missing")
+	assert.NoError(t, err)
+	assert.Nil(t, exist)
+}
+
+func TestSyncGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	commontest.InitStorage(t)
+
+	dir := t.TempDir()
+	bare := filepath.Join(dir, "catalog.git")
+	work := filepath.Join(dir, "work")
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v, %s", args, err, output)
+		}
+	}
+	git("init", "--bare", bare)
+	git("init", work)
+	for name, content := range testFiles {
+		path := filepath.Join(work, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("-C", work, "checkout", "-b", "release")
+	git("-C", work, "add", "-A")
+	git("-C", work, "commit", "-m", "init")
+	git("-C", work, "push", bare, "release")
+
+	repo := &common.AddonRepo{Name: "local", URL: bare, Branch: "release"}
+	count, err := AddRepo(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, common.AddonRepoTypeGit, repo.Type)
+	assertSyncedAddons(t, "local")
+
+	// the path out of the repo is rejected and the synced add-ons are kept.
+	git("-C", work, "rm", "-q", "index.yaml")
+	if err := os.WriteFile(filepath.Join(work, "index.yaml"), []byte("addons:\n- name: nginx\n  version: 1.0.0\n  manifest: ../nginx/1.0.0.yaml\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("-C", work, "add", "-A")
+	git("-C", work, "commit", "-m", "escape")
+	git("-C", work, "push", bare, "release")
+	_, err = SyncRepo(repo)
+	assert.Error(t, err)
+	assertSyncedAddons(t, "local")
+}
+
+func TestDetectRepoType(t *testing.T) {
+	assert.Equal(t, common.AddonRepoTypeHTTP, DetectRepoType("https://example.com/catalog"))
+	assert.Equal(t, common.AddonRepoTypeGit, DetectRepoType("https://github.com/example/catalog.git"))
+	assert.Equal(t, common.AddonRepoTypeGit, DetectRepoType("git@github.com:example/catalog.git"))
+	assert.Equal(t, common.AddonRepoTypeGit, DetectRepoType("/tmp/catalog"))
+}
+
+func TestGitFetcherSymlink(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(t.TempDir(), "id_rsa")
+	if err := os.WriteFile(secret, []byte("private key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte("kind: Deployment"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("manifest.yaml", filepath.Join(dir, "link.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(dir, "secret.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	g := &gitFetcher{dir: dir}
+	content, err := g.read("link.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "kind: Deployment", string(content))
+	_, err = g.read("secret.yaml")
+	assert.Error(t, err)
+	_, err = g.read("../id_rsa")
+	assert.Error(t, err)
+}
+
+func TestHTTPFetcherOutOfRepo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	h, err := newHTTPFetcher(server.URL + "/catalog/index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := h.read("nginx/1.0.0.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "/catalog/nginx/1.0.0.yaml", string(content))
+	content, err = h.read(server.URL + "/catalog/nginx/1.1.0.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "/catalog/nginx/1.1.0.yaml", string(content))
+
+	for _, path := range []string{"../secret.yaml", "/secret.yaml", "http://203.0.113.10/catalog/nginx.yaml"} {
+		_, err = h.read(path)
+		assert.Error(t, err, path)
+	}
+}
@@ -5,17 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/cnrancher/autok3s/pkg/common"
 
+	"github.com/docker/go-units"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -45,11 +49,22 @@ var (
 		checksumBaseName:    {checksumExt},
 	}
 	cancelDownloadMap = &sync.Map{}
+
+	// errResourceUnavailable means the resource can't be got from the source and retrying won't help,
+	// e.g. it doesn't exist or the access is denied.
+	errResourceUnavailable = errors.New("resource is unavailable")
+	// downloadRetries is the max attempts of downloading a resource, the backoff is doubled for each retry.
+	downloadRetries = 5
+	retryBackoff    = 2 * time.Second
+	// maxConcurrentArchDownloads is the max number of archs to download at the same time.
+	maxConcurrentArchDownloads = 2
 )
 
 type version struct {
 	Version string
 	Archs   []string
+	Images  []string `json:",omitempty"`
+	Charts  []string `json:",omitempty"`
 }
 
 func (v *version) diff(pkg common.Package) (toAdd, toDel []string) {
@@ -67,13 +82,18 @@ func (v *version) diff(pkg common.Package) (toAdd, toDel []string) {
 
 // DownloadPackage will update the package state and path for the package record
 func DownloadPackage(pkg common.Package, logger logrus.FieldLogger) error {
+	source, err := getSource(pkg.K3sVersion)
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	downloader := &downloader{
 		ctx:      ctx,
+		source:   source,
 		pkg:      pkg,
 		basePath: PackagePath(pkg.Name),
 	}
-	downloader.sourceURL = getSourceURL(pkg.K3sVersion)
+	downloader.pkg.DownloadedSize, downloader.pkg.TotalSize, downloader.pkg.Progress = 0, 0, 0
 	fields := logrus.Fields{
 		"package": pkg.Name,
 		"version": pkg.K3sVersion,
@@ -83,6 +103,8 @@ func DownloadPackage(pkg common.Package, logger logrus.FieldLogger) error {
 	} else {
 		downloader.logger = logrus.WithFields(fields)
 	}
+	downloader.progress = newProgress(downloader.logger, downloader.updateProgress)
+	downloader.logger.Infof("downloading resources from %s", source)
 
 	sort.Strings(downloader.pkg.Archs)
 	cancelDownloadMap.Store(pkg.Name, cancel)
@@ -96,10 +118,12 @@ func DownloadPackage(pkg common.Package, logger logrus.FieldLogger) error {
 
 type downloader struct {
 	ctx              context.Context
-	sourceURL        string
+	source           source
 	basePath         string
 	imageListContent []byte
 	pkg              common.Package
+	pkgLock          sync.Mutex
+	progress         *progress
 	logger           logrus.FieldLogger
 }
 
@@ -110,12 +134,14 @@ func (d *downloader) downloadPackage() (er error) {
 	}
 
 	toAddArchs, toDelArchs := version.diff(d.pkg)
+	extrasChanged := version != nil && !version.sameExtras(d.pkg)
 	if len(toAddArchs) == 0 &&
 		len(toDelArchs) == 0 &&
+		!extrasChanged &&
 		isDone(d.basePath) {
 		d.logger.Infof("the package %s is ready, skip downloading resources.", d.pkg.Name)
 		if d.pkg.State != common.PackageActive {
-			return updatePackageState(&d.pkg, common.PackageActive)
+			return d.updateState(common.PackageActive)
 		}
 		return nil
 	}
@@ -127,6 +153,12 @@ func (d *downloader) downloadPackage() (er error) {
 		}
 	}
 
+	if extrasChanged {
+		if err := d.removeExtras(version.Archs); err != nil {
+			return err
+		}
+	}
+
 	for _, reconcile := range []struct {
 		state common.State
 		f     func() error
@@ -138,19 +170,9 @@ func (d *downloader) downloadPackage() (er error) {
 		{f: d.writeVersion},
 		{
 			state: common.PackageDownloading,
-			f: func() error {
-				for _, arch := range d.pkg.Archs {
-					if err := os.MkdirAll(filepath.Join(d.basePath, arch), 0755); err != nil {
-						return err
-					}
-					d.logger.Infof("download %s resources", arch)
-					if err := d.downloadArch(arch); err != nil {
-						return err
-					}
-				}
-				return nil
-			},
+			f:     d.downloadArchs,
 		},
+		{f: d.downloadCharts},
 		{f: func() error { return done(d.basePath) }},
 		{state: common.PackageVerifying, f: func() error {
 			_, err := VerifyFiles(d.basePath)
@@ -162,12 +184,12 @@ func (d *downloader) downloadPackage() (er error) {
 			return d.ctx.Err()
 		}
 		if reconcile.state != "" {
-			if err := updatePackageState(&d.pkg, reconcile.state); err != nil {
+			if err := d.updateState(reconcile.state); err != nil {
 				return err
 			}
 		}
 		if err := reconcile.f(); err != nil {
-			if er := updatePackageState(&d.pkg, common.PackageOutOfSync); er != nil {
+			if er := d.updateState(common.PackageOutOfSync); er != nil {
 				d.logger.Warnf("failed to set package %s to %s state", d.pkg.Name, common.PackageOutOfSync)
 			}
 			return err
@@ -175,14 +197,55 @@ func (d *downloader) downloadPackage() (er error) {
 
 	}
 
+	d.pkgLock.Lock()
 	d.pkg.FilePath = d.basePath
-	if err := updatePackageState(&d.pkg, common.PackageActive); err != nil {
+	d.pkgLock.Unlock()
+	if err := d.updateState(common.PackageActive); err != nil {
 		return err
 	}
 
 	return err
 }
 
+// downloadArchs will download resources of archs in parallel, the number of archs downloading at the same time is limited.
+func (d *downloader) downloadArchs() error {
+	// the charts are downloaded after the archs, they're expected together so that the progress doesn't reach 100%
+	// before the charts are downloaded.
+	d.progress.expect(len(d.pkg.Archs)*len(resourceSuffixes) + len(d.pkg.Charts))
+	eg := errgroup.Group{}
+	eg.SetLimit(maxConcurrentArchDownloads)
+	for _, arch := range d.pkg.Archs {
+		if err := os.MkdirAll(filepath.Join(d.basePath, arch), 0755); err != nil {
+			return err
+		}
+		eg.Go(func() error {
+			d.logger.Infof("download %s resources", arch)
+			return d.downloadArch(arch)
+		})
+	}
+	return eg.Wait()
+}
+
+func (d *downloader) updateState(state common.State) error {
+	d.pkgLock.Lock()
+	defer d.pkgLock.Unlock()
+	return updatePackageState(&d.pkg, state)
+}
+
+// updateProgress saves the download progress to the package record so that the API can show it.
+// The package lock is released before saving, so the state updates aren't blocked by the database.
+func (d *downloader) updateProgress(downloaded, total int64, percent int) {
+	d.pkgLock.Lock()
+	d.pkg.DownloadedSize = downloaded
+	d.pkg.TotalSize = total
+	d.pkg.Progress = percent
+	name, progress := d.pkg.Name, d.pkg.Progress
+	d.pkgLock.Unlock()
+	if err := common.DefaultDB.SavePackageProgress(name, downloaded, total, progress); err != nil {
+		d.logger.Warnf("failed to save download progress of package %s, %v", name, err)
+	}
+}
+
 func (d *downloader) downloadArch(arch string) error {
 	if err := d.checkArchExists(arch); err != nil {
 		return err
@@ -190,6 +253,7 @@ func (d *downloader) downloadArch(arch string) error {
 	basePath := filepath.Join(d.basePath, arch)
 	if isDone(basePath) {
 		d.logger.Infof("arch %s has downloaded, skipped download process.", arch)
+		d.progress.skip(len(resourceSuffixes))
 		return nil
 	}
 
@@ -206,14 +270,15 @@ func (d *downloader) downloadArch(arch string) error {
 			// The download process is using tmp file to download. The file will be considered downloaded if the exact file exists.
 			if _, err := os.Lstat(fullPath); err == nil {
 				d.logger.Infof("%s resource %s exists, skip downloading", arch, basename)
+				d.progress.skip(1)
 				break
 			}
 
 			// resourceName is the file name online
 			resourceName := basename + suffix
 			d.logger.Infof("downloading %s for %s", localFileName, arch)
-			err := d.download(fullPath, d.getFileURL(resourceName))
-			if err != nil && err == context.Canceled {
+			err := d.download(fullPath, resourceName)
+			if err != nil && errors.Is(err, context.Canceled) {
 				d.logger.Warnf("failed to download resource %s for %s because of context cancel", localFileName, arch)
 				return err
 			} else if err != nil {
@@ -230,6 +295,10 @@ func (d *downloader) downloadArch(arch string) error {
 
 	d.logger.Infof("all downloaded files are validated for %s", arch)
 
+	if err := d.downloadExtraImages(arch); err != nil {
+		return err
+	}
+
 	if err := done(basePath); err != nil {
 		return err
 	}
@@ -238,26 +307,19 @@ func (d *downloader) downloadArch(arch string) error {
 	return nil
 }
 
-func (d *downloader) getFileURL(Filename string) string {
-	return fmt.Sprintf("%s/%s", d.sourceURL, Filename)
-}
-
 // validateVersion will download k3s-images.txt to check the version exists or not.
 func (d *downloader) validateVersion() error {
-	sourceURL := getSourceURL(d.pkg.K3sVersion)
-	downloadURL := fmt.Sprintf("%s/%s", sourceURL, imageListFilename)
-	resp, err := doRequestWithCtx(d.ctx, http.MethodGet, downloadURL, nil)
+	body, _, _, err := d.source.get(d.ctx, imageListFilename, 0)
+	if errors.Is(err, errResourceUnavailable) {
+		d.logger.Debugf("failed to download image list resource, %v", err)
+		return ErrVersionNotFound
+	}
 	if err != nil {
 		return errors.Wrapf(err, "failed to download image list of k3s version %s, this version may be not validated", d.pkg.K3sVersion)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		content, _ := io.ReadAll(resp.Body)
-		d.logger.Debugf("failed to download image list resource, status code %d, data %s", resp.StatusCode, string(content))
-		return ErrVersionNotFound
-	}
+	defer body.Close()
 
-	d.imageListContent, err = io.ReadAll(resp.Body)
+	d.imageListContent, err = io.ReadAll(body)
 	return err
 }
 
@@ -267,55 +329,117 @@ func (d *downloader) writeVersion() error {
 	versionJSON := versionContent(d.pkg)
 	_ = os.RemoveAll(versionPath)
 	_ = os.RemoveAll(getDonePath(d.basePath))
+	_ = RemoveSignature(d.basePath)
 	d.logger.Info("generating version file")
 	return os.WriteFile(versionPath, versionJSON, 0644)
 }
 
-// checkArchExists will fire HEAD request to server and check response
+// checkArchExists will check the checksum file of the arch exists in the source
 func (d *downloader) checkArchExists(arch string) error {
 	target := checksumBaseName + "-" + arch + checksumExt
-	resp, err := doRequestWithCtx(d.ctx, http.MethodHead, d.getFileURL(target), nil)
-	if err != nil {
-		return err
+	err := d.source.exists(d.ctx, target)
+	if errors.Is(err, errResourceUnavailable) {
+		return fmt.Errorf("%s may not exist, %v", arch, err)
 	}
-	defer resp.Body.Close()
-	if int(resp.StatusCode/100) != 2 {
-		return fmt.Errorf("%s may not exist", arch)
+	return err
+}
+
+func (d *downloader) download(file, resourceName string) error {
+	return d.downloadFrom(d.source, file, resourceName)
+}
+
+func (d *downloader) downloadFrom(src source, file, resourceName string) error {
+	var err error
+	for i := 0; i < downloadRetries; i++ {
+		if i > 0 {
+			backoff := retryBackoff * time.Duration(1<<uint(i-1))
+			d.logger.Warnf("failed to download resource %s, retry in %s (%d/%d), %v", resourceName, backoff, i, downloadRetries-1, err)
+			select {
+			case <-d.ctx.Done():
+				return d.ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+		err = d.downloadOnce(src, file, resourceName)
+		if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, errResourceUnavailable) {
+			return err
+		}
 	}
-	return nil
+	return err
 }
 
-func (d *downloader) download(file, fromURL string) error {
-	resp, err := doRequestWithCtx(d.ctx, http.MethodGet, fromURL, nil)
+// downloadOnce will resume the download from the existing tmp file if the source supports it.
+// The full file will be downloaded again if the source doesn't support resuming.
+func (d *downloader) downloadOnce(src source, file, resourceName string) error {
+	tmpFile := file + tmpSuffix
+	var offset int64
+	if info, err := os.Lstat(tmpFile); err == nil && info.Mode().IsRegular() {
+		offset = info.Size()
+	}
+
+	body, start, total, err := src.get(d.ctx, resourceName, offset)
+	if errors.Is(err, errRangeNotSatisfiable) {
+		// the tmp file can't be resumed, remove it and download again.
+		_ = os.RemoveAll(tmpFile)
+		return fmt.Errorf("failed to resume resource %s from offset %d", resourceName, offset)
+	}
 	if err != nil {
+		if d.ctx.Err() != nil {
+			return d.ctx.Err()
+		}
 		return err
 	}
-	defer resp.Body.Close()
-	if int(resp.StatusCode/100) != 2 {
-		content, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to download resource %s, %s", fromURL, string(content))
-	}
+	defer body.Close()
 
-	tmpFile := file + ".tmp"
-	_ = os.RemoveAll(tmpFile)
+	flag := os.O_CREATE | os.O_WRONLY
+	if start > 0 {
+		flag |= os.O_APPEND
+		d.logger.Infof("resume downloading %s from %s", filepath.Base(file), units.HumanSize(float64(start)))
+	} else {
+		flag |= os.O_TRUNC
+	}
 
-	fp, err := os.Create(tmpFile)
+	fp, err := os.OpenFile(tmpFile, flag, 0644)
 	if err != nil {
 		return err
 	}
 	defer fp.Close()
 
-	if _, err := io.Copy(fp, resp.Body); err != nil {
+	name := filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file)
+	d.progress.start(name, start, total)
+	if _, err := io.Copy(fp, d.progress.reader(name, body)); err != nil {
+		if d.ctx.Err() != nil {
+			return d.ctx.Err()
+		}
 		return err
 	}
+	if err := fp.Close(); err != nil {
+		return err
+	}
+	d.progress.finish(name)
 
 	return os.Rename(tmpFile, file)
 }
 
+// getContentRangeSize returns the full size from Content-Range header, e.g. bytes 100-199/200
+func getContentRangeSize(contentRange string) int64 {
+	i := strings.LastIndex(contentRange, "/")
+	if i < 0 {
+		return -1
+	}
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
 func versionContent(pkg common.Package) []byte {
 	version := version{
 		Version: pkg.K3sVersion,
 		Archs:   pkg.Archs,
+		Images:  pkg.Images,
+		Charts:  pkg.Charts,
 	}
 	data, _ := json.Marshal(version)
 	return data
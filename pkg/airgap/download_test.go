@@ -1,9 +1,17 @@
 package airgap
 
 import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -85,3 +93,143 @@ func TestSuffixWithArch(t *testing.T) {
 		assert.Equal(t, c.target, rtn)
 	}
 }
+
+func newTestDownloader(t *testing.T, s source) *downloader {
+	retryBackoff = time.Millisecond
+	logger := logrus.WithField("test", t.Name())
+	return &downloader{
+		ctx:      context.Background(),
+		source:   s,
+		logger:   logger,
+		progress: newProgress(logger, nil),
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1024)
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "k3s", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	target := filepath.Join(t.TempDir(), "k3s")
+	if err := os.WriteFile(target+tmpSuffix, content[:4096], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := newTestDownloader(t, &httpSource{baseURL: server.URL})
+	if err := d.download(target, "k3s"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, content, data)
+	assert.Equal(t, []string{"bytes=4096-"}, ranges)
+	_, err = os.Lstat(target + tmpSuffix)
+	assert.True(t, os.IsNotExist(err))
+
+	downloaded, total := d.progress.summary()
+	assert.Equal(t, int64(len(content)), downloaded)
+	assert.Equal(t, int64(len(content)), total)
+}
+
+func TestDownloadRetry(t *testing.T) {
+	content := []byte("k3s binary")
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	target := filepath.Join(t.TempDir(), "k3s")
+	d := newTestDownloader(t, &httpSource{baseURL: server.URL})
+	if err := d.download(target, "k3s"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, content, data)
+	assert.Equal(t, 3, requests)
+}
+
+func TestDownloadNotFound(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	d := newTestDownloader(t, &httpSource{baseURL: server.URL})
+	err := d.download(filepath.Join(t.TempDir(), "k3s"), "k3s")
+	assert.True(t, errors.Is(err, errResourceUnavailable))
+	assert.Equal(t, 1, requests)
+}
+
+func TestContentRangeSize(t *testing.T) {
+	assert.Equal(t, int64(200), getContentRangeSize("bytes 100-199/200"))
+	assert.Equal(t, int64(-1), getContentRangeSize("bytes 100-199/*"))
+	assert.Equal(t, int64(-1), getContentRangeSize(""))
+}
+
+func TestProgressReportWithoutLock(t *testing.T) {
+	var p *progress
+	var reports [][2]int64
+	p = newProgress(logrus.New(), func(downloaded, total int64, _ int) {
+		// the files can still be updated by the other readers during the report.
+		assert.True(t, p.TryLock())
+		p.Unlock()
+		reports = append(reports, [2]int64{downloaded, total})
+	})
+	p.start("k3s", 0, 10)
+	p.add("k3s", 4)
+	p.finish("k3s")
+	assert.Equal(t, [][2]int64{{4, 10}, {4, 10}}, reports)
+}
+
+func TestProgressPercent(t *testing.T) {
+	var percents []int
+	p := newProgress(logrus.New(), func(_, _ int64, percent int) {
+		percents = append(percents, percent)
+	})
+	p.expect(2)
+	p.start("amd64/k3s", 0, 10)
+	p.add("amd64/k3s", 10)
+	p.finish("amd64/k3s")
+	// the first file is finished before the size of the second one is known.
+	assert.Equal(t, 50, p.percent())
+	p.start("amd64/k3s-airgap-images.tar.gz", 0, 1000)
+	p.add("amd64/k3s-airgap-images.tar.gz", 999)
+	assert.Less(t, p.percent(), 100)
+	p.add("amd64/k3s-airgap-images.tar.gz", 1)
+	assert.Less(t, p.percent(), 100)
+	p.finish("amd64/k3s-airgap-images.tar.gz")
+	assert.Equal(t, 100, p.percent())
+
+	for i, percent := range percents {
+		if i < len(percents)-1 {
+			assert.Less(t, percent, 100)
+		}
+		if i > 0 {
+			assert.GreaterOrEqual(t, percent, percents[i-1])
+		}
+	}
+	assert.Equal(t, 100, percents[len(percents)-1])
+
+	// the skipped files are counted as finished.
+	p = newProgress(logrus.New(), nil)
+	p.expect(2)
+	p.skip(1)
+	assert.Equal(t, 50, p.percent())
+}
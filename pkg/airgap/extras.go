@@ -0,0 +1,416 @@
+package airgap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	extraImagesFilename   = "extra-images.tar"
+	extraChecksumFilename = "extra-sha256sum.txt"
+	chartsDirName         = "charts"
+	chartIndexFilename    = "index.json"
+	// bundledChartURLPrefix is the url of the charts served by k3s static file server in server/static/charts.
+	bundledChartURLPrefix = "https://%{KUBERNETES_API}%/static/charts/"
+)
+
+var imagePlatformVariants = map[string]string{
+	"arm": "v7",
+}
+
+// BundledChart is the helm chart stored in the airgap package.
+type BundledChart struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Source   string `json:"source"`
+	Filename string `json:"filename"`
+}
+
+// sameExtras returns true if the extra images and charts of the version are the same as the package.
+func (v *version) sameExtras(pkg common.Package) bool {
+	return equalStrings(v.Images, pkg.Images) && equalStrings(v.Charts, pkg.Charts)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// removeExtras removes the downloaded extra images and charts, so they will be downloaded again.
+func (d *downloader) removeExtras(archs []string) error {
+	d.logger.Info("extra images or charts are changed, removing downloaded extra resources")
+	for _, arch := range archs {
+		archBase := filepath.Join(d.basePath, arch)
+		for _, f := range []string{extraImagesFilename, extraChecksumFilename, doneFilename} {
+			if err := os.RemoveAll(filepath.Join(archBase, f)); err != nil {
+				return err
+			}
+		}
+	}
+	return os.RemoveAll(filepath.Join(d.basePath, chartsDirName))
+}
+
+// downloadExtraImages pulls the extra images of the arch and saves them to an OCI image tarball,
+// i.e. the tar of OCI image layout, which can be imported by k3s from the agent/images directory.
+func (d *downloader) downloadExtraImages(arch string) error {
+	archBase := filepath.Join(d.basePath, arch)
+	target := filepath.Join(archBase, extraImagesFilename)
+	_ = os.RemoveAll(filepath.Join(archBase, extraChecksumFilename))
+	if len(d.pkg.Images) == 0 {
+		return os.RemoveAll(target)
+	}
+
+	platform := v1.Platform{
+		OS:           "linux",
+		Architecture: arch,
+		Variant:      imagePlatformVariants[arch],
+	}
+	layoutDir := target + ".layout" + tmpSuffix
+	_ = os.RemoveAll(layoutDir)
+	defer func() {
+		_ = os.RemoveAll(layoutDir)
+	}()
+	imageLayout, err := layout.Write(layoutDir, empty.Index)
+	if err != nil {
+		return err
+	}
+	for _, image := range d.pkg.Images {
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			return errors.Wrapf(err, "image %s is invalid", image)
+		}
+		d.logger.Infof("pulling image %s for %s", image, arch)
+		img, err := remote.Image(ref,
+			remote.WithContext(d.ctx),
+			remote.WithPlatform(platform),
+			remote.WithAuthFromKeychain(authn.DefaultKeychain))
+		if err != nil {
+			return errors.Wrapf(err, "failed to pull image %s for %s", image, arch)
+		}
+		if err := imageLayout.AppendImage(img, layout.WithAnnotations(ociImageAnnotations(ref))); err != nil {
+			if d.ctx.Err() != nil {
+				return d.ctx.Err()
+			}
+			return errors.Wrapf(err, "failed to save image %s for %s", image, arch)
+		}
+	}
+
+	tmpFile := target + tmpSuffix
+	if err := tarDir(layoutDir, tmpFile); err != nil {
+		_ = os.RemoveAll(tmpFile)
+		return errors.Wrapf(err, "failed to save extra images for %s", arch)
+	}
+	if err := os.Rename(tmpFile, target); err != nil {
+		return err
+	}
+	d.logger.Infof("%d extra images saved for %s", len(d.pkg.Images), arch)
+	return writeChecksumFile(archBase, extraChecksumFilename, []string{extraImagesFilename})
+}
+
+// ociImageAnnotations returns the annotations of the image in the OCI index, the image name is set by
+// io.containerd.image.name which containerd uses to name the imported image, and the tag by the OCI ref name.
+func ociImageAnnotations(ref name.Reference) map[string]string {
+	annotations := map[string]string{
+		"io.containerd.image.name": ref.Name(),
+	}
+	if tag, ok := ref.(name.Tag); ok {
+		annotations["org.opencontainers.image.ref.name"] = tag.TagStr()
+	}
+	return annotations
+}
+
+// downloadCharts downloads the helm chart archives to the charts directory.
+// The chart can be an http(s) url or a local path of the chart archive.
+func (d *downloader) downloadCharts() error {
+	chartsDir := filepath.Join(d.basePath, chartsDirName)
+	if len(d.pkg.Charts) > 0 && verifyChecksumFile(chartsDir, checksumFilename) == nil {
+		d.logger.Info("charts have downloaded, skipped download process.")
+		d.progress.skip(len(d.pkg.Charts))
+		return nil
+	}
+	if err := os.RemoveAll(chartsDir); err != nil {
+		return err
+	}
+	if len(d.pkg.Charts) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(chartsDir, 0755); err != nil {
+		return err
+	}
+
+	charts := make([]BundledChart, 0, len(d.pkg.Charts))
+	filenames := make([]string, 0, len(d.pkg.Charts))
+	for i, chartRef := range d.pkg.Charts {
+		src, resourceName, err := getChartSource(chartRef)
+		if err != nil {
+			return err
+		}
+		d.logger.Infof("downloading chart %s", chartRef)
+		tmpPath := filepath.Join(chartsDir, fmt.Sprintf("chart-%d.tgz", i))
+		if err := d.downloadFrom(src, tmpPath, resourceName); err != nil {
+			return errors.Wrapf(err, "failed to download chart %s", chartRef)
+		}
+		chart, err := loader.LoadFile(tmpPath)
+		if err != nil {
+			return errors.Wrapf(err, "chart %s is not a valid chart archive", chartRef)
+		}
+		bundled := BundledChart{
+			Name:     chart.Metadata.Name,
+			Version:  chart.Metadata.Version,
+			Source:   chartRef,
+			Filename: fmt.Sprintf("%s-%s.tgz", chart.Metadata.Name, chart.Metadata.Version),
+		}
+		if err := os.Rename(tmpPath, filepath.Join(chartsDir, bundled.Filename)); err != nil {
+			return err
+		}
+		charts = append(charts, bundled)
+		filenames = append(filenames, bundled.Filename)
+	}
+
+	data, err := json.Marshal(charts)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(chartsDir, chartIndexFilename), data, 0644); err != nil {
+		return err
+	}
+	return writeChecksumFile(chartsDir, checksumFilename, append(filenames, chartIndexFilename))
+}
+
+func getChartSource(chartRef string) (source, string, error) {
+	u, err := url.Parse(chartRef)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		resourceName := path.Base(u.Path)
+		u.Path = path.Dir(u.Path)
+		return &httpSource{baseURL: u.String()}, resourceName, nil
+	}
+	if err == nil && u.Scheme == "file" {
+		chartRef = filepath.FromSlash(u.Path)
+	}
+	if _, err := os.Stat(chartRef); err != nil {
+		return nil, "", errors.Wrapf(err, "chart %s should be an http(s) url or an existing chart archive", chartRef)
+	}
+	abs, err := filepath.Abs(chartRef)
+	if err != nil {
+		return nil, "", err
+	}
+	return &localSource{dir: filepath.Dir(abs)}, filepath.Base(abs), nil
+}
+
+// verifyExtras checks the extra images and charts of the package with the checksum files generated in downloading.
+func verifyExtras(v *version, basePath string) error {
+	if len(v.Images) > 0 {
+		for _, arch := range v.Archs {
+			if err := verifyChecksumFile(filepath.Join(basePath, arch), extraChecksumFilename); err != nil {
+				return errors.Wrapf(err, "extra images for %s check fail", arch)
+			}
+		}
+	}
+	if len(v.Charts) > 0 {
+		if err := verifyChecksumFile(filepath.Join(basePath, chartsDirName), checksumFilename); err != nil {
+			return errors.Wrap(err, "charts check fail")
+		}
+	}
+	return nil
+}
+
+// GetBundledCharts returns the helm charts stored in the package path.
+func GetBundledCharts(packagePath string) ([]BundledChart, error) {
+	data, err := os.ReadFile(filepath.Join(packagePath, chartsDirName, chartIndexFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rtn := []BundledChart{}
+	if err := json.Unmarshal(data, &rtn); err != nil {
+		return nil, errors.Wrap(err, "failed to decode bundled charts index")
+	}
+	return rtn, nil
+}
+
+// GetPackageImages returns the k3s images of all archs and the extra images in the package.
+func GetPackageImages(pkg *common.Package) ([]string, error) {
+	images := map[string]struct{}{}
+	for _, arch := range pkg.Archs {
+		data, err := os.ReadFile(filepath.Join(pkg.FilePath, arch, imageListFilename))
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range strings.Split(string(data), "\n") {
+			if image = strings.TrimSpace(image); image != "" {
+				images[image] = struct{}{}
+			}
+		}
+	}
+	for _, image := range pkg.Images {
+		images[image] = struct{}{}
+	}
+	rtn := make([]string, 0, len(images))
+	for image := range images {
+		rtn = append(rtn, image)
+	}
+	sort.Strings(rtn)
+	return rtn, nil
+}
+
+// RewriteHelmCharts rewrites the HelmChart resources in the manifest to use the charts bundled in the airgap package.
+// The bundled charts are served by k3s and the repo and version of the HelmChart will be removed.
+func RewriteHelmCharts(manifest []byte, charts []BundledChart) ([]byte, error) {
+	if len(charts) == 0 {
+		return manifest, nil
+	}
+	docs := splitYAMLDocuments(manifest)
+	for i, doc := range docs {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return nil, err
+		}
+		if obj["kind"] != "HelmChart" || !strings.HasPrefix(fmt.Sprint(obj["apiVersion"]), "helm.cattle.io/") {
+			continue
+		}
+		spec, ok := obj["spec"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		chartName, _ := spec["chart"].(string)
+		chartVersion, _ := spec["version"].(string)
+		bundled := findBundledChart(charts, chartName, chartVersion)
+		if bundled == nil {
+			continue
+		}
+		spec["chart"] = bundledChartURLPrefix + bundled.Filename
+		delete(spec, "repo")
+		delete(spec, "version")
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = data
+	}
+	return joinYAMLDocuments(docs), nil
+}
+
+func findBundledChart(charts []BundledChart, chartName, chartVersion string) *BundledChart {
+	if chartName == "" || strings.HasPrefix(chartName, bundledChartURLPrefix) {
+		return nil
+	}
+	// chart can be <repo>/<name> or <name>
+	chartName = path.Base(chartName)
+	chartVersion = strings.TrimPrefix(chartVersion, "v")
+	for i, chart := range charts {
+		if chart.Name != chartName {
+			continue
+		}
+		if chartVersion == "" || strings.TrimPrefix(chart.Version, "v") == chartVersion {
+			return &charts[i]
+		}
+	}
+	return nil
+}
+
+func splitYAMLDocuments(manifest []byte) [][]byte {
+	rtn := [][]byte{}
+	current := &bytes.Buffer{}
+	for _, line := range strings.SplitAfter(string(manifest), "\n") {
+		if strings.TrimRight(line, " \r\n") == "---" {
+			if len(bytes.TrimSpace(current.Bytes())) > 0 {
+				rtn = append(rtn, current.Bytes())
+			}
+			current = &bytes.Buffer{}
+			continue
+		}
+		current.WriteString(line)
+	}
+	if len(bytes.TrimSpace(current.Bytes())) > 0 {
+		rtn = append(rtn, current.Bytes())
+	}
+	return rtn
+}
+
+func joinYAMLDocuments(docs [][]byte) []byte {
+	buff := &bytes.Buffer{}
+	for _, doc := range docs {
+		buff.WriteString("---\n")
+		buff.Write(doc)
+		if !bytes.HasSuffix(doc, []byte("\n")) {
+			buff.WriteString("\n")
+		}
+	}
+	return buff.Bytes()
+}
+
+func writeChecksumFile(dir, checksumFile string, filenames []string) error {
+	sort.Strings(filenames)
+	buff := &bytes.Buffer{}
+	for _, filename := range filenames {
+		hash, err := getFileHash(filepath.Join(dir, filename))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buff, "%s  %s\n", hash, filename)
+	}
+	return os.WriteFile(filepath.Join(dir, checksumFile), buff.Bytes(), 0644)
+}
+
+func verifyChecksumFile(dir, checksumFile string) error {
+	checksumMap, err := getHashMapFromFile(filepath.Join(dir, checksumFile))
+	if err != nil {
+		return err
+	}
+	if len(checksumMap) == 0 {
+		return fmt.Errorf("checksum file %s is empty", checksumFile)
+	}
+	for filename, hash := range checksumMap {
+		ok, err := checkFileHash(filepath.Join(dir, filename), hash)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("checksum for file %s mismatch", filename)
+		}
+	}
+	return nil
+}
+
+func getFileHash(filepath string) (string, error) {
+	hasher := sha256.New()
+	fp, err := os.Open(filepath)
+	if err != nil {
+		return "", err
+	}
+	defer fp.Close()
+	if _, err := io.Copy(hasher, fp); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
@@ -0,0 +1,223 @@
+package airgap
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteHelmCharts(t *testing.T) {
+	manifest := `
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: cert-manager
+---
+apiVersion: helm.cattle.io/v1
+kind: HelmChart
+metadata:
+  namespace: kube-system
+  name: cert-manager
+spec:
+  targetNamespace: cert-manager
+  version: v1.11.0
+  chart: cert-manager
+  repo: https://charts.jetstack.io
+---
+apiVersion: helm.cattle.io/v1
+kind: HelmChart
+metadata:
+  namespace: kube-system
+  name: rancher
+spec:
+  chart: rancher
+  repo: https://releases.rancher.com/server-charts/latest
+`
+	charts := []BundledChart{
+		{
+			Name:     "cert-manager",
+			Version:  "v1.11.0",
+			Filename: "cert-manager-v1.11.0.tgz",
+		},
+	}
+	rtn, err := RewriteHelmCharts([]byte(manifest), charts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := splitYAMLDocuments(rtn)
+	assert.Equal(t, 3, len(docs))
+	assert.Contains(t, string(docs[0]), "kind: Namespace")
+	assert.Contains(t, string(docs[1]), "chart: https://%{KUBERNETES_API}%/static/charts/cert-manager-v1.11.0.tgz")
+	assert.NotContains(t, string(docs[1]), "repo:")
+	assert.NotContains(t, string(docs[1]), "version:")
+	assert.Contains(t, string(docs[2]), "repo: https://releases.rancher.com/server-charts/latest")
+
+	unchanged, err := RewriteHelmCharts([]byte(manifest), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, manifest, string(unchanged))
+}
+
+func TestFindBundledChart(t *testing.T) {
+	charts := []BundledChart{
+		{Name: "rancher", Version: "2.7.9"},
+		{Name: "rancher", Version: "2.8.0"},
+	}
+	assert.Equal(t, "2.8.0", findBundledChart(charts, "rancher-latest/rancher", "v2.8.0").Version)
+	assert.Equal(t, "2.7.9", findBundledChart(charts, "rancher", "").Version)
+	assert.Nil(t, findBundledChart(charts, "rancher", "2.6.0"))
+	assert.Nil(t, findBundledChart(charts, "cert-manager", ""))
+}
+
+func writeTestChart(t *testing.T, path, chartName, chartVersion string) {
+	buff := &bytes.Buffer{}
+	gw := gzip.NewWriter(buff)
+	tw := tar.NewWriter(gw)
+	chartYAML := "apiVersion: v2\nname: " + chartName + "\nversion: " + chartVersion + "\n"
+	if err := tw.WriteHeader(&tar.Header{
+		Name: chartName + "/Chart.yaml",
+		Mode: 0644,
+		Size: int64(len(chartYAML)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(chartYAML)); err != nil {
+		t.Fatal(err)
+	}
+	_ = tw.Close()
+	_ = gw.Close()
+	if err := os.WriteFile(path, buff.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownloadCharts(t *testing.T) {
+	chartPath := filepath.Join(t.TempDir(), "demo.tgz")
+	writeTestChart(t, chartPath, "demo", "0.1.0")
+
+	d := newTestDownloader(t, nil)
+	d.basePath = t.TempDir()
+	d.pkg = common.Package{Charts: []string{chartPath}}
+	if err := d.downloadCharts(); err != nil {
+		t.Fatal(err)
+	}
+
+	charts, err := GetBundledCharts(d.basePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []BundledChart{{
+		Name:     "demo",
+		Version:  "0.1.0",
+		Source:   chartPath,
+		Filename: "demo-0.1.0.tgz",
+	}}, charts)
+	assert.NoError(t, verifyExtras(&version{Charts: d.pkg.Charts}, d.basePath))
+
+	if err := os.WriteFile(filepath.Join(d.basePath, chartsDirName, "demo-0.1.0.tgz"), []byte("modified"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, verifyExtras(&version{Charts: d.pkg.Charts}, d.basePath))
+}
+
+func TestDownloadExtraImages(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	image := u.Host + "/library/demo:v1"
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+
+	d := newTestDownloader(t, nil)
+	d.basePath = t.TempDir()
+	d.pkg = common.Package{Images: []string{image}}
+	if err := os.MkdirAll(filepath.Join(d.basePath, "amd64"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.downloadExtraImages("amd64"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tarball is an OCI image layout with the image name for containerd.
+	layoutDir := t.TempDir()
+	untar(t, filepath.Join(d.basePath, "amd64", extraImagesFilename), layoutDir)
+	_, err = os.Stat(filepath.Join(layoutDir, "oci-layout"))
+	assert.NoError(t, err)
+	index, err := layout.ImageIndexFromPath(layoutDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, manifest.Manifests, 1)
+	digest, _ := img.Digest()
+	assert.Equal(t, digest, manifest.Manifests[0].Digest)
+	assert.Equal(t, ref.Name(), manifest.Manifests[0].Annotations["io.containerd.image.name"])
+	assert.Equal(t, "v1", manifest.Manifests[0].Annotations["org.opencontainers.image.ref.name"])
+	saved, err := index.Image(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := saved.Layers()
+	assert.NoError(t, err)
+	assert.Len(t, layers, 1)
+	assert.NoError(t, verifyExtras(&version{Archs: []string{"amd64"}, Images: d.pkg.Images}, d.basePath))
+}
+
+func untar(t *testing.T, file, dir string) {
+	fp, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	tr := tar.NewReader(fp)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		target := filepath.Join(dir, header.Name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -51,6 +51,15 @@ var (
 			targetPath: "/usr/local/bin",
 		},
 	}
+	extraImagesFileMap = fileMap{
+		mode:           0644,
+		dataDirSubpath: "agent/images",
+	}
+	// charts in server/static/charts are served by k3s with https://%{KUBERNETES_API}%/static/charts/ url.
+	chartFileMap = fileMap{
+		mode:           0644,
+		dataDirSubpath: "server/static/charts",
+	}
 	parseArchMap = map[string]string{
 		"x86_64":  "amd64",
 		"aarch64": "arm64",
@@ -58,8 +67,10 @@ var (
 	}
 )
 
-func ScpFiles(logger *logrus.Logger, clusterName string, pkg *common.Package, dialer *dialer.SSHDialer, extraArgs string) (er error) {
-	dataPath := getDataPath(extraArgs)
+// ScpFiles copies the package resources to the node, the bundled charts are only copied to the master nodes.
+// The package must be returned by PreparePackage, which verifies it once for all the nodes of the install.
+func ScpFiles(logger *logrus.Logger, clusterName string, pkg *common.Package, dialer *dialer.SSHDialer, extraArgs string, master bool) (er error) {
+	dataPath := DataPath(extraArgs)
 	conn := dialer.GetClient()
 	fieldLogger := logger.WithFields(logrus.Fields{
 		"cluster":   clusterName,
@@ -82,7 +93,7 @@ func ScpFiles(logger *logrus.Logger, clusterName string, pkg *common.Package, di
 	}
 
 	fieldLogger.Infof("Get remote server arch %s", arch)
-	files, err := getScpFileMap(arch, pkg)
+	files, err := getScpFileMap(arch, pkg, master)
 	if err != nil {
 		return err
 	}
@@ -159,6 +170,8 @@ func ScpFiles(logger *logrus.Logger, clusterName string, pkg *common.Package, di
 	return nil
 }
 
+// PreparePackage returns the package of the cluster after verifying its content and signature,
+// it's called once before the package is copied to the nodes.
 func PreparePackage(cluster *types.Cluster) (*common.Package, error) {
 	clusterName := cluster.Name
 	packageName := cluster.PackageName
@@ -173,6 +186,9 @@ func PreparePackage(cluster *types.Cluster) (*common.Package, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := VerifyPackageSignature(pkgs[0].FilePath); err != nil {
+			return nil, errors.Wrapf(err, "failed to verify signature of package %s", packageName)
+		}
 		return &pkgs[0], nil
 	}
 
@@ -198,6 +214,9 @@ func PreparePackage(cluster *types.Cluster) (*common.Package, error) {
 	}
 
 	rtn, err := VerifyFiles(currentPath)
+	if err == nil {
+		err = VerifyPackageSignature(currentPath)
+	}
 	if err != nil {
 		if tmpPath != "" {
 			_ = os.RemoveAll(currentPath)
@@ -226,8 +245,9 @@ func parseUnameArch(output string) string {
 	return output
 }
 
-// getScpFileMap will return the local file path for specific arch to target file map
-func getScpFileMap(arch string, pkg *common.Package) (map[string]fileMap, error) {
+// getScpFileMap will return the local file path for specific arch to target file map,
+// charts are only served by k3s servers so they are skipped for agents.
+func getScpFileMap(arch string, pkg *common.Package, master bool) (map[string]fileMap, error) {
 	var rtn = make(map[string]fileMap, len(remoteFileMap))
 	archBasePath := filepath.Join(pkg.FilePath, arch)
 	for key, file := range remoteFileMap {
@@ -248,6 +268,24 @@ func getScpFileMap(arch string, pkg *common.Package) (map[string]fileMap, error)
 			return nil, fmt.Errorf("resource file %s is missing in package %s", key, pkg.FilePath)
 		}
 	}
+
+	if len(pkg.Images) > 0 {
+		filename := filepath.Join(archBasePath, extraImagesFilename)
+		if _, err := os.Lstat(filename); err != nil {
+			return nil, fmt.Errorf("extra images file is missing in package %s", pkg.FilePath)
+		}
+		rtn[filename] = extraImagesFileMap
+	}
+	if !master {
+		return rtn, nil
+	}
+	charts, err := GetBundledCharts(pkg.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, chart := range charts {
+		rtn[filepath.Join(pkg.FilePath, chartsDirName, chart.Filename)] = chartFileMap
+	}
 	return rtn, nil
 }
 
@@ -255,7 +293,8 @@ func getRemoteTmpDir(clustername string) string {
 	return filepath.Join(remoteTmpDir, clustername)
 }
 
-func getDataPath(extraArgs string) string {
+// DataPath returns the k3s data dir set by the --data-dir or -d of the extra args, or the default data dir.
+func DataPath(extraArgs string) string {
 	dataPath := defaultDataDirPath
 	args := strings.Split(extraArgs, " ")
 	for i, arg := range args {
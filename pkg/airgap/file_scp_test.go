@@ -1,8 +1,12 @@
 package airgap
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/cnrancher/autok3s/pkg/common"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,7 +25,29 @@ func TestGetDataPath(t *testing.T) {
 		{name: "data dir args with short name and equal sign", args: "-d=/data", expectPath: "/data"},
 		{name: "wrong data dir args", args: "--data-dir", expectPath: defaultDataDirPath},
 	} {
-		path := getDataPath(c.args)
+		path := DataPath(c.args)
 		assert.Equalf(t, c.expectPath, path, "test: %s failed", c.name)
 	}
 }
+
+func TestGetScpFileMapChartsOnMasters(t *testing.T) {
+	base := t.TempDir()
+	for _, f := range []string{"amd64/k3s", "amd64/k3s-airgap-images.tar.gz", "charts/foo-1.0.0.tgz"} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(base, f)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(base, f), []byte(f), 0644))
+	}
+	index := `[{"name":"foo","version":"1.0.0","filename":"foo-1.0.0.tgz"}]`
+	assert.NoError(t, os.WriteFile(filepath.Join(base, chartsDirName, chartIndexFilename), []byte(index), 0644))
+	pkg := &common.Package{FilePath: base}
+	chart := filepath.Join(base, chartsDirName, "foo-1.0.0.tgz")
+
+	files, err := getScpFileMap("amd64", pkg, true)
+	assert.NoError(t, err)
+	assert.Equal(t, chartFileMap, files[chart])
+	assert.Len(t, files, 4)
+
+	files, err = getScpFileMap("amd64", pkg, false)
+	assert.NoError(t, err)
+	assert.NotContains(t, files, chart)
+	assert.Len(t, files, 3)
+}
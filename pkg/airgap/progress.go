@@ -0,0 +1,208 @@
+package airgap
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/sirupsen/logrus"
+)
+
+var progressReportInterval = 5 * time.Second
+
+type fileProgress struct {
+	current int64
+	total   int64
+	done    bool
+}
+
+// progress tracks the downloaded bytes of all the resources in a package,
+// the report function is called with the summary at most once per progressReportInterval.
+// The sizes of the files are only known when they are started, so the percent is counted by files:
+// the finished and skipped files count as a whole and the downloading ones by their downloaded bytes,
+// out of the files expected to be downloaded.
+// The report function is called without holding the lock of files, so the readers aren't blocked by the report,
+// and the reports are serialized by reportLock so that a stale summary won't be reported after a newer one.
+type progress struct {
+	sync.Mutex
+	reportLock sync.Mutex
+	files      map[string]*fileProgress
+	expected   int
+	skipped    int
+	lastReport time.Time
+	logger     logrus.FieldLogger
+	report     func(downloaded, total int64, percent int)
+}
+
+func newProgress(logger logrus.FieldLogger, report func(downloaded, total int64, percent int)) *progress {
+	return &progress{
+		files:  map[string]*fileProgress{},
+		logger: logger,
+		report: report,
+	}
+}
+
+// expect adds the number of files to be downloaded.
+func (p *progress) expect(n int) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.expected += n
+}
+
+// skip counts the expected files which are already downloaded as finished.
+func (p *progress) skip(n int) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.skipped += n
+}
+
+// start registers the file with the bytes already downloaded and the full size of the file.
+func (p *progress) start(name string, current, total int64) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.files[name] = &fileProgress{
+		current: current,
+		total:   total,
+	}
+}
+
+func (p *progress) add(name string, n int64) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	f, ok := p.files[name]
+	if !ok {
+		p.Unlock()
+		return
+	}
+	f.current += n
+	if time.Since(p.lastReport) < progressReportInterval {
+		p.Unlock()
+		return
+	}
+	p.lastReport = time.Now()
+	p.logger.Infof("downloading %s: %s", name, humanProgress(f.current, f.total))
+	p.Unlock()
+	p.doReport()
+}
+
+// finish forces a report after the file is downloaded.
+func (p *progress) finish(name string) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	if f, ok := p.files[name]; ok {
+		f.done = true
+		p.logger.Infof("downloaded %s: %s", name, humanProgress(f.current, f.total))
+	}
+	p.lastReport = time.Now()
+	p.Unlock()
+	p.doReport()
+}
+
+func (p *progress) summary() (downloaded, total int64) {
+	for _, f := range p.files {
+		downloaded += f.current
+		total += f.total
+	}
+	return
+}
+
+// percent returns the percent of the expected files, it's 100 only if all the files are finished.
+func (p *progress) percent() int {
+	expected := p.expected
+	if started := p.skipped + len(p.files); started > expected {
+		expected = started
+	}
+	if expected == 0 {
+		return 0
+	}
+	finished := float64(p.skipped)
+	all := true
+	for _, f := range p.files {
+		switch {
+		case f.done:
+			finished++
+		case f.total > 0:
+			finished += float64(min(f.current, f.total)) / float64(f.total)
+			all = false
+		default:
+			all = false
+		}
+	}
+	if p.skipped+len(p.files) < expected {
+		all = false
+	}
+	rtn := int(finished * 100 / float64(expected))
+	if !all && rtn >= 100 {
+		rtn = 99
+	}
+	return rtn
+}
+
+// doReport takes the summary under the lock and reports it after the lock is released.
+func (p *progress) doReport() {
+	if p.report == nil {
+		return
+	}
+	p.reportLock.Lock()
+	defer p.reportLock.Unlock()
+	p.Lock()
+	downloaded, total := p.summary()
+	percent := p.percent()
+	p.Unlock()
+	p.report(downloaded, total, percent)
+}
+
+// reader wraps the source reader and counts the bytes read for the file.
+func (p *progress) reader(name string, r io.Reader) io.Reader {
+	return &progressReader{
+		name:     name,
+		reader:   r,
+		progress: p,
+	}
+}
+
+type progressReader struct {
+	name     string
+	reader   io.Reader
+	progress *progress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	if n > 0 {
+		r.progress.add(r.name, int64(n))
+	}
+	return n, err
+}
+
+func percent(current, total int64) int {
+	if total <= 0 {
+		return 0
+	}
+	if current >= total {
+		return 100
+	}
+	return int(current * 100 / total)
+}
+
+func humanProgress(current, total int64) string {
+	if total <= 0 {
+		return units.HumanSize(float64(current))
+	}
+	return fmt.Sprintf("%s/%s (%d%%)", units.HumanSize(float64(current)), units.HumanSize(float64(total)), percent(current, total))
+}
@@ -0,0 +1,257 @@
+package airgap
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/settings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	signatureFilename  = "signature.json"
+	signatureAlgorithm = "ed25519"
+	logFilename        = "log"
+	privateKeyPEMType  = "PRIVATE KEY"
+	publicKeyPEMType   = "PUBLIC KEY"
+)
+
+var (
+	ErrPackageUnsigned   = errors.New("package is not signed")
+	ErrSignatureMismatch = errors.New("package signature mismatch")
+	ErrUntrustedKey      = errors.New("package is signed by an untrusted key")
+)
+
+// Signature is the detached signature of the package content.
+type Signature struct {
+	KeyID     string    `json:"keyID"`
+	Algorithm string    `json:"algorithm"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TrustedKey is the public key in package trust store.
+type TrustedKey struct {
+	ID  string
+	Key ed25519.PublicKey
+}
+
+// GenerateSigningKey generates ed25519 key pair with PEM format.
+func GenerateSigningKey() (privateKeyPEM, publicKeyPEM []byte, err error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: privateKeyPEMType, Bytes: privateBytes}),
+		pem.EncodeToMemory(&pem.Block{Type: publicKeyPEMType, Bytes: publicBytes}), nil
+}
+
+// ParsePrivateKey parses the ed25519 private key with PEM format.
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != privateKeyPEMType {
+		return nil, errors.New("failed to decode PEM private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("only %s private key is supported", signatureAlgorithm)
+	}
+	return privateKey, nil
+}
+
+// ParsePublicKeys parses all the ed25519 public keys with PEM format in data.
+func ParsePublicKeys(data []byte) ([]TrustedKey, error) {
+	rtn := []TrustedKey{}
+	rest := bytes.TrimSpace(data)
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("failed to decode PEM public key")
+		}
+		if block.Type != publicKeyPEMType {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("only %s public key is supported", signatureAlgorithm)
+		}
+		rtn = append(rtn, TrustedKey{
+			ID:  KeyID(publicKey),
+			Key: publicKey,
+		})
+		rest = bytes.TrimSpace(rest)
+	}
+	return rtn, nil
+}
+
+// EncodePublicKey encodes the ed25519 public key with PEM format.
+func EncodePublicKey(publicKey ed25519.PublicKey) ([]byte, error) {
+	data, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: publicKeyPEMType, Bytes: data}), nil
+}
+
+// KeyID returns the fingerprint of the public key.
+func KeyID(publicKey ed25519.PublicKey) string {
+	hash := sha256.Sum256(publicKey)
+	return hex.EncodeToString(hash[:8])
+}
+
+// GetTrustedKeys returns the public keys configured in package-trusted-keys setting.
+func GetTrustedKeys() ([]TrustedKey, error) {
+	return ParsePublicKeys([]byte(settings.PackageTrustedKeys.Get()))
+}
+
+// SignPackage signs the files of the package and writes the signature file to the package path.
+func SignPackage(basePath string, privateKey ed25519.PrivateKey) (*Signature, error) {
+	digest, err := packageDigest(basePath)
+	if err != nil {
+		return nil, err
+	}
+	signature := &Signature{
+		KeyID:     KeyID(privateKey.Public().(ed25519.PublicKey)),
+		Algorithm: signatureAlgorithm,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, digest)),
+		CreatedAt: time.Now().UTC(),
+	}
+	data, err := json.Marshal(signature)
+	if err != nil {
+		return nil, err
+	}
+	return signature, os.WriteFile(filepath.Join(basePath, signatureFilename), data, 0644)
+}
+
+// VerifyPackageSignature verifies the package signature with the trusted keys.
+// The verification is skipped if there's no trusted key configured, otherwise the package must be signed by a trusted key.
+// The package content is hashed for each verification, so that the package modified after the last verification is rejected.
+func VerifyPackageSignature(basePath string) error {
+	keys, err := GetTrustedKeys()
+	if err != nil {
+		return errors.Wrap(err, "failed to load package trusted keys")
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(basePath, signatureFilename))
+	if os.IsNotExist(err) {
+		return ErrPackageUnsigned
+	}
+	if err != nil {
+		return err
+	}
+
+	signature := &Signature{}
+	if err := json.Unmarshal(data, signature); err != nil {
+		return errors.Wrap(err, "failed to decode package signature")
+	}
+	if signature.Algorithm != signatureAlgorithm {
+		return fmt.Errorf("signature algorithm %s is not supported", signature.Algorithm)
+	}
+	var publicKey ed25519.PublicKey
+	for _, key := range keys {
+		if key.ID == signature.KeyID {
+			publicKey = key.Key
+			break
+		}
+	}
+	if publicKey == nil {
+		return errors.Wrapf(ErrUntrustedKey, "key id %s", signature.KeyID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode package signature")
+	}
+	digest, err := packageDigest(basePath)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, digest, sig) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// RemoveSignature removes the signature of the package as the package content is going to change.
+func RemoveSignature(basePath string) error {
+	return os.RemoveAll(filepath.Join(basePath, signatureFilename))
+}
+
+// packageDigest returns the sha256 digest of the sorted list of file hashes in the package.
+func packageDigest(basePath string) ([]byte, error) {
+	files, err := packageFiles(basePath)
+	if err != nil {
+		return nil, err
+	}
+	hasher := sha256.New()
+	for _, f := range files {
+		hash, err := getFileHash(filepath.Join(basePath, f))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(hasher, "%s  %s\n", hash, f)
+	}
+	return hasher.Sum(nil), nil
+}
+
+// packageFiles returns the sorted relative paths of the package files, the signature file and download log are excluded.
+func packageFiles(basePath string) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(basePath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == tmpDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(basePath, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == signatureFilename || rel == logFilename || strings.HasSuffix(rel, tmpSuffix) {
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
@@ -0,0 +1,103 @@
+package airgap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/settings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestPackage(t *testing.T) string {
+	basePath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(basePath, "amd64"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"version.json": `{"version":"v1.26.4+k3s1","archs":["amd64"]}`,
+		"amd64/k3s":    "k3s binary",
+		logFilename:    "download log",
+	} {
+		if err := os.WriteFile(filepath.Join(basePath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return basePath
+}
+
+func TestPackageSignature(t *testing.T) {
+	privatePEM, publicPEM, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPublicPEM, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	basePath := writeTestPackage(t)
+	// no trusted key configured, verification is skipped.
+	setSettingForTest(t, settings.PackageTrustedKeys, "")
+	assert.NoError(t, VerifyPackageSignature(basePath))
+
+	setSettingForTest(t, settings.PackageTrustedKeys, string(publicPEM))
+	assert.ErrorIs(t, VerifyPackageSignature(basePath), ErrPackageUnsigned)
+
+	signature, err := SignPackage(basePath, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := ParsePublicKeys(publicPEM)
+	assert.Equal(t, keys[0].ID, signature.KeyID)
+	assert.NoError(t, VerifyPackageSignature(basePath))
+
+	// download log is not part of the package content.
+	if err := os.WriteFile(filepath.Join(basePath, logFilename), []byte("more log"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, VerifyPackageSignature(basePath))
+
+	// the tampered content is detected even if the size and the modified time are kept.
+	k3sPath := filepath.Join(basePath, "amd64", "k3s")
+	info, err := os.Stat(k3sPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(k3sPath, []byte("k3s binarx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(k3sPath, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	assert.ErrorIs(t, VerifyPackageSignature(basePath), ErrSignatureMismatch)
+
+	setSettingForTest(t, settings.PackageTrustedKeys, string(otherPublicPEM))
+	assert.ErrorIs(t, VerifyPackageSignature(basePath), ErrUntrustedKey)
+
+	assert.NoError(t, RemoveSignature(basePath))
+	assert.ErrorIs(t, VerifyPackageSignature(basePath), ErrPackageUnsigned)
+}
+
+func TestParsePublicKeys(t *testing.T) {
+	_, first, _ := GenerateSigningKey()
+	_, second, _ := GenerateSigningKey()
+	keys, err := ParsePublicKeys(append(append(first, '\n'), second...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(keys))
+	encoded, err := EncodePublicKey(keys[1].Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, second, encoded)
+
+	_, err = ParsePublicKeys([]byte("invalid"))
+	assert.Error(t, err)
+}
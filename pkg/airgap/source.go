@@ -0,0 +1,354 @@
+package airgap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/cnrancher/autok3s/pkg/settings"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultSourceName   = "github"
+	defaultPathTemplate = "{{ .Version }}"
+)
+
+var (
+	errRangeNotSatisfiable = errors.New("range not satisfiable")
+	// builtinSources are the validated source names which can be used as package-download-source setting directly.
+	builtinSources = map[string]builtinSource{
+		"github": {
+			baseURL:      "https://github.com/k3s-io/k3s/releases/download",
+			pathTemplate: "{{ .Version | urlquery }}",
+		},
+		"aliyunoss": {
+			baseURL:      "https://rancher-mirror.rancher.cn/k3s",
+			pathTemplate: `{{ .Version | replace "+" "-" | urlquery }}`,
+		},
+	}
+)
+
+type builtinSource struct {
+	baseURL      string
+	pathTemplate string
+}
+
+// source is where the package resources are downloaded from.
+type source interface {
+	// exists returns errResourceUnavailable if the resource doesn't exist or can't be accessed in the source.
+	exists(ctx context.Context, name string) error
+	// get returns the content of the resource starting from offset.
+	// The start is where the content actually starts from as the source may not support resuming, the total is the full size of the resource.
+	get(ctx context.Context, name string, offset int64) (body io.ReadCloser, start, total int64, err error)
+	String() string
+}
+
+type pathTemplateData struct {
+	Version string
+}
+
+// getSource returns the source of k3s version configured by package-download-source setting.
+// The setting can be a builtin source name, a http(s) base url, a local directory(or file:// url) or a s3://bucket/prefix url.
+// The path of the version under the custom source is rendered with package-download-path-template setting.
+func getSource(version string) (source, error) {
+	sourceSetting := strings.TrimSpace(settings.PackageDownloadSource.Get())
+	if sourceSetting == "" {
+		sourceSetting = defaultSourceName
+	}
+	if builtin, ok := builtinSources[sourceSetting]; ok {
+		versionPath, err := renderVersionPath(builtin.pathTemplate, version)
+		if err != nil {
+			return nil, err
+		}
+		return &httpSource{baseURL: joinURL(builtin.baseURL, versionPath)}, nil
+	}
+
+	versionPath, err := renderVersionPath(settings.PackageDownloadPathTemplate.Get(), version)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.IsAbs(sourceSetting) {
+		return &localSource{dir: filepath.Join(sourceSetting, versionPath)}, nil
+	}
+	u, err := url.Parse(sourceSetting)
+	if err != nil {
+		return nil, errors.Wrapf(err, "package download source %s is invalid", sourceSetting)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return &httpSource{baseURL: joinURL(sourceSetting, versionPath)}, nil
+	case "file":
+		return &localSource{dir: filepath.Join(filepath.FromSlash(u.Path), versionPath)}, nil
+	case "s3":
+		return newS3Source(u.Host, path.Join(u.Path, versionPath))
+	}
+	return nil, unsupportedSourceError(sourceSetting)
+}
+
+// ValidateDownloadSource validates the value of package-download-source setting before it's saved.
+func ValidateDownloadSource(value string) error {
+	value = strings.TrimSpace(value)
+	if _, ok := builtinSources[value]; ok || value == "" || filepath.IsAbs(value) {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return errors.Wrapf(err, "package download source %s is invalid", value)
+	}
+	switch u.Scheme {
+	case "http", "https", "file":
+		return nil
+	case "s3":
+		if u.Host == "" {
+			return fmt.Errorf("bucket of package download source %s is required", value)
+		}
+		return nil
+	}
+	return unsupportedSourceError(value)
+}
+
+func unsupportedSourceError(value string) error {
+	return fmt.Errorf("package download source %s is not supported, builtin sources %s, http(s), file and s3 urls are validated",
+		value, strings.Join(getBuiltinSourceNames(), ","))
+}
+
+func renderVersionPath(pathTemplate, version string) (string, error) {
+	if strings.TrimSpace(pathTemplate) == "" {
+		pathTemplate = defaultPathTemplate
+	}
+	tmpl, err := template.New("path").Funcs(sprig.TxtFuncMap()).Parse(pathTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "package download path template %s is invalid", pathTemplate)
+	}
+	buff := &bytes.Buffer{}
+	if err := tmpl.Execute(buff, pathTemplateData{Version: version}); err != nil {
+		return "", errors.Wrapf(err, "failed to render package download path template %s", pathTemplate)
+	}
+	return strings.Trim(buff.String(), "/"), nil
+}
+
+func joinURL(base, p string) string {
+	base = strings.TrimSuffix(base, "/")
+	if p == "" {
+		return base
+	}
+	return base + "/" + p
+}
+
+func getBuiltinSourceNames() []string {
+	rtn := make([]string, 0, len(builtinSources))
+	for name := range builtinSources {
+		rtn = append(rtn, name)
+	}
+	return rtn
+}
+
+// httpSource downloads resources from baseURL/<name> and supports resuming with range request.
+type httpSource struct {
+	baseURL string
+}
+
+func (s *httpSource) String() string {
+	return s.baseURL
+}
+
+func (s *httpSource) exists(ctx context.Context, name string) error {
+	resp, err := doRequestWithCtx(ctx, http.MethodHead, joinURL(s.baseURL, name), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if int(resp.StatusCode/100) != 2 {
+		return errors.Wrapf(errResourceUnavailable, "%s, status code %d", name, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *httpSource) get(ctx context.Context, name string, offset int64) (io.ReadCloser, int64, int64, error) {
+	fromURL := joinURL(s.baseURL, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fromURL, nil)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		total := getContentRangeSize(resp.Header.Get("Content-Range"))
+		if total <= 0 && resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+		return resp.Body, offset, total, nil
+	case int(resp.StatusCode/100) == 2:
+		return resp.Body, 0, resp.ContentLength, nil
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return nil, 0, 0, errRangeNotSatisfiable
+	}
+	content, _ := io.ReadAll(resp.Body)
+	err = fmt.Errorf("failed to download resource %s, %s", fromURL, string(content))
+	if int(resp.StatusCode/100) == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return nil, 0, 0, errors.Wrap(errResourceUnavailable, err.Error())
+	}
+	return nil, 0, 0, err
+}
+
+// localSource reads resources from a local directory, e.g. a mounted mirror of k3s releases.
+type localSource struct {
+	dir string
+}
+
+func (s *localSource) String() string {
+	return s.dir
+}
+
+func (s *localSource) exists(_ context.Context, name string) error {
+	info, err := os.Stat(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return errors.Wrap(errResourceUnavailable, name)
+	}
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("resource %s is not a regular file", name)
+	}
+	return nil
+}
+
+func (s *localSource) get(ctx context.Context, name string, offset int64) (io.ReadCloser, int64, int64, error) {
+	if err := s.exists(ctx, name); err != nil {
+		return nil, 0, 0, err
+	}
+	fp, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	info, err := fp.Stat()
+	if err != nil {
+		_ = fp.Close()
+		return nil, 0, 0, err
+	}
+	if offset > info.Size() {
+		_ = fp.Close()
+		return nil, 0, 0, errRangeNotSatisfiable
+	}
+	if _, err := fp.Seek(offset, io.SeekStart); err != nil {
+		_ = fp.Close()
+		return nil, 0, 0, err
+	}
+	return fp, offset, info.Size(), nil
+}
+
+// s3Source downloads resources from a S3-compatible bucket.
+// The credentials are loaded from the environment variables or the shared credentials file, anonymous access is used if not found.
+type s3Source struct {
+	client *s3.S3
+	bucket string
+	prefix string
+}
+
+func newS3Source(bucket, prefix string) (*s3Source, error) {
+	if bucket == "" {
+		return nil, errors.New("bucket is required for s3 package download source")
+	}
+	creds := credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvProvider{},
+		&credentials.SharedCredentialsProvider{},
+	})
+	if _, err := creds.Get(); err != nil {
+		creds = credentials.AnonymousCredentials
+	}
+	config := aws.NewConfig().
+		WithCredentials(creds).
+		WithRegion(settings.PackageDownloadS3Region.Get()).
+		WithHTTPClient(&downloadClient)
+	if endpoint := settings.PackageDownloadS3Endpoint.Get(); endpoint != "" {
+		config = config.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Source{
+		client: s3.New(sess),
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+	}, nil
+}
+
+func (s *s3Source) String() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.prefix)
+}
+
+func (s *s3Source) key(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+func (s *s3Source) exists(ctx context.Context, name string) error {
+	_, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	return convertS3Error(err, name)
+}
+
+func (s *s3Source) get(ctx context.Context, name string, offset int64) (io.ReadCloser, int64, int64, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	}
+	if offset > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	}
+	output, err := s.client.GetObjectWithContext(ctx, input)
+	if err != nil {
+		return nil, 0, 0, convertS3Error(err, name)
+	}
+	if output.ContentRange != nil {
+		return output.Body, offset, getContentRangeSize(aws.StringValue(output.ContentRange)), nil
+	}
+	return output.Body, 0, aws.Int64Value(output.ContentLength), nil
+}
+
+func convertS3Error(err error, name string) error {
+	if err == nil {
+		return nil
+	}
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		switch reqErr.StatusCode() {
+		case http.StatusNotFound:
+			return errors.Wrap(errResourceUnavailable, name)
+		case http.StatusUnauthorized, http.StatusForbidden:
+			// retrying won't help with the permission errors, the message is kept to tell them from not found.
+			return errors.Wrapf(errResourceUnavailable, "access to %s is denied, %v", name, err)
+		case http.StatusRequestedRangeNotSatisfiable:
+			return errRangeNotSatisfiable
+		}
+	}
+	return err
+}
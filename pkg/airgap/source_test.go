@@ -0,0 +1,167 @@
+package airgap
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/settings"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func setSettingForTest(t *testing.T, s settings.Setting, value string) {
+	origin := s.Get()
+	if err := s.Set(value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Set(origin) })
+}
+
+func TestGetSource(t *testing.T) {
+	type testcase struct {
+		source       string
+		pathTemplate string
+		target       string
+	}
+	for _, c := range []testcase{
+		{
+			source: "github",
+			target: "https://github.com/k3s-io/k3s/releases/download/v1.28.5%2Bk3s1",
+		},
+		{
+			source: "aliyunoss",
+			target: "https://rancher-mirror.rancher.cn/k3s/v1.28.5-k3s1",
+		},
+		{
+			source: "https://artifacts.example.com/k3s/",
+			target: "https://artifacts.example.com/k3s/v1.28.5+k3s1",
+		},
+		{
+			source:       "https://artifacts.example.com/k3s",
+			pathTemplate: `releases/{{ .Version | replace "+" "-" }}`,
+			target:       "https://artifacts.example.com/k3s/releases/v1.28.5-k3s1",
+		},
+		{
+			source: "/mnt/mirror",
+			target: "/mnt/mirror/v1.28.5+k3s1",
+		},
+		{
+			source: "file:///mnt/mirror",
+			target: "/mnt/mirror/v1.28.5+k3s1",
+		},
+		{
+			source: "s3://k3s-releases/mirror",
+			target: "s3://k3s-releases/mirror/v1.28.5+k3s1",
+		},
+	} {
+		assert.NoError(t, ValidateDownloadSource(c.source))
+		setSettingForTest(t, settings.PackageDownloadSource, c.source)
+		setSettingForTest(t, settings.PackageDownloadPathTemplate, c.pathTemplate)
+		s, err := getSource("v1.28.5+k3s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, c.target, s.String())
+	}
+
+	setSettingForTest(t, settings.PackageDownloadSource, "ftp://mirror")
+	_, err := getSource("v1.28.5+k3s1")
+	assert.Error(t, err)
+	assert.Error(t, ValidateDownloadSource("ftp://mirror"))
+	assert.Error(t, ValidateDownloadSource("githubb"))
+	assert.Error(t, ValidateDownloadSource("s3:///mirror"))
+}
+
+func TestLocalSource(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("0123456789")
+	if err := os.WriteFile(filepath.Join(dir, "k3s"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := &localSource{dir: dir}
+	ctx := context.Background()
+
+	assert.NoError(t, s.exists(ctx, "k3s"))
+	assert.True(t, errors.Is(s.exists(ctx, "k3s-arm64"), errResourceUnavailable))
+
+	target := filepath.Join(t.TempDir(), "k3s")
+	if err := os.WriteFile(target+tmpSuffix, content[:4], 0644); err != nil {
+		t.Fatal(err)
+	}
+	d := newTestDownloader(t, s)
+	if err := d.download(target, "k3s"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, content, data)
+
+	_, _, _, err = s.get(ctx, "k3s", 20)
+	assert.True(t, errors.Is(err, errRangeNotSatisfiable))
+}
+
+func TestS3Source(t *testing.T) {
+	content := []byte("k3s airgap images")
+	var paths []string
+	// the server is a stand-in of S3-compatible service with path style bucket.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/forbidden") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, "<Error><Code>AccessDenied</Code></Error>")
+			return
+		}
+		if r.URL.Path != "/k3s-releases/mirror/v1.28.5+k3s1/k3s-airgap-images-amd64.tar" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		http.ServeContent(w, r, "images", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	setSettingForTest(t, settings.PackageDownloadSource, "s3://k3s-releases/mirror")
+	setSettingForTest(t, settings.PackageDownloadS3Endpoint, server.URL)
+	s, err := getSource("v1.28.5+k3s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	assert.NoError(t, s.exists(ctx, "k3s-airgap-images-amd64.tar"))
+	assert.True(t, errors.Is(s.exists(ctx, "k3s-airgap-images-amd64.tar.gz"), errResourceUnavailable))
+	// the permission errors fail fast without retrying, and are not hidden as not found.
+	err = s.exists(ctx, "forbidden")
+	assert.True(t, errors.Is(err, errResourceUnavailable))
+	assert.Contains(t, err.Error(), "denied")
+	err = newTestDownloader(t, s).download(filepath.Join(t.TempDir(), "forbidden"), "forbidden")
+	assert.True(t, errors.Is(err, errResourceUnavailable))
+	assert.Contains(t, err.Error(), "denied")
+
+	target := filepath.Join(t.TempDir(), "k3s-airgap-images.tar")
+	if err := os.WriteFile(target+tmpSuffix, content[:3], 0644); err != nil {
+		t.Fatal(err)
+	}
+	d := newTestDownloader(t, s)
+	if err := d.download(target, "k3s-airgap-images-amd64.tar"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, content, data)
+	assert.Contains(t, paths, "GET /k3s-releases/mirror/v1.28.5+k3s1/k3s-airgap-images-amd64.tar")
+}
@@ -109,6 +109,35 @@ func TarAndGzip(from, to string) error {
 	return TarAndGzipToWriter(from, toFile)
 }
 
+// tarDir writes the files in the directory to the tar file without compression.
+func tarDir(from, to string) error {
+	toFile, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	defer toFile.Close()
+	tw := tar.NewWriter(toFile)
+	if err := filepath.Walk(from, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if from == path || info.IsDir() {
+			return nil
+		}
+		f, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		return addToArchive(tw, filepath.ToSlash(f), path)
+	}); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return toFile.Close()
+}
+
 func createArchive(files map[string]string, buf io.Writer) error {
 	// Create new Writers for gzip and tar
 	// These writers are chained. Writing to the tar writer will
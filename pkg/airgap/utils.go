@@ -3,48 +3,33 @@ package airgap
 import (
 	"bufio"
 	"context"
-	"crypto/sha256"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/cnrancher/autok3s/pkg/common"
-	"github.com/cnrancher/autok3s/pkg/settings"
 	"github.com/pkg/errors"
 )
 
 var (
 	packagePath        = filepath.Join(common.CfgPath, "package")
 	packageTmpBasePath = filepath.Join(packagePath, tmpDirName)
-	downloadSourceMap  = map[string]string{
-		"github":    "https://github.com/k3s-io/k3s/releases/download",
-		"aliyunoss": "https://rancher-mirror.rancher.cn/k3s",
-	}
-	client = http.Client{
+	client             = http.Client{
 		Timeout: 45 * time.Second,
 	}
-)
-
-func getSourceURL(version string) string {
-	source := settings.PackageDownloadSource.Get()
-	baseURL := downloadSourceMap[source]
-	if baseURL == "" {
-		source = "github"
-		baseURL = downloadSourceMap[source]
-	}
-
-	versionPath := version
-	if source == "aliyunoss" {
-		versionPath = strings.ReplaceAll(versionPath, "+", "-")
+	// downloadClient has no overall timeout as the resources can take a long time to download.
+	downloadClient = http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			TLSHandshakeTimeout:   15 * time.Second,
+			ResponseHeaderTimeout: 45 * time.Second,
+		},
 	}
-	versionPath = url.QueryEscape(versionPath)
-	return fmt.Sprintf("%s/%s", baseURL, versionPath)
-}
+)
 
 func RemovePackage(name string) error {
 	return os.RemoveAll(PackagePath(name))
@@ -76,10 +61,15 @@ func VerifyFiles(basePath string) (*common.Package, error) {
 			return nil, err
 		}
 	}
+	if err := verifyExtras(version, basePath); err != nil {
+		return nil, err
+	}
 
 	return &common.Package{
 		Archs:      version.Archs,
 		K3sVersion: version.Version,
+		Images:     version.Images,
+		Charts:     version.Charts,
 	}, nil
 }
 
@@ -173,16 +163,11 @@ func getHashMapFromFile(path string) (map[string]string, error) {
 }
 
 func checkFileHash(filepath, targetHash string) (bool, error) {
-	hasher := sha256.New()
-	fp, err := os.Open(filepath)
+	hash, err := getFileHash(filepath)
 	if err != nil {
 		return false, err
 	}
-	defer fp.Close()
-	if _, err := io.Copy(hasher, fp); err != nil {
-		return false, err
-	}
-	return fmt.Sprintf("%x", hasher.Sum(nil)) == targetHash, nil
+	return hash == targetHash, nil
 }
 
 func isDone(basePath string) bool {
@@ -204,7 +189,7 @@ func updatePackageState(pkg *common.Package, state common.State) error {
 }
 
 func GetDownloadFilePath(name string) string {
-	return filepath.Join(PackagePath(name), "log")
+	return filepath.Join(PackagePath(name), logFilename)
 }
 
 func GetLogFile(name string) (logFile *os.File, err error) {
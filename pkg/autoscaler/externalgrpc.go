@@ -0,0 +1,515 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of the external gRPC cloud provider of cluster-autoscaler, which are defined by
+// cluster-autoscaler/cloudprovider/externalgrpc/protos/externalgrpc.proto. Only the fields used by autok3s are encoded,
+// the unknown fields are skipped when decoding.
+
+const serviceName = "clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider"
+
+// The instance states of InstanceStatus.
+const (
+	instanceRunning  int32 = 1
+	instanceCreating int32 = 2
+	instanceDeleting int32 = 3
+)
+
+// message is the protobuf message encoded by the codec.
+type message interface {
+	marshal(b []byte) []byte
+	unmarshal(b []byte) error
+}
+
+// codec encodes the messages in protobuf wire format, it replaces the default codec of the server which requires
+// the generated messages.
+type codec struct{}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(message)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal, message is %T", v)
+	}
+	return m.marshal(nil), nil
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(message)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal, message is %T", v)
+	}
+	return m.unmarshal(data)
+}
+
+func (codec) Name() string {
+	return "proto"
+}
+
+// emptyMessage is the message without fields used by autok3s, e.g. NodeGroupsRequest and RefreshResponse.
+type emptyMessage struct{}
+
+func (*emptyMessage) marshal(b []byte) []byte {
+	return b
+}
+
+func (*emptyMessage) unmarshal(b []byte) error {
+	return consumeFields(b, func(_ field) error { return nil })
+}
+
+// nodeGroup is NodeGroup.
+type nodeGroup struct {
+	ID      string
+	MinSize int32
+	MaxSize int32
+	Debug   string
+}
+
+func (m *nodeGroup) marshal(b []byte) []byte {
+	b = appendString(b, 1, m.ID)
+	b = appendInt32(b, 2, m.MinSize)
+	b = appendInt32(b, 3, m.MaxSize)
+	return appendString(b, 4, m.Debug)
+}
+
+func (m *nodeGroup) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.ID = string(f.bytes)
+		case 2:
+			m.MinSize = int32(f.varint)
+		case 3:
+			m.MaxSize = int32(f.varint)
+		case 4:
+			m.Debug = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+// externalNode is ExternalGrpcNode.
+type externalNode struct {
+	ProviderID  string
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+func (m *externalNode) marshal(b []byte) []byte {
+	b = appendString(b, 1, m.ProviderID)
+	b = appendString(b, 2, m.Name)
+	b = appendMap(b, 3, m.Labels)
+	return appendMap(b, 4, m.Annotations)
+}
+
+func (m *externalNode) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.ProviderID = string(f.bytes)
+		case 2:
+			m.Name = string(f.bytes)
+		case 3:
+			if m.Labels == nil {
+				m.Labels = map[string]string{}
+			}
+			return consumeMapEntry(f.bytes, m.Labels)
+		case 4:
+			if m.Annotations == nil {
+				m.Annotations = map[string]string{}
+			}
+			return consumeMapEntry(f.bytes, m.Annotations)
+		}
+		return nil
+	})
+}
+
+// nodeGroupsResponse is NodeGroupsResponse.
+type nodeGroupsResponse struct {
+	NodeGroups []*nodeGroup
+}
+
+func (m *nodeGroupsResponse) marshal(b []byte) []byte {
+	for _, g := range m.NodeGroups {
+		b = appendMessage(b, 1, g)
+	}
+	return b
+}
+
+func (m *nodeGroupsResponse) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		if f.num == 1 {
+			g := &nodeGroup{}
+			m.NodeGroups = append(m.NodeGroups, g)
+			return g.unmarshal(f.bytes)
+		}
+		return nil
+	})
+}
+
+// nodeGroupForNodeRequest is NodeGroupForNodeRequest.
+type nodeGroupForNodeRequest struct {
+	Node *externalNode
+}
+
+func (m *nodeGroupForNodeRequest) marshal(b []byte) []byte {
+	if m.Node != nil {
+		b = appendMessage(b, 1, m.Node)
+	}
+	return b
+}
+
+func (m *nodeGroupForNodeRequest) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		if f.num == 1 {
+			m.Node = &externalNode{}
+			return m.Node.unmarshal(f.bytes)
+		}
+		return nil
+	})
+}
+
+// nodeGroupForNodeResponse is NodeGroupForNodeResponse, the id of node group is empty if the node isn't in any group.
+type nodeGroupForNodeResponse struct {
+	NodeGroup *nodeGroup
+}
+
+func (m *nodeGroupForNodeResponse) marshal(b []byte) []byte {
+	if m.NodeGroup != nil {
+		b = appendMessage(b, 1, m.NodeGroup)
+	}
+	return b
+}
+
+func (m *nodeGroupForNodeResponse) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		if f.num == 1 {
+			m.NodeGroup = &nodeGroup{}
+			return m.NodeGroup.unmarshal(f.bytes)
+		}
+		return nil
+	})
+}
+
+// gpuLabelResponse is GPULabelResponse.
+type gpuLabelResponse struct {
+	Label string
+}
+
+func (m *gpuLabelResponse) marshal(b []byte) []byte {
+	return appendString(b, 1, m.Label)
+}
+
+func (m *gpuLabelResponse) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		if f.num == 1 {
+			m.Label = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+// idRequest is the request with the id of node group only, e.g. NodeGroupTargetSizeRequest and NodeGroupNodesRequest.
+type idRequest struct {
+	ID string
+}
+
+func (m *idRequest) marshal(b []byte) []byte {
+	return appendString(b, 1, m.ID)
+}
+
+func (m *idRequest) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		if f.num == 1 {
+			m.ID = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+// targetSizeResponse is NodeGroupTargetSizeResponse.
+type targetSizeResponse struct {
+	TargetSize int32
+}
+
+func (m *targetSizeResponse) marshal(b []byte) []byte {
+	return appendInt32(b, 1, m.TargetSize)
+}
+
+func (m *targetSizeResponse) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		if f.num == 1 {
+			m.TargetSize = int32(f.varint)
+		}
+		return nil
+	})
+}
+
+// deltaRequest is NodeGroupIncreaseSizeRequest and NodeGroupDecreaseTargetSizeRequest.
+type deltaRequest struct {
+	Delta int32
+	ID    string
+}
+
+func (m *deltaRequest) marshal(b []byte) []byte {
+	b = appendInt32(b, 1, m.Delta)
+	return appendString(b, 2, m.ID)
+}
+
+func (m *deltaRequest) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Delta = int32(f.varint)
+		case 2:
+			m.ID = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+// deleteNodesRequest is NodeGroupDeleteNodesRequest.
+type deleteNodesRequest struct {
+	Nodes []*externalNode
+	ID    string
+}
+
+func (m *deleteNodesRequest) marshal(b []byte) []byte {
+	for _, n := range m.Nodes {
+		b = appendMessage(b, 1, n)
+	}
+	return appendString(b, 2, m.ID)
+}
+
+func (m *deleteNodesRequest) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			n := &externalNode{}
+			m.Nodes = append(m.Nodes, n)
+			return n.unmarshal(f.bytes)
+		case 2:
+			m.ID = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+// instance is Instance with the InstanceStatus flattened, the error info is not reported.
+type instance struct {
+	ID    string
+	State int32
+}
+
+func (m *instance) marshal(b []byte) []byte {
+	b = appendString(b, 1, m.ID)
+	if m.State != 0 {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, appendInt32(nil, 1, m.State))
+	}
+	return b
+}
+
+func (m *instance) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.ID = string(f.bytes)
+		case 2:
+			return consumeFields(f.bytes, func(f field) error {
+				if f.num == 1 {
+					m.State = int32(f.varint)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+}
+
+// nodeGroupNodesResponse is NodeGroupNodesResponse.
+type nodeGroupNodesResponse struct {
+	Instances []*instance
+}
+
+func (m *nodeGroupNodesResponse) marshal(b []byte) []byte {
+	for _, i := range m.Instances {
+		b = appendMessage(b, 1, i)
+	}
+	return b
+}
+
+func (m *nodeGroupNodesResponse) unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		if f.num == 1 {
+			i := &instance{}
+			m.Instances = append(m.Instances, i)
+			return i.unmarshal(f.bytes)
+		}
+		return nil
+	})
+}
+
+// field is the decoded field, the value is held by varint or bytes according to the wire type.
+type field struct {
+	num    protowire.Number
+	varint uint64
+	bytes  []byte
+}
+
+// consumeFields decodes the varint and length-delimited fields of the message, the fields of other types are skipped.
+func consumeFields(b []byte, fn func(f field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		f := field{num: num}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func consumeMapEntry(b []byte, m map[string]string) error {
+	var key, value string
+	if err := consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			key = string(f.bytes)
+		case 2:
+			value = string(f.bytes)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	m[key] = value
+	return nil
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendInt32(b []byte, num protowire.Number, v int32) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(v)))
+}
+
+func appendMessage(b []byte, num protowire.Number, m message) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m.marshal(nil))
+}
+
+// appendMap encodes the map entries in the order of keys, so the encoding is deterministic.
+func appendMap(b []byte, num protowire.Number, m map[string]string) []byte {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, appendString(appendString(nil, 1, k), 2, m[k]))
+	}
+	return b
+}
+
+// serviceDesc registers the methods implemented by the server, the other methods of the service, e.g. the pricing
+// and the template node info, are answered with Unimplemented by gRPC, which are optional for cluster-autoscaler.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		unary("NodeGroups", func() message { return &emptyMessage{} },
+			func(s *Server, ctx context.Context, _ message) (message, error) { return s.NodeGroups(ctx) }),
+		unary("NodeGroupForNode", func() message { return &nodeGroupForNodeRequest{} },
+			func(s *Server, ctx context.Context, req message) (message, error) {
+				return s.NodeGroupForNode(ctx, req.(*nodeGroupForNodeRequest))
+			}),
+		unary("GPULabel", func() message { return &emptyMessage{} },
+			func(_ *Server, _ context.Context, _ message) (message, error) { return &gpuLabelResponse{}, nil }),
+		unary("GetAvailableGPUTypes", func() message { return &emptyMessage{} },
+			func(_ *Server, _ context.Context, _ message) (message, error) { return &emptyMessage{}, nil }),
+		unary("Cleanup", func() message { return &emptyMessage{} },
+			func(_ *Server, _ context.Context, _ message) (message, error) { return &emptyMessage{}, nil }),
+		unary("Refresh", func() message { return &emptyMessage{} },
+			func(_ *Server, _ context.Context, _ message) (message, error) { return &emptyMessage{}, nil }),
+		unary("NodeGroupTargetSize", func() message { return &idRequest{} },
+			func(s *Server, ctx context.Context, req message) (message, error) {
+				return s.NodeGroupTargetSize(ctx, req.(*idRequest))
+			}),
+		unary("NodeGroupIncreaseSize", func() message { return &deltaRequest{} },
+			func(s *Server, ctx context.Context, req message) (message, error) {
+				return s.NodeGroupIncreaseSize(ctx, req.(*deltaRequest))
+			}),
+		unary("NodeGroupDeleteNodes", func() message { return &deleteNodesRequest{} },
+			func(s *Server, ctx context.Context, req message) (message, error) {
+				return s.NodeGroupDeleteNodes(ctx, req.(*deleteNodesRequest))
+			}),
+		unary("NodeGroupDecreaseTargetSize", func() message { return &deltaRequest{} },
+			func(s *Server, ctx context.Context, req message) (message, error) {
+				return s.NodeGroupDecreaseTargetSize(ctx, req.(*deltaRequest))
+			}),
+		unary("NodeGroupNodes", func() message { return &idRequest{} },
+			func(s *Server, ctx context.Context, req message) (message, error) {
+				return s.NodeGroupNodes(ctx, req.(*idRequest))
+			}),
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// unary returns the method handler which decodes the request and calls the method of server.
+func unary(name string, newRequest func() message, call func(s *Server, ctx context.Context, req message) (message, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := newRequest()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			s := srv.(*Server)
+			if interceptor == nil {
+				return call(s, ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fmt.Sprintf("/%s/%s", serviceName, name)}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return call(s, ctx, req.(message))
+			})
+		},
+	}
+}
@@ -0,0 +1,250 @@
+package autoscaler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The messages of cluster-autoscaler/cloudprovider/externalgrpc/protos/externalgrpc.proto used by autok3s, with the
+// same names, numbers and types as the upstream definition, which are encoded by the protobuf runtime to verify the
+// wire format of the codec.
+var upstreamMessages = []*descriptorpb.DescriptorProto{
+	descMessage("NodeGroup",
+		descScalar("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		descScalar("minSize", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+		descScalar("maxSize", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+		descScalar("debug", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+	descWithMaps(descMessage("ExternalGrpcNode",
+		descScalar("providerID", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		descScalar("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		descRepeated(descReference("labels", 3, "ExternalGrpcNode.LabelsEntry")),
+		descRepeated(descReference("annotations", 4, "ExternalGrpcNode.AnnotationsEntry"))), "LabelsEntry", "AnnotationsEntry"),
+	descMessage("NodeGroupsRequest"),
+	descMessage("NodeGroupsResponse", descRepeated(descReference("nodeGroups", 1, "NodeGroup"))),
+	descMessage("NodeGroupForNodeRequest", descReference("node", 1, "ExternalGrpcNode")),
+	descMessage("NodeGroupForNodeResponse", descReference("nodeGroup", 1, "NodeGroup")),
+	descMessage("GPULabelResponse", descScalar("label", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+	descMessage("NodeGroupTargetSizeRequest", descScalar("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+	descMessage("NodeGroupTargetSizeResponse", descScalar("targetSize", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32)),
+	descMessage("NodeGroupIncreaseSizeRequest",
+		descScalar("delta", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+		descScalar("id", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+	descMessage("NodeGroupDecreaseTargetSizeRequest",
+		descScalar("delta", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+		descScalar("id", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+	descMessage("NodeGroupDeleteNodesRequest",
+		descRepeated(descReference("nodes", 1, "ExternalGrpcNode")),
+		descScalar("id", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+	descMessage("NodeGroupNodesRequest", descScalar("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+	descMessage("NodeGroupNodesResponse", descRepeated(descReference("instances", 1, "Instance"))),
+	descMessage("Instance",
+		descScalar("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		descReference("status", 2, "InstanceStatus")),
+	descWithEnum(descMessage("InstanceStatus",
+		descEnum("instanceState", 1, "InstanceStatus.InstanceState"),
+		descReference("errorInfo", 2, "InstanceErrorInfo")),
+		"InstanceState", "unspecified", "instanceRunning", "instanceCreating", "instanceDeleting"),
+	descMessage("InstanceErrorInfo",
+		descScalar("errorCode", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		descScalar("errorMessage", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		descScalar("instanceErrorClass", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32)),
+}
+
+const upstreamPackage = "clusterautoscaler.cloudprovider.v1.externalgrpc"
+
+func descMessage(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+func descScalar(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(num),
+		Type:   typ.Enum(),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+}
+
+func descReference(name string, num int32, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := descScalar(name, num, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+	f.TypeName = proto.String("." + upstreamPackage + "." + typeName)
+	return f
+}
+
+func descEnum(name string, num int32, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := descReference(name, num, typeName)
+	f.Type = descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum()
+	return f
+}
+
+func descRepeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+// descWithMaps adds the entries of map<string, string> fields to the message.
+func descWithMaps(m *descriptorpb.DescriptorProto, entries ...string) *descriptorpb.DescriptorProto {
+	for _, entry := range entries {
+		m.NestedType = append(m.NestedType, &descriptorpb.DescriptorProto{
+			Name: proto.String(entry),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				descScalar("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				descScalar("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			},
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		})
+	}
+	return m
+}
+
+func descWithEnum(m *descriptorpb.DescriptorProto, name string, values ...string) *descriptorpb.DescriptorProto {
+	e := &descriptorpb.EnumDescriptorProto{Name: proto.String(name)}
+	for i, v := range values {
+		e.Value = append(e.Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String(v), Number: proto.Int32(int32(i))})
+	}
+	m.EnumType = append(m.EnumType, e)
+	return m
+}
+
+func upstreamFile(t *testing.T) protoreflect.FileDescriptor {
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("externalgrpc.proto"),
+		Package:     proto.String(upstreamPackage),
+		Syntax:      proto.String("proto3"),
+		MessageType: upstreamMessages,
+	}, nil)
+	require.NoError(t, err)
+	return fd
+}
+
+func TestCodecMatchesUpstream(t *testing.T) {
+	fd := upstreamFile(t)
+	cases := []struct {
+		upstream string
+		msg      message
+		empty    func() message
+		json     string
+	}{
+		{
+			upstream: "NodeGroupsResponse",
+			msg: &nodeGroupsResponse{NodeGroups: []*nodeGroup{
+				{ID: "a", MinSize: 1, MaxSize: 3, Debug: "workers of a"},
+				{ID: "b", MaxSize: 2},
+			}},
+			empty: func() message { return &nodeGroupsResponse{} },
+			json:  `{"nodeGroups":[{"id":"a","minSize":1,"maxSize":3,"debug":"workers of a"},{"id":"b","maxSize":2}]}`,
+		},
+		{
+			upstream: "NodeGroupForNodeRequest",
+			msg: &nodeGroupForNodeRequest{Node: &externalNode{
+				ProviderID:  "k3s://worker-1",
+				Name:        "worker-1",
+				Labels:      map[string]string{"a": "1", "b": "2"},
+				Annotations: map[string]string{"c": "3"},
+			}},
+			empty: func() message { return &nodeGroupForNodeRequest{} },
+			json:  `{"node":{"providerID":"k3s://worker-1","name":"worker-1","labels":{"a":"1","b":"2"},"annotations":{"c":"3"}}}`,
+		},
+		{
+			upstream: "NodeGroupForNodeResponse",
+			msg:      &nodeGroupForNodeResponse{NodeGroup: &nodeGroup{ID: "a", MinSize: 1, MaxSize: 3}},
+			empty:    func() message { return &nodeGroupForNodeResponse{} },
+			json:     `{"nodeGroup":{"id":"a","minSize":1,"maxSize":3}}`,
+		},
+		{
+			upstream: "GPULabelResponse",
+			msg:      &gpuLabelResponse{Label: "gpu"},
+			empty:    func() message { return &gpuLabelResponse{} },
+			json:     `{"label":"gpu"}`,
+		},
+		{
+			upstream: "NodeGroupTargetSizeRequest",
+			msg:      &idRequest{ID: "a"},
+			empty:    func() message { return &idRequest{} },
+			json:     `{"id":"a"}`,
+		},
+		{
+			upstream: "NodeGroupTargetSizeResponse",
+			msg:      &targetSizeResponse{TargetSize: 4},
+			empty:    func() message { return &targetSizeResponse{} },
+			json:     `{"targetSize":4}`,
+		},
+		{
+			upstream: "NodeGroupIncreaseSizeRequest",
+			msg:      &deltaRequest{Delta: 2, ID: "a"},
+			empty:    func() message { return &deltaRequest{} },
+			json:     `{"delta":2,"id":"a"}`,
+		},
+		{
+			upstream: "NodeGroupDecreaseTargetSizeRequest",
+			msg:      &deltaRequest{Delta: -2, ID: "a"},
+			empty:    func() message { return &deltaRequest{} },
+			json:     `{"delta":-2,"id":"a"}`,
+		},
+		{
+			upstream: "NodeGroupDeleteNodesRequest",
+			msg: &deleteNodesRequest{
+				Nodes: []*externalNode{{ProviderID: "k3s://worker-1"}, {Name: "worker-2"}},
+				ID:    "a",
+			},
+			empty: func() message { return &deleteNodesRequest{} },
+			json:  `{"nodes":[{"providerID":"k3s://worker-1"},{"name":"worker-2"}],"id":"a"}`,
+		},
+		{
+			upstream: "NodeGroupNodesResponse",
+			msg: &nodeGroupNodesResponse{Instances: []*instance{
+				{ID: "k3s://worker-1", State: instanceRunning},
+				{ID: "k3s://worker-2", State: instanceCreating},
+				{ID: "k3s://worker-3", State: instanceDeleting},
+			}},
+			empty: func() message { return &nodeGroupNodesResponse{} },
+			json: `{"instances":[
+				{"id":"k3s://worker-1","status":{"instanceState":"instanceRunning"}},
+				{"id":"k3s://worker-2","status":{"instanceState":"instanceCreating"}},
+				{"id":"k3s://worker-3","status":{"instanceState":"instanceDeleting"}}]}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.upstream, func(t *testing.T) {
+			desc := fd.Messages().ByName(protoreflect.Name(c.upstream))
+			require.NotNil(t, desc)
+
+			// the message encoded by the codec is decoded by the protobuf runtime.
+			decoded := dynamicpb.NewMessage(desc)
+			require.NoError(t, proto.Unmarshal(c.msg.marshal(nil), decoded))
+			data, err := protojson.Marshal(decoded)
+			require.NoError(t, err)
+			assert.JSONEq(t, c.json, string(data))
+
+			// the message encoded by the protobuf runtime is decoded by the codec.
+			encoded := dynamicpb.NewMessage(desc)
+			require.NoError(t, protojson.Unmarshal([]byte(c.json), encoded))
+			data, err = proto.Marshal(encoded)
+			require.NoError(t, err)
+			got := c.empty()
+			require.NoError(t, got.unmarshal(data))
+			assert.Equal(t, c.msg, got)
+		})
+	}
+}
+
+func TestCodecSkipsUnknownFields(t *testing.T) {
+	desc := upstreamFile(t).Messages().ByName("NodeGroupNodesResponse")
+	encoded := dynamicpb.NewMessage(desc)
+	require.NoError(t, protojson.Unmarshal([]byte(`{"instances":[{"id":"k3s://worker-1","status":{
+		"instanceState":"instanceCreating",
+		"errorInfo":{"errorCode":"quota","errorMessage":"out of quota","instanceErrorClass":1}}}]}`), encoded))
+	data, err := proto.Marshal(encoded)
+	require.NoError(t, err)
+	got := &nodeGroupNodesResponse{}
+	require.NoError(t, got.unmarshal(data))
+	assert.Equal(t, []*instance{{ID: "k3s://worker-1", State: instanceCreating}}, got.Instances)
+}
@@ -0,0 +1,452 @@
+package autoscaler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Server serves cluster-autoscaler as the external gRPC cloud provider. The workers of each cluster are a node group
+// whose id is the context name of cluster, and the cluster-autoscaler deployed in the cluster is identified by the
+// common name of its client certificate, so it only sees and scales the workers of its own cluster.
+// The workers are joined and removed in background as the calls of cluster-autoscaler are expected to return quickly,
+// the changes of a cluster are applied one by one.
+type Server struct {
+	mu sync.Mutex
+	// joining holds the number of workers being joined, keyed by cluster.
+	joining map[string]int
+	// deleting holds the instance ids of workers being removed, keyed by cluster.
+	deleting map[string]map[string]bool
+	wg       sync.WaitGroup
+
+	getCluster func(contextName string) (*common.ClusterState, error)
+	listNodes  func(ctx context.Context, state *common.ClusterState) ([]v1.Node, error)
+	join       func(state *common.ClusterState, n int) error
+	remove     func(state *common.ClusterState, ids []string) error
+}
+
+// NewServer returns the server which joins and removes the workers by the providers of clusters.
+func NewServer() *Server {
+	return &Server{
+		joining:    map[string]int{},
+		deleting:   map[string]map[string]bool{},
+		getCluster: common.DefaultDB.GetClusterByID,
+		listNodes:  listKubeNodes,
+		join:       joinWorkers,
+		remove:     removeWorkers,
+	}
+}
+
+// Serve serves the autoscaler endpoint on the address until the context is done, the serving certificate is valid for
+// the host of address and the SANs.
+func Serve(ctx context.Context, address string, sans []string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsUnspecified() {
+		sans = append([]string{host}, sans...)
+	}
+	tlsConfig, err := ServerTLSConfig(sans)
+	if err != nil {
+		return fmt.Errorf("failed to generate certificate of autoscaler endpoint: %v", err)
+	}
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)), grpc.ForceServerCodec(codec{}))
+	srv.RegisterService(&serviceDesc, NewServer())
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+	logrus.Infof("[autoscaler] serving cluster-autoscaler external gRPC provider on %s", address)
+	return srv.Serve(lis)
+}
+
+// Wait waits for the workers being joined and removed.
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+// NodeGroups returns the workers of the calling cluster as the node group if autoscaling is enabled.
+func (s *Server) NodeGroups(ctx context.Context) (*nodeGroupsResponse, error) {
+	state, err := s.state(ctx, "")
+	if status.Code(err) == codes.NotFound {
+		return &nodeGroupsResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &nodeGroupsResponse{NodeGroups: []*nodeGroup{toNodeGroup(state)}}, nil
+}
+
+// NodeGroupForNode returns the node group of the node, the id of node group is empty if the node isn't a worker
+// managed by autok3s, e.g. the masters.
+func (s *Server) NodeGroupForNode(ctx context.Context, req *nodeGroupForNodeRequest) (*nodeGroupForNodeResponse, error) {
+	if req.Node == nil {
+		return nil, status.Error(codes.InvalidArgument, "node is required")
+	}
+	state, err := s.state(ctx, "")
+	if status.Code(err) == codes.NotFound {
+		return &nodeGroupForNodeResponse{NodeGroup: &nodeGroup{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	workers, kubeNodes, err := s.nodes(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	for i := range kubeNodes {
+		if !isRequestNode(&kubeNodes[i], req.Node) {
+			continue
+		}
+		for _, n := range workers {
+			if cluster.IsKubeNode(&kubeNodes[i], n) {
+				return &nodeGroupForNodeResponse{NodeGroup: toNodeGroup(state)}, nil
+			}
+		}
+	}
+	return &nodeGroupForNodeResponse{NodeGroup: &nodeGroup{}}, nil
+}
+
+// NodeGroupTargetSize returns the number of workers including the ones being joined and excluding the ones being
+// removed.
+func (s *Server) NodeGroupTargetSize(ctx context.Context, req *idRequest) (*targetSizeResponse, error) {
+	state, err := s.state(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	size, err := s.targetSize(state)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return &targetSizeResponse{TargetSize: int32(size)}, nil
+}
+
+// NodeGroupIncreaseSize joins the workers to the cluster, the target size can't exceed the max size of cluster.
+func (s *Server) NodeGroupIncreaseSize(ctx context.Context, req *deltaRequest) (*emptyMessage, error) {
+	if req.Delta <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "size increase must be positive, got %d", req.Delta)
+	}
+	state, err := s.state(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Status != common.StatusRunning {
+		return nil, status.Errorf(codes.FailedPrecondition, "cluster %s is %s", state.ContextName, state.Status)
+	}
+	delta := int(req.Delta)
+
+	s.mu.Lock()
+	size, err := s.targetSize(state)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if size+delta > state.AutoscaleMax {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.InvalidArgument, "size increase is too large, target size %d exceeds max size %d",
+			size+delta, state.AutoscaleMax)
+	}
+	s.joining[state.ContextName] += delta
+	s.mu.Unlock()
+
+	logrus.Infof("[autoscaler] joining %d workers to cluster %s", delta, state.ContextName)
+	s.apply(state.ContextName, func(state *common.ClusterState) error {
+		return s.join(state, delta)
+	}, func() {
+		s.joining[state.ContextName] -= delta
+	})
+	return &emptyMessage{}, nil
+}
+
+// NodeGroupDeleteNodes removes the workers from the cluster, the target size can't be less than the min size of
+// cluster.
+func (s *Server) NodeGroupDeleteNodes(ctx context.Context, req *deleteNodesRequest) (*emptyMessage, error) {
+	state, err := s.state(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	workers, kubeNodes, err := s.nodes(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, node := range req.Nodes {
+		id := ""
+		for i := range kubeNodes {
+			if !isRequestNode(&kubeNodes[i], node) {
+				continue
+			}
+			for _, n := range workers {
+				if cluster.IsKubeNode(&kubeNodes[i], n) {
+					id = n.InstanceID
+					break
+				}
+			}
+		}
+		if id == "" {
+			return nil, status.Errorf(codes.NotFound, "node %s is not a worker of cluster %s", node.Name, state.ContextName)
+		}
+		ids = append(ids, id)
+	}
+
+	s.mu.Lock()
+	deleting := s.deleting[state.ContextName]
+	if deleting == nil {
+		deleting = map[string]bool{}
+		s.deleting[state.ContextName] = deleting
+	}
+	removed := []string{}
+	for _, id := range ids {
+		if !deleting[id] {
+			removed = append(removed, id)
+		}
+	}
+	size, err := s.targetSize(state)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if size-len(removed) < state.AutoscaleMin {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "target size %d is less than min size %d",
+			size-len(removed), state.AutoscaleMin)
+	}
+	for _, id := range removed {
+		deleting[id] = true
+	}
+	s.mu.Unlock()
+	if len(removed) == 0 {
+		return &emptyMessage{}, nil
+	}
+
+	logrus.Infof("[autoscaler] removing workers %v from cluster %s", removed, state.ContextName)
+	s.apply(state.ContextName, func(state *common.ClusterState) error {
+		return s.remove(state, removed)
+	}, func() {
+		for _, id := range removed {
+			delete(deleting, id)
+		}
+	})
+	return &emptyMessage{}, nil
+}
+
+// NodeGroupDecreaseTargetSize is called to give up the workers not registered yet, it's refused as the workers being
+// joined can't be cancelled, they're removed by cluster-autoscaler after they are registered.
+func (s *Server) NodeGroupDecreaseTargetSize(ctx context.Context, req *deltaRequest) (*emptyMessage, error) {
+	state, err := s.state(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "the workers being joined to cluster %s can't be cancelled",
+		state.ContextName)
+}
+
+// NodeGroupNodes returns the workers by the provider ids of Kubernetes nodes, the workers which are not registered
+// are reported as creating.
+func (s *Server) NodeGroupNodes(ctx context.Context, req *idRequest) (*nodeGroupNodesResponse, error) {
+	state, err := s.state(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	workers, kubeNodes, err := s.nodes(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	deleting := s.deleting[state.ContextName]
+	instances := make([]*instance, 0, len(workers))
+	for _, n := range workers {
+		i := &instance{ID: n.InstanceID, State: instanceCreating}
+		for j := range kubeNodes {
+			if cluster.IsKubeNode(&kubeNodes[j], n) {
+				i.ID = providerID(&kubeNodes[j])
+				i.State = instanceRunning
+				break
+			}
+		}
+		if deleting[n.InstanceID] {
+			i.State = instanceDeleting
+		}
+		instances = append(instances, i)
+	}
+	s.mu.Unlock()
+	return &nodeGroupNodesResponse{Instances: instances}, nil
+}
+
+// state returns the calling cluster, the id of node group must be the calling cluster if it's set.
+func (s *Server) state(ctx context.Context, id string) (*common.ClusterState, error) {
+	contextName, err := clientCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if id != "" && id != contextName {
+		return nil, status.Errorf(codes.NotFound, "node group %s is not found", id)
+	}
+	state, err := s.getCluster(contextName)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get cluster %s: %v", contextName, err)
+	}
+	if state == nil {
+		return nil, status.Errorf(codes.NotFound, "cluster %s is not found", contextName)
+	}
+	if state.AutoscaleMax == 0 {
+		return nil, status.Errorf(codes.NotFound, "autoscaling of cluster %s is not enabled", contextName)
+	}
+	return state, nil
+}
+
+// targetSize returns the number of workers including the ones being joined and excluding the ones being removed,
+// it's called with the lock of server.
+func (s *Server) targetSize(state *common.ClusterState) (int, error) {
+	workers, err := workerNodes(state)
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "failed to get workers of cluster %s: %v", state.ContextName, err)
+	}
+	return len(workers) + s.joining[state.ContextName] - len(s.deleting[state.ContextName]), nil
+}
+
+// nodes returns the workers of cluster state and the nodes of Kubernetes.
+func (s *Server) nodes(ctx context.Context, state *common.ClusterState) ([]types.Node, []v1.Node, error) {
+	workers, err := workerNodes(state)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "failed to get workers of cluster %s: %v", state.ContextName, err)
+	}
+	kubeNodes, err := s.listNodes(ctx, state)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unavailable, "failed to list nodes of cluster %s: %v", state.ContextName, err)
+	}
+	return workers, kubeNodes, nil
+}
+
+// apply runs the change with the latest state of cluster in background, the changes of a cluster are applied one by
+// one with the node lock of cluster shared with auto-heal, and done is called with the lock of server after the
+// change is applied.
+func (s *Server) apply(contextName string, change func(state *common.ClusterState) error, done func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cluster.LockNodes(contextName)()
+		defer func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			done()
+		}()
+		state, err := s.getCluster(contextName)
+		if err == nil && state == nil {
+			err = fmt.Errorf("cluster %s is not found", contextName)
+		}
+		if err == nil {
+			err = change(state)
+		}
+		if err != nil {
+			logrus.Errorf("[autoscaler] failed to scale cluster %s: %v", contextName, err)
+			return
+		}
+		logrus.Infof("[autoscaler] successfully scaled cluster %s", contextName)
+	}()
+}
+
+// clientCluster returns the context name of the calling cluster by the common name of client certificate.
+func clientCluster(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "no peer is found")
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", status.Error(codes.Unauthenticated, "client certificate is required")
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName, nil
+}
+
+func toNodeGroup(state *common.ClusterState) *nodeGroup {
+	return &nodeGroup{
+		ID:      state.ContextName,
+		MinSize: int32(state.AutoscaleMin),
+		MaxSize: int32(state.AutoscaleMax),
+		Debug:   fmt.Sprintf("workers of %s cluster %s", state.Provider, state.ContextName),
+	}
+}
+
+// isRequestNode returns whether the Kubernetes node is the node of cluster-autoscaler by the name or provider id.
+func isRequestNode(kubeNode *v1.Node, node *externalNode) bool {
+	if node.Name != "" {
+		return kubeNode.Name == node.Name
+	}
+	return node.ProviderID != "" && providerID(kubeNode) == node.ProviderID
+}
+
+// providerID returns the provider id of Kubernetes node, K3s sets k3s://<node name> without cloud provider.
+func providerID(kubeNode *v1.Node) string {
+	if kubeNode.Spec.ProviderID != "" {
+		return kubeNode.Spec.ProviderID
+	}
+	return "k3s://" + kubeNode.Name
+}
+
+func workerNodes(state *common.ClusterState) ([]types.Node, error) {
+	workers := []types.Node{}
+	if len(state.WorkerNodes) == 0 {
+		return workers, nil
+	}
+	err := json.Unmarshal(state.WorkerNodes, &workers)
+	return workers, err
+}
+
+func listKubeNodes(ctx context.Context, state *common.ClusterState) ([]v1.Node, error) {
+	client, err := cluster.GetClusterConfig(state.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		return nil, err
+	}
+	timeout := int64(15)
+	list, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{TimeoutSeconds: &timeout})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// joinWorkers joins the workers by the provider with the options of the cluster.
+func joinWorkers(state *common.ClusterState, n int) error {
+	provider, err := cluster.ProviderFromState(state, "0", strconv.Itoa(n))
+	if err != nil {
+		return err
+	}
+	provider.RegisterCallbacks(state.ContextName, "update", common.DefaultDB.BroadcastObject)
+	if err = provider.JoinCheck(); err != nil {
+		return err
+	}
+	return provider.JoinK3sNode()
+}
+
+func removeWorkers(state *common.ClusterState, ids []string) error {
+	provider, err := cluster.ProviderFromState(state, state.Master, state.Worker)
+	if err != nil {
+		return err
+	}
+	return provider.RemoveK3sNodes(ids)
+}
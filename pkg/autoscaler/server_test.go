@@ -0,0 +1,208 @@
+package autoscaler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/common/commontest"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testCluster = "demo.ap-southeast-2.aws"
+
+// testClient calls the server with the client certificate of the cluster.
+type testClient struct {
+	conn *grpc.ClientConn
+}
+
+func (c *testClient) call(method string, req, resp message) error {
+	return c.conn.Invoke(context.Background(), "/"+serviceName+"/"+method, req, resp, grpc.ForceCodec(codec{}))
+}
+
+func startServer(t *testing.T, s *Server) string {
+	commontest.InitStorage(t)
+	tlsConfig, err := ServerTLSConfig(nil)
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)), grpc.ForceServerCodec(codec{}))
+	srv.RegisterService(&serviceDesc, s)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func newTestClient(t *testing.T, address, contextName string) *testClient {
+	caPEM, certPEM, keyPEM, err := ClientCertificate(contextName)
+	require.NoError(t, err)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   "localhost",
+	})))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return &testClient{conn: conn}
+}
+
+func TestServer(t *testing.T) {
+	var m sync.Mutex
+	workers := []types.Node{
+		{InstanceID: "i-1", InternalIPAddress: []string{"10.0.0.1"}},
+		{InstanceID: "i-2", InternalIPAddress: []string{"10.0.0.2"}},
+	}
+	wb, err := json.Marshal(workers)
+	require.NoError(t, err)
+	state := &common.ClusterState{
+		Metadata:      types.Metadata{ContextName: testCluster, Provider: "aws"},
+		Status:        common.StatusRunning,
+		WorkerNodes:   wb,
+		ClusterPolicy: common.ClusterPolicy{AutoscaleMin: 1, AutoscaleMax: 3},
+	}
+	setWorkers := func(workers []types.Node) {
+		m.Lock()
+		defer m.Unlock()
+		state.WorkerNodes, _ = json.Marshal(workers)
+	}
+	kubeNodes := []v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-0-100"},
+			Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.100"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-0-1"},
+			Spec:       v1.NodeSpec{ProviderID: "aws:///ap-southeast-2a/i-1"},
+			Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-0-2"},
+			Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.2"}}},
+		},
+	}
+
+	release := make(chan struct{})
+	var removed []string
+	s := NewServer()
+	s.getCluster = func(contextName string) (*common.ClusterState, error) {
+		if contextName != testCluster {
+			return nil, nil
+		}
+		m.Lock()
+		defer m.Unlock()
+		copied := *state
+		return &copied, nil
+	}
+	s.listNodes = func(_ context.Context, _ *common.ClusterState) ([]v1.Node, error) {
+		return kubeNodes, nil
+	}
+	s.join = func(_ *common.ClusterState, n int) error {
+		<-release
+		assert.Equal(t, 1, n)
+		setWorkers(append(workers, types.Node{InstanceID: "i-3"}))
+		return nil
+	}
+	s.remove = func(_ *common.ClusterState, ids []string) error {
+		removed = ids
+		setWorkers([]types.Node{workers[1], {InstanceID: "i-3"}})
+		return nil
+	}
+
+	address := startServer(t, s)
+	client := newTestClient(t, address, testCluster)
+
+	groups := &nodeGroupsResponse{}
+	require.NoError(t, client.call("NodeGroups", &emptyMessage{}, groups))
+	require.Len(t, groups.NodeGroups, 1)
+	assert.Equal(t, testCluster, groups.NodeGroups[0].ID)
+	assert.Equal(t, int32(1), groups.NodeGroups[0].MinSize)
+	assert.Equal(t, int32(3), groups.NodeGroups[0].MaxSize)
+
+	// the clusters only see their own node groups.
+	other := &nodeGroupsResponse{}
+	require.NoError(t, newTestClient(t, address, "other.ap-southeast-2.aws").call("NodeGroups", &emptyMessage{}, other))
+	assert.Empty(t, other.NodeGroups)
+	err = client.call("NodeGroupTargetSize", &idRequest{ID: "other.ap-southeast-2.aws"}, &targetSizeResponse{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	forNode := &nodeGroupForNodeResponse{}
+	require.NoError(t, client.call("NodeGroupForNode", &nodeGroupForNodeRequest{Node: &externalNode{
+		Name:   "ip-10-0-0-1",
+		Labels: map[string]string{"kubernetes.io/os": "linux"},
+	}}, forNode))
+	assert.Equal(t, testCluster, forNode.NodeGroup.ID)
+	forNode = &nodeGroupForNodeResponse{}
+	require.NoError(t, client.call("NodeGroupForNode", &nodeGroupForNodeRequest{Node: &externalNode{Name: "ip-10-0-0-100"}}, forNode))
+	assert.Empty(t, forNode.NodeGroup.ID)
+
+	size := &targetSizeResponse{}
+	require.NoError(t, client.call("NodeGroupTargetSize", &idRequest{ID: testCluster}, size))
+	assert.Equal(t, int32(2), size.TargetSize)
+
+	// the target size can't exceed the max size.
+	err = client.call("NodeGroupIncreaseSize", &deltaRequest{Delta: 2, ID: testCluster}, &emptyMessage{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.NoError(t, client.call("NodeGroupIncreaseSize", &deltaRequest{Delta: 1, ID: testCluster}, &emptyMessage{}))
+	// the workers being joined are counted.
+	require.NoError(t, client.call("NodeGroupTargetSize", &idRequest{ID: testCluster}, size))
+	assert.Equal(t, int32(3), size.TargetSize)
+	err = client.call("NodeGroupDecreaseTargetSize", &deltaRequest{Delta: -1, ID: testCluster}, &emptyMessage{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	close(release)
+	s.Wait()
+	require.NoError(t, client.call("NodeGroupTargetSize", &idRequest{ID: testCluster}, size))
+	assert.Equal(t, int32(3), size.TargetSize)
+
+	nodes := &nodeGroupNodesResponse{}
+	require.NoError(t, client.call("NodeGroupNodes", &idRequest{ID: testCluster}, nodes))
+	assert.Equal(t, []*instance{
+		{ID: "aws:///ap-southeast-2a/i-1", State: instanceRunning},
+		{ID: "k3s://ip-10-0-0-2", State: instanceRunning},
+		{ID: "i-3", State: instanceCreating},
+	}, nodes.Instances)
+
+	// only the workers can be deleted.
+	err = client.call("NodeGroupDeleteNodes", &deleteNodesRequest{ID: testCluster, Nodes: []*externalNode{
+		{Name: "ip-10-0-0-1"}, {Name: "ip-10-0-0-2"}, {ProviderID: "k3s://ip-10-0-0-100"},
+	}}, &emptyMessage{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	require.NoError(t, client.call("NodeGroupDeleteNodes", &deleteNodesRequest{ID: testCluster, Nodes: []*externalNode{
+		{ProviderID: "aws:///ap-southeast-2a/i-1"},
+	}}, &emptyMessage{}))
+	s.Wait()
+	assert.Equal(t, []string{"i-1"}, removed)
+	require.NoError(t, client.call("NodeGroupTargetSize", &idRequest{ID: testCluster}, size))
+	assert.Equal(t, int32(2), size.TargetSize)
+	// the target size can't be less than the min size.
+	m.Lock()
+	state.AutoscaleMin = 2
+	m.Unlock()
+	err = client.call("NodeGroupDeleteNodes", &deleteNodesRequest{ID: testCluster, Nodes: []*externalNode{
+		{Name: "ip-10-0-0-2"},
+	}}, &emptyMessage{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// the optional methods are not implemented.
+	err = client.call("PricingNodePrice", &emptyMessage{}, &emptyMessage{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
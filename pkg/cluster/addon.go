@@ -0,0 +1,406 @@
+package cluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/airgap"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+)
+
+const (
+	addonManifestHashCmd = "sha256sum \"%s/%s.yaml\" 2>/dev/null | awk '{print $1}'"
+	// the manifest file is removed before deleting resources, otherwise k3s deploy controller may re-apply it.
+	removeAddonCmd = "rm -f \"%s/%s.yaml\" && echo \"%s\" | base64 -d | k3s kubectl delete --ignore-not-found --wait=false -f -"
+)
+
+// InstallAddon deploys the add-on to the existing cluster and records the deployed add-on.
+// The addon can be the catalog add-on reference <repo>/<name>[@version].
+func (p *ProviderBase) InstallAddon(clusterName, addon string, values map[string]string) error {
+	c, cleanup, err := p.prepareAddonCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	exist, err := common.DefaultDB.GetClusterAddon(c.ContextName, common.AddonRefName(addon))
+	if err != nil {
+		return err
+	}
+	if exist != nil {
+		return fmt.Errorf("add-on %s is already installed in cluster %s, please use upgrade instead", addon, c.ContextName)
+	}
+	if err := checkAddonDependencies(c.ContextName, addon); err != nil {
+		return err
+	}
+	if values == nil {
+		values = map[string]string{}
+	}
+	return p.deployAddon(c, addon, values)
+}
+
+// UpgradeAddon re-renders the installed add-on with the latest add-on manifest and the merged values.
+// The catalog add-on is upgraded to the latest version unless the version is set in the reference.
+func (p *ProviderBase) UpgradeAddon(clusterName, addon string, values map[string]string, unsetValues []string) error {
+	c, cleanup, err := p.prepareAddonCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	exist, err := common.DefaultDB.GetClusterAddon(c.ContextName, common.AddonRefName(addon))
+	if err != nil {
+		return err
+	}
+	if exist == nil {
+		return fmt.Errorf("add-on %s is not installed in cluster %s", addon, c.ContextName)
+	}
+	merged := map[string]string{}
+	for k, v := range exist.Values {
+		merged[k] = v
+	}
+	for _, k := range unsetValues {
+		delete(merged, k)
+	}
+	for k, v := range values {
+		merged[k] = v
+	}
+	if err := checkAddonDependencies(c.ContextName, addon); err != nil {
+		return err
+	}
+	return p.deployAddon(c, addon, merged)
+}
+
+// UninstallAddon removes the add-on manifest and the resources deployed by the add-on.
+func (p *ProviderBase) UninstallAddon(clusterName, addon string) error {
+	c, cleanup, err := p.prepareAddonCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	addon = common.AddonRefName(addon)
+	exist, err := common.DefaultDB.GetClusterAddon(c.ContextName, addon)
+	if err != nil {
+		return err
+	}
+	if exist == nil {
+		return fmt.Errorf("add-on %s is not installed in cluster %s", addon, c.ContextName)
+	}
+	if dependents := addonDependents(c.ContextName, addon); len(dependents) > 0 {
+		return fmt.Errorf("add-on %s is required by the installed add-ons %v, please uninstall them first", addon, dependents)
+	}
+	p.Logger.Infof("[%s] uninstalling add-on %s from cluster %s...", p.Provider, addon, c.ContextName)
+	if _, err := p.execute(&c.MasterNodes[0], fmt.Sprintf(removeAddonCmd, common.K3sManifestsDir, addonManifestName(addon),
+		base64.StdEncoding.EncodeToString(exist.Manifest))); err != nil {
+		return err
+	}
+	if err := common.DefaultDB.DeleteClusterAddon(c.ContextName, addon); err != nil {
+		return err
+	}
+	p.Logger.Infof("[%s] successfully uninstalled add-on %s", p.Provider, addon)
+	return nil
+}
+
+// GetAddonStatus returns the installed add-ons of the cluster with the status, all the add-ons are returned if addon is empty.
+func (p *ProviderBase) GetAddonStatus(clusterName, addon string) ([]apis.ClusterAddonStatus, error) {
+	c, cleanup, err := p.prepareAddonCluster(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	addons, err := common.DefaultDB.ListClusterAddons(c.ContextName)
+	if err != nil {
+		return nil, err
+	}
+	addon = common.AddonRefName(addon)
+	rtn := make([]apis.ClusterAddonStatus, 0, len(addons))
+	for _, a := range addons {
+		if addon != "" && a.Name != addon {
+			continue
+		}
+		rtn = append(rtn, apis.ClusterAddonStatus{
+			Name:             a.Name,
+			Version:          a.Version,
+			Values:           a.Values,
+			ManifestHash:     a.ManifestHash,
+			Status:           p.addonStatus(c, a),
+			Readiness:        a.Readiness,
+			ReadinessMessage: a.ReadinessMessage,
+			CreatedAt:        a.CreatedAt,
+			UpdatedAt:        a.UpdatedAt,
+		})
+	}
+	if addon != "" && len(rtn) == 0 {
+		return nil, fmt.Errorf("add-on %s is not installed in cluster %s", addon, c.ContextName)
+	}
+	return rtn, nil
+}
+
+func (p *ProviderBase) addonStatus(c *types.Cluster, a *common.ClusterAddon) string {
+	output, err := p.execute(&c.MasterNodes[0], fmt.Sprintf(addonManifestHashCmd, common.K3sManifestsDir, addonManifestName(a.Name)))
+	if err != nil {
+		p.Logger.Debugf("[%s] failed to check manifest of add-on %s: %v", p.Provider, a.Name, err)
+		return common.AddonStatusUnknown
+	}
+	switch strings.TrimSpace(output) {
+	case "":
+		return common.AddonStatusMissing
+	case a.ManifestHash:
+	default:
+		return common.AddonStatusModified
+	}
+	rendered, err := p.renderAddon(a.Name, a.Values)
+	if err != nil {
+		return common.AddonStatusUnknown
+	}
+	if rendered.ManifestHash != a.ManifestHash {
+		return common.AddonStatusOutdated
+	}
+	return common.AddonStatusDeployed
+}
+
+func (p *ProviderBase) deployAddon(c *types.Cluster, addon string, values map[string]string) error {
+	p.Logger.Infof("[%s] deploying add-on %s to cluster %s...", p.Provider, addon, c.ContextName)
+	definition, _, err := common.DefaultDB.GetAddonByRef(addon)
+	if err != nil {
+		return err
+	}
+	clusterAddon, err := p.renderAddon(addon, values)
+	if err != nil {
+		return err
+	}
+	cmd := fmt.Sprintf(deployPluginCmd, base64.StdEncoding.EncodeToString(clusterAddon.Manifest), common.K3sManifestsDir, addonManifestName(clusterAddon.Name))
+	if err := p.DeployExtraManifest(c, []string{cmd}); err != nil {
+		return err
+	}
+
+	var readinessErr error
+	if len(definition.ReadinessChecks) > 0 {
+		p.Logger.Infof("[%s] waiting for add-on %s to be ready...", p.Provider, addon)
+		readinessErr = p.waitAddonReady(c.ContextName, definition.ReadinessChecks)
+		clusterAddon.Readiness = common.AddonReadinessReady
+		if readinessErr != nil {
+			clusterAddon.Readiness = common.AddonReadinessNotReady
+			clusterAddon.ReadinessMessage = readinessErr.Error()
+		}
+	}
+	if err := common.DefaultDB.SaveClusterAddon(clusterAddon); err != nil {
+		return err
+	}
+	if readinessErr != nil {
+		return fmt.Errorf("add-on %s is deployed but not ready: %v", addon, readinessErr)
+	}
+	p.Logger.Infof("[%s] successfully deployed add-on %s", p.Provider, addon)
+	return nil
+}
+
+func (p *ProviderBase) waitAddonReady(contextName string, checks common.ReadinessChecks) error {
+	checker, err := newReadinessChecker(contextName)
+	if err != nil {
+		return err
+	}
+	return checker.wait(context.Background(), checks, addonReadinessTimeout(), addonReadinessInterval)
+}
+
+// ErrAddonsNotDeployed is returned when creating cluster if any enabled add-on failed to deploy or is not ready,
+// the cluster itself is running.
+var ErrAddonsNotDeployed = errors.New("the cluster is running but some add-ons are not deployed")
+
+// deployEnabledAddons deploys the add-ons enabled when creating cluster in dependency order,
+// the add-on is skipped if any of its dependencies failed to deploy or is not ready.
+// The failed and skipped add-ons are returned with ErrAddonsNotDeployed.
+func (p *ProviderBase) deployEnabledAddons(c *types.Cluster) error {
+	plugins := []string{}
+	for _, plugin := range p.Enable {
+		if plugin != "explorer" {
+			plugins = append(plugins, plugin)
+		}
+	}
+	if len(plugins) == 0 {
+		return nil
+	}
+	ordered, dependencies, err := sortAddons(plugins, getAddonByRef)
+	if err != nil {
+		return fmt.Errorf("%w: failed to resolve dependencies of add-ons %v: %v", ErrAddonsNotDeployed, plugins, err)
+	}
+	failed := map[string]bool{}
+	problems := []string{}
+	for _, ref := range ordered {
+		name := common.AddonRefName(ref)
+		skip := false
+		for _, dep := range dependencies[name] {
+			if failed[dep] {
+				p.Logger.Errorf("[%s] skip deploying add-on %s as its dependency %s is not ready", p.Provider, ref, dep)
+				problems = append(problems, fmt.Sprintf("add-on %s is skipped as its dependency %s is not ready", ref, dep))
+				skip = true
+				break
+			}
+		}
+		if skip {
+			failed[name] = true
+			continue
+		}
+		if err := p.deployAddon(c, ref, p.addonSetValues(ref)); err != nil {
+			p.Logger.Errorf("[%s] failed to deploy add-on %s: %v", p.Provider, ref, err)
+			problems = append(problems, err.Error())
+			failed[name] = true
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrAddonsNotDeployed, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func getAddonByRef(ref string) (*common.Addon, error) {
+	addon, _, err := common.DefaultDB.GetAddonByRef(ref)
+	return addon, err
+}
+
+// sortAddons sorts the add-ons topologically so that the dependencies are placed before the add-ons depend on them,
+// the dependencies which are not in the list are included. The dependency names of each add-on are returned as well.
+func sortAddons(plugins []string, getAddon func(ref string) (*common.Addon, error)) ([]string, map[string][]string, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	ordered := []string{}
+	dependencies := map[string][]string{}
+	state := map[string]int{}
+	var visit func(ref string, path []string) error
+	visit = func(ref string, path []string) error {
+		name := common.AddonRefName(ref)
+		path = append(path, name)
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular dependencies %s", strings.Join(path, " -> "))
+		}
+		state[name] = visiting
+		addon, err := getAddon(ref)
+		if err != nil {
+			return fmt.Errorf("failed to get add-on %s: %v", ref, err)
+		}
+		for _, dep := range addon.Dependencies {
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+			dependencies[name] = append(dependencies[name], common.AddonRefName(dep))
+		}
+		state[name] = visited
+		ordered = append(ordered, ref)
+		return nil
+	}
+	for _, plugin := range plugins {
+		if err := visit(plugin, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	return ordered, dependencies, nil
+}
+
+// checkAddonDependencies checks the dependencies of the add-on are installed and ready in the cluster.
+func checkAddonDependencies(contextName, addon string) error {
+	definition, err := getAddonByRef(addon)
+	if err != nil {
+		return err
+	}
+	for _, dep := range definition.Dependencies {
+		name := common.AddonRefName(dep)
+		installed, err := common.DefaultDB.GetClusterAddon(contextName, name)
+		if err != nil {
+			return err
+		}
+		if installed == nil {
+			return fmt.Errorf("dependency %s of add-on %s is not installed in cluster %s, please install it first", name, addon, contextName)
+		}
+		if installed.Readiness == common.AddonReadinessNotReady {
+			return fmt.Errorf("dependency %s of add-on %s is not ready in cluster %s: %s", name, addon, contextName, installed.ReadinessMessage)
+		}
+	}
+	return nil
+}
+
+// addonDependents returns the installed add-ons of the cluster which depend on the add-on.
+func addonDependents(contextName, addon string) []string {
+	installed, err := common.DefaultDB.ListClusterAddons(contextName)
+	if err != nil {
+		return nil
+	}
+	dependents := []string{}
+	for _, a := range installed {
+		ref := a.Name
+		if a.Version != "" {
+			ref = fmt.Sprintf("%s@%s", a.Name, a.Version)
+		}
+		definition, err := getAddonByRef(ref)
+		if err != nil {
+			continue
+		}
+		for _, dep := range definition.Dependencies {
+			if common.AddonRefName(dep) == addon {
+				dependents = append(dependents, a.Name)
+				break
+			}
+		}
+	}
+	return dependents
+}
+
+// prepareAddonCluster loads the cluster state and the bundled charts of the airgap package for add-on rendering.
+func (p *ProviderBase) prepareAddonCluster(clusterName string) (*types.Cluster, func(), error) {
+	if p.Provider == "k3d" {
+		return nil, nil, errors.New("the add-ons management for K3d provider is not supported yet")
+	}
+	state, err := common.DefaultDB.GetCluster(clusterName, p.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state == nil {
+		return nil, nil, fmt.Errorf("cluster %s is not exist", clusterName)
+	}
+	c := common.ConvertToCluster(state, true)
+	if len(c.MasterNodes) == 0 {
+		return nil, nil, fmt.Errorf("cluster %s has no master node", clusterName)
+	}
+	p.Name = clusterName
+	p.ContextName = state.ContextName
+	p.Status = c.Status
+	if p.Logger == nil {
+		p.Logger = common.NewLogger(nil)
+	}
+
+	cleanup := func() {}
+	pkg, err := airgap.PreparePackage(&c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pkg != nil {
+		// package's name is empty, it means that it is a temporary dir and it needs to be remove after.
+		if pkg.Name == "" {
+			cleanup = func() { _ = os.RemoveAll(pkg.FilePath) }
+		}
+		if p.bundledCharts, err = airgap.GetBundledCharts(pkg.FilePath); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+	return &c, cleanup, nil
+}
+
+// addonManifestName returns the manifest file name of the add-on, the catalog add-on <repo>/<name> is deployed as <repo>-<name>.yaml.
+func addonManifestName(name string) string {
+	return strings.ReplaceAll(name, "/", "-")
+}
+
+func manifestHash(manifest []byte) string {
+	hash := sha256.Sum256(manifest)
+	return hex.EncodeToString(hash[:])
+}
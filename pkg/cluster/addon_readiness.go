@@ -0,0 +1,201 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/settings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultAddonReadinessTimeout = 10 * time.Minute
+	addonReadinessInterval       = 5 * time.Second
+)
+
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// readinessChecker checks the resources of add-on readiness checks with the cluster clients.
+type readinessChecker struct {
+	client  kubernetes.Interface
+	dynamic dynamic.Interface
+}
+
+func newReadinessChecker(contextName string) (*readinessChecker, error) {
+	config, err := buildConfigFromFlags(contextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = 15 * time.Second
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &readinessChecker{client: client, dynamic: dynamicClient}, nil
+}
+
+// wait polls the readiness checks until all of them are ready, the last not ready reason is returned with the timeout error.
+func (r *readinessChecker) wait(ctx context.Context, checks common.ReadinessChecks, timeout, interval time.Duration) error {
+	reason := ""
+	err := wait.PollUntilContextTimeout(ctx, interval, timeout, true, func(ctx context.Context) (bool, error) {
+		for _, check := range checks {
+			ready, msg, err := r.check(ctx, check)
+			if err != nil {
+				// the api server may be unavailable temporarily, keep waiting.
+				reason = fmt.Sprintf("%s: %v", check.String(), err)
+				return false, nil
+			}
+			if !ready {
+				reason = fmt.Sprintf("%s: %s", check.String(), msg)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil && reason != "" {
+		return fmt.Errorf("%s is not ready after %s", reason, timeout)
+	}
+	return err
+}
+
+// check returns whether the resource is ready, and the reason if not.
+func (r *readinessChecker) check(ctx context.Context, check common.ReadinessCheck) (bool, string, error) {
+	var (
+		ready bool
+		msg   string
+		err   error
+	)
+	switch check.Kind {
+	case common.ReadinessKindDeployment:
+		var obj *appsv1.Deployment
+		if obj, err = r.client.AppsV1().Deployments(check.Namespace).Get(ctx, check.Name, metav1.GetOptions{}); err == nil {
+			ready, msg = deploymentReady(obj)
+		}
+	case common.ReadinessKindDaemonSet:
+		var obj *appsv1.DaemonSet
+		if obj, err = r.client.AppsV1().DaemonSets(check.Namespace).Get(ctx, check.Name, metav1.GetOptions{}); err == nil {
+			ready, msg = daemonSetReady(obj)
+		}
+	case common.ReadinessKindStatefulSet:
+		var obj *appsv1.StatefulSet
+		if obj, err = r.client.AppsV1().StatefulSets(check.Namespace).Get(ctx, check.Name, metav1.GetOptions{}); err == nil {
+			ready, msg = statefulSetReady(obj)
+		}
+	case common.ReadinessKindJob:
+		var obj *batchv1.Job
+		if obj, err = r.client.BatchV1().Jobs(check.Namespace).Get(ctx, check.Name, metav1.GetOptions{}); err == nil {
+			ready, msg = jobReady(obj)
+		}
+	case common.ReadinessKindCRD:
+		var obj *unstructured.Unstructured
+		if obj, err = r.dynamic.Resource(crdResource).Get(ctx, check.Name, metav1.GetOptions{}); err == nil {
+			ready, msg = crdReady(obj)
+		}
+	default:
+		return false, "", fmt.Errorf("readiness check kind %s is not supported", check.Kind)
+	}
+	if apierrors.IsNotFound(err) {
+		// the resource may not be created yet, e.g. the HelmChart is not installed.
+		return false, "not found", nil
+	}
+	return ready, msg, err
+}
+
+func deploymentReady(obj *appsv1.Deployment) (bool, string) {
+	replicas := int32(1)
+	if obj.Spec.Replicas != nil {
+		replicas = *obj.Spec.Replicas
+	}
+	if obj.Status.ObservedGeneration < obj.Generation {
+		return false, "waiting for the rollout to be observed"
+	}
+	if obj.Status.UpdatedReplicas < replicas || obj.Status.AvailableReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d updated replicas are available", obj.Status.AvailableReplicas, replicas)
+	}
+	return true, ""
+}
+
+func daemonSetReady(obj *appsv1.DaemonSet) (bool, string) {
+	if obj.Status.ObservedGeneration < obj.Generation {
+		return false, "waiting for the rollout to be observed"
+	}
+	desired := obj.Status.DesiredNumberScheduled
+	if obj.Status.UpdatedNumberScheduled < desired || obj.Status.NumberAvailable < desired {
+		return false, fmt.Sprintf("%d of %d updated pods are available", obj.Status.NumberAvailable, desired)
+	}
+	return true, ""
+}
+
+func statefulSetReady(obj *appsv1.StatefulSet) (bool, string) {
+	replicas := int32(1)
+	if obj.Spec.Replicas != nil {
+		replicas = *obj.Spec.Replicas
+	}
+	if obj.Status.ObservedGeneration < obj.Generation {
+		return false, "waiting for the rollout to be observed"
+	}
+	if obj.Status.ReadyReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d replicas are ready", obj.Status.ReadyReplicas, replicas)
+	}
+	return true, ""
+}
+
+func jobReady(obj *batchv1.Job) (bool, string) {
+	for _, c := range obj.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return true, ""
+		case batchv1.JobFailed:
+			return false, fmt.Sprintf("job failed: %s", c.Message)
+		}
+	}
+	return false, "job is not completed"
+}
+
+func crdReady(obj *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Established" && condition["status"] == string(corev1.ConditionTrue) {
+			return true, ""
+		}
+	}
+	return false, "not established"
+}
+
+// addonReadinessTimeout returns the timeout of waiting for each add-on from settings.
+func addonReadinessTimeout() time.Duration {
+	value := strings.TrimSpace(settings.AddonReadinessTimeout.Get())
+	if value == "" {
+		return defaultAddonReadinessTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return defaultAddonReadinessTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,104 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReadinessChecker(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "cert-manager", Namespace: "cert-manager", Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, UpdatedReplicas: 2, AvailableReplicas: 1},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "init", Namespace: "default"},
+		Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+		}},
+	}
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "certificates.cert-manager.io"},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Established", "status": "True"},
+		}},
+	}}
+	client := fake.NewSimpleClientset(deployment, job)
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdResource: "CustomResourceDefinitionList"}, crd)
+	checker := &readinessChecker{client: client, dynamic: dynamicClient}
+
+	ctx := context.Background()
+	cases := []struct {
+		check common.ReadinessCheck
+		ready bool
+	}{
+		{common.ReadinessCheck{Kind: common.ReadinessKindDeployment, Namespace: "cert-manager", Name: "cert-manager"}, false},
+		{common.ReadinessCheck{Kind: common.ReadinessKindDeployment, Namespace: "cert-manager", Name: "missing"}, false},
+		{common.ReadinessCheck{Kind: common.ReadinessKindJob, Namespace: "default", Name: "init"}, true},
+		{common.ReadinessCheck{Kind: common.ReadinessKindCRD, Name: "certificates.cert-manager.io"}, true},
+		{common.ReadinessCheck{Kind: common.ReadinessKindCRD, Name: "issuers.cert-manager.io"}, false},
+	}
+	for _, c := range cases {
+		ready, _, err := checker.check(ctx, c.check)
+		assert.NoError(t, err, c.check.String())
+		assert.Equal(t, c.ready, ready, c.check.String())
+	}
+
+	checks := common.ReadinessChecks{cases[0].check, cases[2].check}
+	err := checker.wait(ctx, checks, 50*time.Millisecond, 10*time.Millisecond)
+	assert.ErrorContains(t, err, "1 of 2 updated replicas are available")
+
+	deployment.Status.AvailableReplicas = 2
+	if _, err := client.AppsV1().Deployments("cert-manager").UpdateStatus(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, checker.wait(ctx, checks, time.Second, 10*time.Millisecond))
+}
+
+func TestSortAddons(t *testing.T) {
+	addons := map[string]*common.Addon{
+		"rancher":           {Name: "rancher", Dependencies: []string{"cert-manager"}},
+		"cert-manager":      {Name: "cert-manager"},
+		"stable/monitoring": {Name: "stable/monitoring", Dependencies: []string{"stable/crds@1.0.0", "cert-manager"}},
+		"stable/crds":       {Name: "stable/crds"},
+		"a":                 {Name: "a", Dependencies: []string{"b"}},
+		"b":                 {Name: "b", Dependencies: []string{"a"}},
+	}
+	getAddon := func(ref string) (*common.Addon, error) {
+		if addon, ok := addons[common.AddonRefName(ref)]; ok {
+			return addon, nil
+		}
+		return nil, fmt.Errorf("add-on %s is not found", ref)
+	}
+
+	ordered, dependencies, err := sortAddons([]string{"stable/monitoring@2.0.0", "rancher"}, getAddon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"stable/crds@1.0.0", "cert-manager", "stable/monitoring@2.0.0", "rancher"}, ordered)
+	assert.Equal(t, []string{"stable/crds", "cert-manager"}, dependencies["stable/monitoring"])
+
+	_, _, err = sortAddons([]string{"a"}, getAddon)
+	assert.ErrorContains(t, err, "a -> b -> a")
+	_, _, err = sortAddons([]string{"missing"}, getAddon)
+	assert.Error(t, err)
+}
@@ -13,9 +13,9 @@ import (
 	"sync"
 	"text/template"
 
+	"github.com/cnrancher/autok3s/pkg/airgap"
 	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/hosts/dialer"
-	"github.com/cnrancher/autok3s/pkg/providers"
 	putil "github.com/cnrancher/autok3s/pkg/providers/utils"
 	pkgsshkey "github.com/cnrancher/autok3s/pkg/sshkey"
 	"github.com/cnrancher/autok3s/pkg/types"
@@ -50,6 +50,12 @@ type ProviderBase struct {
 	ErrM           map[string]string
 	Logger         *logrus.Logger
 	Callbacks      map[string]*providerProcess
+	// bundledCharts are the charts in airgap package which will be used by the add-on HelmChart manifests.
+	bundledCharts []airgap.BundledChart
+	// registryImages are the airgap package images to seed the builtin registry.
+	registryImages []string
+	// nodePool is the pool settings of the joining workers which are set by the join flags.
+	nodePool types.NodePool
 }
 
 type providerProcess struct {
@@ -93,6 +99,12 @@ func (p *ProviderBase) GetCreateOptions() []types.Flag {
 			V:     p.Cluster,
 			Usage: "Form k3s cluster using embedded etcd (requires K8s >= 1.19), see: https://docs.k3s.io/installation/ha-embedded",
 		},
+		{
+			Name:  "control-plane-endpoint",
+			P:     &p.ControlPlaneEndpoint,
+			V:     p.ControlPlaneEndpoint,
+			Usage: "Provision a fixed control-plane endpoint for HA cluster(requires `--cluster` or `--datastore`), which is the TCP load balancer of cloud providers or the kube-vip VIP of native provider(requires `--vip`). The endpoint is used by agents to join and added to TLS SANs and kubeconfig",
+		},
 		{
 			Name:  "manifests",
 			P:     &p.Manifests,
@@ -197,6 +209,12 @@ func (p *ProviderBase) GetClusterOptions() []types.Flag {
 			V:     p.SystemDefaultRegistry,
 			Usage: "K3s private registry to be used for all system images, see: https://docs.k3s.io/reference/server-config",
 		},
+		{
+			Name:  "builtin-registry",
+			P:     &p.BuiltinRegistry,
+			V:     p.BuiltinRegistry,
+			Usage: "Deploy a private registry on the first master node and seed it with the airgap package images, all nodes will pull images from it. Requires airgap package",
+		},
 		{
 			Name:  "datastore",
 			P:     &p.DataStore,
@@ -362,7 +380,12 @@ func (p *ProviderBase) InitCluster(options interface{}, deployCCM func() []strin
 		Status:   p.Status,
 	}
 	defer func() {
-		if er != nil || len(p.ErrM) > 0 {
+		// the cluster is kept running if only the add-ons are not deployed, the error is returned as the create result.
+		addonsFailed := errors.Is(er, ErrAddonsNotDeployed)
+		if addonsFailed {
+			p.Logger.Errorf("%v", er)
+		}
+		if (er != nil && !addonsFailed) || len(p.ErrM) > 0 {
 			// save failed status.
 			state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
 			if err == nil {
@@ -377,7 +400,7 @@ func (p *ProviderBase) InitCluster(options interface{}, deployCCM func() []strin
 				} else {
 					c = common.ConvertToCluster(state, true)
 				}
-				if er != nil {
+				if er != nil && !addonsFailed {
 					p.Logger.Errorf("%v", er)
 					c.Status.Status = common.StatusFailed
 				}
@@ -396,7 +419,7 @@ func (p *ProviderBase) InitCluster(options interface{}, deployCCM func() []strin
 			}
 			_ = p.RollbackCluster(rollbackInstance)
 		}
-		if er == nil && len(p.Status.MasterNodes) > 0 {
+		if (er == nil || addonsFailed) && len(p.Status.MasterNodes) > 0 {
 			p.Logger.Info(common.UsageInfoTitle)
 			p.Logger.Infof(common.UsageContext, p.ContextName)
 			p.Logger.Info(common.UsagePods)
@@ -453,7 +476,7 @@ func (p *ProviderBase) InitCluster(options interface{}, deployCCM func() []strin
 			return err
 		}
 		// save current cluster's kubeConfig.
-		if err := SaveCfg(cfg, ip, c.ContextName); err != nil {
+		if err := SaveCfg(cfg, ip, c.ContextName, c.Provider); err != nil {
 			return err
 		}
 		_ = os.Setenv(clientcmd.RecommendedConfigPathEnvVar, filepath.Join(common.CfgPath, common.KubeCfgFile))
@@ -479,18 +502,6 @@ func (p *ProviderBase) InitCluster(options interface{}, deployCCM func() []strin
 		cmds = append(cmds, deployCmd...)
 	}
 
-	if p.Enable != nil {
-		for _, plugin := range p.Enable {
-			if plugin != "explorer" {
-				cmd, err := p.addonInstallation(plugin)
-				if err != nil {
-					continue
-				}
-				cmds = append(cmds, cmd)
-			}
-		}
-	}
-
 	// deploy custom manifests.
 	if len(cmds) > 0 {
 		if err = p.DeployExtraManifest(c, cmds); err != nil {
@@ -498,8 +509,8 @@ func (p *ProviderBase) InitCluster(options interface{}, deployCCM func() []strin
 		}
 		p.Logger.Infof("[%s] successfully deployed custom manifests", p.Provider)
 	}
-
-	return
+	// deploy the enabled add-ons in dependency order, they are recorded so that they can be managed by add-on commands later.
+	return p.deployEnabledAddons(c)
 }
 
 // JoinNodes join K3S nodes.
@@ -670,6 +681,7 @@ func (p *ProviderBase) overwriteMetadata(matched *common.ClusterState) {
 	p.Network = matched.Network
 	p.Cluster = matched.Cluster
 	p.Rollback = matched.Rollback
+	p.ControlPlaneEndpoint = matched.ControlPlaneEndpoint
 	// needed to be overwrite.
 	if p.K3sChannel == "" {
 		p.K3sChannel = matched.K3sChannel
@@ -698,6 +710,11 @@ func (p *ProviderBase) overwriteMetadata(matched *common.ClusterState) {
 	if p.WorkerExtraArgs == "" {
 		p.WorkerExtraArgs = matched.WorkerExtraArgs
 	}
+	// the joined masters must have the same TLS SANs, e.g. the control-plane endpoint.
+	if len(p.TLSSans) == 0 {
+		p.TLSSans = matched.TLSSans
+	}
+	p.NodePools = p.mergeNodePools(matched.NodePools)
 }
 
 func (p *ProviderBase) CheckCreateArgs(checkClusterExist func() (bool, []string, error)) error {
@@ -753,6 +770,26 @@ func (p *ProviderBase) CheckCreateArgs(checkClusterExist func() (bool, []string,
 		return fmt.Errorf("[%s] failed to check --registry %s", p.Provider, p.Registry)
 	}
 
+	if p.ControlPlaneEndpoint {
+		if p.Provider == "k3d" {
+			return fmt.Errorf("[%s] calling preflight error: `--control-plane-endpoint` is not supported", p.Provider)
+		}
+		if !p.Cluster && p.DataStore == "" {
+			return fmt.Errorf("[%s] calling preflight error: `--control-plane-endpoint` requires `--cluster` or `--datastore`", p.Provider)
+		}
+		if p.IP != "" {
+			return fmt.Errorf("[%s] calling preflight error: `--control-plane-endpoint` can't be used with `--ip`", p.Provider)
+		}
+	}
+
+	if p.BuiltinRegistry && p.PackageName == "" && p.PackagePath == "" {
+		return fmt.Errorf("[%s] calling preflight error: `--builtin-registry` requires `--package-name` or `--package-path`", p.Provider)
+	}
+
+	if err := p.validateAddons(); err != nil {
+		return err
+	}
+
 	if p.DataStoreCAFile != "" && !utils.IsFileExists(p.DataStoreCAFile) {
 		return fmt.Errorf("[%s] failed to check --datastore-cafile %s", p.Provider, p.DataStoreCAFile)
 	}
@@ -808,6 +845,9 @@ func (p *ProviderBase) CheckJoinArgs(checkClusterExist func() (bool, []string, e
 		if masterNum > 0 && p.DataStore == "" && !p.Cluster {
 			return fmt.Errorf("[%s] calling preflight error: can't join master nodes to single node cluster", p.Provider)
 		}
+		if masterNum > 0 && p.NodePool != "" {
+			return fmt.Errorf("[%s] calling preflight error: only workers can be joined to node pool", p.Provider)
+		}
 
 		workerNum, err := strconv.Atoi(p.Worker)
 		if err != nil {
@@ -873,10 +913,34 @@ func (p *ProviderBase) DeleteCluster(force bool, delete func(f bool) (string, er
 		if err != nil && !force {
 			return fmt.Errorf("[%s] merge kubeconfig error, msg: %v", p.Provider, err)
 		}
+		err = common.FileManager.RemoveClusterCfg(contextName, p.Provider)
+		if err != nil && !force {
+			return fmt.Errorf("[%s] failed to remove kubeconfig of cluster %s: %v", p.Provider, p.Name, err)
+		}
 		err = common.DefaultDB.DeleteCluster(p.Name, p.Provider)
 		if err != nil && !force {
 			return fmt.Errorf("[%s] failed to delete cluster state, msg: %v", p.Provider, err)
 		}
+		err = common.DefaultDB.DeleteClusterAddons(contextName)
+		if err != nil && !force {
+			return fmt.Errorf("[%s] failed to delete add-ons of cluster %s: %v", p.Provider, p.Name, err)
+		}
+		err = common.DefaultDB.DeleteKubeconfigCredentials(contextName)
+		if err != nil && !force {
+			return fmt.Errorf("[%s] failed to delete kubeconfig credentials of cluster %s: %v", p.Provider, p.Name, err)
+		}
+		err = common.DefaultDB.DeleteClusterStatusCache(contextName)
+		if err != nil && !force {
+			return fmt.Errorf("[%s] failed to delete cached status of cluster %s: %v", p.Provider, p.Name, err)
+		}
+		err = common.DefaultDB.DeleteClusterHealth(contextName)
+		if err != nil && !force {
+			return fmt.Errorf("[%s] failed to delete health history of cluster %s: %v", p.Provider, p.Name, err)
+		}
+		err = common.DefaultDB.DeleteNodeRepairs(contextName)
+		if err != nil && !force {
+			return fmt.Errorf("[%s] failed to delete node repairs of cluster %s: %v", p.Provider, p.Name, err)
+		}
 
 		// release kube-explorer
 		exp, err := common.DefaultDB.GetExplorer(p.ContextName)
@@ -1007,68 +1071,6 @@ func (p *ProviderBase) SaveCredential(secrets map[string]string) error {
 	return nil
 }
 
-// ListClusters list clusters.
-func ListClusters(providerName string) ([]*types.ClusterInfo, error) {
-	stateList, err := common.DefaultDB.ListCluster(providerName)
-	if err != nil {
-		return nil, err
-	}
-	kubeCfg := filepath.Join(common.CfgPath, common.KubeCfgFile)
-	clusterList := make([]*types.ClusterInfo, 0)
-	for _, state := range stateList {
-		// TODO skip harvester for historical data, will remove here after harvester provider added back
-		if state.Provider == "harvester" {
-			continue
-		}
-		provider, err := providers.GetProvider(state.Provider)
-		if err != nil {
-			logrus.Errorf("failed to get provider %v: %v", state.Provider, err)
-			continue
-		}
-		provider.SetMetadata(&state.Metadata)
-		_ = provider.SetOptions(state.Options)
-		contextName := provider.GenerateClusterName()
-		if state.Status != common.StatusRunning {
-			info := provider.GetCluster("")
-			info.Status = state.Status
-			info.Master = state.Master
-			info.Worker = state.Worker
-			clusterList = append(clusterList, info)
-			continue
-		}
-		isExist, _, err := provider.IsClusterExist()
-		if err != nil {
-			info := provider.GetCluster("")
-			info.Status = common.StatusUnknown
-			info.Master = state.Master
-			info.Worker = state.Worker
-			clusterList = append(clusterList, info)
-			logrus.Errorf("failed to check provider %s cluster %s exist, got error: %v ", state.Provider, state.Name, err)
-			continue
-		}
-		if !isExist {
-			logrus.Warnf("cluster %s (provider %s) is not exist, will remove from config", state.Name, state.Provider)
-			// remove kube config if cluster not exist
-			if err := common.FileManager.ClearCfgByContext(contextName); err != nil {
-				logrus.Errorf("failed to remove unexist cluster %s from kube config", state.Name)
-			}
-			// update status to missing
-			state.Status = common.StatusMissing
-			if err := common.DefaultDB.SaveClusterState(state); err != nil {
-				logrus.Errorf("failed to update cluster %s state to missing", state.Name)
-			}
-			info := provider.GetCluster("")
-			info.Status = state.Status
-			info.Master = state.Master
-			info.Worker = state.Worker
-			clusterList = append(clusterList, info)
-			continue
-		}
-		clusterList = append(clusterList, provider.GetCluster(kubeCfg))
-	}
-	return clusterList, nil
-}
-
 func (p *ProviderBase) syncExistNodes() {
 	p.M.Range(func(key, value interface{}) bool {
 		v := value.(types.Node)
@@ -1097,6 +1099,7 @@ func (p *ProviderBase) syncExistNodes() {
 func (p *ProviderBase) Describe(kubeCfg string, c *types.ClusterInfo, describeInstance func() ([]types.Node, error)) *types.ClusterInfo {
 	c.Master = p.Master
 	c.Worker = p.Worker
+	c.NodePools = p.NodePools
 
 	if p.Cluster {
 		c.IsHAMode = true
@@ -1132,6 +1135,7 @@ func (p *ProviderBase) Describe(kubeCfg string, c *types.ClusterInfo, describeIn
 			c.Worker = "0"
 			return c
 		}
+		pools := p.workerPools()
 		instanceNodes := make([]types.ClusterNode, 0)
 		masterCount := 0
 		workerCount := 0
@@ -1142,6 +1146,8 @@ func (p *ProviderBase) Describe(kubeCfg string, c *types.ClusterInfo, describeIn
 				InternalIP:              instance.InternalIPAddress,
 				ExternalIP:              instance.PublicIPAddress,
 				Standalone:              instance.Standalone,
+				Pool:                    pools[instance.InstanceID],
+				Zone:                    instance.Zone,
 				Status:                  types.ClusterStatusUnknown,
 				ContainerRuntimeVersion: types.ClusterStatusUnknown,
 				Version:                 types.ClusterStatusUnknown,
@@ -1171,6 +1177,23 @@ func (p *ProviderBase) Describe(kubeCfg string, c *types.ClusterInfo, describeIn
 	return c
 }
 
+// workerPools returns the node pools of the workers by instance id.
+func (p *ProviderBase) workerPools() map[string]string {
+	pools := map[string]string{}
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil || state == nil {
+		return pools
+	}
+	workers := []types.Node{}
+	_ = json.Unmarshal(state.WorkerNodes, &workers)
+	for _, n := range workers {
+		if n.Pool != "" {
+			pools[n.InstanceID] = n.Pool
+		}
+	}
+	return pools
+}
+
 // Connect ssh & connect to the K3S node.
 func (p *ProviderBase) Connect(ip string, ssh *types.SSH, c *types.Cluster, getStatus func() ([]types.Node, error),
 	isRunning func(status string) bool, customConnect func(id string, cluster *types.Cluster) error) error {
@@ -1276,6 +1299,9 @@ func (p *ProviderBase) RollbackCluster(rollbackInstance func(ids []string) error
 			if err := common.FileManager.ClearCfgByContext(p.ContextName); err != nil {
 				logrus.Errorf("failed to remove cluster context %s from kube config", p.ContextName)
 			}
+			if err := common.FileManager.RemoveClusterCfg(p.ContextName, p.Provider); err != nil {
+				logrus.Errorf("failed to remove kube config of cluster context %s", p.ContextName)
+			}
 		}
 
 		p.Logger.Infof("[%s] successfully executed rollback logic", p.Provider)
@@ -1481,35 +1507,92 @@ func (p *ProviderBase) parseDefaultTemplates() template.FuncMap {
 	}
 }
 
-func (p *ProviderBase) addonInstallation(plugin string) (string, error) {
-	// check addon plugin
-	addon, err := common.DefaultDB.GetAddon(plugin)
-	if err != nil {
-		p.Logger.Errorf("[%s] failed to get addon by name %s, got error: %v", p.Provider, plugin, err)
-		return "", err
+// addonSetValues returns the --set values of the add-on, the catalog add-on uses <repo>/<name> as prefix.
+// The values with the prefix of other enabled add-ons are ignored.
+func (p *ProviderBase) addonSetValues(plugin string) map[string]string {
+	prefix := fmt.Sprintf("%s.", common.AddonRefName(plugin))
+	otherPrefixes := []string{}
+	for _, enabled := range p.Enable {
+		if other := fmt.Sprintf("%s.", common.AddonRefName(enabled)); other != prefix {
+			otherPrefixes = append(otherPrefixes, other)
+		}
 	}
-	manifest := addon.Manifest
-	defaultValues := addon.Values
-	// check --set values
 	setValues := map[string]string{}
 	for key, value := range p.Values {
-		if strings.HasPrefix(key, fmt.Sprintf("%s.", plugin)) {
-			setValues[strings.TrimPrefix(key, fmt.Sprintf("%s.", plugin))] = value
-		} else {
+		if strings.HasPrefix(key, prefix) {
+			setValues[strings.TrimPrefix(key, prefix)] = value
+		} else if !hasAnyPrefix(key, otherPrefixes) {
 			setValues[key] = value
 		}
 	}
-	values, err := common.GenerateValues(setValues, defaultValues)
+	return setValues
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAddons checks the enabled add-ons and their dependencies exist,
+// and validates the --set values with the add-on values schema.
+func (p *ProviderBase) validateAddons() error {
+	plugins := []string{}
+	for _, plugin := range p.Enable {
+		if plugin != "explorer" {
+			plugins = append(plugins, plugin)
+		}
+	}
+	ordered, _, err := sortAddons(plugins, getAddonByRef)
 	if err != nil {
-		p.Logger.Errorf("[%s] failed to generate values for addon %s with values %v: %v", p.Provider, plugin, p.Values, err)
-		return "", err
+		return fmt.Errorf("[%s] %v", p.Provider, err)
+	}
+	for _, plugin := range ordered {
+		addon, _, err := common.DefaultDB.GetAddonByRef(plugin)
+		if err != nil {
+			return fmt.Errorf("[%s] failed to get add-on %s: %v", p.Provider, plugin, err)
+		}
+		values, err := common.GenerateValues(p.addonSetValues(plugin), addon.Values)
+		if err != nil {
+			return fmt.Errorf("[%s] failed to generate values for add-on %s: %v", p.Provider, plugin, err)
+		}
+		if err := common.ValidateValues(addon.ValuesSchema, values); err != nil {
+			return fmt.Errorf("[%s] add-on %s: %v", p.Provider, plugin, err)
+		}
+	}
+	return nil
+}
+
+// renderAddon renders the add-on manifest with the set values and the add-on default values.
+// The plugin can be the catalog add-on reference <repo>/<name>[@version], the latest version is used if version is not set.
+func (p *ProviderBase) renderAddon(plugin string, setValues map[string]string) (*common.ClusterAddon, error) {
+	// check addon plugin
+	addon, version, err := common.DefaultDB.GetAddonByRef(plugin)
+	if err != nil {
+		p.Logger.Errorf("[%s] failed to get addon by name %s, got error: %v", p.Provider, plugin, err)
+		return nil, err
 	}
-	p.Logger.Debugf("assemble manifest with value %++v", values)
-	assembleManifest, err := common.AssembleManifest(values, string(manifest), p.parseDefaultTemplates())
+	assembleManifest, err := common.RenderAddon(addon, setValues, p.parseDefaultTemplates())
 	if err != nil {
-		p.Logger.Errorf("[%s] failed to assemble manifest for addon %s with values %v: %v", p.Provider, plugin, setValues, err)
-		return "", err
+		p.Logger.Errorf("[%s] failed to render manifest for addon %s with values %v: %v", p.Provider, plugin, setValues, err)
+		return nil, err
+	}
+	if len(p.bundledCharts) > 0 {
+		assembleManifest, err = airgap.RewriteHelmCharts(assembleManifest, p.bundledCharts)
+		if err != nil {
+			p.Logger.Errorf("[%s] failed to use airgap package charts for addon %s: %v", p.Provider, plugin, err)
+			return nil, err
+		}
 	}
-	return fmt.Sprintf(deployPluginCmd,
-		base64.StdEncoding.EncodeToString(assembleManifest), common.K3sManifestsDir, plugin), nil
+	return &common.ClusterAddon{
+		Cluster:      p.ContextName,
+		Name:         addon.Name,
+		Version:      version,
+		Values:       setValues,
+		Manifest:     assembleManifest,
+		ManifestHash: manifestHash(assembleManifest),
+	}, nil
 }
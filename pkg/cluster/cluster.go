@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"sort"
@@ -22,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rancher/wharfie/pkg/registries"
 	"github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -52,6 +54,11 @@ func (p *ProviderBase) InitK3sCluster(cluster *types.Cluster, deployCCM func() [
 	if pkg != nil && pkg.Name == "" {
 		defer os.RemoveAll(pkg.FilePath)
 	}
+	if pkg != nil {
+		if p.bundledCharts, err = airgap.GetBundledCharts(pkg.FilePath); err != nil {
+			return err
+		}
+	}
 
 	if cluster.Token == "" {
 		token, err := utils.RandomToken(16)
@@ -71,6 +78,12 @@ func (p *ProviderBase) InitK3sCluster(cluster *types.Cluster, deployCCM func() [
 		publicIP = cluster.MasterNodes[0].PublicIPAddress[0]
 	}
 
+	if cluster.BuiltinRegistry {
+		if err := p.prepareBuiltinRegistry(cluster, pkg); err != nil {
+			return err
+		}
+	}
+
 	// initialize the first master node and worker node to validate the K3s configuration.
 	var firstControl, firstWorker types.Node
 	controlNodes := []types.Node{}
@@ -97,6 +110,12 @@ func (p *ProviderBase) InitK3sCluster(cluster *types.Cluster, deployCCM func() [
 		return err
 	}
 
+	if cluster.BuiltinRegistry {
+		if err := p.seedBuiltinRegistry(cluster); err != nil {
+			return err
+		}
+	}
+
 	for i, master := range controlNodes {
 		p.Logger.Infof("[%s] join k3s control-%d...", p.Provider, i+1)
 		if err := p.initControlNode(cluster, provider, publicIP, pkg, master, false); err != nil {
@@ -130,7 +149,7 @@ func (p *ProviderBase) InitK3sCluster(cluster *types.Cluster, deployCCM func() [
 	}
 
 	// merge current cluster to kube config.
-	if err := SaveCfg(cfg, publicIP, cluster.ContextName); err != nil {
+	if err := SaveCfg(cfg, publicIP, cluster.ContextName, cluster.Provider); err != nil {
 		return err
 	}
 	_ = os.Setenv(clientcmd.RecommendedConfigPathEnvVar, filepath.Join(common.CfgPath, common.KubeCfgFile))
@@ -229,7 +248,7 @@ func (p *ProviderBase) Join(merged, added *types.Cluster) error {
 		if additionalExtraArgs != "" {
 			extraArgs += additionalExtraArgs
 		}
-		if err := p.initNode(false, publicIP, merged, full, extraArgs, pkg); err != nil {
+		if err := p.initNode(true, false, publicIP, merged, full, extraArgs, pkg); err != nil {
 			return err
 		}
 		p.Logger.Infof("[%s] successfully joined k3s master-%d", merged.Provider, i+1)
@@ -254,7 +273,7 @@ func (p *ProviderBase) Join(merged, added *types.Cluster) error {
 			if additionalExtraArgs != "" {
 				extraArgs += additionalExtraArgs
 			}
-			if err := p.initNode(false, publicIP, merged, full, extraArgs, pkg); err != nil {
+			if err := p.initNode(false, false, publicIP, merged, full, extraArgs, pkg); err != nil {
 				l.Lock()
 				p.ErrM[full.InstanceID] = err.Error()
 				l.Unlock()
@@ -285,7 +304,7 @@ func (p *ProviderBase) Join(merged, added *types.Cluster) error {
 			}, catCfgCommand)
 			if err == nil {
 				// merge current cluster to kube config.
-				if err := SaveCfg(cfg, merged.IP, p.ContextName); err != nil {
+				if err := SaveCfg(cfg, merged.IP, p.ContextName, merged.Provider); err != nil {
 					p.Logger.Warnf("[%s] can't save kubeconfig file with error: %v", merged.Provider, err)
 				}
 				_ = os.Setenv(clientcmd.RecommendedConfigPathEnvVar, filepath.Join(common.CfgPath, common.KubeCfgFile))
@@ -383,8 +402,8 @@ func (p *ProviderBase) UninstallK3sNodes(nodes []types.Node) (warnMsg []string)
 	return
 }
 
-// SaveCfg save kube config file.
-func SaveCfg(cfg, ip, context string) error {
+// SaveCfg merges the kube config to the autok3s kube config file and saves the standalone kube config of the cluster.
+func SaveCfg(cfg, ip, context, provider string) error {
 	replacer := strings.NewReplacer(
 		"127.0.0.1", ip,
 		"localhost", ip,
@@ -414,7 +433,106 @@ func SaveCfg(cfg, ip, context string) error {
 		return fmt.Errorf("[cluster] write content to kubecfg temp file error: %v", err)
 	}
 
-	return common.FileManager.SaveCfg(context, temp.Name())
+	if err := common.FileManager.SaveCfg(context, temp.Name()); err != nil {
+		return err
+	}
+	return common.FileManager.SaveClusterCfg(context, provider, []byte(result))
+}
+
+// RepairCfg re-fetches the kube config from the first reachable master of the cluster and saves it by SaveCfg.
+func RepairCfg(state *common.ClusterState, logger *logrus.Logger) error {
+	c := common.ConvertToCluster(state, true)
+	if len(c.MasterNodes) == 0 {
+		return fmt.Errorf("cluster %s has no master node", state.ContextName)
+	}
+	var lastErr error
+	for _, node := range c.MasterNodes {
+		if len(node.PublicIPAddress) == 0 {
+			continue
+		}
+		fillNodeSSH(&node, &c.SSH)
+		cfg, err := executeOnNode(&node, logger, catCfgCommand)
+		if err != nil {
+			logger.Warnf("[cluster] failed to get kubeconfig from master %s: %v", node.PublicIPAddress[0], err)
+			lastErr = err
+			continue
+		}
+		return SaveCfg(cfg, cfgServerIP(&c, node), state.ContextName, state.Provider)
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no master of cluster %s has public ip address", state.ContextName)
+	}
+	return fmt.Errorf("failed to get kubeconfig of cluster %s from masters: %v", state.ContextName, lastErr)
+}
+
+// CfgServerIPs returns the addresses which the kube config of the cluster may use as the server,
+// the ip of cluster is used if it's set explicitly, otherwise the public ip of masters.
+func CfgServerIPs(c *types.Cluster) []string {
+	if c.IP != "" && !isMasterInternalIP(c, c.IP) {
+		return []string{c.IP}
+	}
+	ips := []string{}
+	for _, node := range c.MasterNodes {
+		if len(node.PublicIPAddress) > 0 {
+			ips = append(ips, node.PublicIPAddress[0])
+		}
+	}
+	return ips
+}
+
+// cfgServerIP returns the server address of the kube config fetched from the master,
+// the ip of cluster is set to the internal ip of the first master if it isn't set when the cluster is created.
+func cfgServerIP(c *types.Cluster, master types.Node) string {
+	if c.IP != "" && !isMasterInternalIP(c, c.IP) {
+		return c.IP
+	}
+	return master.PublicIPAddress[0]
+}
+
+func isMasterInternalIP(c *types.Cluster, ip string) bool {
+	for _, node := range c.MasterNodes {
+		for _, internal := range node.InternalIPAddress {
+			if internal == ip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fillNodeSSH sets the ssh config of the cluster to the node if it's not set.
+func fillNodeSSH(node *types.Node, ssh *types.SSH) {
+	if node.SSHUser == "" {
+		node.SSHUser = ssh.SSHUser
+	}
+	if node.SSHPort == "" {
+		node.SSHPort = ssh.SSHPort
+	}
+	if node.SSHPort == "" {
+		node.SSHPort = "22"
+	}
+	if node.SSHPassword == "" && node.SSHKeyPath == "" && !node.SSHAgentAuth {
+		node.SSHPassword = ssh.SSHPassword
+		node.SSHKeyPath = ssh.SSHKeyPath
+		node.SSHCertPath = ssh.SSHCertPath
+		node.SSHKeyPassphrase = ssh.SSHKeyPassphrase
+		node.SSHAgentAuth = ssh.SSHAgentAuth
+	}
+}
+
+func executeOnNode(n *types.Node, logger *logrus.Logger, cmds ...string) (string, error) {
+	dialer, err := dialer.NewSSHDialer(n, true, logger)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = dialer.Close()
+	}()
+	output, err := dialer.ExecuteCommands(cmds...)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, output)
+	}
+	return output, nil
 }
 
 // DeployExtraManifest deploy extra K3S manifest.
@@ -428,7 +546,7 @@ func (p *ProviderBase) DeployExtraManifest(cluster *types.Cluster, cmds []string
 	return nil
 }
 
-func (p *ProviderBase) initNode(isFirstMaster bool, fixedIP string, cluster *types.Cluster, node types.Node, extraArgs string, pkg *common.Package) error {
+func (p *ProviderBase) initNode(master, isFirstMaster bool, fixedIP string, cluster *types.Cluster, node types.Node, extraArgs string, pkg *common.Package) error {
 	if strings.Contains(extraArgs, "--docker") {
 		dockerCmd := fmt.Sprintf(dockerCommand, cluster.DockerScript, cluster.DockerArg, cluster.DockerMirror)
 		p.Logger.Infof("[cluster] install docker command %s", dockerCmd)
@@ -443,6 +561,12 @@ func (p *ProviderBase) initNode(isFirstMaster bool, fixedIP string, cluster *typ
 		}
 	}
 
+	if isFirstMaster && cluster.BuiltinRegistry {
+		if err := p.deployBuiltinRegistry(&node, cluster, extraArgs); err != nil {
+			return err
+		}
+	}
+
 	if cluster.DataStoreCAFile != "" || cluster.DataStoreCAFileContent != "" ||
 		cluster.DataStoreCertFileContent != "" || cluster.DataStoreCertFile != "" ||
 		cluster.DataStoreKeyFileContent != "" || cluster.DataStoreKeyFile != "" {
@@ -452,7 +576,7 @@ func (p *ProviderBase) initNode(isFirstMaster bool, fixedIP string, cluster *typ
 	}
 
 	if pkg != nil {
-		if err := p.scpFiles(cluster.Name, pkg, &node, extraArgs); err != nil {
+		if err := p.scpFiles(cluster.Name, pkg, &node, extraArgs, master); err != nil {
 			return err
 		}
 	}
@@ -849,7 +973,7 @@ func (p *ProviderBase) Upgrade(cluster *types.Cluster) error {
 		var cmd string
 
 		if pkg != nil {
-			if err := p.scpFiles(cluster.Name, pkg, &node, extraArgs); err != nil {
+			if err := p.scpFiles(cluster.Name, pkg, &node, extraArgs, true); err != nil {
 				return err
 			}
 			cmd = k3sRestart
@@ -874,7 +998,7 @@ func (p *ProviderBase) Upgrade(cluster *types.Cluster) error {
 
 		var cmd string
 		if pkg != nil {
-			if err := p.scpFiles(cluster.Name, pkg, &node, extraArgs); err != nil {
+			if err := p.scpFiles(cluster.Name, pkg, &node, extraArgs, false); err != nil {
 				return err
 			}
 			cmd = k3sAgentRestart
@@ -900,13 +1024,13 @@ func nodeByInstanceID(nodes []types.Node) map[string]types.Node {
 	return rtn
 }
 
-func (p *ProviderBase) scpFiles(clusterName string, pkg *common.Package, node *types.Node, extraArgs string) error {
+func (p *ProviderBase) scpFiles(clusterName string, pkg *common.Package, node *types.Node, extraArgs string, master bool) error {
 	dialer, err := dialer.NewSSHDialer(node, true, p.Logger)
 	if err != nil {
 		return err
 	}
 	defer dialer.Close()
-	return airgap.ScpFiles(p.Logger, clusterName, pkg, dialer, extraArgs)
+	return airgap.ScpFiles(p.Logger, clusterName, pkg, dialer, extraArgs, master)
 }
 
 func (p *ProviderBase) handleDataStoreCertificate(n *types.Node, c *types.Cluster) error {
@@ -964,6 +1088,10 @@ func (p *ProviderBase) validateClusterConfig(cluster *types.Cluster, provider pr
 			return err
 		}
 	}
+	// the other nodes join by the control-plane endpoint.
+	if err := p.waitControlPlaneEndpoint(cluster, &firstControl); err != nil {
+		return err
+	}
 	p.Logger.Infof("[%s] successfully initialize the first control node", p.Provider)
 
 	if len(firstWorker.PublicIPAddress) <= 0 && firstWorker.InstanceID == "" {
@@ -986,7 +1114,7 @@ func (p *ProviderBase) initControlNode(cluster *types.Cluster, provider provider
 		masterExtraArgs += providerExtraArgs
 	}
 
-	return p.initNode(isFirst, publicIP, cluster, controlNode, masterExtraArgs, pkg)
+	return p.initNode(true, isFirst, publicIP, cluster, controlNode, masterExtraArgs, pkg)
 }
 
 func (p *ProviderBase) initWorkerNode(cluster *types.Cluster, provider providers.Provider, publicIP string, pkg *common.Package, workerNode types.Node) error {
@@ -995,5 +1123,36 @@ func (p *ProviderBase) initWorkerNode(cluster *types.Cluster, provider providers
 	if providerExtraArgs != "" {
 		workerExtraArgs += providerExtraArgs
 	}
-	return p.initNode(false, publicIP, cluster, workerNode, workerExtraArgs, pkg)
+	return p.initNode(false, false, publicIP, cluster, workerNode, workerExtraArgs, pkg)
+}
+
+// ProbeSSH checks whether the node can be logged in by SSH within the timeout, there's no retry so that it can be
+// used to check the reachability of nodes periodically.
+func ProbeSSH(node types.Node, ssh *types.SSH, timeout time.Duration) error {
+	fillNodeSSH(&node, ssh)
+	address := getFirstAddress(node.PublicIPAddress)
+	if address == "" {
+		return fmt.Errorf("no public address of node %s", node.InstanceID)
+	}
+	var key, cert string
+	if node.SSHPassword == "" && !node.SSHAgentAuth && node.SSHKeyPath != "" {
+		var err error
+		if key, err = utils.SSHPrivateKeyPath(node.SSHKeyPath); err != nil {
+			return err
+		}
+		if node.SSHCertPath != "" {
+			if cert, err = utils.SSHCertificatePath(node.SSHCertPath); err != nil {
+				return err
+			}
+		}
+	}
+	cfg, err := utils.GetSSHConfig(node.SSHUser, key, node.SSHKeyPassphrase, cert, node.SSHPassword, timeout, node.SSHAgentAuth)
+	if err != nil {
+		return err
+	}
+	client, err := gossh.Dial("tcp", net.JoinHostPort(address, node.SSHPort), cfg)
+	if err != nil {
+		return err
+	}
+	return client.Close()
 }
@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/rancher/wrangler/v2/pkg/slice"
+)
+
+const (
+	// EndpointPort is the port of the control-plane endpoint which is the same as the K3s API server.
+	EndpointPort = 6443
+	// endpointNameMaxLength is the shortest name limit of the cloud load balancers, which is AWS NLB.
+	endpointNameMaxLength = 32
+	// waitEndpointCommand checks the endpoint from the master, so that the agents in the same network can join by it.
+	waitEndpointCommand = `for i in $(seq 1 60); do if curl -sk -o /dev/null --max-time 5 https://%s:%d/ping; then exit 0; fi; sleep 5; done; echo "control-plane endpoint %s is not reachable"; exit 1`
+)
+
+var endpointNameInvalidChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// EndpointName returns the name of the control-plane load balancer of the cluster,
+// it's unique for the context name and valid for all the cloud providers.
+func EndpointName(contextName string) string {
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(contextName)))[:6]
+	name := endpointNameInvalidChars.ReplaceAllString(strings.ToLower(strings.SplitN(contextName, ".", 2)[0]), "-")
+	prefix := "autok3s-"
+	if limit := endpointNameMaxLength - len(prefix) - len(hash) - 1; len(name) > limit {
+		name = name[:limit]
+	}
+	return fmt.Sprintf("%s%s-%s", prefix, strings.Trim(name, "-"), hash)
+}
+
+// ConfigControlPlaneEndpoint provisions the control-plane endpoint of the cluster by the ensure function if it's enabled,
+// the masters which are generated by current command are passed to be registered as the targets of the endpoint.
+// The address of the endpoint is set as the IP of the cluster and added to the TLS SANs,
+// so that it's used by the agents to join and saved in the kubeconfig.
+func (p *ProviderBase) ConfigControlPlaneEndpoint(c *types.Cluster, ensure func(masters []types.Node) (string, error)) error {
+	if !c.ControlPlaneEndpoint {
+		return nil
+	}
+	masters := []types.Node{}
+	p.M.Range(func(_, value interface{}) bool {
+		if v := value.(types.Node); v.Master {
+			masters = append(masters, v)
+		}
+		return true
+	})
+	p.Logger.Infof("[%s] provisioning control-plane endpoint for %d master(s)...", p.Provider, len(masters))
+	address, err := ensure(masters)
+	if err != nil {
+		return fmt.Errorf("[%s] failed to provision control-plane endpoint: %v", p.Provider, err)
+	}
+	if address == "" {
+		return fmt.Errorf("[%s] the address of control-plane endpoint is empty", p.Provider)
+	}
+	p.IP = address
+	if !slice.ContainsString(p.TLSSans, address) {
+		p.TLSSans = append(p.TLSSans, address)
+	}
+	c.IP = p.IP
+	c.TLSSans = p.TLSSans
+	p.Logger.Infof("[%s] control-plane endpoint %s is ready", p.Provider, address)
+	return nil
+}
+
+// waitControlPlaneEndpoint waits until the control-plane endpoint of the cluster is reachable from the master.
+func (p *ProviderBase) waitControlPlaneEndpoint(cluster *types.Cluster, master *types.Node) error {
+	if !cluster.ControlPlaneEndpoint {
+		return nil
+	}
+	p.Logger.Infof("[%s] waiting for control-plane endpoint %s...", p.Provider, cluster.IP)
+	if _, err := p.execute(master, fmt.Sprintf(waitEndpointCommand, cluster.IP, EndpointPort, cluster.IP)); err != nil {
+		return fmt.Errorf("[%s] failed to wait for control-plane endpoint: %v", p.Provider, err)
+	}
+	return nil
+}
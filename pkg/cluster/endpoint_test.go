@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointName(t *testing.T) {
+	name := EndpointName("myk3s.ap-southeast-1.aws")
+	assert.True(t, strings.HasPrefix(name, "autok3s-myk3s-"))
+	assert.Equal(t, name, EndpointName("myk3s.ap-southeast-1.aws"))
+	assert.NotEqual(t, name, EndpointName("myk3s.us-east-1.aws"))
+
+	long := EndpointName("A_Very_Long_Cluster_Name_Exceeding_The_Limit.cn-hangzhou.alibaba")
+	assert.LessOrEqual(t, len(long), endpointNameMaxLength)
+	assert.Regexp(t, `^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`, long)
+}
+
+func TestConfigControlPlaneEndpoint(t *testing.T) {
+	p := NewBaseProvider()
+	p.Provider = "test"
+	p.Logger = common.NewLogger(nil)
+	p.ControlPlaneEndpoint = true
+	p.TLSSans = []string{"1.2.3.4"}
+	p.M.Store("master", types.Node{InstanceID: "master", Master: true})
+	p.M.Store("worker", types.Node{InstanceID: "worker"})
+
+	c := &types.Cluster{Metadata: p.Metadata}
+	var registered []types.Node
+	err := p.ConfigControlPlaneEndpoint(c, func(masters []types.Node) (string, error) {
+		registered = masters
+		return "lb.example.com", nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, registered, 1)
+	assert.Equal(t, "master", registered[0].InstanceID)
+	assert.Equal(t, "lb.example.com", c.IP)
+	assert.Equal(t, types.StringArray{"1.2.3.4", "lb.example.com"}, c.TLSSans)
+
+	// the address is not duplicated in TLS SANs when the endpoint is ensured again on join.
+	err = p.ConfigControlPlaneEndpoint(c, func(_ []types.Node) (string, error) {
+		return "lb.example.com", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, types.StringArray{"1.2.3.4", "lb.example.com"}, c.TLSSans)
+
+	c.ControlPlaneEndpoint = false
+	err = p.ConfigControlPlaneEndpoint(c, func(_ []types.Node) (string, error) {
+		t.Fatal("ensure should not be called when the endpoint is disabled")
+		return "", nil
+	})
+	assert.NoError(t, err)
+}
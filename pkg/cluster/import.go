@@ -0,0 +1,538 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	pkgsshkey "github.com/cnrancher/autok3s/pkg/sshkey"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	importSectionPrefix = "@@autok3s:"
+	importSectionSuffix = "@@"
+
+	k3sServerRole = "server"
+)
+
+// discoverCommand prints the K3s role, version, service args and configurations of the node in sections.
+var discoverCommand = `section() { echo "@@autok3s:$1@@"; }
+if [ -f /etc/systemd/system/k3s.service ] || [ -f /etc/init.d/k3s ]; then role=server; svc=k3s
+elif [ -f /etc/systemd/system/k3s-agent.service ] || [ -f /etc/init.d/k3s-agent ]; then role=agent; svc=k3s-agent
+else role=""; fi
+section role; echo "$role"
+if [ -z "$role" ]; then exit 0; fi
+section version; $(command -v k3s || echo /usr/local/bin/k3s) --version 2>/dev/null | head -n 1 || true
+section service; cat /etc/systemd/system/$svc.service 2>/dev/null || cat /etc/init.d/$svc 2>/dev/null || true
+section env; cat /etc/systemd/system/$svc.service.env 2>/dev/null || true
+section config; cat /etc/rancher/k3s/config.yaml 2>/dev/null || true
+section registries; cat /etc/rancher/k3s/registries.yaml 2>/dev/null || true
+section internal-ip; (ip -4 route get 8.8.8.8 2>/dev/null | sed -n 's/.* src \([0-9.]*\).*/\1/p'; hostname -I 2>/dev/null | awk '{print $1}') | head -n 1 || true
+if [ "$role" = "server" ]; then
+section token; cat /var/lib/rancher/k3s/server/token 2>/dev/null || true
+section etcd; if [ -d /var/lib/rancher/k3s/server/db/etcd ]; then echo true; else echo false; fi
+section kubeconfig; cat /etc/rancher/k3s/k3s.yaml 2>/dev/null || true
+fi`
+
+// nodeSpecificArgs are the args of a single node, which can't be shared with the other nodes.
+var nodeSpecificArgs = []string{
+	"--node-name", "--node-ip", "--node-external-ip", "--advertise-address", "--bind-address", "--with-node-id",
+	"--token", "--token-file", "--agent-token", "--agent-token-file", "--server",
+}
+
+// k3sNodeInfo is the K3s installation discovered on the existing node.
+type k3sNodeInfo struct {
+	Role       string
+	Version    string
+	Args       []string
+	Env        map[string]string
+	Config     map[string]interface{}
+	ConfigRaw  string
+	Registries string
+	InternalIP string
+	Token      string
+	Etcd       bool
+	Kubeconfig string
+}
+
+// GetImportFlags returns no flag by default as importing cluster is not supported.
+func (p *ProviderBase) GetImportFlags() []types.Flag {
+	return nil
+}
+
+// ImportK3sCluster imports the existing cluster, it's not supported by default.
+func (p *ProviderBase) ImportK3sCluster() error {
+	return fmt.Errorf("[%s] importing cluster is not supported", p.Provider)
+}
+
+// ImportCluster adopts the existing K3s cluster of the nodes into autok3s management, the role and configurations of
+// K3s are discovered over SSH. The imported cluster is standalone, so K3s won't be uninstalled when it's deleted.
+// The discovered function is called to update the provider options after the roles of nodes are discovered.
+func (p *ProviderBase) ImportCluster(nodes []types.Node, ssh *types.SSH, options interface{}, discovered func(c *types.Cluster)) error {
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		return fmt.Errorf("[%s] cluster %s is already exist", p.Provider, p.Name)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("[%s] no node to import", p.Provider)
+	}
+	logFile, err := common.GetLogFile(p.ContextName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+	p.Logger = common.NewLogger(logFile)
+	p.Logger.Infof("[%s] begin to import cluster %s...", p.Provider, p.Name)
+	// store ssh key, so that the stored key pair can be used to access the nodes later.
+	if newSSH, err := pkgsshkey.StoreClusterSSHKeys(p.ContextName, ssh); err != nil {
+		return err
+	} else if newSSH != nil {
+		ssh = newSSH
+	}
+
+	// the discovered outputs contain the token and kubeconfig, don't write them to the log.
+	quiet := logrus.New()
+	quiet.SetOutput(io.Discard)
+	c := &types.Cluster{
+		Metadata: p.Metadata,
+		Options:  options,
+		SSH:      *ssh,
+	}
+	infos := map[string]*k3sNodeInfo{}
+	for i := range nodes {
+		node := &nodes[i]
+		fillNodeSSH(node, ssh)
+		ip := getFirstAddress(node.PublicIPAddress)
+		p.Logger.Infof("[%s] discovering k3s on node %s...", p.Provider, ip)
+		output, err := executeOnNode(node, quiet, discoverCommand)
+		if err != nil {
+			return fmt.Errorf("[%s] failed to discover k3s on node %s: %v", p.Provider, ip, err)
+		}
+		info, err := parseK3sNodeInfo(output)
+		if err != nil {
+			return fmt.Errorf("[%s] failed to discover k3s on node %s: %v", p.Provider, ip, err)
+		}
+		if info.Role == "" {
+			return fmt.Errorf("[%s] k3s is not installed on node %s", p.Provider, ip)
+		}
+		p.Logger.Infof("[%s] node %s is k3s %s %s", p.Provider, ip, info.Role, info.Version)
+		node.Master = info.Role == k3sServerRole
+		node.InstanceStatus = "-"
+		if info.InternalIP != "" {
+			node.InternalIPAddress = []string{info.InternalIP}
+		}
+		infos[node.InstanceID] = info
+		if node.Master {
+			c.MasterNodes = append(c.MasterNodes, *node)
+		} else {
+			c.WorkerNodes = append(c.WorkerNodes, *node)
+		}
+	}
+	if len(c.MasterNodes) == 0 {
+		return fmt.Errorf("[%s] there's no k3s server in the nodes, at least one server is required", p.Provider)
+	}
+	if err := mergeImportedInfo(c, infos, p.Logger); err != nil {
+		return err
+	}
+	if discovered != nil {
+		discovered(c)
+	}
+
+	// save the kubeconfig before the cluster state, so that there's no cluster without kubeconfig.
+	first := infos[c.MasterNodes[0].InstanceID]
+	if first.Kubeconfig == "" {
+		return fmt.Errorf("[%s] failed to get kubeconfig from k3s server %s", p.Provider, c.MasterNodes[0].PublicIPAddress[0])
+	}
+	if err := SaveCfg(first.Kubeconfig, cfgServerIP(c, c.MasterNodes[0]), p.ContextName, p.Provider); err != nil {
+		return err
+	}
+
+	c.Status.Status = common.StatusRunning
+	c.Status.Standalone = true
+	if err := common.DefaultDB.SaveCluster(c); err != nil {
+		return err
+	}
+	p.Logger.Infof("[%s] successfully imported cluster %s with %d server(s) and %d agent(s)", p.Provider, p.Name,
+		len(c.MasterNodes), len(c.WorkerNodes))
+	return nil
+}
+
+// mergeImportedInfo sets the discovered K3s configurations to the cluster metadata, the first server and agent are
+// used as the reference of the args.
+func mergeImportedInfo(c *types.Cluster, infos map[string]*k3sNodeInfo, logger *logrus.Logger) error {
+	c.Master = strconv.Itoa(len(c.MasterNodes))
+	c.Worker = strconv.Itoa(len(c.WorkerNodes))
+
+	server := infos[c.MasterNodes[0].InstanceID]
+	c.K3sVersion = server.Version
+	c.K3sChannel = ""
+	c.Token = server.Token
+	c.RegistryContent = server.Registries
+	c.ServerConfigFileContent = stripNodeSpecificConfig(server.ConfigRaw, server.Config)
+
+	for _, master := range c.MasterNodes {
+		info := infos[master.InstanceID]
+		if info.Version != server.Version {
+			logger.Warnf("[cluster] k3s server %s is %s which is different from %s", master.PublicIPAddress[0], info.Version, server.Version)
+		}
+		if info.Etcd || hasArg(info.Args, "--cluster-init") || hasArg(info.Args, "--server") {
+			c.Cluster = true
+		}
+	}
+
+	var extraArgs, sans []string
+	for _, arg := range server.Args {
+		name, value, _ := strings.Cut(arg, "=")
+		switch name {
+		case "--cluster-init":
+		case "--tls-san":
+			sans = append(sans, value)
+		case "--datastore-endpoint":
+			c.DataStore = value
+		case "--cluster-cidr":
+			c.ClusterCidr = value
+		case "--flannel-backend":
+			c.Network = value
+		case "--system-default-registry":
+			c.SystemDefaultRegistry = value
+		default:
+			if !isNodeSpecificArg(name) {
+				extraArgs = append(extraArgs, arg)
+			}
+		}
+	}
+	if c.DataStore != "" {
+		c.Cluster = false
+	}
+	c.MasterExtraArgs = strings.Join(extraArgs, " ")
+	c.TLSSans = importedTLSSans(c, sans)
+
+	if len(c.WorkerNodes) > 0 {
+		agent := infos[c.WorkerNodes[0].InstanceID]
+		extraArgs = nil
+		for _, arg := range agent.Args {
+			name, _, _ := strings.Cut(arg, "=")
+			if name == "--flannel-backend" || isNodeSpecificArg(name) {
+				continue
+			}
+			extraArgs = append(extraArgs, arg)
+		}
+		c.WorkerExtraArgs = strings.Join(extraArgs, " ")
+		c.AgentConfigFileContent = stripNodeSpecificConfig(agent.ConfigRaw, agent.Config)
+		if c.Token == "" {
+			c.Token = agent.Env["K3S_TOKEN"]
+		}
+		// the agents may join the cluster by the address of load balancer or control-plane endpoint.
+		if host := urlHost(agent.Env["K3S_URL"]); host != "" && c.IP == "" && !isMasterAddress(c, host) {
+			c.IP = host
+		}
+	}
+	if c.Token == "" {
+		return fmt.Errorf("[cluster] failed to discover the token of k3s cluster")
+	}
+	return nil
+}
+
+// importedTLSSans returns the TLS SANs which are not the addresses of masters, as they're always added by autok3s.
+func importedTLSSans(c *types.Cluster, sans []string) types.StringArray {
+	rtn := types.StringArray{}
+	for _, san := range sans {
+		if san != "" && !isMasterAddress(c, san) {
+			rtn = append(rtn, san)
+		}
+	}
+	sort.Strings(rtn)
+	return rtn
+}
+
+func isMasterAddress(c *types.Cluster, address string) bool {
+	for _, master := range c.MasterNodes {
+		if getFirstAddress(master.PublicIPAddress) == address || getFirstAddress(master.InternalIPAddress) == address {
+			return true
+		}
+	}
+	return false
+}
+
+func isNodeSpecificArg(name string) bool {
+	for _, arg := range nodeSpecificArgs {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasArg(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == name || strings.HasPrefix(arg, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func urlHost(address string) string {
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(u.Host); err == nil {
+		return host
+	}
+	return u.Host
+}
+
+// stripNodeSpecificConfig removes the node specific keys from the config file, the content is kept as is if there's
+// no such key.
+func stripNodeSpecificConfig(raw string, config map[string]interface{}) string {
+	if len(config) == 0 {
+		return ""
+	}
+	stripped := false
+	for _, arg := range nodeSpecificArgs {
+		key := strings.TrimPrefix(arg, "--")
+		if _, ok := config[key]; ok {
+			delete(config, key)
+			stripped = true
+		}
+	}
+	if !stripped {
+		return raw
+	}
+	if len(config) == 0 {
+		return ""
+	}
+	b, err := yaml.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// parseK3sNodeInfo parses the output of discoverCommand.
+func parseK3sNodeInfo(output string) (*k3sNodeInfo, error) {
+	sections := map[string]string{}
+	current := ""
+	var content []string
+	flush := func() {
+		if current != "" {
+			sections[current] = strings.TrimSpace(strings.Join(content, "\n"))
+		}
+	}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, importSectionPrefix) && strings.HasSuffix(line, importSectionSuffix) {
+			flush()
+			current = strings.TrimSuffix(strings.TrimPrefix(line, importSectionPrefix), importSectionSuffix)
+			content = nil
+			continue
+		}
+		content = append(content, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	info := &k3sNodeInfo{
+		Role:       sections["role"],
+		Registries: sections["registries"],
+		InternalIP: sections["internal-ip"],
+		Token:      sections["token"],
+		Etcd:       sections["etcd"] == "true",
+		Kubeconfig: sections["kubeconfig"],
+		Env:        parseEnvFile(sections["env"]),
+	}
+	if info.Role == "" {
+		return info, nil
+	}
+	// e.g. k3s version v1.28.5+k3s1 (5b2d1271)
+	if fields := strings.Fields(sections["version"]); len(fields) >= 3 {
+		info.Version = fields[2]
+	}
+	args, err := parseServiceArgs(sections["service"])
+	if err != nil {
+		return nil, err
+	}
+	info.Args = args
+	if raw := sections["config"]; raw != "" {
+		info.ConfigRaw = raw + "\n"
+		if err := yaml.Unmarshal([]byte(raw), &info.Config); err != nil {
+			return nil, fmt.Errorf("failed to parse k3s config file: %v", err)
+		}
+		// the args of config file are merged with the service args, e.g. datastore-endpoint and cluster-init.
+		for _, key := range []string{"cluster-init", "datastore-endpoint", "cluster-cidr", "flannel-backend", "system-default-registry"} {
+			if v, ok := info.Config[key]; ok && !hasArg(info.Args, "--"+key) {
+				if b, isBool := v.(bool); isBool {
+					if b {
+						info.Args = append(info.Args, "--"+key)
+					}
+				} else {
+					info.Args = append(info.Args, fmt.Sprintf("--%s=%v", key, v))
+				}
+				delete(info.Config, key)
+			}
+		}
+		if token, ok := info.Config["token"].(string); ok && info.Token == "" {
+			info.Token = token
+		}
+	}
+	return info, nil
+}
+
+// parseServiceArgs returns the args of the k3s command in the systemd service or openrc script, the args are
+// normalized to --name=value, and the subcommand server or agent is removed.
+func parseServiceArgs(service string) ([]string, error) {
+	var (
+		command []string
+		openrc  bool
+	)
+	collecting := false
+	for _, line := range strings.Split(service, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !collecting {
+			if strings.HasPrefix(trimmed, "ExecStart=") {
+				trimmed = strings.TrimPrefix(trimmed, "ExecStart=")
+			} else if strings.HasPrefix(trimmed, "command_args=") {
+				trimmed = strings.TrimPrefix(trimmed, "command_args=")
+				openrc = true
+			} else {
+				continue
+			}
+			collecting = true
+		}
+		if openrc {
+			// the args of openrc are quoted and may span lines until the closing quote.
+			command = append(command, trimmed)
+			if strings.Count(strings.Join(command, " "), `"`)%2 == 0 {
+				break
+			}
+			continue
+		}
+		if strings.HasSuffix(trimmed, "\\") {
+			command = append(command, strings.TrimSuffix(trimmed, "\\"))
+			continue
+		}
+		command = append(command, trimmed)
+		break
+	}
+	words, err := splitShellWords(strings.Join(command, " "))
+	if err != nil {
+		return nil, err
+	}
+	if openrc && len(words) == 1 {
+		if words, err = splitShellWords(words[0]); err != nil {
+			return nil, err
+		}
+	}
+	var args []string
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if !strings.HasPrefix(word, "-") {
+			// the k3s binary, the subcommand and the log redirection of openrc.
+			continue
+		}
+		if !strings.Contains(word, "=") && i+1 < len(words) && !strings.HasPrefix(words[i+1], "-") &&
+			!isRedirection(words[i+1]) && !isBoolArg(word) {
+			word = word + "=" + words[i+1]
+			i++
+		}
+		args = append(args, word)
+	}
+	return args, nil
+}
+
+func isRedirection(word string) bool {
+	return strings.HasPrefix(word, ">") || strings.HasPrefix(word, "2>")
+}
+
+// isBoolArg returns whether the arg of k3s is a flag without value, only the common ones are listed as the value of
+// other flags starts with "-" is rare.
+func isBoolArg(name string) bool {
+	switch name {
+	case "--cluster-init", "--disable-network-policy", "--disable-kube-proxy", "--disable-cloud-controller",
+		"--disable-helm-controller", "--disable-scheduler", "--disable-apiserver", "--disable-controller-manager",
+		"--disable-etcd", "--secrets-encryption", "--protect-kernel-defaults", "--selinux", "--rootless", "--docker",
+		"--prefer-bundled-bin", "--embedded-registry", "--write-kubeconfig-mode-default", "--debug", "--with-node-id",
+		"--flannel-ipv6-masq", "--flannel-external-ip", "--egress-selector-mode-default", "--disable-default-registry-endpoint":
+		return true
+	}
+	return false
+}
+
+// splitShellWords splits the command into words, the single and double quotes are removed.
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command %q", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// parseEnvFile parses the environment file of the k3s service, e.g. K3S_TOKEN='xxx'.
+func parseEnvFile(content string) map[string]string {
+	env := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || key == "" || strings.HasPrefix(key, "#") {
+			continue
+		}
+		env[key] = strings.Trim(value, `'"`)
+	}
+	return env
+}
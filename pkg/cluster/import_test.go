@@ -0,0 +1,133 @@
+package cluster
+
+import (
+	"io"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testServerOutput = `@@autok3s:role@@
+server
+@@autok3s:version@@
+k3s version v1.28.5+k3s1 (5b2d1271)
+@@autok3s:service@@
+[Service]
+Type=notify
+ExecStart=/usr/local/bin/k3s \
+    server \
+	'--tls-san' \
+	'lb.example.com' \
+	'--tls-san=1.1.1.1' \
+	'--node-name' \
+	'server-1' \
+	'--disable' \
+	'traefik' \
+	'--cluster-init' \
+
+@@autok3s:env@@
+K3S_TOKEN='secret'
+@@autok3s:config@@
+flannel-backend: wireguard-native
+node-ip: 10.0.0.1
+@@autok3s:registries@@
+mirrors:
+  docker.io:
+    endpoint:
+      - https://mirror.example.com
+@@autok3s:internal-ip@@
+10.0.0.1
+@@autok3s:token@@
+K10abc::server:secret
+@@autok3s:etcd@@
+true
+@@autok3s:kubeconfig@@
+apiVersion: v1
+`
+
+const testAgentOutput = `@@autok3s:role@@
+agent
+@@autok3s:version@@
+k3s version v1.28.5+k3s1 (5b2d1271)
+@@autok3s:service@@
+command="/usr/local/bin/k3s"
+command_args="agent --node-label role=worker >>/var/log/k3s-agent.log 2>&1"
+@@autok3s:env@@
+K3S_URL='https://lb.example.com:6443'
+K3S_TOKEN="secret"
+@@autok3s:config@@
+@@autok3s:registries@@
+@@autok3s:internal-ip@@
+10.0.0.2
+`
+
+func TestParseServiceArgs(t *testing.T) {
+	args, err := parseServiceArgs("ExecStart=/usr/local/bin/k3s server --cluster-init --disable traefik \"--node-label=a=b c\"")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--cluster-init", "--disable=traefik", "--node-label=a=b c"}, args)
+
+	args, err = parseServiceArgs(`command_args="server \"--disable\" \"traefik\" --secrets-encryption
+    >>/var/log/k3s.log 2>&1"`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--disable=traefik", "--secrets-encryption"}, args)
+
+	_, err = parseServiceArgs("ExecStart=/usr/local/bin/k3s server '--disable")
+	assert.Error(t, err)
+}
+
+func TestParseK3sNodeInfo(t *testing.T) {
+	info, err := parseK3sNodeInfo(testServerOutput)
+	assert.NoError(t, err)
+	assert.Equal(t, "server", info.Role)
+	assert.Equal(t, "v1.28.5+k3s1", info.Version)
+	assert.Equal(t, []string{"--tls-san=lb.example.com", "--tls-san=1.1.1.1", "--node-name=server-1", "--disable=traefik",
+		"--cluster-init", "--flannel-backend=wireguard-native"}, info.Args)
+	assert.Equal(t, "K10abc::server:secret", info.Token)
+	assert.Equal(t, "10.0.0.1", info.InternalIP)
+	assert.True(t, info.Etcd)
+	assert.Equal(t, "secret", info.Env["K3S_TOKEN"])
+
+	info, err = parseK3sNodeInfo("@@autok3s:role@@\n\n")
+	assert.NoError(t, err)
+	assert.Empty(t, info.Role)
+}
+
+func TestMergeImportedInfo(t *testing.T) {
+	server, err := parseK3sNodeInfo(testServerOutput)
+	assert.NoError(t, err)
+	agent, err := parseK3sNodeInfo(testAgentOutput)
+	assert.NoError(t, err)
+
+	c := &types.Cluster{
+		Status: types.Status{
+			MasterNodes: []types.Node{{InstanceID: "s1", Master: true, PublicIPAddress: []string{"1.1.1.1"}, InternalIPAddress: []string{"10.0.0.1"}}},
+			WorkerNodes: []types.Node{{InstanceID: "a1", PublicIPAddress: []string{"2.2.2.2"}, InternalIPAddress: []string{"10.0.0.2"}}},
+		},
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	assert.NoError(t, mergeImportedInfo(c, map[string]*k3sNodeInfo{"s1": server, "a1": agent}, logger))
+	assert.Equal(t, "1", c.Master)
+	assert.Equal(t, "1", c.Worker)
+	assert.Equal(t, "v1.28.5+k3s1", c.K3sVersion)
+	assert.Equal(t, "K10abc::server:secret", c.Token)
+	assert.True(t, c.Cluster)
+	assert.Equal(t, "wireguard-native", c.Network)
+	assert.Equal(t, types.StringArray{"lb.example.com"}, c.TLSSans)
+	assert.Equal(t, "--disable=traefik", c.MasterExtraArgs)
+	assert.Equal(t, "--node-label=role=worker", c.WorkerExtraArgs)
+	assert.Equal(t, "lb.example.com", c.IP)
+	assert.Contains(t, c.RegistryContent, "mirror.example.com")
+	assert.Empty(t, c.ServerConfigFileContent)
+}
+
+func TestStripNodeSpecificConfig(t *testing.T) {
+	raw := "# comment\ndisable: traefik\n"
+	assert.Equal(t, raw, stripNodeSpecificConfig(raw, map[string]interface{}{"disable": "traefik"}))
+	assert.Equal(t, "disable: traefik\n",
+		stripNodeSpecificConfig(raw+"node-name: a\n", map[string]interface{}{"disable": "traefik", "node-name": "a"}))
+	assert.Empty(t, stripNodeSpecificConfig("node-ip: 10.0.0.1\n", map[string]interface{}{"node-ip": "10.0.0.1"}))
+}
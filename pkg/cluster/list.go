@@ -0,0 +1,256 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DefaultRefreshConcurrency is the max number of clusters whose status is refreshed at the same time.
+	DefaultRefreshConcurrency = 5
+	// DefaultRefreshQPS is the max number of clusters of each provider refreshed per second, which limits the calls
+	// of the cloud API.
+	DefaultRefreshQPS = 2.0
+)
+
+// ProviderLimiter limits the calls of the cloud API of each provider, it's shared by the background tasks calling the
+// cloud API, e.g. the status refresher and the health checker.
+type ProviderLimiter struct {
+	QPS float64
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewProviderLimiter returns the limiter allowing qps calls of each provider per second, the default is used if qps
+// is not positive.
+func NewProviderLimiter(qps float64) *ProviderLimiter {
+	if qps <= 0 {
+		qps = DefaultRefreshQPS
+	}
+	return &ProviderLimiter{QPS: qps, limiters: map[string]*rate.Limiter{}}
+}
+
+// Wait blocks until the provider is allowed to call the cloud API or the context is done.
+func (l *ProviderLimiter) Wait(ctx context.Context, provider string) error {
+	l.mu.Lock()
+	limiter, ok := l.limiters[provider]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(l.QPS), int(math.Max(1, math.Ceil(l.QPS))))
+		l.limiters[provider] = limiter
+	}
+	l.mu.Unlock()
+	return limiter.Wait(ctx)
+}
+
+// StatusRefresher refreshes the status of clusters with bounded concurrency and per-provider rate limits.
+type StatusRefresher struct {
+	Concurrency int
+	Limiter     *ProviderLimiter
+
+	refresh func(state *common.ClusterState) *types.ClusterInfo
+}
+
+// NewStatusRefresher returns the status refresher, the defaults are used if concurrency or qps is not positive.
+func NewStatusRefresher(concurrency int, qps float64) *StatusRefresher {
+	if concurrency <= 0 {
+		concurrency = DefaultRefreshConcurrency
+	}
+	return &StatusRefresher{
+		Concurrency: concurrency,
+		Limiter:     NewProviderLimiter(qps),
+		refresh:     refreshClusterInfo,
+	}
+}
+
+// StartStatusRefresher refreshes the cached status of clusters by the refresher every interval until the context is
+// done.
+func StartStatusRefresher(ctx context.Context, interval time.Duration, r *StatusRefresher) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if _, err := r.Refresh(ctx, ""); err != nil {
+			logrus.Warnf("[cluster] failed to refresh status of clusters: %v", err)
+		}
+	}, interval)
+}
+
+// ListClusters returns the clusters with the cached status, which is refreshed by serve in the background or by
+// RefreshClusters. The running clusters which are never refreshed are listed with the status of cluster state.
+func ListClusters(providerName string) ([]*types.ClusterInfo, error) {
+	states, err := common.DefaultDB.ListCluster(providerName)
+	if err != nil {
+		return nil, err
+	}
+	caches, err := common.DefaultDB.ListClusterStatusCache(providerName)
+	if err != nil {
+		return nil, err
+	}
+	cached := map[string]*common.ClusterStatusCache{}
+	for _, c := range caches {
+		cached[c.ContextName] = c
+	}
+	clusterList := make([]*types.ClusterInfo, 0)
+	for _, state := range states {
+		info := stateClusterInfo(state)
+		if info == nil {
+			continue
+		}
+		if c, ok := cached[info.ID]; ok && state.Status == common.StatusRunning {
+			cachedInfo := &types.ClusterInfo{}
+			if err := json.Unmarshal(c.Info, cachedInfo); err != nil {
+				logrus.Warnf("failed to decode cached status of cluster %s: %v", info.ID, err)
+			} else {
+				info = cachedInfo
+			}
+		}
+		clusterList = append(clusterList, info)
+	}
+	return clusterList, nil
+}
+
+// RefreshClusters refreshes the status of clusters with the default concurrency and rate limits, and returns the
+// live list of clusters.
+func RefreshClusters(providerName string) ([]*types.ClusterInfo, error) {
+	return NewStatusRefresher(DefaultRefreshConcurrency, DefaultRefreshQPS).Refresh(context.Background(), providerName)
+}
+
+// Refresh checks the running clusters from the cloud API and Kubernetes API and updates the cache, the list of all
+// the clusters is returned.
+func (r *StatusRefresher) Refresh(ctx context.Context, providerName string) ([]*types.ClusterInfo, error) {
+	states, err := common.DefaultDB.ListCluster(providerName)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*types.ClusterInfo, len(states))
+	refreshed := make([]bool, len(states))
+	sem := make(chan struct{}, r.Concurrency)
+	var wg sync.WaitGroup
+	for i, state := range states {
+		infos[i] = stateClusterInfo(state)
+		if infos[i] == nil || state.Status != common.StatusRunning {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, state *common.ClusterState) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			if err := r.Limiter.Wait(ctx, state.Provider); err != nil {
+				return
+			}
+			infos[i] = r.refresh(state)
+			refreshed[i] = true
+		}(i, state)
+	}
+	wg.Wait()
+
+	clusterList := make([]*types.ClusterInfo, 0, len(infos))
+	now := time.Now()
+	for i, info := range infos {
+		if info == nil {
+			continue
+		}
+		clusterList = append(clusterList, info)
+		if !refreshed[i] {
+			continue
+		}
+		// the caches are saved one by one to avoid the concurrent writes of database.
+		b, err := json.Marshal(info)
+		if err != nil {
+			return nil, err
+		}
+		if err = common.DefaultDB.SaveClusterStatusCache(&common.ClusterStatusCache{
+			ContextName: info.ID,
+			Provider:    states[i].Provider,
+			Info:        b,
+			RefreshedAt: now,
+		}); err != nil {
+			logrus.Warnf("failed to save cached status of cluster %s: %v", info.ID, err)
+		}
+	}
+	return clusterList, ctx.Err()
+}
+
+// stateClusterInfo returns the list info of cluster from the cluster state without calling any API.
+func stateClusterInfo(state *common.ClusterState) *types.ClusterInfo {
+	provider := listProvider(state)
+	if provider == nil {
+		return nil
+	}
+	info := provider.GetCluster("")
+	info.Status = state.Status
+	info.Master = state.Master
+	info.Worker = state.Worker
+	return info
+}
+
+// refreshClusterInfo returns the list info of running cluster from the cloud API and Kubernetes API, the cluster is
+// marked as missing if it doesn't exist anymore.
+func refreshClusterInfo(state *common.ClusterState) *types.ClusterInfo {
+	provider := listProvider(state)
+	if provider == nil {
+		return nil
+	}
+	contextName := provider.GenerateClusterName()
+	isExist, _, err := provider.IsClusterExist()
+	if err != nil {
+		info := provider.GetCluster("")
+		info.Status = common.StatusUnknown
+		info.Master = state.Master
+		info.Worker = state.Worker
+		logrus.Errorf("failed to check provider %s cluster %s exist, got error: %v ", state.Provider, state.Name, err)
+		return info
+	}
+	if !isExist {
+		logrus.Warnf("cluster %s (provider %s) is not exist, will remove from config", state.Name, state.Provider)
+		// remove kube config if cluster not exist
+		if err := common.FileManager.ClearCfgByContext(contextName); err != nil {
+			logrus.Errorf("failed to remove unexist cluster %s from kube config", state.Name)
+		}
+		if err := common.FileManager.RemoveClusterCfg(contextName, state.Provider); err != nil {
+			logrus.Errorf("failed to remove kube config of unexist cluster %s", state.Name)
+		}
+		// update status to missing
+		state.Status = common.StatusMissing
+		if err := common.DefaultDB.SaveClusterState(state); err != nil {
+			logrus.Errorf("failed to update cluster %s state to missing", state.Name)
+		}
+		info := provider.GetCluster("")
+		info.Status = state.Status
+		info.Master = state.Master
+		info.Worker = state.Worker
+		return info
+	}
+	return provider.GetCluster(filepath.Join(common.CfgPath, common.KubeCfgFile))
+}
+
+func listProvider(state *common.ClusterState) providers.Provider {
+	// TODO skip harvester for historical data, will remove here after harvester provider added back
+	if state.Provider == "harvester" {
+		return nil
+	}
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		logrus.Errorf("failed to get provider %v: %v", state.Provider, err)
+		return nil
+	}
+	provider.SetMetadata(&state.Metadata)
+	_ = provider.SetOptions(state.Options)
+	provider.GenerateClusterName()
+	return provider
+}
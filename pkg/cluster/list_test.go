@@ -0,0 +1,67 @@
+package cluster_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/common/commontest"
+	_ "github.com/cnrancher/autok3s/pkg/providers/native"
+	"github.com/cnrancher/autok3s/pkg/types"
+	typesnative "github.com/cnrancher/autok3s/pkg/types/native"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListClusters(t *testing.T) {
+	commontest.InitStorage(t)
+	for name, status := range map[string]string{"running": common.StatusRunning, "stopped": common.StatusStopped} {
+		assert.NoError(t, common.DefaultDB.SaveCluster(&types.Cluster{
+			Metadata: types.Metadata{Name: name, Provider: "native", Master: "1", Worker: "0"},
+			Options:  typesnative.Options{},
+			Status:   types.Status{Status: status},
+		}))
+	}
+
+	statuses := func(list []*types.ClusterInfo) map[string]string {
+		rtn := map[string]string{}
+		for _, info := range list {
+			rtn[info.ID] = info.Status
+		}
+		return rtn
+	}
+	// the running cluster which is never refreshed is listed with the status of cluster state.
+	list, err := cluster.ListClusters("")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"running": common.StatusRunning, "stopped": common.StatusStopped}, statuses(list))
+
+	// there's no kubeconfig for the running cluster, so the refreshed status is unknown.
+	list, err = cluster.NewStatusRefresher(1, 100).Refresh(context.Background(), "native")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"running": types.ClusterStatusUnknown, "stopped": common.StatusStopped}, statuses(list))
+
+	caches, err := common.DefaultDB.ListClusterStatusCache("")
+	assert.NoError(t, err)
+	assert.Len(t, caches, 1)
+	info := &types.ClusterInfo{}
+	assert.NoError(t, json.Unmarshal(caches[0].Info, info))
+	assert.Equal(t, types.ClusterStatusUnknown, info.Status)
+
+	list, err = cluster.ListClusters("native")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"running": types.ClusterStatusUnknown, "stopped": common.StatusStopped}, statuses(list))
+}
+
+func TestProviderLimiter(t *testing.T) {
+	l := cluster.NewProviderLimiter(1)
+	assert.NoError(t, l.Wait(context.Background(), "aws"))
+	// the limits of providers are separated.
+	assert.NoError(t, l.Wait(context.Background(), "alibaba"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Error(t, l.Wait(ctx, "aws"), "the second call of aws should exceed the rate limit")
+}
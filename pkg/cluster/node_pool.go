@@ -0,0 +1,332 @@
+package cluster
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// nodePoolLabel is set to the workers of node pool, so that the workloads can be scheduled to the pool.
+const nodePoolLabel = "autok3s.cattle.io/node-pool"
+
+var taintEffects = []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}
+
+// GetNodePoolOptions get node pool flag options of join command.
+func (p *ProviderBase) GetNodePoolOptions() []types.Flag {
+	return []types.Flag{
+		{
+			Name:  "node-pool",
+			P:     &p.NodePool,
+			V:     p.NodePool,
+			Usage: "Join the workers to the node pool, the pool is created with the node pool flags if it's not exist",
+		},
+		{
+			Name:  "node-pool-option",
+			P:     &p.nodePool.Options,
+			V:     p.nodePool.Options,
+			Usage: "Override the provider option for the workers of node pool by the option name, e.g.(--node-pool-option instance-type=t3.large --node-pool-option zone=ap-southeast-2b)",
+		},
+		{
+			Name:  "node-pool-label",
+			P:     &p.nodePool.Labels,
+			V:     p.nodePool.Labels,
+			Usage: "Register the workers of node pool with the label, e.g.(--node-pool-label gpu=true)",
+		},
+		{
+			Name:  "node-pool-taint",
+			P:     &p.nodePool.Taints,
+			V:     p.nodePool.Taints,
+			Usage: "Register the workers of node pool with the taint, e.g.(--node-pool-taint gpu=true:NoSchedule)",
+		},
+		{
+			Name:  "node-pool-extra-args",
+			P:     &p.nodePool.ExtraArgs,
+			V:     p.nodePool.ExtraArgs,
+			Usage: "Extra arguments for k3s installer of the workers of node pool, which are appended to worker extra args",
+		},
+	}
+}
+
+// ValidateNodePool validates the node pool of the joining workers, the options override of the pool is validated
+// against the provider options, nil options means the provider doesn't support options override. The fixed options
+// can't be overridden, e.g. the zone which the instances of cluster are listed by.
+func (p *ProviderBase) ValidateNodePool(options interface{}, fixed ...string) error {
+	if p.NodePool == "" {
+		if !isEmptyNodePool(p.nodePool) {
+			return fmt.Errorf("[%s] calling preflight error: `--node-pool` is required by the node pool flags", p.Provider)
+		}
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(p.NodePool); len(errs) > 0 {
+		return fmt.Errorf("[%s] calling preflight error: invalid node pool name %s: %s", p.Provider, p.NodePool, strings.Join(errs, ", "))
+	}
+	pool := p.mergeNodePools(p.NodePools).Get(p.NodePool)
+	if len(pool.Options) > 0 {
+		if options == nil {
+			return fmt.Errorf("[%s] calling preflight error: options override of node pool is not supported", p.Provider)
+		}
+		for _, name := range fixed {
+			if _, ok := pool.Options[name]; ok {
+				return fmt.Errorf("[%s] calling preflight error: option %s can't be overridden by node pool", p.Provider, name)
+			}
+		}
+		v := reflect.New(reflect.ValueOf(options).Elem().Type()).Elem()
+		if err := setNodePoolOptions(v, pool.Options); err != nil {
+			return fmt.Errorf("[%s] calling preflight error: invalid options of node pool %s: %v", p.Provider, pool.Name, err)
+		}
+	}
+	for _, label := range pool.Labels {
+		if err := validateNodeLabel(label); err != nil {
+			return fmt.Errorf("[%s] calling preflight error: invalid label of node pool %s: %v", p.Provider, pool.Name, err)
+		}
+	}
+	for _, taint := range pool.Taints {
+		if err := validateNodeTaint(taint); err != nil {
+			return fmt.Errorf("[%s] calling preflight error: invalid taint of node pool %s: %v", p.Provider, pool.Name, err)
+		}
+	}
+	return nil
+}
+
+// NodePoolInstance wraps the instance function of join, the instances are created with the options override of
+// the node pool, and the joining workers are marked with the pool.
+func (p *ProviderBase) NodePoolInstance(options interface{}, fn func(ssh *types.SSH) (*types.Cluster, error)) func(ssh *types.SSH) (*types.Cluster, error) {
+	return func(ssh *types.SSH) (*types.Cluster, error) {
+		if p.NodePool == "" {
+			return fn(ssh)
+		}
+		p.NodePools = p.mergeNodePools(p.NodePools)
+		pool := p.NodePools.Get(p.NodePool)
+		var restore func()
+		if options != nil && len(pool.Options) > 0 {
+			v := reflect.ValueOf(options).Elem()
+			origin := reflect.New(v.Type()).Elem()
+			origin.Set(v)
+			if err := setNodePoolOptions(v, pool.Options); err != nil {
+				return nil, err
+			}
+			// only the overridden options are restored, the others may be updated when creating instances.
+			restore = func() {
+				for name := range pool.Options {
+					i := optionFieldIndex(v.Type(), name)
+					v.Field(i).Set(origin.Field(i))
+				}
+			}
+			p.Logger.Infof("[%s] the workers of node pool %s are created with options %v", p.Provider, pool.Name, pool.Options)
+		}
+		c, err := fn(ssh)
+		if restore != nil {
+			restore()
+			if c != nil {
+				c.Options = reflect.ValueOf(options).Elem().Interface()
+			}
+		}
+		if err != nil {
+			return c, err
+		}
+		p.M.Range(func(key, value interface{}) bool {
+			n := value.(types.Node)
+			if n.Current && !n.Master {
+				n.Pool = pool.Name
+				p.M.Store(key, n)
+			}
+			return true
+		})
+		c.Metadata.NodePools = p.NodePools
+		return c, nil
+	}
+}
+
+// NodePoolCluster returns the cluster with the options override of the worker's node pool, which is used to generate
+// the provider args of the worker, e.g. the zone of provider id.
+func (p *ProviderBase) NodePoolCluster(c *types.Cluster, worker types.Node) *types.Cluster {
+	pool := c.NodePools.Get(worker.Pool)
+	if pool == nil || len(pool.Options) == 0 || c.Options == nil {
+		return c
+	}
+	v := reflect.ValueOf(c.Options)
+	isPtr := v.Kind() == reflect.Ptr
+	if isPtr {
+		v = v.Elem()
+	}
+	options := reflect.New(v.Type())
+	options.Elem().Set(v)
+	if err := setNodePoolOptions(options.Elem(), pool.Options); err != nil {
+		return c
+	}
+	merged := *c
+	if isPtr {
+		merged.Options = options.Interface()
+	} else {
+		merged.Options = options.Elem().Interface()
+	}
+	return &merged
+}
+
+// NodePoolExtraArgs generates the k3s args of the worker's node pool.
+func (p *ProviderBase) NodePoolExtraArgs(c *types.Cluster, worker types.Node) string {
+	pool := c.NodePools.Get(worker.Pool)
+	if pool == nil {
+		return ""
+	}
+	args := fmt.Sprintf(" --node-label %s=%s", nodePoolLabel, pool.Name)
+	for _, label := range pool.Labels {
+		args += fmt.Sprintf(" --node-label %s", label)
+	}
+	for _, taint := range pool.Taints {
+		args += fmt.Sprintf(" --node-taint %s", taint)
+	}
+	if pool.ExtraArgs != "" {
+		args += " " + pool.ExtraArgs
+	}
+	return args
+}
+
+// mergeNodePools merges the pools of the cluster with the pools and the node pool flags to be joined.
+func (p *ProviderBase) mergeNodePools(pools types.NodePools) types.NodePools {
+	merged := make(types.NodePools, 0, len(pools))
+	for _, pool := range pools {
+		pool.Options = copyStringMap(pool.Options)
+		merged = append(merged, pool)
+	}
+	updates := make([]types.NodePool, 0, len(p.NodePools)+1)
+	updates = append(updates, p.NodePools...)
+	if p.NodePool != "" {
+		update := p.nodePool
+		update.Name = p.NodePool
+		updates = append(updates, update)
+	}
+	for _, update := range updates {
+		pool := merged.Get(update.Name)
+		if pool == nil {
+			merged = append(merged, types.NodePool{Name: update.Name})
+			pool = &merged[len(merged)-1]
+		}
+		for k, v := range update.Options {
+			if pool.Options == nil {
+				pool.Options = types.StringMap{}
+			}
+			pool.Options[k] = v
+		}
+		if len(update.Labels) > 0 {
+			pool.Labels = update.Labels
+		}
+		if len(update.Taints) > 0 {
+			pool.Taints = update.Taints
+		}
+		if update.ExtraArgs != "" {
+			pool.ExtraArgs = update.ExtraArgs
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+func isEmptyNodePool(pool types.NodePool) bool {
+	return len(pool.Options) == 0 && len(pool.Labels) == 0 && len(pool.Taints) == 0 && pool.ExtraArgs == ""
+}
+
+func copyStringMap(m types.StringMap) types.StringMap {
+	if m == nil {
+		return nil
+	}
+	copied := types.StringMap{}
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// setNodePoolOptions overrides the fields of the provider options by the json names of the fields.
+func setNodePoolOptions(v reflect.Value, overrides map[string]string) error {
+	for name, value := range overrides {
+		i := optionFieldIndex(v.Type(), name)
+		if i < 0 {
+			return fmt.Errorf("unknown option %s", name)
+		}
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(value)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("option %s must be bool", name)
+			}
+			f.SetBool(b)
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("option %s must be number", name)
+			}
+			f.SetInt(n)
+		case reflect.Slice:
+			if f.Type().Elem().Kind() != reflect.String {
+				return fmt.Errorf("option %s can't be overridden", name)
+			}
+			f.Set(reflect.ValueOf(strings.Split(value, ",")).Convert(f.Type()))
+		default:
+			return fmt.Errorf("option %s can't be overridden", name)
+		}
+	}
+	return nil
+}
+
+func optionFieldIndex(t reflect.Type, name string) int {
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag == name && t.Field(i).IsExported() {
+			return i
+		}
+	}
+	return -1
+}
+
+func validateNodeLabel(label string) error {
+	kv := strings.SplitN(label, "=", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("%s must be formatted as key=value", label)
+	}
+	if errs := validation.IsQualifiedName(kv[0]); len(errs) > 0 {
+		return fmt.Errorf("%s: %s", label, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsValidLabelValue(kv[1]); len(errs) > 0 {
+		return fmt.Errorf("%s: %s", label, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func validateNodeTaint(taint string) error {
+	i := strings.LastIndex(taint, ":")
+	if i < 0 {
+		return fmt.Errorf("%s must be formatted as key=value:effect", taint)
+	}
+	effect := taint[i+1:]
+	valid := false
+	for _, e := range taintEffects {
+		if effect == e {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%s: effect must be one of %s", taint, strings.Join(taintEffects, ", "))
+	}
+	kv := strings.SplitN(taint[:i], "=", 2)
+	if errs := validation.IsQualifiedName(kv[0]); len(errs) > 0 {
+		return fmt.Errorf("%s: %s", taint, strings.Join(errs, ", "))
+	}
+	if len(kv) == 2 {
+		if errs := validation.IsValidLabelValue(kv[1]); len(errs) > 0 {
+			return fmt.Errorf("%s: %s", taint, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
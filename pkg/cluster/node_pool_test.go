@@ -0,0 +1,122 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type testOptions struct {
+	InstanceType  string   `json:"instance-type,omitempty"`
+	Zone          string   `json:"zone,omitempty"`
+	RootSize      int      `json:"root-size,omitempty"`
+	Spot          bool     `json:"request-spot-instance,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	SecurityGroup string   `json:"security-group,omitempty"`
+}
+
+func TestValidateNodePool(t *testing.T) {
+	p := NewBaseProvider()
+	p.Provider = "test"
+	options := &testOptions{}
+	assert.NoError(t, p.ValidateNodePool(options))
+
+	p.nodePool.Labels = types.StringArray{"gpu=true"}
+	assert.Error(t, p.ValidateNodePool(options))
+
+	p.NodePool = "gpu"
+	assert.NoError(t, p.ValidateNodePool(options))
+	p.nodePool.Labels = types.StringArray{"gpu"}
+	assert.Error(t, p.ValidateNodePool(options))
+	p.nodePool.Labels = nil
+
+	p.nodePool.Taints = types.StringArray{"gpu=true:NoSchedule", "dedicated:NoExecute"}
+	assert.NoError(t, p.ValidateNodePool(options))
+	p.nodePool.Taints = types.StringArray{"gpu=true:Never"}
+	assert.Error(t, p.ValidateNodePool(options))
+	p.nodePool.Taints = nil
+
+	p.nodePool.Options = types.StringMap{"instance-type": "t3.large", "root-size": "30", "request-spot-instance": "true"}
+	assert.NoError(t, p.ValidateNodePool(options))
+	assert.Error(t, p.ValidateNodePool(nil))
+	p.nodePool.Options["root-size"] = "large"
+	assert.Error(t, p.ValidateNodePool(options))
+	p.nodePool.Options = types.StringMap{"unknown": "value"}
+	assert.Error(t, p.ValidateNodePool(options))
+	p.nodePool.Options = types.StringMap{"zone": "ap-southeast-2b"}
+	assert.NoError(t, p.ValidateNodePool(options))
+	assert.Error(t, p.ValidateNodePool(options, "zone"))
+
+	p.NodePool = "GPU_Pool"
+	assert.Error(t, p.ValidateNodePool(options))
+}
+
+func TestMergeNodePools(t *testing.T) {
+	p := NewBaseProvider()
+	p.NodePool = "gpu"
+	p.nodePool.Options = types.StringMap{"instance-type": "g4dn.xlarge"}
+	p.nodePool.Taints = types.StringArray{"gpu=true:NoSchedule"}
+	existing := types.NodePools{
+		{Name: "spot", Options: types.StringMap{"request-spot-instance": "true"}},
+		{Name: "gpu", Options: types.StringMap{"instance-type": "p3.2xlarge", "root-size": "100"}, Labels: types.StringArray{"gpu=true"}},
+	}
+
+	merged := p.mergeNodePools(existing)
+	assert.Len(t, merged, 2)
+	gpu := merged.Get("gpu")
+	assert.Equal(t, types.StringMap{"instance-type": "g4dn.xlarge", "root-size": "100"}, gpu.Options)
+	assert.Equal(t, types.StringArray{"gpu=true"}, gpu.Labels)
+	assert.Equal(t, types.StringArray{"gpu=true:NoSchedule"}, gpu.Taints)
+	// the pools of cluster are not changed.
+	assert.Equal(t, "p3.2xlarge", existing.Get("gpu").Options["instance-type"])
+
+	p.NodePool = "arm"
+	p.nodePool = types.NodePool{}
+	merged = p.mergeNodePools(merged)
+	assert.Len(t, merged, 3)
+	assert.NotNil(t, merged.Get("arm"))
+}
+
+func TestNodePoolInstance(t *testing.T) {
+	p := NewBaseProvider()
+	p.Provider = "test"
+	p.Logger = common.NewLogger(nil)
+	p.NodePool = "gpu"
+	p.nodePool.Options = types.StringMap{"instance-type": "g4dn.xlarge", "zone": "ap-southeast-2b", "tags": "gpu=true,team=ml"}
+	p.nodePool.Labels = types.StringArray{"gpu=true"}
+	p.M.Store("master", types.Node{InstanceID: "master", Master: true})
+	options := &testOptions{InstanceType: "t3.medium", Zone: "ap-southeast-2a"}
+
+	c, err := p.NodePoolInstance(options, func(_ *types.SSH) (*types.Cluster, error) {
+		assert.Equal(t, "g4dn.xlarge", options.InstanceType)
+		assert.Equal(t, "ap-southeast-2b", options.Zone)
+		assert.Equal(t, []string{"gpu=true", "team=ml"}, options.Tags)
+		// the options which are not overridden are kept.
+		options.SecurityGroup = "sg-1"
+		p.M.Store("worker", types.Node{InstanceID: "worker", Current: true})
+		return &types.Cluster{Metadata: p.Metadata, Options: *options}, nil
+	})(&p.SSH)
+	assert.NoError(t, err)
+	assert.Equal(t, testOptions{InstanceType: "t3.medium", Zone: "ap-southeast-2a", SecurityGroup: "sg-1"}, *options)
+	assert.Equal(t, *options, c.Options)
+	assert.Len(t, c.NodePools, 1)
+
+	worker, _ := p.M.Load("worker")
+	assert.Equal(t, "gpu", worker.(types.Node).Pool)
+	master, _ := p.M.Load("master")
+	assert.Empty(t, master.(types.Node).Pool)
+
+	args := p.NodePoolExtraArgs(c, worker.(types.Node))
+	assert.Equal(t, " --node-label autok3s.cattle.io/node-pool=gpu --node-label gpu=true", args)
+	assert.Empty(t, p.NodePoolExtraArgs(c, master.(types.Node)))
+
+	merged := p.NodePoolCluster(c, worker.(types.Node))
+	assert.Equal(t, "ap-southeast-2b", merged.Options.(testOptions).Zone)
+	assert.Equal(t, "ap-southeast-2a", c.Options.(testOptions).Zone)
+	c.Options = options
+	merged = p.NodePoolCluster(c, worker.(types.Node))
+	assert.Equal(t, "g4dn.xlarge", merged.Options.(*testOptions).InstanceType)
+	assert.Equal(t, "t3.medium", options.InstanceType)
+}
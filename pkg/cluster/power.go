@@ -0,0 +1,245 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// k3sServiceFiles are the service and environment files of K3s server and agent which contain the node addresses.
+const k3sServiceFiles = "/etc/systemd/system/k3s*.service /etc/systemd/system/k3s*.service.env"
+
+// StopK3sCluster stops the cluster, it's not supported by default as the instances are not managed by the provider.
+func (p *ProviderBase) StopK3sCluster() error {
+	return fmt.Errorf("[%s] stopping cluster is not supported", p.Provider)
+}
+
+// StartK3sCluster starts the cluster, it's not supported by default as the instances are not managed by the provider.
+func (p *ProviderBase) StartK3sCluster() error {
+	return fmt.Errorf("[%s] starting cluster is not supported", p.Provider)
+}
+
+// StopCluster stops the instances of the cluster by the stop function,
+// the workers are stopped before the masters so that the workloads are not rescheduled to the stopping nodes.
+// The cluster is marked as failed if it's stopped partially, and it can be stopped again to stop the rest.
+func (p *ProviderBase) StopCluster(stop func(ids []string) error) error {
+	state, closeLog, err := p.beginPowerAction(common.StatusStopping, common.StatusRunning, common.StatusFailed)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	c := common.ConvertToCluster(state, true)
+	for _, nodes := range [][]types.Node{c.WorkerNodes, c.MasterNodes} {
+		if len(nodes) == 0 {
+			continue
+		}
+		if err = stop(nodeIDs(nodes)); err != nil {
+			p.endPowerAction(state, common.StatusFailed)
+			return fmt.Errorf("[%s] failed to stop cluster %s, some instances may be stopped: %v", p.Provider, p.Name, err)
+		}
+	}
+	p.endPowerAction(state, common.StatusStopped)
+	p.Logger.Infof("[%s] successfully stopped cluster %s", p.Provider, p.Name)
+	return nil
+}
+
+// StartCluster starts the instances of the cluster by the start function, the masters are started before the workers.
+// The addresses of instances may change after started, so the nodes returned by describe function are used to update
+// the addresses in the cluster state, the K3s services and the kubeconfig.
+// The cluster is marked as failed if it's started partially, and it can be started again to start the rest.
+func (p *ProviderBase) StartCluster(start func(ids []string) error, describe func() ([]types.Node, error)) error {
+	state, closeLog, err := p.beginPowerAction(common.StatusStarting, common.StatusStopped, common.StatusFailed)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	c := common.ConvertToCluster(state, true)
+	for _, nodes := range [][]types.Node{c.MasterNodes, c.WorkerNodes} {
+		if len(nodes) == 0 {
+			continue
+		}
+		if err = start(nodeIDs(nodes)); err != nil {
+			p.endPowerAction(state, common.StatusFailed)
+			return fmt.Errorf("[%s] failed to start cluster %s, some instances may be started: %v", p.Provider, p.Name, err)
+		}
+	}
+
+	nodes, err := describe()
+	if err != nil {
+		p.endPowerAction(state, common.StatusFailed)
+		return fmt.Errorf("[%s] failed to describe instances of cluster %s: %v", p.Provider, p.Name, err)
+	}
+	current := map[string]types.Node{}
+	for _, n := range nodes {
+		current[n.InstanceID] = n
+	}
+	changed := map[string]string{}
+	syncNodeAddresses(c.MasterNodes, current, changed)
+	syncNodeAddresses(c.WorkerNodes, current, changed)
+	if len(changed) > 0 {
+		p.Logger.Infof("[%s] addresses of cluster %s are changed: %v", p.Provider, p.Name, changed)
+		if err = p.updateStateAddresses(state, &c, changed); err != nil {
+			p.endPowerAction(state, common.StatusFailed)
+			return err
+		}
+		// the masters are updated first, so that the agents can join by the new addresses.
+		for _, node := range append(append([]types.Node{}, c.MasterNodes...), c.WorkerNodes...) {
+			fillNodeSSH(&node, &c.SSH)
+			if _, err = p.execute(&node, updateAddressCommand(changed, node.Master)); err != nil {
+				p.endPowerAction(state, common.StatusFailed)
+				return fmt.Errorf("[%s] failed to update addresses of node %s: %v", p.Provider, node.InstanceID, err)
+			}
+		}
+	}
+	p.endPowerAction(state, common.StatusRunning)
+
+	if len(changed) > 0 {
+		if err = RepairCfg(state, p.Logger); err != nil {
+			return fmt.Errorf("[%s] cluster %s is started but failed to update kubeconfig: %v", p.Provider, p.Name, err)
+		}
+	}
+	p.Logger.Infof("[%s] successfully started cluster %s", p.Provider, p.Name)
+	return nil
+}
+
+// beginPowerAction checks the cluster is in one of the expected statuses and saves it with the transitional status,
+// the returned function closes the log file of the cluster.
+func (p *ProviderBase) beginPowerAction(status string, expected ...string) (*common.ClusterState, func(), error) {
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state == nil {
+		return nil, nil, fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	if !types.StringArray(expected).Contains(state.Status) {
+		return nil, nil, fmt.Errorf("[%s] cluster %s is %s, only %s cluster can be %s", p.Provider, p.Name,
+			state.Status, strings.ToLower(strings.Join(expected, " or ")), strings.ToLower(status))
+	}
+	logFile, err := common.GetLogFile(state.ContextName)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeLog := func() {
+		_ = logFile.Close()
+	}
+	p.Logger = common.NewLogger(logFile)
+	p.Logger.Infof("[%s] cluster %s is %s...", p.Provider, p.Name, strings.ToLower(status))
+	state.Status = status
+	if err = common.DefaultDB.SaveClusterState(state); err != nil {
+		closeLog()
+		return nil, nil, err
+	}
+	p.notifyUpdate(state.ContextName)
+	return state, closeLog, nil
+}
+
+// endPowerAction saves the cluster with the final status.
+func (p *ProviderBase) endPowerAction(state *common.ClusterState, status string) {
+	state.Status = status
+	if err := common.DefaultDB.SaveClusterState(state); err != nil {
+		p.Logger.Errorf("[%s] failed to update status of cluster %s to %s: %v", p.Provider, p.Name, status, err)
+	}
+	p.notifyUpdate(state.ContextName)
+}
+
+func (p *ProviderBase) notifyUpdate(contextName string) {
+	if p.Callbacks == nil {
+		return
+	}
+	if process, ok := p.Callbacks[contextName]; ok && process.Event == "update" {
+		process.Fn(&common.LogEvent{
+			Name:        process.Event,
+			ContextType: "cluster",
+			ContextName: contextName,
+		})
+	}
+}
+
+// updateStateAddresses saves the changed addresses of the nodes, the ip and the TLS SANs of the cluster.
+func (p *ProviderBase) updateStateAddresses(state *common.ClusterState, c *types.Cluster, changed map[string]string) error {
+	masters, err := json.Marshal(c.MasterNodes)
+	if err != nil {
+		return err
+	}
+	workers, err := json.Marshal(c.WorkerNodes)
+	if err != nil {
+		return err
+	}
+	state.MasterNodes, state.WorkerNodes = masters, workers
+	if ip, ok := changed[state.IP]; ok {
+		state.IP = ip
+		c.IP = ip
+	}
+	for i, san := range state.TLSSans {
+		if ip, ok := changed[san]; ok {
+			state.TLSSans[i] = ip
+		}
+	}
+	c.TLSSans = state.TLSSans
+	return common.DefaultDB.SaveClusterState(state)
+}
+
+// syncNodeAddresses updates the addresses of nodes with the current ones, and records the changed addresses.
+func syncNodeAddresses(nodes []types.Node, current map[string]types.Node, changed map[string]string) {
+	for i := range nodes {
+		n, ok := current[nodes[i].InstanceID]
+		if !ok {
+			continue
+		}
+		for _, pair := range [][2][]string{
+			{nodes[i].PublicIPAddress, n.PublicIPAddress},
+			{nodes[i].InternalIPAddress, n.InternalIPAddress},
+		} {
+			if old, ip := getFirstAddress(pair[0]), getFirstAddress(pair[1]); old != "" && ip != "" && old != ip {
+				changed[old] = ip
+			}
+		}
+		if getFirstAddress(n.PublicIPAddress) != "" {
+			nodes[i].PublicIPAddress = n.PublicIPAddress
+		}
+		if getFirstAddress(n.InternalIPAddress) != "" {
+			nodes[i].InternalIPAddress = n.InternalIPAddress
+		}
+		nodes[i].InstanceStatus = n.InstanceStatus
+	}
+}
+
+// updateAddressCommand replaces the changed addresses in the K3s service files and restarts K3s if any is replaced.
+// The addresses are replaced with placeholders first, in case that the addresses are swapped between nodes.
+func updateAddressCommand(changed map[string]string, master bool) string {
+	olds := make([]string, 0, len(changed))
+	for old := range changed {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	patterns := make([]string, 0, len(olds))
+	toPlaceholder := make([]string, 0, len(olds))
+	fromPlaceholder := make([]string, 0, len(olds))
+	for i, old := range olds {
+		pattern := regexp.QuoteMeta(old)
+		placeholder := fmt.Sprintf("__autok3s_address_%d__", i)
+		patterns = append(patterns, pattern)
+		toPlaceholder = append(toPlaceholder, fmt.Sprintf(`s/\b%s\b/%s/g`, pattern, placeholder))
+		fromPlaceholder = append(fromPlaceholder, fmt.Sprintf("s/%s/%s/g", placeholder, changed[old]))
+	}
+	service := "k3s-agent"
+	if master {
+		service = "k3s"
+	}
+	return fmt.Sprintf(`files=$(grep -lsE '\b(%s)\b' %s); if [ -n "$files" ]; then sed -i -E '%s' $files && systemctl daemon-reload && systemctl restart %s; fi`,
+		strings.Join(patterns, "|"), k3sServiceFiles, strings.Join(append(toPlaceholder, fromPlaceholder...), ";"), service)
+}
+
+func nodeIDs(nodes []types.Node) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.InstanceID)
+	}
+	return ids
+}
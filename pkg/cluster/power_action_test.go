@@ -0,0 +1,55 @@
+package cluster_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/common/commontest"
+	_ "github.com/cnrancher/autok3s/pkg/providers/native"
+	"github.com/cnrancher/autok3s/pkg/types"
+	typesnative "github.com/cnrancher/autok3s/pkg/types/native"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopClusterPartially(t *testing.T) {
+	commontest.InitStorage(t)
+	assert.NoError(t, common.DefaultDB.SaveCluster(&types.Cluster{
+		Metadata: types.Metadata{Name: "demo", Provider: "native", Master: "1", Worker: "1"},
+		Options:  typesnative.Options{},
+		Status: types.Status{
+			Status:      common.StatusRunning,
+			MasterNodes: []types.Node{{InstanceID: "master"}},
+			WorkerNodes: []types.Node{{InstanceID: "worker"}},
+		},
+	}))
+	p := cluster.NewBaseProvider()
+	p.Name, p.Provider = "demo", "native"
+
+	// the workers are stopped but the masters are not, so the cluster is neither running nor stopped.
+	var stopped []string
+	err := p.StopCluster(func(ids []string) error {
+		if ids[0] == "master" {
+			return errors.New("quota exceeded")
+		}
+		stopped = append(stopped, ids...)
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"worker"}, stopped)
+	state, err := common.DefaultDB.GetCluster("demo", "native")
+	assert.NoError(t, err)
+	assert.Equal(t, common.StatusFailed, state.Status)
+
+	// the failed cluster can be stopped again.
+	assert.NoError(t, p.StopCluster(func(ids []string) error {
+		stopped = append(stopped, ids...)
+		return nil
+	}))
+	assert.Equal(t, []string{"worker", "worker", "master"}, stopped)
+	state, err = common.DefaultDB.GetCluster("demo", "native")
+	assert.NoError(t, err)
+	assert.Equal(t, common.StatusStopped, state.Status)
+}
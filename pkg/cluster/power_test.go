@@ -0,0 +1,67 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncNodeAddresses(t *testing.T) {
+	nodes := []types.Node{
+		{
+			InstanceID:        "i-master",
+			Master:            true,
+			PublicIPAddress:   []string{"1.1.1.1"},
+			InternalIPAddress: []string{"10.0.0.1"},
+		},
+		{
+			InstanceID:        "i-worker",
+			PublicIPAddress:   []string{"2.2.2.2"},
+			InternalIPAddress: []string{"10.0.0.2"},
+		},
+		{
+			InstanceID:        "i-missing",
+			PublicIPAddress:   []string{"3.3.3.3"},
+			InternalIPAddress: []string{"10.0.0.3"},
+		},
+	}
+	current := map[string]types.Node{
+		"i-master": {
+			InstanceID:        "i-master",
+			InstanceStatus:    "running",
+			PublicIPAddress:   []string{"4.4.4.4"},
+			InternalIPAddress: []string{"10.0.0.1"},
+		},
+		"i-worker": {
+			InstanceID:     "i-worker",
+			InstanceStatus: "running",
+		},
+	}
+	changed := map[string]string{}
+	syncNodeAddresses(nodes, current, changed)
+
+	assert.Equal(t, map[string]string{"1.1.1.1": "4.4.4.4"}, changed)
+	assert.Equal(t, []string{"4.4.4.4"}, nodes[0].PublicIPAddress)
+	assert.Equal(t, "running", nodes[0].InstanceStatus)
+	// addresses that are not reported after start are kept.
+	assert.Equal(t, []string{"2.2.2.2"}, nodes[1].PublicIPAddress)
+	assert.Equal(t, []string{"3.3.3.3"}, nodes[2].PublicIPAddress)
+}
+
+func TestUpdateAddressCommand(t *testing.T) {
+	changed := map[string]string{
+		"1.1.1.1": "2.2.2.2",
+		"2.2.2.2": "1.1.1.1",
+	}
+	cmd := updateAddressCommand(changed, true)
+	assert.Contains(t, cmd, `\b(1\.1\.1\.1|2\.2\.2\.2)\b`)
+	// swapped addresses go through placeholders so they are not replaced twice.
+	assert.Contains(t, cmd, `s/\b1\.1\.1\.1\b/__autok3s_address_0__/g;s/\b2\.2\.2\.2\b/__autok3s_address_1__/g;`+
+		`s/__autok3s_address_0__/2.2.2.2/g;s/__autok3s_address_1__/1.1.1.1/g`)
+	assert.True(t, strings.HasSuffix(cmd, "systemctl restart k3s; fi"))
+
+	cmd = updateAddressCommand(changed, false)
+	assert.True(t, strings.HasSuffix(cmd, "systemctl restart k3s-agent; fi"))
+}
@@ -0,0 +1,329 @@
+package cluster
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/airgap"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/settings"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	"github.com/rancher/wharfie/pkg/registries"
+)
+
+const (
+	builtinRegistryPort = 5000
+	// builtinRegistryCertPath is the path of the builtin registry certificates on the first master node.
+	builtinRegistryCertPath = "/etc/rancher/k3s/builtin-registry"
+	builtinRegistryDataPath = "/var/lib/rancher/autok3s/registry"
+	// builtinRegistryManifest is a static pod under the k3s data dir so that the registry is running as soon as k3s started.
+	builtinRegistryManifest = "agent/pod-manifests/autok3s-registry.yaml"
+	builtinRegistryCertDays = 3650
+	// builtinRegistryRewrite matches the whole repository of image to prefix it with the source registry.
+	builtinRegistryRewrite = "^(.*)$"
+
+	builtinRegistryPodTmpl = `apiVersion: v1
+kind: Pod
+metadata:
+  name: autok3s-registry
+  namespace: kube-system
+  labels:
+    app: autok3s-registry
+spec:
+  hostNetwork: true
+  priorityClassName: system-node-critical
+  containers:
+  - name: registry
+    image: %s
+    imagePullPolicy: IfNotPresent
+    env:
+    - name: REGISTRY_HTTP_ADDR
+      value: 0.0.0.0:%d
+    - name: REGISTRY_HTTP_TLS_CERTIFICATE
+      value: /certs/tls.crt
+    - name: REGISTRY_HTTP_TLS_KEY
+      value: /certs/tls.key
+    volumeMounts:
+    - name: certs
+      mountPath: /certs
+      readOnly: true
+    - name: data
+      mountPath: /var/lib/registry
+  volumes:
+  - name: certs
+    hostPath:
+      path: %s
+      type: Directory
+  - name: data
+    hostPath:
+      path: %s
+      type: DirectoryOrCreate
+`
+	waitRegistryCommand = "for i in $(seq 1 60); do if curl -sfk -o /dev/null https://127.0.0.1:%[1]d/v2/ || wget -q --no-check-certificate -O /dev/null https://127.0.0.1:%[1]d/v2/; then exit 0; fi; sleep 5; done; echo 'builtin registry is not ready'; exit 1"
+	pushImageCommand    = "k3s ctr -n k8s.io images tag --force %[1]s %[2]s && k3s ctr -n k8s.io images push --skip-verify %[2]s"
+)
+
+// registryImage is the image of airgap package and its location in the builtin registry.
+type registryImage struct {
+	registry string
+	source   string
+	target   string
+}
+
+// builtinRegistryHost returns the address of the builtin registry which is running on the first master node.
+func builtinRegistryHost(cluster *types.Cluster) string {
+	return net.JoinHostPort(cluster.MasterNodes[0].InternalIPAddress[0], fmt.Sprint(builtinRegistryPort))
+}
+
+// localRegistryCertPath returns the local path to store the certificates of the builtin registry.
+func localRegistryCertPath(contextName string) string {
+	return filepath.Join(common.GetClusterContextPath(contextName), "registry")
+}
+
+// prepareBuiltinRegistry generates the certificates of the builtin registry and
+// merges the mirrors of package images into the cluster registry configuration.
+func (p *ProviderBase) prepareBuiltinRegistry(cluster *types.Cluster, pkg *common.Package) error {
+	if pkg == nil {
+		return errors.New("[cluster] builtin registry requires airgap package")
+	}
+	images, err := airgap.GetPackageImages(pkg)
+	if err != nil {
+		return errors.Wrap(err, "[cluster] failed to load images of airgap package")
+	}
+	registryImg, err := parseRegistryImage(settings.BuiltinRegistryImage.Get(), "")
+	if err != nil {
+		return err
+	}
+	host := builtinRegistryHost(cluster)
+	parsed := make([]registryImage, 0, len(images))
+	found := false
+	for _, image := range images {
+		ri, err := parseRegistryImage(image, host)
+		if err != nil {
+			return err
+		}
+		if ri.source == registryImg.source {
+			found = true
+		}
+		parsed = append(parsed, ri)
+	}
+	if !found {
+		return fmt.Errorf("[cluster] builtin registry image %s is not in airgap package, please add it with `autok3s airgap update %s --image %s`",
+			registryImg.source, pkg.Name, settings.BuiltinRegistryImage.Get())
+	}
+
+	certPath := localRegistryCertPath(cluster.ContextName)
+	if err := generateRegistryCertificates(certPath, cluster.MasterNodes[0].InternalIPAddress[0]); err != nil {
+		return err
+	}
+
+	registry := &registries.Registry{}
+	if p.Registry != "" || p.RegistryContent != "" {
+		if registry, err = utils.VerifyRegistryFileContent(p.Registry, p.RegistryContent); err != nil {
+			return err
+		}
+	}
+	if registry.Mirrors == nil {
+		registry.Mirrors = map[string]registries.Mirror{}
+	}
+	if registry.Configs == nil {
+		registry.Configs = map[string]registries.RegistryConfig{}
+	}
+	endpoint := "https://" + host
+	for _, image := range parsed {
+		mirror := registry.Mirrors[image.registry]
+		if !types.StringArray(mirror.Endpoints).Contains(endpoint) {
+			mirror.Endpoints = append([]string{endpoint}, mirror.Endpoints...)
+		}
+		// the images are pushed under the path of source registry, so the repositories are rewritten.
+		rewrite := image.registry + "/$1"
+		if r, ok := mirror.Rewrites[builtinRegistryRewrite]; ok && r != rewrite {
+			return fmt.Errorf("[cluster] rewrite %s of registry mirror %s conflicts with builtin registry", builtinRegistryRewrite, image.registry)
+		}
+		if mirror.Rewrites == nil {
+			mirror.Rewrites = map[string]string{}
+		}
+		mirror.Rewrites[builtinRegistryRewrite] = rewrite
+		registry.Mirrors[image.registry] = mirror
+	}
+	registry.Configs[host] = registries.RegistryConfig{
+		TLS: &registries.TLSConfig{
+			CAFile: filepath.Join(certPath, "ca.crt"),
+		},
+	}
+	content, err := utils.RegistryToString(registry)
+	if err != nil {
+		return err
+	}
+	// the registry content is saved with cluster, so that the joined nodes will use the builtin registry as well.
+	cluster.RegistryContent = content
+	p.RegistryContent = content
+	p.registryImages = images
+	return nil
+}
+
+// deployBuiltinRegistry saves the certificates and the registry static pod manifest to the first master node, the
+// manifest is saved under the data dir of k3s set by the extra args.
+func (p *ProviderBase) deployBuiltinRegistry(n *types.Node, cluster *types.Cluster, extraArgs string) error {
+	certPath := localRegistryCertPath(cluster.ContextName)
+	manifestPath := path.Join(airgap.DataPath(extraArgs), builtinRegistryManifest)
+	cmds := []string{
+		fmt.Sprintf("mkdir -p %s %s %s", builtinRegistryCertPath, builtinRegistryDataPath, path.Dir(manifestPath)),
+	}
+	for _, f := range []string{"tls.crt", "tls.key"} {
+		b, err := os.ReadFile(filepath.Join(certPath, f))
+		if err != nil {
+			return err
+		}
+		cmds = append(cmds, fmt.Sprintf("echo \"%s\" | base64 -d | tee \"%s/%s\" >/dev/null",
+			base64.StdEncoding.EncodeToString(b), builtinRegistryCertPath, f))
+	}
+	cmds = append(cmds, fmt.Sprintf("chmod 600 %s/tls.key", builtinRegistryCertPath))
+	manifest := fmt.Sprintf(builtinRegistryPodTmpl, settings.BuiltinRegistryImage.Get(), builtinRegistryPort, builtinRegistryCertPath, builtinRegistryDataPath)
+	cmds = append(cmds, fmt.Sprintf("echo \"%s\" | base64 -d | tee \"%s\" >/dev/null",
+		base64.StdEncoding.EncodeToString([]byte(manifest)), manifestPath))
+	_, err := p.execute(n, cmds...)
+	return err
+}
+
+// seedBuiltinRegistry pushes the airgap package images imported by k3s to the builtin registry.
+func (p *ProviderBase) seedBuiltinRegistry(cluster *types.Cluster) error {
+	node := &cluster.MasterNodes[0]
+	p.Logger.Infof("[%s] waiting for builtin registry to be ready...", p.Provider)
+	if _, err := p.execute(node, fmt.Sprintf(waitRegistryCommand, builtinRegistryPort)); err != nil {
+		return err
+	}
+	host := builtinRegistryHost(cluster)
+	for _, image := range p.registryImages {
+		ri, err := parseRegistryImage(image, host)
+		if err != nil {
+			return err
+		}
+		if _, err := p.execute(node, fmt.Sprintf(pushImageCommand, ri.source, ri.target)); err != nil {
+			// the extra images may not be available for the arch of the first master node.
+			p.Logger.Warnf("[%s] failed to push image %s to builtin registry: %v", p.Provider, ri.source, err)
+			continue
+		}
+		p.Logger.Debugf("[%s] pushed image %s to builtin registry", p.Provider, ri.target)
+	}
+	p.Logger.Infof("[%s] successfully seeded builtin registry %s", p.Provider, host)
+	return nil
+}
+
+// parseRegistryImage returns the registry and the fully qualified name of the image used by containerd,
+// and the target name in the builtin registry host, which is prefixed with the source registry.
+func parseRegistryImage(image, host string) (registryImage, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return registryImage{}, errors.Wrapf(err, "invalid image %s", image)
+	}
+	registry := ref.Context().RegistryStr()
+	if registry == name.DefaultRegistry {
+		registry = "docker.io"
+	}
+	identifier := ":" + ref.Identifier()
+	if _, ok := ref.(name.Digest); ok {
+		identifier = "@" + ref.Identifier()
+	}
+	repository := ref.Context().RepositoryStr()
+	return registryImage{
+		registry: registry,
+		source:   registry + "/" + repository + identifier,
+		// the source registry is kept in the path, so the same repositories of different registries don't collide.
+		target: host + "/" + registry + "/" + repository + identifier,
+	}, nil
+}
+
+// generateRegistryCertificates generates the self-signed CA and the serving certificate of the builtin registry.
+// The existing certificates are reused if the serving certificate is valid for the ip.
+func generateRegistryCertificates(certPath, ip string) error {
+	if data, err := os.ReadFile(filepath.Join(certPath, "tls.crt")); err == nil {
+		if block, _ := pem.Decode(data); block != nil {
+			if cert, err := x509.ParseCertificate(block.Bytes); err == nil && cert.VerifyHostname(ip) == nil {
+				return nil
+			}
+		}
+	}
+	if err := utils.EnsureFolderExist(certPath); err != nil {
+		return err
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(now.UnixNano()),
+		Subject:               pkix.Name{CommonName: "autok3s-registry-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(0, 0, builtinRegistryCertDays),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano() + 1),
+		Subject:      pkix.Name{CommonName: "autok3s-registry"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(0, 0, builtinRegistryCertDays),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+	if parsed := net.ParseIP(ip); parsed != nil {
+		template.IPAddresses = append(template.IPAddresses, parsed)
+	} else {
+		template.DNSNames = append(template.DNSNames, ip)
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]*pem.Block{
+		"ca.crt":  {Type: "CERTIFICATE", Bytes: caDER},
+		"tls.crt": {Type: "CERTIFICATE", Bytes: certDER},
+		"tls.key": {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	}
+	for f, block := range files {
+		if err := os.WriteFile(filepath.Join(certPath, f), pem.EncodeToMemory(block), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
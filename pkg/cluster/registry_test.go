@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRegistryImage(t *testing.T) {
+	type testcase struct {
+		image    string
+		registry string
+		source   string
+		target   string
+	}
+	for _, c := range []testcase{
+		{
+			image:    "registry:2",
+			registry: "docker.io",
+			source:   "docker.io/library/registry:2",
+			target:   "10.0.0.1:5000/docker.io/library/registry:2",
+		},
+		{
+			image:    "docker.io/rancher/mirrored-pause:3.6",
+			registry: "docker.io",
+			source:   "docker.io/rancher/mirrored-pause:3.6",
+			target:   "10.0.0.1:5000/docker.io/rancher/mirrored-pause:3.6",
+		},
+		{
+			image:    "quay.io/jetstack/cert-manager-controller:v1.11.0",
+			registry: "quay.io",
+			source:   "quay.io/jetstack/cert-manager-controller:v1.11.0",
+			target:   "10.0.0.1:5000/quay.io/jetstack/cert-manager-controller:v1.11.0",
+		},
+	} {
+		ri, err := parseRegistryImage(c.image, "10.0.0.1:5000")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, c.registry, ri.registry, c.image)
+		assert.Equal(t, c.source, ri.source, c.image)
+		assert.Equal(t, c.target, ri.target, c.image)
+	}
+}
+
+func TestGenerateRegistryCertificates(t *testing.T) {
+	certPath := t.TempDir()
+	if err := generateRegistryCertificates(certPath, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	caData, err := os.ReadFile(filepath.Join(certPath, "ca.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	certData, err := os.ReadFile(filepath.Join(certPath, "tls.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	assert.True(t, pool.AppendCertsFromPEM(caData))
+	block, _ := pem.Decode(certData)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: "10.0.0.1", Roots: pool})
+	assert.NoError(t, err)
+
+	// the certificates are reused for the same ip and regenerated for a new one.
+	assert.NoError(t, generateRegistryCertificates(certPath, "10.0.0.1"))
+	reused, _ := os.ReadFile(filepath.Join(certPath, "tls.crt"))
+	assert.Equal(t, certData, reused)
+	assert.NoError(t, generateRegistryCertificates(certPath, "10.0.0.2"))
+	regenerated, _ := os.ReadFile(filepath.Join(certPath, "tls.crt"))
+	assert.NotEqual(t, certData, regenerated)
+}
@@ -0,0 +1,204 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RemoveK3sNodes removes the worker nodes, it's not supported by default as the instances are not managed by the provider.
+func (p *ProviderBase) RemoveK3sNodes(_ []string) error {
+	return fmt.Errorf("[%s] removing nodes is not supported", p.Provider)
+}
+
+// RemoveNodes removes the worker nodes from Kubernetes and the cluster state, and releases the instances by the
+// release function. The instances may be already gone, e.g. terminated by the cloud, so the failure of release is
+// only logged.
+func (p *ProviderBase) RemoveNodes(ids []string, release func(ids []string) error) error {
+	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("[%s] cluster %s is not exist", p.Provider, p.Name)
+	}
+	logFile, err := common.GetLogFile(state.ContextName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+	p.Logger = common.NewLogger(logFile)
+
+	workers := []types.Node{}
+	if err = json.Unmarshal(state.WorkerNodes, &workers); err != nil {
+		return err
+	}
+	removed, kept, err := splitNodes(workers, ids)
+	if err != nil {
+		return fmt.Errorf("[%s] %v of cluster %s", p.Provider, err, p.Name)
+	}
+	p.Logger.Infof("[%s] removing worker nodes %v from cluster %s...", p.Provider, ids, p.Name)
+
+	client, err := GetClusterConfig(state.ContextName, filepath.Join(common.CfgPath, common.KubeCfgFile))
+	if err != nil {
+		return fmt.Errorf("[%s] failed to generate kube client for cluster %s: %v", p.Provider, p.Name, err)
+	}
+	timeout := int64(15)
+	list, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{TimeoutSeconds: &timeout})
+	if err != nil {
+		return fmt.Errorf("[%s] failed to list nodes of cluster %s: %v", p.Provider, p.Name, err)
+	}
+	for _, name := range matchKubeNodes(list.Items, removed) {
+		p.Logger.Infof("[%s] deleting node %s from cluster %s", p.Provider, name, p.Name)
+		err = client.CoreV1().Nodes().Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("[%s] failed to delete node %s of cluster %s: %v", p.Provider, name, p.Name, err)
+		}
+	}
+
+	// some providers release the resources of the nodes marked to rollback, e.g. the EIPs.
+	for i := range p.WorkerNodes {
+		for _, n := range removed {
+			if p.WorkerNodes[i].InstanceID == n.InstanceID {
+				p.WorkerNodes[i].RollBack = true
+			}
+		}
+	}
+	if err = release(ids); err != nil {
+		p.Logger.Warnf("[%s] failed to release instances %v of cluster %s: %v", p.Provider, ids, p.Name, err)
+	}
+
+	wb, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	state.WorkerNodes = wb
+	state.Worker = strconv.Itoa(len(kept))
+	if err = common.DefaultDB.SaveClusterState(state); err != nil {
+		return err
+	}
+	p.notifyUpdate(state.ContextName)
+	p.Logger.Infof("[%s] successfully removed worker nodes %v from cluster %s", p.Provider, ids, p.Name)
+	return nil
+}
+
+// splitNodes returns the worker nodes to remove and the ones to keep, all the ids must be workers.
+func splitNodes(workers []types.Node, ids []string) ([]types.Node, []types.Node, error) {
+	removed := make([]types.Node, 0, len(ids))
+	kept := make([]types.Node, 0, len(workers))
+	for _, n := range workers {
+		matched := false
+		for _, id := range ids {
+			if n.InstanceID == id {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			removed = append(removed, n)
+		} else {
+			kept = append(kept, n)
+		}
+	}
+	for _, id := range ids {
+		found := false
+		for _, n := range removed {
+			if n.InstanceID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("node %s is not a worker", id)
+		}
+	}
+	return removed, kept, nil
+}
+
+// matchKubeNodes returns the names of Kubernetes nodes which have the addresses or the instance ids of the nodes.
+func matchKubeNodes(kubeNodes []v1.Node, nodes []types.Node) []string {
+	names := []string{}
+	for i := range kubeNodes {
+		for _, n := range nodes {
+			if IsKubeNode(&kubeNodes[i], n) {
+				names = append(names, kubeNodes[i].Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// IsKubeNode returns whether the Kubernetes node is the node of cluster state, which is matched by the addresses or
+// the instance id, e.g. the container name of k3d node.
+func IsKubeNode(kubeNode *v1.Node, n types.Node) bool {
+	if kubeNode.Name == n.InstanceID {
+		return true
+	}
+	candidates := append(append([]string{n.InstanceID}, n.InternalIPAddress...), n.PublicIPAddress...)
+	for _, address := range kubeNode.Status.Addresses {
+		for _, c := range candidates {
+			if c != "" && address.Address == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nodeLocks holds the locks of clusters whose nodes are changed in background, keyed by context name.
+var nodeLocks sync.Map
+
+// LockNodes locks the nodes of cluster, the background changes of nodes such as the autoscaling and the auto-heal
+// hold the lock, so they don't join or remove the nodes of the same cluster concurrently and overwrite the nodes
+// of each other in the cluster state. The returned function releases the lock.
+func LockNodes(contextName string) func() {
+	lock, _ := nodeLocks.LoadOrStore(contextName, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// ProviderFromState returns the provider of the cluster with the options in the cluster state and the number of
+// nodes, which is used to operate the existing cluster, e.g. join or remove nodes and stop the cluster.
+func ProviderFromState(state *common.ClusterState, master, worker string) (providers.Provider, error) {
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		return nil, err
+	}
+	opt, err := provider.GetProviderOptions(state.Options)
+	if err != nil {
+		return nil, err
+	}
+	metadata := state.Metadata
+	metadata.Master = master
+	metadata.Worker = worker
+	b, err := json.Marshal(&types.Cluster{
+		Metadata: metadata,
+		Options:  opt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = provider.SetConfig(b); err != nil {
+		return nil, err
+	}
+	if err = provider.MergeClusterOptions(); err != nil {
+		return nil, err
+	}
+	provider.GenerateClusterName()
+	return provider, nil
+}
@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSplitNodes(t *testing.T) {
+	workers := []types.Node{{InstanceID: "i-1"}, {InstanceID: "i-2"}, {InstanceID: "i-3"}}
+	removed, kept, err := splitNodes(workers, []string{"i-3", "i-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Node{{InstanceID: "i-1"}, {InstanceID: "i-3"}}, removed)
+	assert.Equal(t, []types.Node{{InstanceID: "i-2"}}, kept)
+
+	_, _, err = splitNodes(workers, []string{"i-1", "i-master"})
+	assert.EqualError(t, err, "node i-master is not a worker")
+}
+
+func TestMatchKubeNodes(t *testing.T) {
+	kubeNodes := []v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-0-1"},
+			Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-0-2"},
+			Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.2"}}},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "k3d-demo-agent-0"}},
+	}
+	nodes := []types.Node{
+		{InstanceID: "i-1", InternalIPAddress: []string{"10.0.0.1"}},
+		{InstanceID: "k3d-demo-agent-0"},
+	}
+	assert.Equal(t, []string{"ip-10-0-0-1", "k3d-demo-agent-0"}, matchKubeNodes(kubeNodes, nodes))
+}
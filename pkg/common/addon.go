@@ -1,15 +1,136 @@
 package common
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/cnrancher/autok3s/pkg/types"
 	apitypes "github.com/rancher/apiserver/pkg/types"
 )
 
+const (
+	ReadinessKindDeployment  = "Deployment"
+	ReadinessKindDaemonSet   = "DaemonSet"
+	ReadinessKindStatefulSet = "StatefulSet"
+	ReadinessKindJob         = "Job"
+	ReadinessKindCRD         = "CustomResourceDefinition"
+)
+
+// readinessKinds maps the lower case kind and the short names to the readiness check kind.
+var readinessKinds = map[string]string{
+	"deployment":               ReadinessKindDeployment,
+	"deploy":                   ReadinessKindDeployment,
+	"daemonset":                ReadinessKindDaemonSet,
+	"ds":                       ReadinessKindDaemonSet,
+	"statefulset":              ReadinessKindStatefulSet,
+	"sts":                      ReadinessKindStatefulSet,
+	"job":                      ReadinessKindJob,
+	"customresourcedefinition": ReadinessKindCRD,
+	"crd":                      ReadinessKindCRD,
+}
+
 type Addon struct {
 	Name        string          `json:"name" gorm:"primaryKey;not null" wrangler:"required,noupdate"`
 	Description string          `json:"description,omitempty"`
 	Manifest    []byte          `json:"manifest" gorm:"type:bytes" wrangler:"required"`
 	Values      types.StringMap `json:"values,omitempty" gorm:"type:stringMap"`
+	// ValuesSchema is the JSON schema of the add-on values, the values are validated with it before rendering.
+	ValuesSchema []byte `json:"valuesSchema,omitempty" gorm:"type:bytes"`
+	// Dependencies are the add-ons which must be deployed and ready before this add-on, e.g. cert-manager.
+	Dependencies types.StringArray `json:"dependencies,omitempty" gorm:"type:stringArray"`
+	// ReadinessChecks are the resources to wait for after deploying the add-on.
+	ReadinessChecks ReadinessChecks `json:"readinessChecks,omitempty" gorm:"type:bytes"`
+}
+
+// ReadinessCheck is the resource to wait for, the namespace is not required for CustomResourceDefinition.
+type ReadinessCheck struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (c ReadinessCheck) String() string {
+	if c.Namespace == "" {
+		return fmt.Sprintf("%s/%s", c.Kind, c.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", c.Kind, c.Namespace, c.Name)
+}
+
+// ParseReadinessCheck parses the readiness check with format <kind>/<namespace>/<name> or crd/<name>,
+// e.g. deployment/cattle-system/rancher.
+func ParseReadinessCheck(s string) (ReadinessCheck, error) {
+	parts := strings.Split(s, "/")
+	check := ReadinessCheck{}
+	switch len(parts) {
+	case 2:
+		check.Kind, check.Name = parts[0], parts[1]
+	case 3:
+		check.Kind, check.Namespace, check.Name = parts[0], parts[1], parts[2]
+	default:
+		return check, fmt.Errorf("invalid readiness check %s, the format is <kind>/<namespace>/<name> or crd/<name>", s)
+	}
+	return check, check.Validate()
+}
+
+// Validate normalizes the kind of the readiness check and checks the required fields.
+func (c *ReadinessCheck) Validate() error {
+	kind, ok := readinessKinds[strings.ToLower(c.Kind)]
+	if !ok {
+		return fmt.Errorf("readiness check kind %s is not supported", c.Kind)
+	}
+	c.Kind = kind
+	if c.Name == "" {
+		return fmt.Errorf("name of readiness check %s is required", c.Kind)
+	}
+	if c.Kind == ReadinessKindCRD {
+		c.Namespace = ""
+	} else if c.Namespace == "" {
+		return fmt.Errorf("namespace of readiness check %s/%s is required", c.Kind, c.Name)
+	}
+	return nil
+}
+
+// ReadinessChecks gorm custom readiness check list type.
+type ReadinessChecks []ReadinessCheck
+
+func (rc *ReadinessChecks) Scan(value interface{}) (err error) {
+	var ba []byte
+	switch v := value.(type) {
+	case string:
+		ba = []byte(v)
+	case []byte:
+		ba = v
+	default:
+		return fmt.Errorf("failed to scan value %v", value)
+	}
+	t := []ReadinessCheck{}
+	err = json.Unmarshal(ba, &t)
+	*rc = t
+	return err
+}
+
+func (rc ReadinessChecks) Value() (driver.Value, error) {
+	if len(rc) == 0 {
+		return nil, nil
+	}
+	ba, err := json.Marshal(rc)
+	return string(ba), err
+}
+
+func (rc ReadinessChecks) GormDataType() string {
+	return "bytes"
+}
+
+// Validate normalizes and validates all the readiness checks.
+func (rc ReadinessChecks) Validate() error {
+	for i := range rc {
+		if err := rc[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (a Addon) GetID() string {
@@ -0,0 +1,197 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/Masterminds/semver"
+	"gorm.io/gorm"
+)
+
+const (
+	AddonRepoTypeGit  = "git"
+	AddonRepoTypeHTTP = "http"
+)
+
+// AddonRepo is the catalog of versioned add-ons from a git repository or a http index.
+type AddonRepo struct {
+	Name       string    `json:"name" gorm:"primaryKey;not null"`
+	Type       string    `json:"type"`
+	URL        string    `json:"url"`
+	Branch     string    `json:"branch,omitempty"`
+	LastSynced time.Time `json:"lastSynced"`
+}
+
+func (r AddonRepo) GetID() string {
+	return r.Name
+}
+
+// CatalogAddon is the versioned add-on definition from add-on repo.
+type CatalogAddon struct {
+	Repo         string          `json:"repo" gorm:"primaryKey;not null"`
+	Name         string          `json:"name" gorm:"primaryKey;not null"`
+	Version      string          `json:"version" gorm:"primaryKey;not null"`
+	Description  string          `json:"description,omitempty"`
+	Manifest     []byte          `json:"manifest" gorm:"type:bytes"`
+	Values       types.StringMap `json:"values,omitempty" gorm:"type:stringMap"`
+	ValuesSchema []byte          `json:"valuesSchema,omitempty" gorm:"type:bytes"`
+	// Dependencies are the add-on references, the add-on name without repo refers to the add-on of the same repo.
+	Dependencies    types.StringArray `json:"dependencies,omitempty" gorm:"type:stringArray"`
+	ReadinessChecks ReadinessChecks   `json:"readinessChecks,omitempty" gorm:"type:bytes"`
+}
+
+func (a CatalogAddon) GetID() string {
+	return fmt.Sprintf("%s/%s@%s", a.Repo, a.Name, a.Version)
+}
+
+func (s *Store) SaveAddonRepo(repo *AddonRepo) error {
+	exist, err := s.GetAddonRepo(repo.Name)
+	if err != nil {
+		return err
+	}
+	if exist != nil {
+		result := s.DB.Where("name = ? ", repo.Name).Omit("name").Save(repo)
+		return result.Error
+	}
+	result := s.DB.Create(repo)
+	return result.Error
+}
+
+func (s *Store) GetAddonRepo(name string) (*AddonRepo, error) {
+	repo := &AddonRepo{}
+	result := s.DB.Where("name = ? ", name).Find(repo)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return repo, nil
+}
+
+func (s *Store) ListAddonRepos() ([]*AddonRepo, error) {
+	list := []*AddonRepo{}
+	result := s.DB.Order("name").Find(&list)
+	return list, result.Error
+}
+
+// DeleteAddonRepo removes the add-on repo and all the add-ons of it.
+func (s *Store) DeleteAddonRepo(name string) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo = ? ", name).Delete(&CatalogAddon{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("name = ? ", name).Delete(&AddonRepo{}).Error
+	})
+}
+
+// ReplaceCatalogAddons replaces all the add-ons of the repo with the synced ones.
+func (s *Store) ReplaceCatalogAddons(repo string, addons []*CatalogAddon) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo = ? ", repo).Delete(&CatalogAddon{}).Error; err != nil {
+			return err
+		}
+		for _, addon := range addons {
+			addon.Repo = repo
+			if err := tx.Create(addon).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListCatalogAddons returns the add-ons of the repo, all the catalog add-ons are returned if repo is empty.
+func (s *Store) ListCatalogAddons(repo string) ([]*CatalogAddon, error) {
+	list := []*CatalogAddon{}
+	db := s.DB
+	if repo != "" {
+		db = db.Where("repo = ? ", repo)
+	}
+	result := db.Order("repo").Order("name").Find(&list)
+	return list, result.Error
+}
+
+// GetCatalogAddon returns the add-on of the repo with the version, the latest version is returned if version is empty.
+func (s *Store) GetCatalogAddon(repo, name, version string) (*CatalogAddon, error) {
+	list := []*CatalogAddon{}
+	db := s.DB.Where("repo = ? AND name = ?", repo, name)
+	if version != "" {
+		db = db.Where("version = ?", version)
+	}
+	if err := db.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		if version != "" {
+			return nil, fmt.Errorf("add-on %s/%s@%s is not found", repo, name, version)
+		}
+		return nil, fmt.Errorf("add-on %s/%s is not found", repo, name)
+	}
+	sortCatalogAddons(list)
+	return list[len(list)-1], nil
+}
+
+// ParseAddonRef parses the add-on reference, the reference of catalog add-on is <repo>/<name>[@version].
+func ParseAddonRef(ref string) (repo, name, version string) {
+	name = ref
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		name, version = name[:i], name[i+1:]
+	}
+	if i := strings.Index(name, "/"); i >= 0 {
+		repo, name = name[:i], name[i+1:]
+	}
+	return
+}
+
+// AddonRefName returns the add-on reference without version, which is used as the name of deployed add-on.
+func AddonRefName(ref string) string {
+	repo, name, _ := ParseAddonRef(ref)
+	if repo == "" {
+		return name
+	}
+	return repo + "/" + name
+}
+
+// GetAddonByRef returns the add-on by reference, the catalog add-on is converted to add-on with name <repo>/<name>.
+// The returned version is empty for the add-on which is not from catalog.
+func (s *Store) GetAddonByRef(ref string) (*Addon, string, error) {
+	repo, name, version := ParseAddonRef(ref)
+	if repo == "" {
+		addon, err := s.GetAddon(name)
+		return addon, "", err
+	}
+	catalogAddon, err := s.GetCatalogAddon(repo, name, version)
+	if err != nil {
+		return nil, "", err
+	}
+	return &Addon{
+		Name:            repo + "/" + name,
+		Description:     catalogAddon.Description,
+		Manifest:        catalogAddon.Manifest,
+		Values:          catalogAddon.Values,
+		ValuesSchema:    catalogAddon.ValuesSchema,
+		Dependencies:    catalogAddon.Dependencies,
+		ReadinessChecks: catalogAddon.ReadinessChecks,
+	}, catalogAddon.Version, nil
+}
+
+// sortCatalogAddons sorts the add-ons by semantic version, the invalid versions are sorted by string and placed before valid ones.
+func sortCatalogAddons(list []*CatalogAddon) {
+	sort.SliceStable(list, func(i, j int) bool {
+		vi, erri := semver.NewVersion(list[i].Version)
+		vj, errj := semver.NewVersion(list[j].Version)
+		switch {
+		case erri == nil && errj == nil:
+			return vi.LessThan(vj)
+		case erri != nil && errj != nil:
+			return list[i].Version < list[j].Version
+		default:
+			return erri != nil
+		}
+	})
+}
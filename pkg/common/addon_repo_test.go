@@ -0,0 +1,65 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddonRef(t *testing.T) {
+	cases := []struct {
+		ref, repo, name, version string
+	}{
+		{"rancher", "", "rancher", ""},
+		{"stable/nginx", "stable", "nginx", ""},
+		{"stable/nginx@1.2.0", "stable", "nginx", "1.2.0"},
+	}
+	for _, c := range cases {
+		repo, name, version := ParseAddonRef(c.ref)
+		assert.Equal(t, c.repo, repo, c.ref)
+		assert.Equal(t, c.name, name, c.ref)
+		assert.Equal(t, c.version, version, c.ref)
+	}
+	assert.Equal(t, "stable/nginx", AddonRefName("stable/nginx@1.2.0"))
+}
+
+func TestCatalogAddonStore(t *testing.T) {
+	initTestStorage(t)
+
+	if err := DefaultDB.SaveAddonRepo(&AddonRepo{Name: "stable", Type: AddonRepoTypeHTTP, URL: "http://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := DefaultDB.ReplaceCatalogAddons("stable", []*CatalogAddon{
+		{Name: "nginx", Version: "1.10.0", Manifest: []byte("v1.10.0")},
+		{Name: "nginx", Version: "1.9.0", Manifest: []byte("v1.9.0")},
+		{Name: "nginx", Version: "1.2.0", Manifest: []byte("v1.2.0")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	addon, version, err := DefaultDB.GetAddonByRef("stable/nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "1.10.0", version)
+	assert.Equal(t, "stable/nginx", addon.Name)
+	assert.Equal(t, "v1.10.0", string(addon.Manifest))
+
+	_, version, err = DefaultDB.GetAddonByRef("stable/nginx@1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "1.2.0", version)
+
+	_, _, err = DefaultDB.GetAddonByRef("stable/nginx@2.0.0")
+	assert.Error(t, err)
+
+	if err := DefaultDB.DeleteAddonRepo("stable"); err != nil {
+		t.Fatal(err)
+	}
+	list, err := DefaultDB.ListCatalogAddons("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, list)
+}
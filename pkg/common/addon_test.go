@@ -0,0 +1,29 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultRancherAddon(t *testing.T) {
+	initTestStorage(t)
+	rancher, err := DefaultDB.GetAddon("rancher")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultRancherReadinessChecks, rancher.ReadinessChecks)
+
+	// the readiness checks are added to the add-on saved by the previous versions.
+	rancher.ReadinessChecks = nil
+	assert.NoError(t, DefaultDB.SaveAddon(rancher))
+	rancher, err = DefaultDB.GetAddon("rancher")
+	assert.NoError(t, err)
+	assert.Empty(t, rancher.ReadinessChecks)
+	if err := InitStorage(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rancher, err = DefaultDB.GetAddon("rancher")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultRancherReadinessChecks, rancher.ReadinessChecks)
+	assert.NoError(t, rancher.ReadinessChecks.Validate())
+}
@@ -0,0 +1,175 @@
+package common
+
+// defaultClusterAutoscalerValuesSchema requires the endpoint and the certificates, which are set by
+// "autok3s autoscaler deploy".
+var defaultClusterAutoscalerValuesSchema = `{
+  "type": "object",
+  "required": ["endpoint", "caCert", "tlsCert", "tlsKey"],
+  "properties": {
+    "endpoint": {"type": "string", "minLength": 1},
+    "caCert": {"type": "string", "minLength": 1},
+    "tlsCert": {"type": "string", "minLength": 1},
+    "tlsKey": {"type": "string", "minLength": 1}
+  }
+}`
+
+// DefaultClusterAutoscalerManifest deploys cluster-autoscaler with the external gRPC cloud provider which points back
+// to the autoscaler endpoint of "autok3s serve", the certificates are issued by "autok3s autoscaler deploy".
+var DefaultClusterAutoscalerManifest = `
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cluster-autoscaler
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: cluster-autoscaler
+rules:
+  - apiGroups: [""]
+    resources: ["events", "endpoints"]
+    verbs: ["create", "patch"]
+  - apiGroups: [""]
+    resources: ["pods/eviction"]
+    verbs: ["create"]
+  - apiGroups: [""]
+    resources: ["pods/status"]
+    verbs: ["update"]
+  - apiGroups: [""]
+    resources: ["endpoints"]
+    resourceNames: ["cluster-autoscaler"]
+    verbs: ["get", "update"]
+  - apiGroups: [""]
+    resources: ["nodes"]
+    verbs: ["watch", "list", "get", "update"]
+  - apiGroups: [""]
+    resources: ["namespaces", "pods", "services", "replicationcontrollers", "persistentvolumeclaims", "persistentvolumes"]
+    verbs: ["watch", "list", "get"]
+  - apiGroups: ["extensions"]
+    resources: ["replicasets", "daemonsets"]
+    verbs: ["watch", "list", "get"]
+  - apiGroups: ["policy"]
+    resources: ["poddisruptionbudgets"]
+    verbs: ["watch", "list"]
+  - apiGroups: ["apps"]
+    resources: ["statefulsets", "replicasets", "daemonsets"]
+    verbs: ["watch", "list", "get"]
+  - apiGroups: ["storage.k8s.io"]
+    resources: ["storageclasses", "csinodes", "csidrivers", "csistoragecapacities", "volumeattachments"]
+    verbs: ["watch", "list", "get"]
+  - apiGroups: ["batch", "extensions"]
+    resources: ["jobs"]
+    verbs: ["get", "list", "watch", "patch"]
+  - apiGroups: ["coordination.k8s.io"]
+    resources: ["leases"]
+    verbs: ["create"]
+  - apiGroups: ["coordination.k8s.io"]
+    resourceNames: ["cluster-autoscaler"]
+    resources: ["leases"]
+    verbs: ["get", "update"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: cluster-autoscaler
+  namespace: kube-system
+rules:
+  - apiGroups: [""]
+    resources: ["configmaps"]
+    verbs: ["create", "list", "watch"]
+  - apiGroups: [""]
+    resources: ["configmaps"]
+    resourceNames: ["cluster-autoscaler-status", "cluster-autoscaler-priority-expander"]
+    verbs: ["delete", "get", "update", "watch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: cluster-autoscaler
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-autoscaler
+subjects:
+  - kind: ServiceAccount
+    name: cluster-autoscaler
+    namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: cluster-autoscaler
+  namespace: kube-system
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: cluster-autoscaler
+subjects:
+  - kind: ServiceAccount
+    name: cluster-autoscaler
+    namespace: kube-system
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: cluster-autoscaler-cloud-config
+  namespace: kube-system
+type: Opaque
+data:
+  cloud-config: {{ printf "address: %s\nkey: /etc/autoscaler/tls.key\ncert: /etc/autoscaler/tls.crt\ncacert: /etc/autoscaler/ca.crt\ngrpc_timeout: %s\n" .endpoint (.grpcTimeout | default "10s") | b64enc }}
+  ca.crt: {{ .caCert | b64enc }}
+  tls.crt: {{ .tlsCert | b64enc }}
+  tls.key: {{ .tlsKey | b64enc }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cluster-autoscaler
+  namespace: kube-system
+  labels:
+    app: cluster-autoscaler
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: cluster-autoscaler
+  template:
+    metadata:
+      labels:
+        app: cluster-autoscaler
+    spec:
+      serviceAccountName: cluster-autoscaler
+      priorityClassName: system-cluster-critical
+      nodeSelector:
+        node-role.kubernetes.io/control-plane: "true"
+      tolerations:
+        - key: node-role.kubernetes.io/control-plane
+          operator: Exists
+          effect: NoSchedule
+        - key: node-role.kubernetes.io/master
+          operator: Exists
+          effect: NoSchedule
+      containers:
+        - name: cluster-autoscaler
+          image: {{ .image | default "registry.k8s.io/autoscaling/cluster-autoscaler" }}:{{ .Version | default "v1.32.1" }}
+          command:
+            - ./cluster-autoscaler
+            - --cloud-provider=externalgrpc
+            - --cloud-config=/etc/autoscaler/cloud-config
+            - --scale-down-unneeded-time={{ .scaleDownUnneededTime | default "10m" }}
+            - --v=2
+          resources:
+            requests:
+              cpu: 100m
+              memory: 300Mi
+          volumeMounts:
+            - name: cloud-config
+              mountPath: /etc/autoscaler
+              readOnly: true
+      volumes:
+        - name: cloud-config
+          secret:
+            secretName: cluster-autoscaler-cloud-config
+`
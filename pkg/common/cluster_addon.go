@@ -0,0 +1,90 @@
+package common
+
+import (
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+const (
+	// AddonStatusDeployed the manifest on node is the same as the rendered one.
+	AddonStatusDeployed = "Deployed"
+	// AddonStatusModified the manifest on node is changed outside of autok3s.
+	AddonStatusModified = "Modified"
+	// AddonStatusMissing the manifest on node is removed outside of autok3s.
+	AddonStatusMissing = "Missing"
+	// AddonStatusOutdated the add-on is updated after installed and the cluster add-on needs to be upgraded.
+	AddonStatusOutdated = "Outdated"
+	// AddonStatusUnknown failed to check the manifest on node.
+	AddonStatusUnknown = "Unknown"
+
+	// AddonReadinessReady all the readiness checks of the add-on passed.
+	AddonReadinessReady = "Ready"
+	// AddonReadinessNotReady the readiness checks of the add-on are not passed before timeout.
+	AddonReadinessNotReady = "NotReady"
+)
+
+// ClusterAddon is the add-on deployed to the cluster.
+type ClusterAddon struct {
+	// Cluster is the context name of the cluster.
+	Cluster string `json:"cluster" gorm:"primaryKey;not null"`
+	Name    string `json:"name" gorm:"primaryKey;not null"`
+	// Version is the version of the add-on from add-on repo, it's empty for the add-on which is not from catalog.
+	Version string `json:"version,omitempty"`
+	// Values are the values set when installing or upgrading the add-on, the add-on default values are merged on each rendering.
+	Values       types.StringMap `json:"values,omitempty" gorm:"type:stringMap"`
+	Manifest     []byte          `json:"manifest,omitempty" gorm:"type:bytes"`
+	ManifestHash string          `json:"manifestHash"`
+	// Readiness is the result of the readiness checks of the last deployment, it's empty if the add-on has no readiness check.
+	Readiness        string    `json:"readiness,omitempty"`
+	ReadinessMessage string    `json:"readinessMessage,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+func (a ClusterAddon) GetID() string {
+	return a.Cluster + "/" + a.Name
+}
+
+func (s *Store) SaveClusterAddon(addon *ClusterAddon) error {
+	exist, err := s.GetClusterAddon(addon.Cluster, addon.Name)
+	if err != nil {
+		return err
+	}
+	if exist != nil {
+		addon.CreatedAt = exist.CreatedAt
+		result := s.DB.Where("cluster = ? AND name = ?", addon.Cluster, addon.Name).Omit("cluster", "name").Save(addon)
+		return result.Error
+	}
+	result := s.DB.Create(addon)
+	return result.Error
+}
+
+func (s *Store) GetClusterAddon(cluster, name string) (*ClusterAddon, error) {
+	addon := &ClusterAddon{}
+	result := s.DB.Where("cluster = ? AND name = ?", cluster, name).Find(addon)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return addon, nil
+}
+
+func (s *Store) ListClusterAddons(cluster string) ([]*ClusterAddon, error) {
+	list := []*ClusterAddon{}
+	result := s.DB.Where("cluster = ?", cluster).Order("name").Find(&list)
+	return list, result.Error
+}
+
+func (s *Store) DeleteClusterAddon(cluster, name string) error {
+	result := s.DB.Where("cluster = ? AND name = ?", cluster, name).Delete(&ClusterAddon{})
+	return result.Error
+}
+
+// DeleteClusterAddons removes all the add-on records of the cluster.
+func (s *Store) DeleteClusterAddons(cluster string) error {
+	result := s.DB.Where("cluster = ?", cluster).Delete(&ClusterAddon{})
+	return result.Error
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterAddonStore(t *testing.T) {
+	initTestStorage(t)
+
+	addon := &ClusterAddon{
+		Cluster:      "demo.native",
+		Name:         "rancher",
+		Values:       map[string]string{"Version": "v2.7.9"},
+		Manifest:     []byte("manifest"),
+		ManifestHash: "hash",
+	}
+	if err := DefaultDB.SaveClusterAddon(addon); err != nil {
+		t.Fatal(err)
+	}
+	if err := DefaultDB.SaveClusterAddon(&ClusterAddon{Cluster: "other.native", Name: "rancher"}); err != nil {
+		t.Fatal(err)
+	}
+
+	addon.ManifestHash = "new-hash"
+	if err := DefaultDB.SaveClusterAddon(addon); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := DefaultDB.GetClusterAddon("demo.native", "rancher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "new-hash", saved.ManifestHash)
+	assert.Equal(t, "v2.7.9", saved.Values["Version"])
+
+	list, err := DefaultDB.ListClusterAddons("demo.native")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(list))
+
+	assert.NoError(t, DefaultDB.DeleteClusterAddons("demo.native"))
+	saved, err = DefaultDB.GetClusterAddon("demo.native", "rancher")
+	assert.NoError(t, err)
+	assert.Nil(t, saved)
+	saved, err = DefaultDB.GetClusterAddon("other.native", "rancher")
+	assert.NoError(t, err)
+	assert.NotNil(t, saved)
+}
@@ -0,0 +1,98 @@
+package common
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	apitypes "github.com/rancher/apiserver/pkg/types"
+)
+
+const (
+	// HealthStatusHealthy the apiserver and all the nodes of the cluster are healthy.
+	HealthStatusHealthy = "Healthy"
+	// HealthStatusDegraded the apiserver is reachable, but some nodes are not ready, under pressure, not running
+	// or not reachable by SSH, or the apiserver is slow.
+	HealthStatusDegraded = "Degraded"
+	// HealthStatusUnhealthy the apiserver of the cluster is not reachable.
+	HealthStatusUnhealthy = "Unhealthy"
+)
+
+// ClusterHealth is a record of the health history of the cluster.
+type ClusterHealth struct {
+	ID uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	// Cluster is the context name of the cluster.
+	Cluster string `json:"cluster" gorm:"index;not null"`
+	Status  string `json:"status"`
+	// Check is the JSON of apis.ClusterHealthCheck.
+	Check     []byte    `json:"check"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// NewClusterHealth returns the health record of the check result.
+func NewClusterHealth(cluster string, check *apis.ClusterHealthCheck) (*ClusterHealth, error) {
+	b, err := json.Marshal(check)
+	if err != nil {
+		return nil, err
+	}
+	return &ClusterHealth{
+		Cluster:   cluster,
+		Status:    check.Status,
+		Check:     b,
+		CheckedAt: check.CheckedAt,
+	}, nil
+}
+
+// ToCheck returns the check result of the health record.
+func (h *ClusterHealth) ToCheck() (*apis.ClusterHealthCheck, error) {
+	check := &apis.ClusterHealthCheck{}
+	if err := json.Unmarshal(h.Check, check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// SaveClusterHealth saves the health record and keeps the latest records of the cluster no more than keep.
+func (s *Store) SaveClusterHealth(health *ClusterHealth, keep int) error {
+	if result := s.DB.Create(health); result.Error != nil {
+		return result.Error
+	}
+	if keep <= 0 {
+		return nil
+	}
+	latest := s.DB.Model(&ClusterHealth{}).Select("id").Where("cluster = ?", health.Cluster).
+		Order("id desc").Limit(keep)
+	result := s.DB.Where("cluster = ? AND id NOT IN (?)", health.Cluster, latest).Delete(&ClusterHealth{})
+	return result.Error
+}
+
+// ListClusterHealth returns the latest health records of the cluster in reverse chronological order, all the
+// records are returned if limit is not positive.
+func (s *Store) ListClusterHealth(cluster string, limit int) ([]*ClusterHealth, error) {
+	list := []*ClusterHealth{}
+	db := s.DB.Where("cluster = ?", cluster).Order("id desc")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	result := db.Find(&list)
+	return list, result.Error
+}
+
+// DeleteClusterHealth removes the health history of the cluster.
+func (s *Store) DeleteClusterHealth(cluster string) error {
+	result := s.DB.Where("cluster = ?", cluster).Delete(&ClusterHealth{})
+	return result.Error
+}
+
+// BroadcastClusterHealth sends the health of cluster to the watchers of cluster health.
+func (s *Store) BroadcastClusterHealth(health *apis.ClusterHealth) {
+	s.BroadcastObject(&event{
+		Name: apitypes.ChangeAPIEvent,
+		Object: &apitypes.APIObject{
+			Type:   getSchemaID(&apis.ClusterHealth{}),
+			ID:     health.Cluster,
+			Object: health,
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	apitypes "github.com/rancher/apiserver/pkg/types"
+)
+
+const (
+	// PolicyActionStop the cluster is stopped by the schedule of the policy.
+	PolicyActionStop = "stop"
+	// PolicyActionStart the cluster is started by the schedule of the policy.
+	PolicyActionStart = "start"
+	// PolicyActionDelete the cluster is deleted when it's expired.
+	PolicyActionDelete = "delete"
+
+	// PolicyWarningEvent is the name of the event broadcast before the scheduled action of the policy is taken.
+	PolicyWarningEvent = "resource.warning"
+)
+
+// ClusterPolicy is the scheduled start/stop window, the time to live, the auto-heal and the autoscaling bounds of the
+// cluster.
+type ClusterPolicy struct {
+	// StopAt and StartAt are the time of day in format HH:MM to stop and start the cluster.
+	StopAt  string `json:"stop-at,omitempty" yaml:"stop-at,omitempty"`
+	StartAt string `json:"start-at,omitempty" yaml:"start-at,omitempty"`
+	// ScheduleDays are the days of week the schedule applies to, e.g. weekdays, sat,sun or mon-fri, default to every day.
+	ScheduleDays string `json:"schedule-days,omitempty" yaml:"schedule-days,omitempty"`
+	// Timezone is the IANA time zone of the schedule, default to the local time zone of autok3s.
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	// ExpireAt is the time to delete the cluster, the cluster is kept forever if it's nil.
+	ExpireAt *time.Time `json:"expire-at,omitempty" yaml:"expire-at,omitempty"`
+	// AutoHeal replaces the failed workers of the cluster, which is enforced by the health monitor of autok3s serve.
+	AutoHeal bool `json:"auto-heal,omitempty" yaml:"auto-heal,omitempty"`
+	// AutoHealGracePeriod is how long a worker can be NotReady before it's replaced, default to 10m.
+	AutoHealGracePeriod string `json:"auto-heal-grace-period,omitempty" yaml:"auto-heal-grace-period,omitempty"`
+	// AutoscaleMin and AutoscaleMax are the bounds of workers scaled by cluster-autoscaler, autoscaling is disabled
+	// if AutoscaleMax is 0.
+	AutoscaleMin int `json:"autoscale-min,omitempty" yaml:"autoscale-min,omitempty"`
+	AutoscaleMax int `json:"autoscale-max,omitempty" yaml:"autoscale-max,omitempty"`
+}
+
+// IsEmpty returns whether there's nothing to enforce for the policy.
+func (p *ClusterPolicy) IsEmpty() bool {
+	return p.StopAt == "" && p.StartAt == "" && p.ExpireAt == nil && !p.AutoHeal && p.AutoscaleMax == 0
+}
+
+// PolicyWarning is broadcast before the scheduled destructive action of the cluster policy is taken.
+type PolicyWarning struct {
+	Cluster     string    `json:"cluster"`
+	Action      string    `json:"action"`
+	ScheduledAt time.Time `json:"scheduledAt"`
+	Message     string    `json:"message"`
+}
+
+// SaveClusterPolicy only updates the policy of the cluster state, so that it won't override the changes of running operations.
+func (d *Store) SaveClusterPolicy(state *ClusterState) error {
+	result := d.DB.Model(state).
+		Where("name = ? AND provider = ?", state.Name, state.Provider).
+		Select("stop_at", "start_at", "schedule_days", "timezone", "expire_at", "auto_heal", "auto_heal_grace_period",
+			"autoscale_min", "autoscale_max").
+		Updates(state)
+	return result.Error
+}
+
+// BroadcastPolicyWarning sends the warning of cluster policy to the watchers of cluster policy.
+func (d *Store) BroadcastPolicyWarning(warning *PolicyWarning) {
+	d.broadcaster.Broadcast(&event{
+		Name: PolicyWarningEvent,
+		Object: &apitypes.APIObject{
+			Type:   getSchemaID(&apis.ClusterPolicy{}),
+			ID:     warning.Cluster,
+			Object: warning,
+		},
+	})
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"time"
+)
+
+// ClusterStatusCache is the cached list info of the running cluster, it's refreshed in the background by serve
+// so that listing clusters doesn't need to call the cloud API and Kubernetes API of every cluster.
+type ClusterStatusCache struct {
+	// ContextName is the context name of the cluster.
+	ContextName string `json:"contextName" gorm:"primaryKey;not null"`
+	Provider    string `json:"provider"`
+	// Info is the JSON of types.ClusterInfo.
+	Info        []byte    `json:"info"`
+	RefreshedAt time.Time `json:"refreshedAt"`
+}
+
+// SaveClusterStatusCache creates or replaces the cached status of the cluster.
+func (s *Store) SaveClusterStatusCache(cache *ClusterStatusCache) error {
+	result := s.DB.Save(cache)
+	return result.Error
+}
+
+// ListClusterStatusCache returns the cached status of clusters, all the caches are returned if provider is empty.
+func (s *Store) ListClusterStatusCache(provider string) ([]*ClusterStatusCache, error) {
+	list := []*ClusterStatusCache{}
+	db := s.DB
+	if provider != "" {
+		db = db.Where("provider = ?", provider)
+	}
+	result := db.Find(&list)
+	return list, result.Error
+}
+
+func (s *Store) DeleteClusterStatusCache(contextName string) error {
+	result := s.DB.Where("context_name = ?", contextName).Delete(&ClusterStatusCache{})
+	return result.Error
+}
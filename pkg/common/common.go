@@ -13,6 +13,8 @@ import (
 const (
 	// KubeCfgFile default kube config file path.
 	KubeCfgFile = ".kube/config"
+	// ClusterKubeCfgFile the standalone kube config file name under the cluster path.
+	ClusterKubeCfgFile = "kubeconfig"
 	// KubeCfgTempName default temp kube config file name prefix.
 	KubeCfgTempName = "autok3s-temp-*"
 	// K3sManifestsDir k3s manifests dir.
@@ -35,6 +37,12 @@ const (
 	StatusUpgrading = "Upgrading"
 	// StatusRemoving instance removing status.
 	StatusRemoving = "Removing"
+	// StatusStopping instance stopping status.
+	StatusStopping = "Stopping"
+	// StatusStopped instance stopped status.
+	StatusStopped = "Stopped"
+	// StatusStarting instance starting status.
+	StatusStarting = "Starting"
 	// StatusUnknown instance unknown status
 	StatusUnknown = "Unknown"
 	// UsageInfoTitle usage info title.
@@ -85,6 +93,11 @@ func GetClusterPath(clusterName, providerName string) string {
 	return filepath.Join(CfgPath, providerName, "clusters", clusterName)
 }
 
+// GetClusterKubeCfgPath returns the standalone kube config path of the cluster.
+func GetClusterKubeCfgPath(contextName, providerName string) string {
+	return filepath.Join(GetClusterPath(contextName, providerName), ClusterKubeCfgFile)
+}
+
 // GetDataSource return default database file path.
 func GetDataSource() string {
 	return filepath.Join(CfgPath, DBFolder, DBFile)
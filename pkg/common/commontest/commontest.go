@@ -0,0 +1,29 @@
+// Package commontest provides the test helpers of the common package for the tests of other packages.
+package commontest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+)
+
+// InitStorage initializes the storage and the config file manager in a temporary config dir for the test,
+// the original ones are restored after the test.
+func InitStorage(t testing.TB) {
+	t.Helper()
+	originPath, originDB, originManager := common.CfgPath, common.DefaultDB, common.FileManager
+	common.CfgPath = t.TempDir()
+	common.FileManager = &common.ConfigFileManager{}
+	t.Cleanup(func() {
+		if common.DefaultDB != nil && common.DefaultDB != originDB {
+			if db, err := common.DefaultDB.DB.DB(); err == nil {
+				_ = db.Close()
+			}
+		}
+		common.CfgPath, common.DefaultDB, common.FileManager = originPath, originDB, originManager
+	})
+	if err := common.InitStorage(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -130,25 +130,55 @@ func InitStorage(ctx context.Context) error {
 		&Setting{},
 		&SSHKey{},
 		&Addon{},
+		&ClusterAddon{},
+		&AddonRepo{},
+		&CatalogAddon{},
+		&KubeconfigCredential{},
+		&ClusterStatusCache{},
+		&ClusterHealth{},
+		&NodeRepair{},
 	); err != nil {
 		return err
 	}
 
 	DefaultDB = store
 
-	_, err = DefaultDB.GetAddon("rancher")
+	rancherAddon, err := DefaultDB.GetAddon("rancher")
 	if err != nil && err == gorm.ErrRecordNotFound {
 		// init default add-on for Rancher Manager
-		rancherAddon := &Addon{
-			Name:        "rancher",
-			Description: "Default Rancher Manager add-on",
-			Manifest:    []byte(DefaultRancherManifest),
-			Values:      make(types.StringMap),
+		rancherAddon = &Addon{
+			Name:            "rancher",
+			Description:     "Default Rancher Manager add-on",
+			Manifest:        []byte(DefaultRancherManifest),
+			Values:          make(types.StringMap),
+			ReadinessChecks: defaultRancherReadinessChecks,
 		}
 		err = DefaultDB.SaveAddon(rancherAddon)
 		if err != nil {
 			logrus.Errorf("failed to save default rancher manager add-on template: %v", err)
 		}
+	} else if err == nil && len(rancherAddon.ReadinessChecks) == 0 && string(rancherAddon.Manifest) == DefaultRancherManifest {
+		// the add-on saved by the previous versions has no readiness check.
+		rancherAddon.ReadinessChecks = defaultRancherReadinessChecks
+		if err = DefaultDB.SaveAddon(rancherAddon); err != nil {
+			logrus.Errorf("failed to save readiness checks of default rancher manager add-on template: %v", err)
+		}
+	}
+
+	_, err = DefaultDB.GetAddon("cluster-autoscaler")
+	if err != nil && err == gorm.ErrRecordNotFound {
+		// init default add-on for cluster-autoscaler with the autoscaler endpoint of autok3s
+		autoscalerAddon := &Addon{
+			Name:         "cluster-autoscaler",
+			Description:  "Default cluster-autoscaler add-on",
+			Manifest:     []byte(DefaultClusterAutoscalerManifest),
+			Values:       make(types.StringMap),
+			ValuesSchema: []byte(defaultClusterAutoscalerValuesSchema),
+		}
+		err = DefaultDB.SaveAddon(autoscalerAddon)
+		if err != nil {
+			logrus.Errorf("failed to save default cluster-autoscaler add-on template: %v", err)
+		}
 	}
 
 	return settings.SetProvider(&DBSettingProvider{})
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/cnrancher/autok3s/pkg/utils"
+
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/client-go/tools/clientcmd"
@@ -46,6 +48,66 @@ func (c *ConfigFileManager) SaveCfg(context, tempFile string) error {
 	return c.OverwriteCfg(filepath.Join(CfgPath, KubeCfgFile), context, c.MergeCfg)
 }
 
+// SaveClusterCfg saves the standalone kube config of the cluster, which doesn't need the lock of the merged kube config.
+func (c *ConfigFileManager) SaveClusterCfg(context, provider string, data []byte) error {
+	path := GetClusterKubeCfgPath(context, provider)
+	if err := utils.EnsureFolderExist(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// RemoveClusterCfg removes the standalone kube config of the cluster.
+func (c *ConfigFileManager) RemoveClusterCfg(context, provider string) error {
+	err := os.Remove(GetClusterKubeCfgPath(context, provider))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// GetClusterCfg returns the standalone kube config of the cluster,
+// the config is extracted from the merged kube config for the cluster created before the standalone config is supported.
+func (c *ConfigFileManager) GetClusterCfg(context, provider string) (*api.Config, error) {
+	config, err := clientcmd.LoadFromFile(GetClusterKubeCfgPath(context, provider))
+	if err == nil {
+		return config, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	merged, err := c.LoadCfg()
+	if err != nil {
+		return nil, err
+	}
+	return ExtractContext(merged, context)
+}
+
+// LoadCfg loads the merged kube config.
+func (c *ConfigFileManager) LoadCfg() (*api.Config, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return clientcmd.LoadFromFile(filepath.Join(CfgPath, KubeCfgFile))
+}
+
+// ExtractContext returns the kube config which only contains the context and its cluster and user.
+func ExtractContext(config *api.Config, context string) (*api.Config, error) {
+	ctx, ok := config.Contexts[context]
+	if !ok {
+		return nil, fmt.Errorf("context %s is not found in kubeconfig", context)
+	}
+	rtn := api.NewConfig()
+	rtn.Contexts[context] = ctx.DeepCopy()
+	if cluster, ok := config.Clusters[ctx.Cluster]; ok {
+		rtn.Clusters[ctx.Cluster] = cluster.DeepCopy()
+	}
+	if authInfo, ok := config.AuthInfos[ctx.AuthInfo]; ok {
+		rtn.AuthInfos[ctx.AuthInfo] = authInfo.DeepCopy()
+	}
+	rtn.CurrentContext = context
+	return rtn, nil
+}
+
 // OverwriteCfg overwrites kubectl config file.
 func (c *ConfigFileManager) OverwriteCfg(path string, context string, cfg func(string, clientcmd.ConfigAccess) (*api.Config, error)) error {
 	c.mutex.Lock()
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"time"
+)
+
+const (
+	// KubeconfigCredentialServiceAccount the scoped kubeconfig is bound to the token of a service account.
+	KubeconfigCredentialServiceAccount = "serviceaccount"
+	// KubeconfigCredentialCertificate the scoped kubeconfig is bound to a client certificate.
+	KubeconfigCredentialCertificate = "certificate"
+)
+
+// KubeconfigCredential is the credential issued for the scoped kubeconfig of the cluster.
+type KubeconfigCredential struct {
+	// Cluster is the context name of the cluster.
+	Cluster string `json:"cluster" gorm:"primaryKey;not null"`
+	Name    string `json:"name" gorm:"primaryKey;not null"`
+	// Kind is serviceaccount or certificate.
+	Kind        string `json:"kind"`
+	ClusterRole string `json:"clusterRole"`
+	// Namespace is the scope of the role binding, the cluster role is bound to the whole cluster if it's empty.
+	Namespace string `json:"namespace,omitempty"`
+	// Subject is the service account in format <namespace>/<name> or the user name of the client certificate.
+	Subject   string    `json:"subject"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Expired returns whether the credential is expired.
+func (c *KubeconfigCredential) Expired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+func (s *Store) CreateKubeconfigCredential(credential *KubeconfigCredential) error {
+	result := s.DB.Create(credential)
+	return result.Error
+}
+
+func (s *Store) GetKubeconfigCredential(cluster, name string) (*KubeconfigCredential, error) {
+	credential := &KubeconfigCredential{}
+	result := s.DB.Where("cluster = ? AND name = ?", cluster, name).Find(credential)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return credential, nil
+}
+
+// ListKubeconfigCredentials returns the credentials of the cluster, all the credentials are returned if cluster is empty.
+func (s *Store) ListKubeconfigCredentials(cluster string) ([]*KubeconfigCredential, error) {
+	list := []*KubeconfigCredential{}
+	db := s.DB
+	if cluster != "" {
+		db = db.Where("cluster = ?", cluster)
+	}
+	result := db.Order("cluster").Order("name").Find(&list)
+	return list, result.Error
+}
+
+func (s *Store) DeleteKubeconfigCredential(cluster, name string) error {
+	result := s.DB.Where("cluster = ? AND name = ?", cluster, name).Delete(&KubeconfigCredential{})
+	return result.Error
+}
+
+// DeleteKubeconfigCredentials removes all the credential records of the cluster.
+func (s *Store) DeleteKubeconfigCredentials(cluster string) error {
+	result := s.DB.Where("cluster = ?", cluster).Delete(&KubeconfigCredential{})
+	return result.Error
+}
@@ -3,10 +3,12 @@ package common
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
 	"helm.sh/helm/v3/pkg/strvals"
 
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -25,6 +27,49 @@ func GenerateValues(setValues map[string]string, defaultValues map[string]string
 	return mergeValues(values)
 }
 
+// ValidateValuesSchema checks the add-on values schema is a valid JSON schema.
+func ValidateValuesSchema(schema []byte) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schema)); err != nil {
+		return errors.Wrap(err, "invalid values schema")
+	}
+	return nil
+}
+
+// ValidateValues validates the generated values with the add-on values schema, nothing to do if the schema is empty.
+func ValidateValues(schema []byte, values map[string]interface{}) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewGoLoader(values))
+	if err != nil {
+		return errors.Wrap(err, "failed to validate values")
+	}
+	if result.Valid() {
+		return nil
+	}
+	msgs := make([]string, 0, len(result.Errors()))
+	for _, e := range result.Errors() {
+		msgs = append(msgs, e.String())
+	}
+	return fmt.Errorf("invalid values: %s", strings.Join(msgs, "; "))
+}
+
+// RenderAddon generates the values with the set values and the add-on default values,
+// validates them with the add-on values schema and renders the add-on manifest.
+func RenderAddon(addon *Addon, setValues map[string]string, templateFunc template.FuncMap) ([]byte, error) {
+	values, err := GenerateValues(setValues, addon.Values)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateValues(addon.ValuesSchema, values); err != nil {
+		return nil, err
+	}
+	return AssembleManifest(values, string(addon.Manifest), templateFunc)
+}
+
 func AssembleManifest(values map[string]interface{}, manifest string, templateFunc template.FuncMap) ([]byte, error) {
 	t := template.New("manifest").Funcs(sprig.TxtFuncMap())
 	if templateFunc != nil {
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"reflect"
@@ -27,6 +28,9 @@ var (
 		&Package{},
 		&SSHKey{},
 		&Addon{},
+		&ClusterAddon{},
+		&AddonRepo{},
+		&CatalogAddon{},
 	}
 )
 
@@ -39,6 +43,7 @@ type ClusterState struct {
 	MasterNodes    []byte `json:"master-nodes,omitempty" gorm:"type:bytes"`
 	WorkerNodes    []byte `json:"worker-nodes,omitempty" gorm:"type:bytes"`
 	types.SSH      `json:",inline" mapstructure:",squash" gorm:"embedded"`
+	ClusterPolicy  `json:",inline" mapstructure:",squash" gorm:"embedded"`
 }
 
 func (c *ClusterState) SchemaID() string {
@@ -60,6 +65,11 @@ type Template struct {
 	Options        []byte `json:"options,omitempty" gorm:"type:bytes"`
 	types.SSH      `json:",inline" mapstructure:",squash" gorm:"embedded"`
 	IsDefault      bool `json:"is-default" gorm:"type:bool"`
+	// Parent is the name of the template of the same provider which this template extends.
+	Parent     string                   `json:"parent,omitempty"`
+	Parameters types.TemplateParameters `json:"parameters,omitempty"`
+	// Overrides are the fields and options set by the template, which override the parent even if they're zero values.
+	Overrides types.StringArray `json:"overrides,omitempty" gorm:"type:stringArray"`
 }
 
 func (t *Template) SchemaID() string {
@@ -383,6 +393,8 @@ func (d *Store) SaveCluster(cluster *types.Cluster) error {
 		WorkerNodes: workerNodeBytes,
 		SSH:         cluster.SSH,
 		Standalone:  cluster.Status.Standalone,
+		// the policy is managed separately, keep it as is.
+		ClusterPolicy: state.ClusterPolicy,
 	}
 
 	if result.RowsAffected == 0 {
@@ -534,16 +546,19 @@ func (d *Store) GetTemplate(name, provider string) (*Template, error) {
 
 func toTemplate(temp *Template) *apis.ClusterTemplate {
 	c := &apis.ClusterTemplate{
-		Metadata:  temp.Metadata,
-		SSH:       temp.SSH,
-		IsDefault: temp.IsDefault,
+		Metadata:   temp.Metadata,
+		SSH:        temp.SSH,
+		IsDefault:  temp.IsDefault,
+		Parent:     temp.Parent,
+		Parameters: temp.Parameters,
+		Overrides:  temp.Overrides,
 	}
 	p, err := providers.GetProvider(temp.Provider)
 	if err != nil {
 		logrus.Errorf("failed to get provider by name %s", temp.Provider)
 		return c
 	}
-	opt, err := p.GetProviderOptions(temp.Options)
+	opt, err := TemplateOptions(p, temp.Options)
 	if err != nil {
 		logrus.Errorf("failed to convert [%s] provider options %s: %v", temp.Provider, string(temp.Options), err)
 		return c
@@ -552,6 +567,19 @@ func toTemplate(temp *Template) *apis.ClusterTemplate {
 	return c
 }
 
+// TemplateOptions converts the template options by provider, the options which refer to the template parameters
+// are returned as is because they are validated after the template is resolved.
+func TemplateOptions(p providers.Provider, options []byte) (interface{}, error) {
+	opt, err := p.GetProviderOptions(options)
+	if err != nil && bytes.Contains(options, []byte("${")) {
+		raw := map[string]interface{}{}
+		if json.Unmarshal(options, &raw) == nil {
+			return raw, nil
+		}
+	}
+	return opt, err
+}
+
 // CreateCredential create credential.
 func (d *Store) CreateCredential(cred *Credential) error {
 	// find exist provider credential.
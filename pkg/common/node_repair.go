@@ -0,0 +1,61 @@
+package common
+
+import (
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	apitypes "github.com/rancher/apiserver/pkg/types"
+)
+
+const (
+	// NodeRepairRepairing the failed worker is being removed and replaced.
+	NodeRepairRepairing = "Repairing"
+	// NodeRepairRepaired the failed worker is removed and the replacement is joined.
+	NodeRepairRepaired = "Repaired"
+	// NodeRepairFailed the failed worker is failed to be removed or replaced.
+	NodeRepairFailed = "Failed"
+
+	// NodeRepairEvent is the name of the event broadcast when the repair of failed worker is changed.
+	NodeRepairEvent = "resource.repair"
+)
+
+// NodeRepair is a record of the repair of failed worker by auto-heal.
+type NodeRepair struct {
+	ID              uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	apis.NodeRepair `json:",inline" gorm:"embedded"`
+}
+
+// SaveNodeRepair creates or updates the repair record.
+func (s *Store) SaveNodeRepair(repair *NodeRepair) error {
+	result := s.DB.Save(repair)
+	return result.Error
+}
+
+// ListNodeRepairs returns the latest repair records of the cluster in reverse chronological order, all the records
+// are returned if limit is not positive.
+func (s *Store) ListNodeRepairs(cluster string, limit int) ([]*NodeRepair, error) {
+	list := []*NodeRepair{}
+	db := s.DB.Where("cluster = ?", cluster).Order("id desc")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	result := db.Find(&list)
+	return list, result.Error
+}
+
+// DeleteNodeRepairs removes the repair records of the cluster.
+func (s *Store) DeleteNodeRepairs(cluster string) error {
+	result := s.DB.Where("cluster = ?", cluster).Delete(&NodeRepair{})
+	return result.Error
+}
+
+// BroadcastNodeRepair sends the repair of failed worker to the watchers of cluster health.
+func (s *Store) BroadcastNodeRepair(repair *apis.NodeRepair) {
+	s.BroadcastObject(&event{
+		Name: NodeRepairEvent,
+		Object: &apitypes.APIObject{
+			Type:   getSchemaID(&apis.ClusterHealth{}),
+			ID:     repair.Cluster,
+			Object: repair,
+		},
+	})
+}
@@ -29,6 +29,16 @@ type Package struct {
 	Archs      types.StringArray `json:"archs,omitempty" gorm:"type:text" wrangler:"required"`
 	FilePath   string            `json:"filePath,omitempty" wrangler:"nocreate,noupdate"`
 	State      State             `json:"state,omitempty" wrangler:"nocreate,noupdate"`
+	// Images and Charts are the extra resources bundled in the package, e.g. the images and charts used by add-ons.
+	Images types.StringArray `json:"images,omitempty" gorm:"type:text"`
+	Charts types.StringArray `json:"charts,omitempty" gorm:"type:text"`
+	// DownloadedSize and TotalSize are the bytes of the package resources started downloading,
+	// the TotalSize grows as the resources are started.
+	DownloadedSize int64 `json:"downloadedSize,omitempty" wrangler:"nocreate,noupdate"`
+	TotalSize      int64 `json:"totalSize,omitempty" wrangler:"nocreate,noupdate"`
+	// Progress is the download percentage of the package resources, which is counted by the resources to download,
+	// so it's only 100 after all of them are downloaded.
+	Progress int `json:"progress,omitempty" wrangler:"nocreate,noupdate"`
 }
 
 func (p Package) GetID() string {
@@ -57,6 +67,16 @@ func (s *Store) SavePackage(pkg Package) error {
 	return s.DB.Save(pkg).Error
 }
 
+// SavePackageProgress only updates the download progress of the package,
+// so that it won't overwrite the other fields saved at the same time, e.g. the state.
+func (s *Store) SavePackageProgress(name string, downloaded, total int64, progress int) error {
+	return s.DB.Model(&Package{}).Where("name = ?", name).Updates(map[string]interface{}{
+		"downloaded_size": downloaded,
+		"total_size":      total,
+		"progress":        progress,
+	}).Error
+}
+
 func (s *Store) DeletePackage(name string) error {
 	pkg, err := s.ListPackages(&name)
 	if err == gorm.ErrRecordNotFound {
@@ -1,5 +1,13 @@
 package common
 
+// defaultRancherReadinessChecks are the resources of the cert-manager and Rancher Manager deployed by the rancher add-on.
+var defaultRancherReadinessChecks = ReadinessChecks{
+	{Kind: ReadinessKindCRD, Name: "certificates.cert-manager.io"},
+	{Kind: ReadinessKindDeployment, Namespace: "cert-manager", Name: "cert-manager"},
+	{Kind: ReadinessKindDeployment, Namespace: "cert-manager", Name: "cert-manager-webhook"},
+	{Kind: ReadinessKindDeployment, Namespace: "cattle-system", Name: "rancher"},
+}
+
 var DefaultRancherManifest = `
 ---
 apiVersion: v1
@@ -0,0 +1,26 @@
+package common
+
+import (
+	"context"
+	"testing"
+)
+
+// initTestStorage is the same as commontest.InitStorage for the tests of this package,
+// which can't import commontest as it imports this package.
+func initTestStorage(t *testing.T) {
+	t.Helper()
+	originPath, originDB, originManager := CfgPath, DefaultDB, FileManager
+	CfgPath = t.TempDir()
+	FileManager = &ConfigFileManager{}
+	t.Cleanup(func() {
+		if DefaultDB != nil && DefaultDB != originDB {
+			if db, err := DefaultDB.DB.DB(); err == nil {
+				_ = db.Close()
+			}
+		}
+		CfgPath, DefaultDB, FileManager = originPath, originDB, originManager
+	})
+	if err := InitStorage(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,266 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultLatencyThreshold is the max latency of apiserver for a healthy cluster.
+	DefaultLatencyThreshold = time.Second
+	// DefaultSSHTimeout is the timeout to probe the SSH of nodes.
+	DefaultSSHTimeout = 10 * time.Second
+)
+
+// pressureConditions are the node conditions which are unhealthy when they're true.
+var pressureConditions = []v1.NodeConditionType{
+	v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure, v1.NodeNetworkUnavailable,
+}
+
+// Checker checks the health of clusters.
+type Checker struct {
+	LatencyThreshold time.Duration
+	SSHTimeout       time.Duration
+	// Limiter limits the calls of the cloud API to describe the instances, it's shared with the status refresher.
+	Limiter *cluster.ProviderLimiter
+}
+
+// Check returns the health of the apiserver, nodes, instances and SSH of the cluster.
+func (c *Checker) Check(ctx context.Context, state *common.ClusterState) *apis.ClusterHealthCheck {
+	check := &apis.ClusterHealthCheck{CheckedAt: time.Now()}
+	nodes := stateNodeHealth(state)
+
+	unreachable := true
+	kubeCfg := filepath.Join(common.CfgPath, common.KubeCfgFile)
+	client, err := cluster.GetClusterConfig(state.ContextName, kubeCfg)
+	if err != nil {
+		check.Problems = append(check.Problems, fmt.Sprintf("failed to generate kube client: %v", err))
+	} else {
+		start := time.Now()
+		_, err = client.RESTClient().Get().Timeout(15 * time.Second).AbsPath("/readyz").DoRaw(ctx)
+		check.APIServerLatency = time.Since(start).Milliseconds()
+		if err != nil {
+			check.Problems = append(check.Problems, fmt.Sprintf("apiserver is not ready: %v", err))
+		} else {
+			unreachable = false
+			timeout := int64(15)
+			list, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{TimeoutSeconds: &timeout})
+			if err != nil {
+				check.Problems = append(check.Problems, fmt.Sprintf("failed to list nodes: %v", err))
+			} else {
+				nodes = mergeKubeNodes(nodes, list.Items)
+			}
+		}
+	}
+
+	c.checkInstances(ctx, state, kubeCfg, nodes)
+	c.checkSSH(state, nodes)
+	for _, node := range nodes {
+		check.Nodes = append(check.Nodes, *node)
+	}
+	summarize(check, c.LatencyThreshold, unreachable)
+	return check
+}
+
+// checkInstances sets the instance status of nodes from the provider, the nodes whose instances are not found are
+// marked missing. The instance status is left empty if the context is done while waiting for the rate limit.
+func (c *Checker) checkInstances(ctx context.Context, state *common.ClusterState, kubeCfg string, nodes []*apis.NodeHealth) {
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		return
+	}
+	if c.Limiter != nil {
+		if err = c.Limiter.Wait(ctx, state.Provider); err != nil {
+			return
+		}
+	}
+	provider.SetMetadata(&state.Metadata)
+	_ = provider.SetOptions(state.Options)
+	provider.GenerateClusterName()
+	info := provider.DescribeCluster(kubeCfg)
+	if info == nil {
+		return
+	}
+	status := map[string]string{}
+	for _, n := range info.Nodes {
+		status[n.InstanceID] = n.InstanceStatus
+	}
+	for _, node := range nodes {
+		if node.InstanceID == "" {
+			continue
+		}
+		if s, ok := status[node.InstanceID]; ok {
+			node.InstanceStatus = s
+		} else if len(info.Nodes) > 0 {
+			// the other instances are found but not this one, which is terminated or deleted outside autok3s.
+			node.InstanceStatus = InstanceStatusMissing
+		}
+	}
+}
+
+// checkSSH probes the SSH of nodes at the same time, the nodes of k3d are containers which can't be reached by SSH.
+func (c *Checker) checkSSH(state *common.ClusterState, nodes []*apis.NodeHealth) {
+	if state.Provider == "k3d" {
+		return
+	}
+	timeout := c.SSHTimeout
+	if timeout <= 0 {
+		timeout = DefaultSSHTimeout
+	}
+	stateNodes := map[string]types.Node{}
+	for _, n := range clusterNodes(state) {
+		stateNodes[n.InstanceID] = n
+	}
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		n, ok := stateNodes[node.InstanceID]
+		if !ok || len(n.PublicIPAddress) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(node *apis.NodeHealth, n types.Node) {
+			defer wg.Done()
+			reachable := true
+			if err := cluster.ProbeSSH(n, &state.SSH, timeout); err != nil {
+				reachable = false
+				node.Problems = append(node.Problems, fmt.Sprintf("ssh is not reachable: %v", err))
+			}
+			node.SSHReachable = &reachable
+		}(node, n)
+	}
+	wg.Wait()
+}
+
+// clusterNodes returns the master and worker nodes of the cluster state.
+func clusterNodes(state *common.ClusterState) []types.Node {
+	var masterNodes, workerNodes []types.Node
+	_ = json.Unmarshal(state.MasterNodes, &masterNodes)
+	_ = json.Unmarshal(state.WorkerNodes, &workerNodes)
+	return append(masterNodes, workerNodes...)
+}
+
+// stateNodeHealth returns the nodes of the cluster state.
+func stateNodeHealth(state *common.ClusterState) []*apis.NodeHealth {
+	nodes := []*apis.NodeHealth{}
+	for _, n := range clusterNodes(state) {
+		node := &apis.NodeHealth{
+			InstanceID: n.InstanceID,
+			Master:     n.Master,
+			Ready:      string(v1.ConditionUnknown),
+		}
+		if len(n.PublicIPAddress) > 0 {
+			node.Address = n.PublicIPAddress[0]
+		}
+		if len(n.InternalIPAddress) > 0 {
+			node.Name = n.InternalIPAddress[0]
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// mergeKubeNodes sets the conditions of Kubernetes nodes to the nodes of cluster state, which are matched by the
+// addresses of nodes, the Kubernetes nodes which are not in the cluster state are appended.
+func mergeKubeNodes(nodes []*apis.NodeHealth, kubeNodes []v1.Node) []*apis.NodeHealth {
+	for i := range kubeNodes {
+		kubeNode := &kubeNodes[i]
+		var matched *apis.NodeHealth
+		for _, node := range nodes {
+			for _, address := range kubeNode.Status.Addresses {
+				if address.Address != "" && (address.Address == node.Name || address.Address == node.Address ||
+					address.Address == node.InstanceID) {
+					matched = node
+					break
+				}
+			}
+			if matched != nil {
+				break
+			}
+		}
+		if matched == nil {
+			_, master := kubeNode.Labels["node-role.kubernetes.io/master"]
+			if _, ok := kubeNode.Labels["node-role.kubernetes.io/control-plane"]; ok {
+				master = true
+			}
+			matched = &apis.NodeHealth{Master: master}
+			nodes = append(nodes, matched)
+		}
+		matched.Name = kubeNode.Name
+		evaluateConditions(matched, kubeNode.Status.Conditions)
+	}
+	return nodes
+}
+
+// evaluateConditions sets the Ready and pressure conditions of the node.
+func evaluateConditions(node *apis.NodeHealth, conditions []v1.NodeCondition) {
+	node.Ready = string(v1.ConditionUnknown)
+	node.Pressures = nil
+	for _, condition := range conditions {
+		if condition.Type == v1.NodeReady {
+			node.Ready = string(condition.Status)
+			continue
+		}
+		for _, t := range pressureConditions {
+			if condition.Type == t && condition.Status == v1.ConditionTrue {
+				node.Pressures = append(node.Pressures, string(t))
+			}
+		}
+	}
+}
+
+// summarize sets the problems of nodes and the status of the check.
+func summarize(check *apis.ClusterHealthCheck, latencyThreshold time.Duration, unreachable bool) {
+	if latencyThreshold <= 0 {
+		latencyThreshold = DefaultLatencyThreshold
+	}
+	degraded := false
+	if !unreachable && check.APIServerLatency > latencyThreshold.Milliseconds() {
+		degraded = true
+		check.Problems = append(check.Problems, fmt.Sprintf("apiserver latency %dms exceeds %s",
+			check.APIServerLatency, latencyThreshold))
+	}
+	for i := range check.Nodes {
+		node := &check.Nodes[i]
+		if !unreachable && node.Ready != string(v1.ConditionTrue) {
+			node.Problems = append(node.Problems, fmt.Sprintf("node is not ready: %s", node.Ready))
+		}
+		for _, pressure := range node.Pressures {
+			node.Problems = append(node.Problems, fmt.Sprintf("node has %s", pressure))
+		}
+		if node.InstanceStatus != "" && node.InstanceStatus != "-" && !strings.EqualFold(node.InstanceStatus, "running") {
+			node.Problems = append(node.Problems, fmt.Sprintf("instance is %s", node.InstanceStatus))
+		}
+		sort.Strings(node.Problems)
+		name := node.Name
+		if name == "" {
+			name = node.InstanceID
+		}
+		for _, problem := range node.Problems {
+			degraded = true
+			check.Problems = append(check.Problems, fmt.Sprintf("%s: %s", name, problem))
+		}
+	}
+	switch {
+	case unreachable:
+		check.Status = common.HealthStatusUnhealthy
+	case degraded:
+		check.Status = common.HealthStatusDegraded
+	default:
+		check.Status = common.HealthStatusHealthy
+	}
+}
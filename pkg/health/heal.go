@@ -0,0 +1,284 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/policy"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultMaxConcurrentRepairs is the max number of workers repaired at the same time.
+	DefaultMaxConcurrentRepairs = 2
+	// InstanceStatusMissing is the instance status of the node which isn't found from the provider.
+	InstanceStatusMissing = "missing"
+)
+
+// goneStatuses are the instance status of nodes which are not coming back, the nodes are replaced without waiting.
+var goneStatuses = []string{InstanceStatusMissing, "terminated", "terminating", "shutting-down", "deleted"}
+
+// Healer removes the failed workers of the clusters whose policy enables auto-heal, and joins the replacements by
+// the provider with the same options.
+type Healer struct {
+	// MaxConcurrentRepairs is the max number of workers repaired at the same time across the clusters.
+	MaxConcurrentRepairs int
+
+	slots chan struct{}
+	// notReadySince holds the time since when the workers are found not ready, keyed by cluster and instance id.
+	notReadySince map[string]map[string]time.Time
+	// busy holds the clusters being repaired, the failed workers of a cluster are repaired in a batch.
+	busy sync.Map
+	wg   sync.WaitGroup
+
+	now    func() time.Time
+	repair func(state *common.ClusterState, ids []string) error
+}
+
+// failure is the failed worker and the reason.
+type failure struct {
+	node   apis.NodeHealth
+	reason string
+}
+
+// NewHealer returns the healer which repairs no more than maxConcurrentRepairs workers at the same time.
+func NewHealer(maxConcurrentRepairs int) *Healer {
+	if maxConcurrentRepairs <= 0 {
+		maxConcurrentRepairs = DefaultMaxConcurrentRepairs
+	}
+	return &Healer{
+		MaxConcurrentRepairs: maxConcurrentRepairs,
+		slots:                make(chan struct{}, maxConcurrentRepairs),
+		notReadySince:        map[string]map[string]time.Time{},
+		now:                  time.Now,
+		repair:               replaceWorkers,
+	}
+}
+
+// Heal finds the failed workers of the cluster by the health check and repairs them in background. The nodes are not
+// judged if the apiserver is unreachable, and the workers exceeding the limit of repairs are left to the later checks.
+func (h *Healer) Heal(state *common.ClusterState, check *apis.ClusterHealthCheck) {
+	if !state.AutoHeal {
+		delete(h.notReadySince, state.ContextName)
+		return
+	}
+	if check.Status == common.HealthStatusUnhealthy {
+		return
+	}
+	if _, ok := h.busy.Load(state.ContextName); ok {
+		return
+	}
+	failed := h.failedWorkers(state, check)
+	if len(failed) == 0 {
+		return
+	}
+	acquired := 0
+acquire:
+	for acquired < len(failed) {
+		select {
+		case h.slots <- struct{}{}:
+			acquired++
+		default:
+			break acquire
+		}
+	}
+	if acquired == 0 {
+		logrus.Infof("[health] %d failed workers of cluster %s are waiting for repair", len(failed), state.ContextName)
+		return
+	}
+	failed = failed[:acquired]
+	h.busy.Store(state.ContextName, true)
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer h.busy.Delete(state.ContextName)
+		defer func() {
+			for range failed {
+				<-h.slots
+			}
+		}()
+		// the nodes are changed with the lock shared with the autoscaler, and the latest state is used as the workers
+		// may be changed while waiting for the lock.
+		defer cluster.LockNodes(state.ContextName)()
+		if latest, err := common.DefaultDB.GetClusterByID(state.ContextName); err == nil && latest != nil {
+			state = latest
+		}
+		h.run(state, failed)
+	}()
+}
+
+// Wait waits for the repairs in progress.
+func (h *Healer) Wait() {
+	h.wg.Wait()
+}
+
+// failedWorkers returns the workers whose instances are gone, or which are not ready longer than the grace period.
+func (h *Healer) failedWorkers(state *common.ClusterState, check *apis.ClusterHealthCheck) []failure {
+	now := h.now()
+	grace := policy.GracePeriod(&state.ClusterPolicy)
+	last := h.notReadySince[state.ContextName]
+	notReadySince := map[string]time.Time{}
+	failed := []failure{}
+	for _, node := range check.Nodes {
+		// the nodes without instance id are not in the cluster state, which are not managed by autok3s.
+		if node.Master || node.InstanceID == "" {
+			continue
+		}
+		if isGone(node.InstanceStatus) {
+			failed = append(failed, failure{node: node, reason: fmt.Sprintf("instance is %s", node.InstanceStatus)})
+			continue
+		}
+		if node.Ready == string(v1.ConditionTrue) {
+			continue
+		}
+		since, ok := last[node.InstanceID]
+		if !ok {
+			since = now
+		}
+		notReadySince[node.InstanceID] = since
+		if now.Sub(since) >= grace {
+			failed = append(failed, failure{node: node,
+				reason: fmt.Sprintf("node is not ready for %s", now.Sub(since).Round(time.Second))})
+		}
+	}
+	h.notReadySince[state.ContextName] = notReadySince
+	return failed
+}
+
+// run repairs the failed workers and records the repairs.
+func (h *Healer) run(state *common.ClusterState, failed []failure) {
+	repairs := make([]*common.NodeRepair, 0, len(failed))
+	ids := make([]string, 0, len(failed))
+	for _, f := range failed {
+		logrus.Infof("[health] repairing worker %s of cluster %s: %s", f.node.InstanceID, state.ContextName, f.reason)
+		repair := &common.NodeRepair{NodeRepair: apis.NodeRepair{
+			Cluster:    state.ContextName,
+			InstanceID: f.node.InstanceID,
+			Node:       f.node.Name,
+			Reason:     f.reason,
+			Status:     common.NodeRepairRepairing,
+			StartedAt:  h.now(),
+		}}
+		recordRepair(repair)
+		repairs = append(repairs, repair)
+		ids = append(ids, f.node.InstanceID)
+	}
+
+	err := h.repair(state, ids)
+	finishedAt := h.now()
+	for _, repair := range repairs {
+		repair.FinishedAt = &finishedAt
+		repair.Status = common.NodeRepairRepaired
+		if err != nil {
+			repair.Status = common.NodeRepairFailed
+			repair.Message = err.Error()
+		}
+		recordRepair(repair)
+	}
+	if err != nil {
+		logrus.Errorf("[health] failed to repair workers %v of cluster %s: %v", ids, state.ContextName, err)
+		return
+	}
+	logrus.Infof("[health] successfully repaired workers %v of cluster %s", ids, state.ContextName)
+}
+
+func recordRepair(repair *common.NodeRepair) {
+	if err := common.DefaultDB.SaveNodeRepair(repair); err != nil {
+		logrus.Warnf("[health] failed to save repair of worker %s of cluster %s: %v", repair.InstanceID, repair.Cluster, err)
+	}
+	common.DefaultDB.BroadcastNodeRepair(&repair.NodeRepair)
+}
+
+func isGone(instanceStatus string) bool {
+	for _, status := range goneStatuses {
+		if strings.EqualFold(instanceStatus, status) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceWorkers removes the failed workers, and joins the same number of workers by the provider with the options of
+// the cluster, the replacements are joined to the node pools of the failed workers.
+func replaceWorkers(state *common.ClusterState, ids []string) error {
+	ids = existingWorkers(state, ids)
+	if len(ids) == 0 {
+		return nil
+	}
+	pools := workerPools(state, ids)
+	provider, err := cluster.ProviderFromState(state, state.Master, state.Worker)
+	if err != nil {
+		return err
+	}
+	if err = provider.RemoveK3sNodes(ids); err != nil {
+		return fmt.Errorf("failed to remove workers: %v", err)
+	}
+
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		joined := *state
+		joined.NodePool = name
+		provider, err = cluster.ProviderFromState(&joined, "0", strconv.Itoa(pools[name]))
+		if err != nil {
+			return err
+		}
+		provider.RegisterCallbacks(state.ContextName, "update", common.DefaultDB.BroadcastObject)
+		if err = provider.JoinCheck(); err != nil {
+			return fmt.Errorf("failed to join replacements: %v", err)
+		}
+		if err = provider.JoinK3sNode(); err != nil {
+			return fmt.Errorf("failed to join replacements: %v", err)
+		}
+	}
+	return nil
+}
+
+// existingWorkers returns the ids of the workers still in the cluster, the failed workers may be already removed, e.g.
+// by the autoscaler.
+func existingWorkers(state *common.ClusterState, ids []string) []string {
+	workers := []types.Node{}
+	_ = json.Unmarshal(state.WorkerNodes, &workers)
+	existing := make([]string, 0, len(ids))
+	for _, id := range ids {
+		for _, n := range workers {
+			if n.InstanceID == id {
+				existing = append(existing, id)
+				break
+			}
+		}
+	}
+	return existing
+}
+
+// workerPools returns the number of the workers in each node pool, the workers without pool are counted by "".
+func workerPools(state *common.ClusterState, ids []string) map[string]int {
+	workers := []types.Node{}
+	_ = json.Unmarshal(state.WorkerNodes, &workers)
+	pools := map[string]int{}
+	for _, id := range ids {
+		pool := ""
+		for _, n := range workers {
+			if n.InstanceID == id {
+				pool = n.Pool
+				break
+			}
+		}
+		pools[pool]++
+	}
+	return pools
+}
@@ -0,0 +1,382 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/common/commontest"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMergeKubeNodes(t *testing.T) {
+	nodes := []*apis.NodeHealth{
+		{InstanceID: "i-1", Name: "10.0.0.1", Address: "1.1.1.1", Master: true, Ready: "Unknown"},
+		{InstanceID: "i-2", Name: "10.0.0.2", Address: "2.2.2.2", Ready: "Unknown"},
+	}
+	kubeNodes := []v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "server-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+				Conditions: []v1.NodeCondition{
+					{Type: v1.NodeReady, Status: v1.ConditionTrue},
+					{Type: v1.NodeDiskPressure, Status: v1.ConditionTrue},
+					{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent-3", Labels: map[string]string{}},
+			Status: v1.NodeStatus{
+				Addresses:  []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.3"}},
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}},
+			},
+		},
+	}
+	nodes = mergeKubeNodes(nodes, kubeNodes)
+	assert.Len(t, nodes, 3)
+	assert.Equal(t, "server-1", nodes[0].Name)
+	assert.Equal(t, "True", nodes[0].Ready)
+	assert.Equal(t, []string{"DiskPressure"}, nodes[0].Pressures)
+	assert.Equal(t, "Unknown", nodes[1].Ready)
+	assert.Equal(t, "agent-3", nodes[2].Name)
+	assert.Equal(t, "False", nodes[2].Ready)
+
+	check := &apis.ClusterHealthCheck{APIServerLatency: 10}
+	for _, node := range nodes {
+		check.Nodes = append(check.Nodes, *node)
+	}
+	check.Nodes[1].InstanceStatus = "stopped"
+	summarize(check, time.Second, false)
+	assert.Equal(t, common.HealthStatusDegraded, check.Status)
+	assert.Equal(t, []string{
+		"server-1: node has DiskPressure",
+		"10.0.0.2: instance is stopped",
+		"10.0.0.2: node is not ready: Unknown",
+		"agent-3: node is not ready: False",
+	}, check.Problems)
+}
+
+func TestSummarize(t *testing.T) {
+	check := &apis.ClusterHealthCheck{
+		APIServerLatency: 10,
+		Nodes:            []apis.NodeHealth{{Name: "a", Ready: "True", InstanceStatus: "Running"}},
+	}
+	summarize(check, time.Second, false)
+	assert.Equal(t, common.HealthStatusHealthy, check.Status)
+	assert.Empty(t, check.Problems)
+
+	check = &apis.ClusterHealthCheck{APIServerLatency: 1500}
+	summarize(check, time.Second, false)
+	assert.Equal(t, common.HealthStatusDegraded, check.Status)
+
+	// the nodes are not ready as the apiserver is unreachable, which is not reported again.
+	check = &apis.ClusterHealthCheck{
+		Problems: []string{"apiserver is not ready"},
+		Nodes:    []apis.NodeHealth{{Name: "a", Ready: "Unknown"}},
+	}
+	summarize(check, time.Second, true)
+	assert.Equal(t, common.HealthStatusUnhealthy, check.Status)
+	assert.Equal(t, []string{"apiserver is not ready"}, check.Problems)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan *Transition, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		transition := &Transition{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(transition))
+		received <- transition
+	}))
+	defer server.Close()
+
+	transition := &Transition{Cluster: "test", From: common.HealthStatusHealthy, To: common.HealthStatusDegraded}
+	assert.NoError(t, NewWebhookNotifier(server.URL+"/hook").Notify(context.Background(), transition))
+	assert.Equal(t, transition.To, (<-received).To)
+	assert.Error(t, NewWebhookNotifier(server.URL+"/fail").Notify(context.Background(), transition))
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+	mails := make(chan string, 1)
+	go serveSMTP(l, mails)
+
+	n := &SMTPNotifier{Address: l.Addr().String(), From: "autok3s@example.com", To: []string{"ops@example.com"}}
+	assert.NoError(t, n.Notify(context.Background(), &Transition{
+		Cluster:  "test",
+		From:     common.HealthStatusHealthy,
+		To:       common.HealthStatusUnhealthy,
+		Problems: []string{"apiserver is not ready"},
+	}))
+	mail := <-mails
+	assert.Contains(t, mail, "Subject: [autok3s] cluster test is Unhealthy")
+	assert.Contains(t, mail, "cluster test changed from Healthy to Unhealthy")
+	assert.Contains(t, mail, "- apiserver is not ready")
+}
+
+func TestSMTPNotifierTimeout(t *testing.T) {
+	// the stand-in accepts the connection but never responds.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	transition := &Transition{Cluster: "test", To: common.HealthStatusUnhealthy}
+	n := &SMTPNotifier{Address: l.Addr().String(), From: "autok3s@example.com", To: []string{"ops@example.com"},
+		Timeout: 200 * time.Millisecond}
+	start := time.Now()
+	assert.Error(t, n.Notify(context.Background(), transition))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	n.Timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	assert.Error(t, n.Notify(ctx, transition))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// serveSMTP is the local stand-in of SMTP server which accepts a single mail.
+func serveSMTP(l net.Listener, mails chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case cmd == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			mails <- data.String()
+			reply("250 ok")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+type recordNotifier struct {
+	m           sync.Mutex
+	transitions []*Transition
+}
+
+func (n *recordNotifier) Notify(_ context.Context, t *Transition) error {
+	n.m.Lock()
+	defer n.m.Unlock()
+	n.transitions = append(n.transitions, t)
+	return nil
+}
+
+func TestMonitorSync(t *testing.T) {
+	commontest.InitStorage(t)
+
+	states := []*common.ClusterState{
+		{Metadata: types.Metadata{ContextName: "a"}, Status: common.StatusRunning},
+		{Metadata: types.Metadata{ContextName: "b"}, Status: common.StatusStopped},
+	}
+	status := common.HealthStatusHealthy
+	notifier := &recordNotifier{}
+	m := NewMonitor(&Checker{}, 2, notifier)
+	m.check = func(_ context.Context, state *common.ClusterState) *apis.ClusterHealthCheck {
+		assert.Equal(t, "a", state.ContextName)
+		return &apis.ClusterHealthCheck{Status: status, CheckedAt: time.Now()}
+	}
+
+	m.Sync(context.Background(), states)
+	assert.Empty(t, notifier.transitions)
+
+	status = common.HealthStatusDegraded
+	m.Sync(context.Background(), states)
+	m.Sync(context.Background(), states)
+	assert.Len(t, notifier.transitions, 1)
+	assert.Equal(t, common.HealthStatusHealthy, notifier.transitions[0].From)
+	assert.Equal(t, common.HealthStatusDegraded, notifier.transitions[0].To)
+
+	// the transition across the restart is notified by the recorded status.
+	status = common.HealthStatusHealthy
+	m = NewMonitor(&Checker{}, 2, notifier)
+	m.check = func(_ context.Context, _ *common.ClusterState) *apis.ClusterHealthCheck {
+		return &apis.ClusterHealthCheck{Status: status, CheckedAt: time.Now()}
+	}
+	m.Sync(context.Background(), states)
+	assert.Len(t, notifier.transitions, 2)
+	assert.Equal(t, common.HealthStatusDegraded, notifier.transitions[1].From)
+
+	h, err := GetClusterHealth("a", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, common.HealthStatusHealthy, h.Status)
+	// only 2 records are kept.
+	assert.Len(t, h.History, 2)
+	assert.Equal(t, common.HealthStatusDegraded, h.History[1].Status)
+
+	h, err = GetClusterHealth("b", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, h.Status)
+}
+
+func TestHealer(t *testing.T) {
+	commontest.InitStorage(t)
+
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	var repaired [][]string
+	repairErr := error(nil)
+	h := NewHealer(1)
+	h.now = func() time.Time { return now }
+	h.repair = func(_ *common.ClusterState, ids []string) error {
+		repaired = append(repaired, ids)
+		return repairErr
+	}
+	state := &common.ClusterState{
+		Metadata:      types.Metadata{ContextName: "a"},
+		Status:        common.StatusRunning,
+		ClusterPolicy: common.ClusterPolicy{AutoHeal: true, AutoHealGracePeriod: "10m"},
+	}
+	check := &apis.ClusterHealthCheck{
+		Status: common.HealthStatusDegraded,
+		Nodes: []apis.NodeHealth{
+			{Name: "master", InstanceID: "i-0", Master: true, Ready: "False"},
+			{Name: "gone", InstanceID: "i-1", Ready: "Unknown", InstanceStatus: InstanceStatusMissing},
+			{Name: "not-ready", InstanceID: "i-2", Ready: "False", InstanceStatus: "running"},
+			{Name: "stranger", Ready: "False"},
+		},
+	}
+
+	// the gone worker is repaired at once, the not ready one is waiting for the grace period.
+	h.Heal(state, check)
+	h.Wait()
+	assert.Equal(t, [][]string{{"i-1"}}, repaired)
+
+	check.Nodes = check.Nodes[2:]
+	now = now.Add(5 * time.Minute)
+	h.Heal(state, check)
+	h.Wait()
+	assert.Len(t, repaired, 1)
+
+	// nothing is judged when the apiserver is unreachable.
+	now = now.Add(6 * time.Minute)
+	h.Heal(state, &apis.ClusterHealthCheck{Status: common.HealthStatusUnhealthy, Nodes: check.Nodes})
+	h.Wait()
+	assert.Len(t, repaired, 1)
+
+	repairErr = errors.New("no capacity")
+	h.Heal(state, check)
+	h.Wait()
+	assert.Equal(t, []string{"i-2"}, repaired[1])
+
+	health, err := GetClusterHealth("a", 0)
+	assert.NoError(t, err)
+	assert.Len(t, health.Repairs, 2)
+	assert.Equal(t, common.NodeRepairFailed, health.Repairs[0].Status)
+	assert.Equal(t, "no capacity", health.Repairs[0].Message)
+	assert.Equal(t, "node is not ready for 11m0s", health.Repairs[0].Reason)
+	assert.Equal(t, common.NodeRepairRepaired, health.Repairs[1].Status)
+	assert.Equal(t, "instance is missing", health.Repairs[1].Reason)
+
+	// the workers exceeding the limit are left to the later checks.
+	block := make(chan struct{})
+	repaired = nil
+	h.repair = func(_ *common.ClusterState, ids []string) error {
+		repaired = append(repaired, ids)
+		<-block
+		return nil
+	}
+	gone := &apis.ClusterHealthCheck{
+		Status: common.HealthStatusDegraded,
+		Nodes: []apis.NodeHealth{
+			{InstanceID: "i-3", Ready: "Unknown", InstanceStatus: "terminated"},
+			{InstanceID: "i-4", Ready: "Unknown", InstanceStatus: "terminated"},
+		},
+	}
+	h.Heal(state, gone)
+	other := &common.ClusterState{Metadata: types.Metadata{ContextName: "b"}, ClusterPolicy: state.ClusterPolicy}
+	h.Heal(other, gone)
+	close(block)
+	h.Wait()
+	assert.Equal(t, [][]string{{"i-3"}}, repaired)
+
+	// the clusters without auto-heal are not repaired.
+	h.Heal(&common.ClusterState{Metadata: types.Metadata{ContextName: "c"}}, gone)
+	h.Wait()
+	assert.Len(t, repaired, 1)
+
+	// the repair waits for the nodes changed by the autoscaler.
+	unlock := cluster.LockNodes("a")
+	started := make(chan struct{}, 1)
+	h.repair = func(_ *common.ClusterState, _ []string) error {
+		started <- struct{}{}
+		return nil
+	}
+	h.Heal(state, gone)
+	select {
+	case <-started:
+		t.Fatal("repair should wait for the lock of nodes")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	h.Wait()
+	assert.Len(t, started, 1)
+}
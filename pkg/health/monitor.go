@@ -0,0 +1,190 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DefaultHistory is the number of health records kept for each cluster.
+	DefaultHistory = 100
+	// defaultConcurrency is the max number of clusters checked at the same time.
+	defaultConcurrency = 5
+)
+
+// Monitor checks the health of running clusters, saves the health history, broadcasts the health and notifies the
+// transitions of health status. The failed workers are repaired by the healer if it's set.
+type Monitor struct {
+	Checker   *Checker
+	History   int
+	Notifiers []Notifier
+	Healer    *Healer
+
+	// last holds the last health status of clusters.
+	last  map[string]string
+	check func(ctx context.Context, state *common.ClusterState) *apis.ClusterHealthCheck
+}
+
+// NewMonitor returns the health monitor.
+func NewMonitor(checker *Checker, history int, notifiers ...Notifier) *Monitor {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &Monitor{
+		Checker:   checker,
+		History:   history,
+		Notifiers: notifiers,
+		last:      map[string]string{},
+		check:     checker.Check,
+	}
+}
+
+// StartMonitor checks the health of clusters every interval until the context is done.
+func StartMonitor(ctx context.Context, interval time.Duration, m *Monitor) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		states, err := common.DefaultDB.ListCluster("")
+		if err != nil {
+			logrus.Warnf("[health] failed to list clusters: %v", err)
+			return
+		}
+		m.Sync(ctx, states)
+	}, interval)
+}
+
+// Sync checks the running clusters, the clusters in other status are not checked as they're not expected to work.
+func (m *Monitor) Sync(ctx context.Context, states []*common.ClusterState) {
+	running := []*common.ClusterState{}
+	for _, state := range states {
+		if state.Status == common.StatusRunning {
+			running = append(running, state)
+		} else {
+			delete(m.last, state.ContextName)
+		}
+	}
+	checks := make([]*apis.ClusterHealthCheck, len(running))
+	sem := make(chan struct{}, defaultConcurrency)
+	var wg sync.WaitGroup
+	for i, state := range running {
+		wg.Add(1)
+		go func(i int, state *common.ClusterState) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			checks[i] = m.check(ctx, state)
+		}(i, state)
+	}
+	wg.Wait()
+
+	for i, state := range running {
+		m.record(ctx, state.ContextName, checks[i])
+		if m.Healer != nil {
+			m.Healer.Heal(state, checks[i])
+		}
+	}
+}
+
+// record saves and broadcasts the health of cluster, and notifies the transition.
+func (m *Monitor) record(ctx context.Context, cluster string, check *apis.ClusterHealthCheck) {
+	record, err := common.NewClusterHealth(cluster, check)
+	if err != nil {
+		logrus.Warnf("[health] failed to encode health of cluster %s: %v", cluster, err)
+		return
+	}
+	if err = common.DefaultDB.SaveClusterHealth(record, m.History); err != nil {
+		logrus.Warnf("[health] failed to save health of cluster %s: %v", cluster, err)
+	}
+	common.DefaultDB.BroadcastClusterHealth(toAPIHealth(cluster, check, nil))
+
+	from, ok := m.last[cluster]
+	if !ok {
+		from = m.lastRecordedStatus(cluster, record.ID)
+	}
+	m.last[cluster] = check.Status
+	// the first healthy check of cluster isn't a transition to notify.
+	if from == check.Status || (from == "" && check.Status == common.HealthStatusHealthy) {
+		return
+	}
+	t := &Transition{
+		Cluster:   cluster,
+		From:      from,
+		To:        check.Status,
+		Problems:  check.Problems,
+		CheckedAt: check.CheckedAt,
+	}
+	logrus.Infof("[health] %s", t.Message())
+	for _, n := range m.Notifiers {
+		if err := n.Notify(ctx, t); err != nil {
+			logrus.Warnf("[health] failed to notify health transition of cluster %s: %v", cluster, err)
+		}
+	}
+}
+
+// lastRecordedStatus returns the status of the record before the current one, so that the transition across the
+// restart of serve is notified.
+func (m *Monitor) lastRecordedStatus(cluster string, current uint) string {
+	records, err := common.DefaultDB.ListClusterHealth(cluster, 2)
+	if err != nil {
+		return ""
+	}
+	for _, record := range records {
+		if record.ID != current {
+			return record.Status
+		}
+	}
+	return ""
+}
+
+// GetClusterHealth returns the latest health of cluster with no more than limit records of history and repairs, the
+// health status is empty if the cluster is never checked.
+func GetClusterHealth(cluster string, limit int) (*apis.ClusterHealth, error) {
+	records, err := common.DefaultDB.ListClusterHealth(cluster, limit)
+	if err != nil {
+		return nil, err
+	}
+	repairs, err := common.DefaultDB.ListNodeRepairs(cluster, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return &apis.ClusterHealth{Cluster: cluster, Repairs: toAPIRepairs(repairs)}, nil
+	}
+	history := make([]apis.ClusterHealthCheck, 0, len(records))
+	for _, record := range records {
+		check, err := record.ToCheck()
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, *check)
+	}
+	health := toAPIHealth(cluster, &history[0], history)
+	health.Repairs = toAPIRepairs(repairs)
+	return health, nil
+}
+
+func toAPIHealth(cluster string, check *apis.ClusterHealthCheck, history []apis.ClusterHealthCheck) *apis.ClusterHealth {
+	checkedAt := check.CheckedAt
+	return &apis.ClusterHealth{
+		Cluster:          cluster,
+		Status:           check.Status,
+		APIServerLatency: check.APIServerLatency,
+		Problems:         check.Problems,
+		Nodes:            check.Nodes,
+		CheckedAt:        &checkedAt,
+		History:          history,
+	}
+}
+
+func toAPIRepairs(repairs []*common.NodeRepair) []apis.NodeRepair {
+	result := make([]apis.NodeRepair, 0, len(repairs))
+	for _, repair := range repairs {
+		result = append(result, repair.NodeRepair)
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Transition is the change of the health status of cluster.
+type Transition struct {
+	Cluster string `json:"cluster"`
+	// From is empty if it's the first check of the cluster.
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Problems  []string  `json:"problems,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Message returns the human readable description of the transition.
+func (t *Transition) Message() string {
+	msg := fmt.Sprintf("cluster %s is %s", t.Cluster, t.To)
+	if t.From != "" {
+		msg = fmt.Sprintf("cluster %s changed from %s to %s", t.Cluster, t.From, t.To)
+	}
+	return fmt.Sprintf("%s at %s", msg, t.CheckedAt.Format(time.RFC3339))
+}
+
+// Notifier sends the transitions of the health status of clusters.
+type Notifier interface {
+	Notify(ctx context.Context, t *Transition) error
+}
+
+// WebhookNotifier posts the transition in JSON to the URL.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier returns the webhook notifier of the URL.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Notify posts the transition to the webhook.
+func (n *WebhookNotifier) Notify(ctx context.Context, t *Transition) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook %s responded with status %s", n.URL, resp.Status)
+	}
+	return nil
+}
+
+// defaultSMTPTimeout is the timeout of sending a mail, the same as the timeout of webhook client.
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPNotifier sends the transition by mail, the plain authentication is used if the username is set.
+type SMTPNotifier struct {
+	// Address is the host:port of the SMTP server.
+	Address  string
+	From     string
+	To       []string
+	Username string
+	Password string
+	// Timeout limits the whole conversation with the SMTP server, defaults to 30s.
+	Timeout time.Duration
+}
+
+// Notify sends the mail of the transition, it gives up when the timeout is reached or the context is done,
+// so that a server which stops responding doesn't block the health checks.
+func (n *SMTPNotifier) Notify(ctx context.Context, t *Transition) error {
+	host, _, err := net.SplitHostPort(n.Address)
+	if err != nil {
+		return err
+	}
+	body := &strings.Builder{}
+	fmt.Fprintf(body, "From: %s\r\n", n.From)
+	fmt.Fprintf(body, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(body, "Subject: [autok3s] cluster %s is %s\r\n", t.Cluster, t.To)
+	fmt.Fprintf(body, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(body, "%s\r\n", t.Message())
+	for _, problem := range t.Problems {
+		fmt.Fprintf(body, "- %s\r\n", problem)
+	}
+	return n.send(ctx, host, []byte(body.String()))
+}
+
+// send is the same as smtp.SendMail except that the connection is bounded by the timeout and the context.
+func (n *SMTPNotifier) send(ctx context.Context, host string, msg []byte) error {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := (&net.Dialer{Timeout: timeout}).DialContext(ctx, "tcp", n.Address)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	// the deadline is moved to now to interrupt the conversation when the context is canceled.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", n.Username, n.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(n.From); err != nil {
+		return err
+	}
+	for _, to := range n.To {
+		if err = c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,363 @@
+package kubeconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"fmt"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+)
+
+const (
+	// MinCredentialTTL is the minimum ttl of the issued credential which is limited by the token request API.
+	MinCredentialTTL = 10 * time.Minute
+	// defaultCredentialNamespace is the namespace of the service account which is bound to the whole cluster.
+	defaultCredentialNamespace = "kube-system"
+	credentialResourcePrefix   = "autok3s-kubeconfig-"
+	credentialUserPrefix       = "autok3s:"
+	credentialLabel            = "autok3s.cattle.io/kubeconfig-credential"
+	csrTimeout                 = time.Minute
+)
+
+// IssueOptions are the options of the scoped kubeconfig.
+type IssueOptions struct {
+	Name string
+	// Kind is serviceaccount or certificate.
+	Kind        string
+	ClusterRole string
+	// Namespace is the scope of the role binding, the cluster role is bound to the whole cluster if it's empty.
+	Namespace string
+	TTL       time.Duration
+}
+
+// Issue creates the credential with the cluster role and namespace scope through the cluster clientset,
+// and returns the kubeconfig which is bound to the credential. The issued credential is saved for listing and revoking.
+func Issue(ctx context.Context, contextName string, opts IssueOptions) (*api.Config, *common.KubeconfigCredential, error) {
+	admin, state, err := Get(contextName)
+	if err != nil {
+		return nil, nil, err
+	}
+	exist, err := common.DefaultDB.GetKubeconfigCredential(state.ContextName, opts.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exist != nil {
+		return nil, nil, fmt.Errorf("kubeconfig credential %s of cluster %s is already exist", opts.Name, state.ContextName)
+	}
+	client, err := newClient(admin)
+	if err != nil {
+		return nil, nil, err
+	}
+	config, credential, err := issue(ctx, client, admin, state.ContextName, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := common.DefaultDB.CreateKubeconfigCredential(credential); err != nil {
+		return nil, nil, err
+	}
+	return config, credential, nil
+}
+
+// Revoke removes the role binding and the service account of the credential, the record of credential is removed
+// even if the cluster is not reachable when force is true.
+// The client certificate cannot be revoked by kubernetes, it has no permission after the role binding is removed.
+func Revoke(ctx context.Context, contextName, name string, force bool) error {
+	credential, err := common.DefaultDB.GetKubeconfigCredential(contextName, name)
+	if err != nil {
+		return err
+	}
+	if credential == nil {
+		return fmt.Errorf("kubeconfig credential %s of cluster %s is not found", name, contextName)
+	}
+	admin, _, err := Get(contextName)
+	if err == nil {
+		var client kubernetes.Interface
+		if client, err = newClient(admin); err == nil {
+			err = revoke(ctx, client, credential)
+		}
+	}
+	if err != nil && !force {
+		return err
+	}
+	return common.DefaultDB.DeleteKubeconfigCredential(contextName, name)
+}
+
+func newClient(config *api.Config) (kubernetes.Interface, error) {
+	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	restConfig.Timeout = 15 * time.Second
+	return kubernetes.NewForConfig(restConfig)
+}
+
+func validateIssueOptions(ctx context.Context, client kubernetes.Interface, opts IssueOptions) error {
+	if errs := validation.IsDNS1123Label(opts.Name); len(errs) > 0 {
+		return fmt.Errorf("credential name %s is not valid, %v", opts.Name, errs)
+	}
+	if opts.Kind != common.KubeconfigCredentialServiceAccount && opts.Kind != common.KubeconfigCredentialCertificate {
+		return fmt.Errorf("credential kind %s is not supported, only %s and %s are supported", opts.Kind,
+			common.KubeconfigCredentialServiceAccount, common.KubeconfigCredentialCertificate)
+	}
+	if opts.TTL < MinCredentialTTL {
+		return fmt.Errorf("the ttl of credential must be at least %s", MinCredentialTTL)
+	}
+	if opts.ClusterRole == "" {
+		return fmt.Errorf("cluster role of credential is required")
+	}
+	if _, err := client.RbacV1().ClusterRoles().Get(ctx, opts.ClusterRole, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("failed to get cluster role %s: %v", opts.ClusterRole, err)
+	}
+	if opts.Namespace != "" {
+		if _, err := client.CoreV1().Namespaces().Get(ctx, opts.Namespace, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("failed to get namespace %s: %v", opts.Namespace, err)
+		}
+	}
+	return nil
+}
+
+func issue(ctx context.Context, client kubernetes.Interface, admin *api.Config, contextName string, opts IssueOptions) (config *api.Config, credential *common.KubeconfigCredential, err error) {
+	if err = validateIssueOptions(ctx, client, opts); err != nil {
+		return nil, nil, err
+	}
+	credential = &common.KubeconfigCredential{
+		Cluster:     contextName,
+		Name:        opts.Name,
+		Kind:        opts.Kind,
+		ClusterRole: opts.ClusterRole,
+		Namespace:   opts.Namespace,
+		CreatedAt:   time.Now(),
+	}
+	// clean up the created resources if failed.
+	defer func() {
+		if err != nil {
+			_ = revoke(context.Background(), client, credential)
+		}
+	}()
+
+	authInfo := &api.AuthInfo{}
+	var subject rbacv1.Subject
+	switch opts.Kind {
+	case common.KubeconfigCredentialServiceAccount:
+		namespace := credentialServiceAccountNamespace(credential)
+		credential.Subject = namespace + "/" + credentialResourcePrefix + opts.Name
+		subject = rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: credentialResourcePrefix + opts.Name}
+		if authInfo.Token, credential.ExpiresAt, err = issueServiceAccountToken(ctx, client, namespace, opts); err != nil {
+			return nil, nil, err
+		}
+	case common.KubeconfigCredentialCertificate:
+		if credential.Subject, err = certificateUser(opts.Name); err != nil {
+			return nil, nil, err
+		}
+		subject = rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: credential.Subject}
+		if authInfo.ClientCertificateData, authInfo.ClientKeyData, credential.ExpiresAt, err = issueCertificate(ctx, client, credential.Subject, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err = createRoleBinding(ctx, client, opts, subject); err != nil {
+		return nil, nil, err
+	}
+	config, err = scopedConfig(admin, contextName, opts.Name, authInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, credential, nil
+}
+
+// certificateUser returns the unique user of the client certificate. The certificate can't be revoked before it expires,
+// so the revoked certificate mustn't be bound again by the credential issued later with the same name.
+func certificateUser(name string) (string, error) {
+	suffix, err := utils.RandomToken(4)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s-%s", credentialUserPrefix, name, suffix), nil
+}
+
+func credentialServiceAccountNamespace(credential *common.KubeconfigCredential) string {
+	if credential.Namespace != "" {
+		return credential.Namespace
+	}
+	return defaultCredentialNamespace
+}
+
+func credentialLabels(name string) map[string]string {
+	return map[string]string{credentialLabel: name}
+}
+
+// issueServiceAccountToken creates the service account and requests the token with the ttl.
+func issueServiceAccountToken(ctx context.Context, client kubernetes.Interface, namespace string, opts IssueOptions) (string, time.Time, error) {
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      credentialResourcePrefix + opts.Name,
+			Namespace: namespace,
+			Labels:    credentialLabels(opts.Name),
+		},
+	}
+	if _, err := client.CoreV1().ServiceAccounts(namespace).Create(ctx, sa, metav1.CreateOptions{}); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create service account %s/%s: %v", namespace, sa.Name, err)
+	}
+	seconds := int64(opts.TTL.Seconds())
+	token, err := client.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, sa.Name, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &seconds},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to request token of service account %s/%s: %v", namespace, sa.Name, err)
+	}
+	expiresAt := token.Status.ExpirationTimestamp.Time
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(opts.TTL)
+	}
+	return token.Status.Token, expiresAt, nil
+}
+
+// issueCertificate signs the client certificate of the user with the kube-apiserver-client signer.
+func issueCertificate(ctx context.Context, client kubernetes.Interface, user string, opts IssueOptions) ([]byte, []byte, time.Time, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	keyPEM, err := keyutil.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	csrPEM, err := certutil.MakeCSR(key, &pkix.Name{CommonName: user}, nil, nil)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	seconds := int32(opts.TTL.Seconds())
+	csr := &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   credentialResourcePrefix + opts.Name,
+			Labels: credentialLabels(opts.Name),
+		},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:           csrPEM,
+			SignerName:        certificatesv1.KubeAPIServerClientSignerName,
+			ExpirationSeconds: &seconds,
+			Usages:            []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth},
+		},
+	}
+	csrs := client.CertificatesV1().CertificateSigningRequests()
+	if csr, err = csrs.Create(ctx, csr, metav1.CreateOptions{}); err != nil {
+		return nil, nil, time.Time{}, fmt.Errorf("failed to create certificate signing request %s: %v", credentialResourcePrefix+opts.Name, err)
+	}
+	// the signing request is useless after the certificate is issued.
+	defer func() {
+		_ = csrs.Delete(context.Background(), csr.Name, metav1.DeleteOptions{})
+	}()
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:    certificatesv1.CertificateApproved,
+		Status:  corev1.ConditionTrue,
+		Reason:  "AutoK3sApproved",
+		Message: "approved by autok3s for the scoped kubeconfig",
+	})
+	if _, err = csrs.UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+		return nil, nil, time.Time{}, fmt.Errorf("failed to approve certificate signing request %s: %v", csr.Name, err)
+	}
+	var certPEM []byte
+	err = wait.PollUntilContextTimeout(ctx, time.Second, csrTimeout, true, func(ctx context.Context) (bool, error) {
+		current, err := csrs.Get(ctx, csr.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		certPEM = current.Status.Certificate
+		return len(certPEM) > 0, nil
+	})
+	if err != nil {
+		return nil, nil, time.Time{}, fmt.Errorf("certificate of signing request %s is not issued: %v", csr.Name, err)
+	}
+	certs, err := certutil.ParseCertsPEM(certPEM)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	return certPEM, keyPEM, certs[0].NotAfter, nil
+}
+
+// createRoleBinding binds the cluster role to the subject, it's a role binding of the namespace if the namespace is set.
+func createRoleBinding(ctx context.Context, client kubernetes.Interface, opts IssueOptions, subject rbacv1.Subject) error {
+	meta := metav1.ObjectMeta{
+		Name:   credentialResourcePrefix + opts.Name,
+		Labels: credentialLabels(opts.Name),
+	}
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: opts.ClusterRole}
+	var err error
+	if opts.Namespace != "" {
+		meta.Namespace = opts.Namespace
+		_, err = client.RbacV1().RoleBindings(opts.Namespace).Create(ctx, &rbacv1.RoleBinding{
+			ObjectMeta: meta,
+			Subjects:   []rbacv1.Subject{subject},
+			RoleRef:    roleRef,
+		}, metav1.CreateOptions{})
+	} else {
+		_, err = client.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+			ObjectMeta: meta,
+			Subjects:   []rbacv1.Subject{subject},
+			RoleRef:    roleRef,
+		}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to bind cluster role %s: %v", opts.ClusterRole, err)
+	}
+	return nil
+}
+
+// revoke removes the role binding and the service account of the credential.
+func revoke(ctx context.Context, client kubernetes.Interface, credential *common.KubeconfigCredential) error {
+	name := credentialResourcePrefix + credential.Name
+	var err error
+	if credential.Namespace != "" {
+		err = client.RbacV1().RoleBindings(credential.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	} else {
+		err = client.RbacV1().ClusterRoleBindings().Delete(ctx, name, metav1.DeleteOptions{})
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove role binding %s: %v", name, err)
+	}
+	if credential.Kind == common.KubeconfigCredentialServiceAccount {
+		namespace := credentialServiceAccountNamespace(credential)
+		err = client.CoreV1().ServiceAccounts(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to remove service account %s/%s: %v", namespace, name, err)
+		}
+	}
+	return nil
+}
+
+// scopedConfig returns the kubeconfig which uses the cluster of admin kubeconfig and the auth info of the credential.
+func scopedConfig(admin *api.Config, contextName, name string, authInfo *api.AuthInfo) (*api.Config, error) {
+	ctx, ok := admin.Contexts[admin.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("current context of cluster %s is not found in kubeconfig", contextName)
+	}
+	cluster, ok := admin.Clusters[ctx.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("cluster of context %s is not found in kubeconfig", contextName)
+	}
+	scopedName := fmt.Sprintf("%s@%s", name, contextName)
+	config := api.NewConfig()
+	config.Clusters[contextName] = cluster.DeepCopy()
+	config.AuthInfos[scopedName] = authInfo
+	config.Contexts[scopedName] = &api.Context{Cluster: contextName, AuthInfo: scopedName}
+	config.CurrentContext = scopedName
+	return config, nil
+}
@@ -0,0 +1,97 @@
+package kubeconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newFakeClient() *fake.Clientset {
+	client := fake.NewSimpleClientset(
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "view"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+	)
+	client.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		request := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		request.Status = authenticationv1.TokenRequestStatus{
+			Token:               "scoped-token",
+			ExpirationTimestamp: metav1.NewTime(time.Now().Add(time.Duration(*request.Spec.ExpirationSeconds) * time.Second)),
+		}
+		return true, request, nil
+	})
+	return client
+}
+
+func TestIssueServiceAccount(t *testing.T) {
+	client := newFakeClient()
+	ctx := context.Background()
+	opts := IssueOptions{
+		Name:        "dev",
+		Kind:        common.KubeconfigCredentialServiceAccount,
+		ClusterRole: "view",
+		Namespace:   "dev",
+		TTL:         time.Hour,
+	}
+	config, credential, err := issue(ctx, client, newConfig("test", "https://127.0.0.1:6443"), "test", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "dev/autok3s-kubeconfig-dev", credential.Subject)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), credential.ExpiresAt, time.Minute)
+	assert.Equal(t, "dev@test", config.CurrentContext)
+	assert.Equal(t, "scoped-token", config.AuthInfos["dev@test"].Token)
+	assert.Equal(t, "https://127.0.0.1:6443", config.Clusters["test"].Server)
+
+	binding, err := client.RbacV1().RoleBindings("dev").Get(ctx, "autok3s-kubeconfig-dev", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "view", binding.RoleRef.Name)
+	assert.Equal(t, rbacv1.ServiceAccountKind, binding.Subjects[0].Kind)
+
+	assert.NoError(t, revoke(ctx, client, credential))
+	_, err = client.RbacV1().RoleBindings("dev").Get(ctx, "autok3s-kubeconfig-dev", metav1.GetOptions{})
+	assert.Error(t, err)
+	_, err = client.CoreV1().ServiceAccounts("dev").Get(ctx, "autok3s-kubeconfig-dev", metav1.GetOptions{})
+	assert.Error(t, err)
+	// revoke again is a no-op.
+	assert.NoError(t, revoke(ctx, client, credential))
+}
+
+func TestIssueValidation(t *testing.T) {
+	client := newFakeClient()
+	admin := newConfig("test", "https://127.0.0.1:6443")
+	cases := []IssueOptions{
+		{Name: "Invalid_Name", Kind: common.KubeconfigCredentialServiceAccount, ClusterRole: "view", TTL: time.Hour},
+		{Name: "dev", Kind: "token", ClusterRole: "view", TTL: time.Hour},
+		{Name: "dev", Kind: common.KubeconfigCredentialServiceAccount, ClusterRole: "view", TTL: time.Minute},
+		{Name: "dev", Kind: common.KubeconfigCredentialServiceAccount, ClusterRole: "missing", TTL: time.Hour},
+		{Name: "dev", Kind: common.KubeconfigCredentialServiceAccount, ClusterRole: "view", Namespace: "missing", TTL: time.Hour},
+	}
+	for _, c := range cases {
+		_, _, err := issue(context.Background(), client, admin, "test", c)
+		assert.Error(t, err, c)
+	}
+	list, err := client.CoreV1().ServiceAccounts("").List(context.Background(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, list.Items)
+}
+
+func TestCertificateUser(t *testing.T) {
+	first, err := certificateUser("dev")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^autok3s:dev-[0-9a-f]{8}$`, first)
+	second, err := certificateUser("dev")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+}
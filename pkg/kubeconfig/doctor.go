@@ -0,0 +1,148 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// DoctorResult is the result of the kubeconfig check of a cluster.
+type DoctorResult struct {
+	Cluster  string   `json:"cluster"`
+	Provider string   `json:"provider"`
+	Problems []string `json:"problems,omitempty"`
+	Repaired bool     `json:"repaired"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Check validates the context of the cluster in the merged kubeconfig against the cluster state,
+// the server address must be the ip of the cluster or one of the masters, and the CA must be the same as the
+// standalone kubeconfig of the cluster. It returns the problems which are found.
+func Check(state *common.ClusterState, merged *api.Config) []string {
+	ctx, ok := merged.Contexts[state.ContextName]
+	if !ok {
+		return []string{"context is missing in kubeconfig"}
+	}
+	problems := []string{}
+	if _, ok := merged.AuthInfos[ctx.AuthInfo]; !ok {
+		problems = append(problems, fmt.Sprintf("user %s is missing in kubeconfig", ctx.AuthInfo))
+	}
+	clusterCfg, ok := merged.Clusters[ctx.Cluster]
+	if !ok {
+		return append(problems, fmt.Sprintf("cluster %s is missing in kubeconfig", ctx.Cluster))
+	}
+
+	c := common.ConvertToCluster(state, true)
+	if expected := cluster.CfgServerIPs(&c); len(expected) > 0 {
+		server, err := url.Parse(clusterCfg.Server)
+		if err != nil || !contains(expected, server.Hostname()) {
+			problems = append(problems, fmt.Sprintf("server %s doesn't match the cluster address %v", clusterCfg.Server, expected))
+		}
+	}
+
+	standalone, err := clientcmd.LoadFromFile(common.GetClusterKubeCfgPath(state.ContextName, state.Provider))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			problems = append(problems, fmt.Sprintf("failed to load standalone kubeconfig: %v", err))
+		}
+		return problems
+	}
+	if standaloneCtx, ok := standalone.Contexts[state.ContextName]; ok {
+		if standaloneCluster, ok := standalone.Clusters[standaloneCtx.Cluster]; ok &&
+			!bytes.Equal(standaloneCluster.CertificateAuthorityData, clusterCfg.CertificateAuthorityData) {
+			problems = append(problems, "certificate authority doesn't match the standalone kubeconfig")
+		}
+	}
+	return problems
+}
+
+// Doctor checks the kubeconfig of the cluster, all clusters are checked if the context name is empty.
+// The kubeconfig of drifted cluster is re-fetched from a healthy master and repaired if repair is true.
+func Doctor(contextName string, repair bool, logger *logrus.Logger) ([]*DoctorResult, error) {
+	var states []*common.ClusterState
+	if contextName != "" {
+		state, err := common.DefaultDB.GetClusterByID(contextName)
+		if err != nil {
+			return nil, err
+		}
+		if state == nil {
+			return nil, fmt.Errorf("cluster %s is not found", contextName)
+		}
+		states = append(states, state)
+	} else {
+		list, err := common.DefaultDB.ListCluster("")
+		if err != nil {
+			return nil, err
+		}
+		states = list
+	}
+	merged, err := common.FileManager.LoadCfg()
+	if os.IsNotExist(err) {
+		merged, err = api.NewConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
+	}
+
+	results := []*DoctorResult{}
+	for _, state := range states {
+		// the kubeconfig of k3d cluster is not fetched by ssh, and the cluster which is not running has no kubeconfig.
+		if state.Provider == "k3d" || state.Status != common.StatusRunning {
+			continue
+		}
+		result := &DoctorResult{
+			Cluster:  state.ContextName,
+			Provider: state.Provider,
+			Problems: Check(state, merged),
+		}
+		results = append(results, result)
+		if len(result.Problems) == 0 || !repair {
+			continue
+		}
+		logger.Infof("[kubeconfig] repairing kubeconfig of cluster %s: %v", state.ContextName, result.Problems)
+		if err := cluster.RepairCfg(state, logger); err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		result.Repaired = true
+	}
+	return results, nil
+}
+
+// StartDoctor checks and repairs the kubeconfig of all clusters periodically until the context is done.
+func StartDoctor(ctx context.Context, interval time.Duration) {
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		results, err := Doctor("", true, logrus.StandardLogger())
+		if err != nil {
+			logrus.Warnf("[kubeconfig] failed to check kubeconfig: %v", err)
+			return
+		}
+		for _, result := range results {
+			if result.Error != "" {
+				logrus.Warnf("[kubeconfig] failed to repair kubeconfig of cluster %s: %s", result.Cluster, result.Error)
+			} else if result.Repaired {
+				logrus.Infof("[kubeconfig] kubeconfig of cluster %s is repaired", result.Cluster)
+			}
+		}
+	}, interval)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package kubeconfig
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/common/commontest"
+	_ "github.com/cnrancher/autok3s/pkg/providers/native"
+	"github.com/cnrancher/autok3s/pkg/types"
+	typesnative "github.com/cnrancher/autok3s/pkg/types/native"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestCheck(t *testing.T) {
+	commontest.InitStorage(t)
+	assert.NoError(t, common.DefaultDB.SaveCluster(&types.Cluster{
+		Metadata: types.Metadata{Name: "test", Provider: "native", ContextName: "test.native", IP: "10.0.0.1"},
+		Options:  typesnative.Options{},
+		Status: types.Status{
+			Status: common.StatusRunning,
+			MasterNodes: []types.Node{
+				{Master: true, PublicIPAddress: []string{"1.1.1.1"}, InternalIPAddress: []string{"10.0.0.1"}},
+				{Master: true, PublicIPAddress: []string{"2.2.2.2"}, InternalIPAddress: []string{"10.0.0.2"}},
+			},
+		},
+	}))
+	state, err := common.DefaultDB.GetClusterByID("test.native")
+	assert.NoError(t, err)
+
+	standalone := newConfig("test.native", "https://1.1.1.1:6443")
+	standalone.Clusters["test.native"].CertificateAuthorityData = []byte("ca")
+	data, err := clientcmd.Write(*standalone)
+	assert.NoError(t, err)
+	assert.NoError(t, common.FileManager.SaveClusterCfg("test.native", "native", data))
+
+	// the server can be any master if the cluster ip is the internal ip of master.
+	merged := newConfig("test.native", "https://2.2.2.2:6443")
+	merged.Clusters["test.native"].CertificateAuthorityData = []byte("ca")
+	assert.Empty(t, Check(state, merged))
+
+	merged.Clusters["test.native"].Server = "https://3.3.3.3:6443"
+	merged.Clusters["test.native"].CertificateAuthorityData = []byte("other")
+	assert.Len(t, Check(state, merged), 2)
+
+	delete(merged.Contexts, "test.native")
+	assert.Equal(t, []string{"context is missing in kubeconfig"}, Check(state, merged))
+
+	// the explicit cluster ip must be used as the server.
+	state.IP = "lb.example.com"
+	merged = newConfig("test.native", "https://1.1.1.1:6443")
+	merged.Clusters["test.native"].CertificateAuthorityData = []byte("ca")
+	assert.Len(t, Check(state, merged), 1)
+}
@@ -0,0 +1,136 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/utils"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Get returns the standalone kubeconfig and the state of the cluster by context name.
+func Get(contextName string) (*api.Config, *common.ClusterState, error) {
+	state, err := common.DefaultDB.GetClusterByID(contextName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state == nil {
+		return nil, nil, fmt.Errorf("cluster %s is not found", contextName)
+	}
+	config, err := common.FileManager.GetClusterCfg(state.ContextName, state.Provider)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get kubeconfig of cluster %s: %v", contextName, err)
+	}
+	return config, state, nil
+}
+
+// RewriteServer replaces the server address of all clusters in the kubeconfig,
+// the server can be a host, a host with port or an url, the scheme and port of original address are kept if not set.
+func RewriteServer(config *api.Config, server string) error {
+	if server == "" {
+		return nil
+	}
+	for name, cluster := range config.Clusters {
+		origin, err := url.Parse(cluster.Server)
+		if err != nil {
+			return fmt.Errorf("failed to parse server address of cluster %s: %v", name, err)
+		}
+		target, err := serverURL(server, origin)
+		if err != nil {
+			return err
+		}
+		cluster.Server = target
+	}
+	return nil
+}
+
+func serverURL(server string, origin *url.URL) (string, error) {
+	if strings.Contains(server, "://") {
+		u, err := url.Parse(server)
+		if err != nil {
+			return "", fmt.Errorf("server %s is not valid: %v", server, err)
+		}
+		return u.String(), nil
+	}
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return (&url.URL{Scheme: origin.Scheme, Host: server}).String(), nil
+	}
+	host := strings.Trim(server, "[]")
+	if port := origin.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return (&url.URL{Scheme: origin.Scheme, Host: host}).String(), nil
+}
+
+// TLSSanServer returns the first TLS SAN of the cluster which can be used as the server address.
+func TLSSanServer(state *common.ClusterState) (string, error) {
+	for _, san := range state.TLSSans {
+		if san = strings.TrimSpace(san); san != "" {
+			return san, nil
+		}
+	}
+	return "", fmt.Errorf("cluster %s has no TLS SAN", state.ContextName)
+}
+
+// Write writes the kubeconfig to the file.
+func Write(config *api.Config, path string) error {
+	path = utils.StripUserHome(path)
+	if dir := filepath.Dir(path); dir != "" {
+		if err := utils.EnsureFolderExist(dir); err != nil {
+			return err
+		}
+	}
+	return clientcmd.WriteToFile(*config, path)
+}
+
+// Merge merges the kubeconfig into the kubeconfig file of path, the file is created if not exists.
+// The context, cluster and user of the same name are replaced only if overwrite is true.
+func Merge(config *api.Config, path string, overwrite, setCurrent bool) error {
+	path = utils.StripUserHome(path)
+	target, err := clientcmd.LoadFromFile(path)
+	if os.IsNotExist(err) {
+		target, err = api.NewConfig(), nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %v", path, err)
+	}
+	if !overwrite {
+		for name := range config.Contexts {
+			if _, ok := target.Contexts[name]; ok {
+				return fmt.Errorf("context %s is already exist in kubeconfig %s", name, path)
+			}
+		}
+		for name := range config.Clusters {
+			if _, ok := target.Clusters[name]; ok {
+				return fmt.Errorf("cluster %s is already exist in kubeconfig %s", name, path)
+			}
+		}
+		for name := range config.AuthInfos {
+			if _, ok := target.AuthInfos[name]; ok {
+				return fmt.Errorf("user %s is already exist in kubeconfig %s", name, path)
+			}
+		}
+	}
+	for name, cluster := range config.Clusters {
+		target.Clusters[name] = cluster
+	}
+	for name, authInfo := range config.AuthInfos {
+		target.AuthInfos[name] = authInfo
+	}
+	for name, ctx := range config.Contexts {
+		target.Contexts[name] = ctx
+	}
+	if setCurrent || target.CurrentContext == "" {
+		target.CurrentContext = config.CurrentContext
+	}
+	return Write(target, path)
+}
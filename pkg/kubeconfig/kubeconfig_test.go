@@ -0,0 +1,92 @@
+package kubeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/common/commontest"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func newConfig(name, server string) *api.Config {
+	config := api.NewConfig()
+	config.Clusters[name] = &api.Cluster{Server: server}
+	config.AuthInfos[name] = &api.AuthInfo{Token: "token"}
+	config.Contexts[name] = &api.Context{Cluster: name, AuthInfo: name}
+	config.CurrentContext = name
+	return config
+}
+
+func TestRewriteServer(t *testing.T) {
+	cases := []struct {
+		server, expected string
+	}{
+		{"", "https://127.0.0.1:6443"},
+		{"10.0.0.1", "https://10.0.0.1:6443"},
+		{"lb.example.com:443", "https://lb.example.com:443"},
+		{"http://lb.example.com", "http://lb.example.com"},
+		{"fd00::1", "https://[fd00::1]:6443"},
+	}
+	for _, c := range cases {
+		config := newConfig("test", "https://127.0.0.1:6443")
+		assert.NoError(t, RewriteServer(config, c.server), c.server)
+		assert.Equal(t, c.expected, config.Clusters["test"].Server, c.server)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, Merge(newConfig("a", "https://1.1.1.1:6443"), path, false, false))
+	assert.NoError(t, Merge(newConfig("b", "https://2.2.2.2:6443"), path, false, false))
+
+	config, err := clientcmd.LoadFromFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, config.Contexts, 2)
+	assert.Equal(t, "a", config.CurrentContext)
+
+	assert.Error(t, Merge(newConfig("b", "https://3.3.3.3:6443"), path, false, false))
+	assert.NoError(t, Merge(newConfig("b", "https://3.3.3.3:6443"), path, true, true))
+	config, err = clientcmd.LoadFromFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", config.CurrentContext)
+	assert.Equal(t, "https://3.3.3.3:6443", config.Clusters["b"].Server)
+}
+
+func TestGet(t *testing.T) {
+	commontest.InitStorage(t)
+	assert.NoError(t, common.DefaultDB.SaveCluster(&types.Cluster{
+		Metadata: types.Metadata{Name: "test", Provider: "native", ContextName: "test.native", TLSSans: types.StringArray{"lb.example.com"}},
+	}))
+
+	// the kubeconfig is extracted from the merged kubeconfig if the standalone kubeconfig doesn't exist.
+	merged := newConfig("test.native", "https://1.1.1.1:6443")
+	other := newConfig("other", "https://2.2.2.2:6443")
+	merged.Clusters["other"], merged.AuthInfos["other"], merged.Contexts["other"] = other.Clusters["other"], other.AuthInfos["other"], other.Contexts["other"]
+	assert.NoError(t, os.MkdirAll(filepath.Join(common.CfgPath, ".kube"), 0755))
+	assert.NoError(t, clientcmd.WriteToFile(*merged, filepath.Join(common.CfgPath, common.KubeCfgFile)))
+
+	config, state, err := Get("test.native")
+	assert.NoError(t, err)
+	assert.Len(t, config.Contexts, 1)
+	assert.Equal(t, "https://1.1.1.1:6443", config.Clusters["test.native"].Server)
+	server, err := TLSSanServer(state)
+	assert.NoError(t, err)
+	assert.Equal(t, "lb.example.com", server)
+
+	data, err := clientcmd.Write(*newConfig("test.native", "https://3.3.3.3:6443"))
+	assert.NoError(t, err)
+	assert.NoError(t, common.FileManager.SaveClusterCfg("test.native", "native", data))
+	config, _, err = Get("test.native")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://3.3.3.3:6443", config.Clusters["test.native"].Server)
+
+	assert.NoError(t, common.FileManager.RemoveClusterCfg("test.native", "native"))
+	_, _, err = Get("missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,297 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+)
+
+// DefaultAutoHealGracePeriod is how long a worker can be NotReady before it's replaced by auto-heal.
+const DefaultAutoHealGracePeriod = 10 * time.Minute
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Validate checks the schedule and time zone of the policy.
+func Validate(p *common.ClusterPolicy) error {
+	for _, clock := range []string{p.StopAt, p.StartAt} {
+		if clock == "" {
+			continue
+		}
+		if _, _, err := parseClock(clock); err != nil {
+			return err
+		}
+	}
+	if (p.ScheduleDays != "" || p.Timezone != "") && p.StopAt == "" && p.StartAt == "" {
+		return fmt.Errorf("schedule days and timezone only work with stop-at or start-at")
+	}
+	if _, err := parseDays(p.ScheduleDays); err != nil {
+		return err
+	}
+	if p.AutoHealGracePeriod != "" {
+		if !p.AutoHeal {
+			return fmt.Errorf("auto-heal grace period only works with auto-heal")
+		}
+		if d, err := time.ParseDuration(p.AutoHealGracePeriod); err != nil || d <= 0 {
+			return fmt.Errorf("invalid auto-heal grace period %q, expected positive duration, e.g. 10m", p.AutoHealGracePeriod)
+		}
+	}
+	if p.AutoscaleMin < 0 || p.AutoscaleMax < 0 {
+		return fmt.Errorf("autoscale bounds must not be negative")
+	}
+	if p.AutoscaleMax == 0 && p.AutoscaleMin > 0 {
+		return fmt.Errorf("autoscale-min only works with autoscale-max")
+	}
+	if p.AutoscaleMin > p.AutoscaleMax {
+		return fmt.Errorf("autoscale-min %d is greater than autoscale-max %d", p.AutoscaleMin, p.AutoscaleMax)
+	}
+	_, err := location(p.Timezone)
+	return err
+}
+
+// ValidateProvider checks the policy is supported by the provider of the cluster, native clusters can't be
+// stopped or started, and the workers can't be replaced or scaled as the hosts are not provisioned by autok3s.
+func ValidateProvider(p *common.ClusterPolicy, provider string) error {
+	if provider != "native" {
+		return nil
+	}
+	if p.StopAt != "" || p.StartAt != "" {
+		return fmt.Errorf("scheduled stop and start are not supported by %s provider", provider)
+	}
+	if p.AutoHeal {
+		return fmt.Errorf("auto-heal is not supported by %s provider", provider)
+	}
+	if p.AutoscaleMax > 0 {
+		return fmt.Errorf("autoscaling is not supported by %s provider", provider)
+	}
+	return nil
+}
+
+// GracePeriod returns how long a worker can be NotReady before it's replaced by auto-heal.
+func GracePeriod(p *common.ClusterPolicy) time.Duration {
+	if d, err := time.ParseDuration(p.AutoHealGracePeriod); err == nil && d > 0 {
+		return d
+	}
+	return DefaultAutoHealGracePeriod
+}
+
+// SetTTL sets the policy to delete the cluster after ttl from now.
+func SetTTL(p *common.ClusterPolicy, ttl time.Duration, now time.Time) error {
+	if ttl <= 0 {
+		return fmt.Errorf("ttl must be greater than 0, got %s", ttl)
+	}
+	expireAt := now.Add(ttl)
+	p.ExpireAt = &expireAt
+	return nil
+}
+
+// Extend postpones the deletion of the cluster, the extension is started from now if the cluster is already expired.
+func Extend(p *common.ClusterPolicy, d time.Duration, now time.Time) error {
+	if p.ExpireAt == nil {
+		return fmt.Errorf("there's no ttl to extend, please set ttl instead")
+	}
+	if d <= 0 {
+		return fmt.Errorf("extension must be greater than 0, got %s", d)
+	}
+	expireAt := *p.ExpireAt
+	if expireAt.Before(now) {
+		expireAt = now
+	}
+	expireAt = expireAt.Add(d)
+	p.ExpireAt = &expireAt
+	return nil
+}
+
+// NextAction returns the next action of the policy for the cluster in the given status.
+func NextAction(p *common.ClusterPolicy, status string, now time.Time) (string, time.Time) {
+	var (
+		action string
+		at     time.Time
+	)
+	pick := func(a string, t time.Time) {
+		if !t.IsZero() && (at.IsZero() || t.Before(at)) {
+			action, at = a, t
+		}
+	}
+	if p.ExpireAt != nil {
+		pick(common.PolicyActionDelete, *p.ExpireAt)
+	}
+	if p.StopAt != "" && status != common.StatusStopped {
+		pick(common.PolicyActionStop, nextOccurrence(p, p.StopAt, now))
+	}
+	if p.StartAt != "" && status == common.StatusStopped {
+		pick(common.PolicyActionStart, nextOccurrence(p, p.StartAt, now))
+	}
+	return action, at
+}
+
+// nextOccurrence returns the first time after now of the clock on the schedule days.
+func nextOccurrence(p *common.ClusterPolicy, clock string, now time.Time) time.Time {
+	return occurrence(p, clock, now, 1)
+}
+
+// lastOccurrence returns the last time not after now of the clock on the schedule days.
+func lastOccurrence(p *common.ClusterPolicy, clock string, now time.Time) time.Time {
+	return occurrence(p, clock, now, -1)
+}
+
+func occurrence(p *common.ClusterPolicy, clock string, now time.Time, step int) time.Time {
+	hour, minute, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}
+	}
+	days, err := parseDays(p.ScheduleDays)
+	if err != nil {
+		return time.Time{}
+	}
+	loc, err := location(p.Timezone)
+	if err != nil {
+		return time.Time{}
+	}
+	local := now.In(loc)
+	// a week later the same clock of today is checked again, so 8 days cover all the cases.
+	for i := 0; i <= 7; i++ {
+		day := local.AddDate(0, 0, i*step)
+		t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+		if !days[t.Weekday()] {
+			continue
+		}
+		if (step > 0 && t.After(now)) || (step < 0 && !t.After(now)) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// location returns the local time zone if tz is empty, as time.LoadLocation returns UTC for it.
+func location(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+func parseClock(clock string) (int, int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of day %q, expected format HH:MM", clock)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// parseDays parses the days of week, e.g. weekdays, weekends, daily, sat,sun or mon-fri.
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "daily":
+		return [7]bool{true, true, true, true, true, true, true}, nil
+	case "weekdays":
+		s = "mon-fri"
+	case "weekends":
+		s = "sat,sun"
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		start, ok := parseWeekday(from)
+		if !ok {
+			return days, fmt.Errorf("invalid schedule days %q", s)
+		}
+		end := start
+		if isRange {
+			if end, ok = parseWeekday(to); !ok {
+				return days, fmt.Errorf("invalid schedule days %q", s)
+			}
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == end {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.TrimSpace(s)
+	if d, ok := weekdays[s]; ok {
+		return d, true
+	}
+	for _, d := range weekdays {
+		if strings.ToLower(d.String()) == s {
+			return d, true
+		}
+	}
+	if i, err := strconv.Atoi(s); err == nil && i >= 0 && i <= 6 {
+		return time.Weekday(i), true
+	}
+	return 0, false
+}
+
+// Apply updates the policy with the input, the schedule, auto-heal and autoscale bounds are replaced and ttl or extension is applied after expireAt.
+func Apply(p *common.ClusterPolicy, in *apis.ClusterPolicy, now time.Time) error {
+	p.StopAt = in.StopAt
+	p.StartAt = in.StartAt
+	p.ScheduleDays = in.ScheduleDays
+	p.Timezone = in.Timezone
+	p.ExpireAt = in.ExpireAt
+	p.AutoHeal = in.AutoHeal
+	p.AutoHealGracePeriod = in.AutoHealGracePeriod
+	p.AutoscaleMin = in.AutoscaleMin
+	p.AutoscaleMax = in.AutoscaleMax
+	if in.TTL != "" {
+		ttl, err := time.ParseDuration(in.TTL)
+		if err != nil {
+			return fmt.Errorf("invalid ttl %q: %v", in.TTL, err)
+		}
+		if err = SetTTL(p, ttl, now); err != nil {
+			return err
+		}
+	}
+	if in.Extend != "" {
+		d, err := time.ParseDuration(in.Extend)
+		if err != nil {
+			return fmt.Errorf("invalid extension %q: %v", in.Extend, err)
+		}
+		if err = Extend(p, d, now); err != nil {
+			return err
+		}
+	}
+	return Validate(p)
+}
+
+// ToAPIPolicy converts the policy of cluster state to the API object.
+func ToAPIPolicy(state *common.ClusterState, now time.Time) *apis.ClusterPolicy {
+	p := state.ClusterPolicy
+	result := &apis.ClusterPolicy{
+		Cluster:      state.ContextName,
+		StopAt:       p.StopAt,
+		StartAt:      p.StartAt,
+		ScheduleDays: p.ScheduleDays,
+		Timezone:     p.Timezone,
+		ExpireAt:     p.ExpireAt,
+
+		AutoHeal:            p.AutoHeal,
+		AutoHealGracePeriod: p.AutoHealGracePeriod,
+		AutoscaleMin:        p.AutoscaleMin,
+		AutoscaleMax:        p.AutoscaleMax,
+	}
+	if action, at := NextAction(&p, state.Status, now); action != "" {
+		result.NextAction = action
+		result.NextActionAt = &at
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package policy
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDays(t *testing.T) {
+	days, err := parseDays("weekdays")
+	assert.NoError(t, err)
+	assert.Equal(t, [7]bool{false, true, true, true, true, true, false}, days)
+
+	days, err = parseDays("fri-mon")
+	assert.NoError(t, err)
+	assert.Equal(t, [7]bool{true, true, false, false, false, true, true}, days)
+
+	days, err = parseDays("Saturday, sun")
+	assert.NoError(t, err)
+	assert.Equal(t, [7]bool{true, false, false, false, false, false, true}, days)
+
+	_, err = parseDays("mon-someday")
+	assert.Error(t, err)
+}
+
+func TestOccurrence(t *testing.T) {
+	p := &common.ClusterPolicy{StopAt: "20:00", StartAt: "08:00", ScheduleDays: "weekdays", Timezone: "UTC"}
+	// Friday
+	now := time.Date(2026, 10, 16, 21, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC), lastOccurrence(p, p.StopAt, now))
+	assert.Equal(t, time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), nextOccurrence(p, p.StartAt, now))
+	// the clock of now is not the next occurrence.
+	assert.Equal(t, time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC),
+		nextOccurrence(p, p.StopAt, time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)))
+
+	action, at := NextAction(p, common.StatusStopped, now)
+	assert.Equal(t, common.PolicyActionStart, action)
+	assert.Equal(t, time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), at)
+
+	expireAt := now.Add(time.Hour)
+	p.ExpireAt = &expireAt
+	action, at = NextAction(p, common.StatusStopped, now)
+	assert.Equal(t, common.PolicyActionDelete, action)
+	assert.Equal(t, expireAt, at)
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Date(2026, 10, 16, 21, 0, 0, 0, time.UTC)
+	p := &common.ClusterPolicy{}
+	assert.Error(t, Extend(p, time.Hour, now))
+	assert.NoError(t, SetTTL(p, 48*time.Hour, now))
+	assert.Equal(t, now.Add(48*time.Hour), *p.ExpireAt)
+	assert.NoError(t, Extend(p, 24*time.Hour, now))
+	assert.Equal(t, now.Add(72*time.Hour), *p.ExpireAt)
+	// the extension of expired cluster is started from now.
+	assert.NoError(t, Extend(p, time.Hour, now.Add(100*time.Hour)))
+	assert.Equal(t, now.Add(101*time.Hour), *p.ExpireAt)
+}
+
+func TestAutoHeal(t *testing.T) {
+	p := &common.ClusterPolicy{AutoHealGracePeriod: "5m"}
+	assert.Error(t, Validate(p))
+	p.AutoHeal = true
+	assert.NoError(t, Validate(p))
+	assert.False(t, p.IsEmpty())
+	assert.Equal(t, 5*time.Minute, GracePeriod(p))
+	p.AutoHealGracePeriod = "-1m"
+	assert.Error(t, Validate(p))
+	p.AutoHealGracePeriod = ""
+	assert.Equal(t, DefaultAutoHealGracePeriod, GracePeriod(p))
+
+	assert.NoError(t, ValidateProvider(p, "aws"))
+	assert.Error(t, ValidateProvider(p, "native"))
+}
+
+func TestValidateProviderSchedule(t *testing.T) {
+	for _, p := range []*common.ClusterPolicy{
+		{StopAt: "20:00"},
+		{StartAt: "08:00"},
+		{StopAt: "20:00", StartAt: "08:00", ScheduleDays: "weekdays"},
+	} {
+		assert.NoError(t, ValidateProvider(p, "aws"))
+		assert.Error(t, ValidateProvider(p, "native"))
+	}
+	// the ttl works with native clusters.
+	expireAt := time.Now().Add(time.Hour)
+	assert.NoError(t, ValidateProvider(&common.ClusterPolicy{ExpireAt: &expireAt}, "native"))
+}
+
+func TestSchedulerSync(t *testing.T) {
+	now := time.Date(2026, 10, 16, 19, 50, 0, 0, time.UTC)
+	expireAt := now.Add(5 * time.Minute)
+	states := []*common.ClusterState{
+		{
+			Metadata:      types.Metadata{ContextName: "stop"},
+			Status:        common.StatusRunning,
+			ClusterPolicy: common.ClusterPolicy{StopAt: "20:00", StartAt: "08:00", Timezone: "UTC"},
+		},
+		{
+			Metadata:      types.Metadata{ContextName: "expire"},
+			Status:        common.StatusStopped,
+			ClusterPolicy: common.ClusterPolicy{ExpireAt: &expireAt},
+		},
+		{
+			Metadata: types.Metadata{ContextName: "none"},
+			Status:   common.StatusRunning,
+		},
+	}
+
+	var (
+		m        sync.Mutex
+		wg       sync.WaitGroup
+		actions  = map[string]string{}
+		warnings = map[string]string{}
+	)
+	s := NewScheduler(30 * time.Minute)
+	s.last = now.Add(-time.Minute)
+	s.now = func() time.Time { return now }
+	s.run = func(state *common.ClusterState, action string) error {
+		defer wg.Done()
+		m.Lock()
+		defer m.Unlock()
+		actions[state.ContextName] = action
+		return nil
+	}
+	s.warn = func(warning *common.PolicyWarning) {
+		warnings[warning.Cluster] = warning.Action
+	}
+
+	s.Sync(states)
+	assert.Equal(t, map[string]string{"stop": common.PolicyActionStop, "expire": common.PolicyActionDelete}, warnings)
+	assert.Empty(t, actions)
+
+	// warnings are only broadcast once.
+	warnings = map[string]string{}
+	s.Sync(states)
+	assert.Empty(t, warnings)
+
+	now = now.Add(11 * time.Minute)
+	wg.Add(2)
+	s.Sync(states)
+	wg.Wait()
+	assert.Equal(t, map[string]string{"stop": common.PolicyActionStop, "expire": common.PolicyActionDelete}, actions)
+
+	// the scheduled stop is taken only once even if the cluster is running again.
+	actions = map[string]string{}
+	states = states[:1]
+	now = now.Add(time.Minute)
+	s.Sync(states)
+	assert.Empty(t, actions)
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Scheduler enforces the policies of clusters.
+type Scheduler struct {
+	// WarnBefore is the duration to broadcast warning before the cluster is stopped or deleted.
+	WarnBefore time.Duration
+	// last is the time of last sync, the scheduled stop/start between last sync and now will be taken.
+	last   time.Time
+	warned map[string]time.Time
+	// busy holds the clusters whose action is still in progress.
+	busy sync.Map
+
+	now  func() time.Time
+	run  func(state *common.ClusterState, action string) error
+	warn func(warning *common.PolicyWarning)
+}
+
+// NewScheduler returns the scheduler to enforce the cluster policies from now on.
+func NewScheduler(warnBefore time.Duration) *Scheduler {
+	return &Scheduler{
+		WarnBefore: warnBefore,
+		last:       time.Now(),
+		warned:     map[string]time.Time{},
+		now:        time.Now,
+		run:        runAction,
+		warn:       broadcastWarning,
+	}
+}
+
+// StartScheduler checks the policies of clusters every interval until the context is done.
+func StartScheduler(ctx context.Context, interval, warnBefore time.Duration) {
+	s := NewScheduler(warnBefore)
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		states, err := common.DefaultDB.ListCluster("")
+		if err != nil {
+			logrus.Warnf("[policy] failed to list clusters: %v", err)
+			return
+		}
+		s.Sync(states)
+	}, interval)
+}
+
+// Sync takes the due actions of the cluster policies and broadcasts the warnings of coming destructive actions.
+func (s *Scheduler) Sync(states []*common.ClusterState) {
+	now := s.now()
+	for _, state := range states {
+		if state.ClusterPolicy.IsEmpty() {
+			continue
+		}
+		if _, ok := s.busy.Load(state.ContextName); ok {
+			continue
+		}
+		if action := s.dueAction(state, now); action != "" {
+			s.take(state, action)
+			continue
+		}
+		s.warnComing(state, now)
+	}
+	for key, at := range s.warned {
+		if at.Before(now) {
+			delete(s.warned, key)
+		}
+	}
+	s.last = now
+}
+
+func (s *Scheduler) dueAction(state *common.ClusterState, now time.Time) string {
+	p := &state.ClusterPolicy
+	if p.ExpireAt != nil && !p.ExpireAt.After(now) {
+		switch state.Status {
+		case common.StatusRunning, common.StatusStopped, common.StatusFailed, common.StatusMissing:
+			return common.PolicyActionDelete
+		}
+		return ""
+	}
+	if p.StopAt != "" && state.Status == common.StatusRunning && lastOccurrence(p, p.StopAt, now).After(s.last) {
+		return common.PolicyActionStop
+	}
+	if p.StartAt != "" && state.Status == common.StatusStopped && lastOccurrence(p, p.StartAt, now).After(s.last) {
+		return common.PolicyActionStart
+	}
+	return ""
+}
+
+func (s *Scheduler) warnComing(state *common.ClusterState, now time.Time) {
+	action, at := NextAction(&state.ClusterPolicy, state.Status, now)
+	if action == "" || action == common.PolicyActionStart || at.Sub(now) > s.WarnBefore {
+		return
+	}
+	if action == common.PolicyActionStop && state.Status != common.StatusRunning {
+		return
+	}
+	key := fmt.Sprintf("%s/%s/%d", state.ContextName, action, at.Unix())
+	if _, ok := s.warned[key]; ok {
+		return
+	}
+	s.warned[key] = at
+	s.warn(&common.PolicyWarning{
+		Cluster:     state.ContextName,
+		Action:      action,
+		ScheduledAt: at,
+		Message: fmt.Sprintf("cluster %s will be %s by policy at %s",
+			state.ContextName, actionDone(action), at.Format(time.RFC3339)),
+	})
+}
+
+func (s *Scheduler) take(state *common.ClusterState, action string) {
+	logrus.Infof("[policy] cluster %s is %s by policy", state.ContextName, actionDone(action))
+	s.busy.Store(state.ContextName, action)
+	go func() {
+		defer s.busy.Delete(state.ContextName)
+		if err := s.run(state, action); err != nil {
+			logrus.Errorf("[policy] failed to %s cluster %s: %v", action, state.ContextName, err)
+		}
+	}()
+}
+
+func actionDone(action string) string {
+	switch action {
+	case common.PolicyActionStop:
+		return "stopped"
+	case common.PolicyActionStart:
+		return "started"
+	case common.PolicyActionDelete:
+		return "deleted"
+	}
+	return action
+}
+
+func broadcastWarning(warning *common.PolicyWarning) {
+	logrus.Warnf("[policy] %s", warning.Message)
+	common.DefaultDB.BroadcastPolicyWarning(warning)
+}
+
+func runAction(state *common.ClusterState, action string) error {
+	provider, err := cluster.ProviderFromState(state, state.Master, state.Worker)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case common.PolicyActionStop:
+		provider.RegisterCallbacks(state.ContextName, "update", common.DefaultDB.BroadcastObject)
+		return provider.StopK3sCluster()
+	case common.PolicyActionStart:
+		provider.RegisterCallbacks(state.ContextName, "update", common.DefaultDB.BroadcastObject)
+		return provider.StartK3sCluster()
+	case common.PolicyActionDelete:
+		return provider.DeleteK3sCluster(true)
+	}
+	return fmt.Errorf("unknown action %s", action)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/slb"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
@@ -57,8 +58,9 @@ type Alibaba struct {
 	alibaba.Options       `json:",inline"`
 	VpcCIDR               string
 
-	c *ecs.Client
-	v *vpc.Client
+	c  *ecs.Client
+	v  *vpc.Client
+	lb *slb.Client
 }
 
 func init() {
@@ -121,7 +123,7 @@ func (p *Alibaba) JoinK3sNode() (err error) {
 	if p.SSHUser == "" {
 		p.SSHUser = defaultUser
 	}
-	return p.JoinNodes(p.generateInstance, func() error { return nil }, false, p.rollbackInstance)
+	return p.JoinNodes(p.NodePoolInstance(&p.Options, p.generateInstance), func() error { return nil }, false, p.rollbackInstance)
 }
 
 func (p *Alibaba) rollbackInstance(ids []string) error {
@@ -158,6 +160,30 @@ func (p *Alibaba) DeleteK3sCluster(f bool) error {
 	return p.DeleteCluster(f, p.deleteInstance)
 }
 
+// StopK3sCluster stops the instances of K3s cluster, the instances are not charged after stopped.
+func (p *Alibaba) StopK3sCluster() error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	return p.StopCluster(p.stopInstances)
+}
+
+// StartK3sCluster starts the instances of K3s cluster.
+func (p *Alibaba) StartK3sCluster() error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	return p.StartCluster(p.startInstances, p.getInstanceNodes)
+}
+
+// RemoveK3sNodes removes the worker nodes from K3s cluster and deletes the instances.
+func (p *Alibaba) RemoveK3sNodes(ids []string) error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	return p.RemoveNodes(ids, p.rollbackInstance)
+}
+
 // SSHK3sNode ssh K3s node.
 func (p *Alibaba) SSHK3sNode(ip string) error {
 	c := &types.Cluster{
@@ -207,18 +233,24 @@ func (p *Alibaba) IsClusterExist() (bool, []string, error) {
 // GenerateMasterExtraArgs generates K3S master extra args.
 func (p *Alibaba) GenerateMasterExtraArgs(cluster *types.Cluster, master types.Node) string {
 	if option, ok := cluster.Options.(alibaba.Options); ok {
+		// the nodes created before spreading across zones are in the zone of cluster.
+		zone := master.Zone
+		if zone == "" {
+			zone = option.Zone
+		}
 		if option.CloudControllerManager {
 			extraArgs := fmt.Sprintf(" --kubelet-arg=cloud-provider=external --kubelet-arg=provider-id=%s.%s --node-name=%s.%s",
 				option.Region, master.InstanceID, option.Region, master.InstanceID)
-			return extraArgs
+			return extraArgs + putil.ZoneExtraArgs(zone)
 		}
+		return putil.ZoneExtraArgs(zone)
 	}
 	return ""
 }
 
 // GenerateWorkerExtraArgs generates K3S worker extra args.
 func (p *Alibaba) GenerateWorkerExtraArgs(cluster *types.Cluster, worker types.Node) string {
-	return p.GenerateMasterExtraArgs(cluster, worker)
+	return p.GenerateMasterExtraArgs(p.NodePoolCluster(cluster, worker), worker) + p.NodePoolExtraArgs(cluster, worker)
 }
 
 // GetCluster returns cluster status.
@@ -242,6 +274,9 @@ func (p *Alibaba) DescribeCluster(kubecfg string) *types.ClusterInfo {
 		Zone:     p.Zone,
 		Provider: p.GetProviderName(),
 	}
+	if len(p.Zones) > 0 {
+		c.Zone = strings.Join(p.Zones, ",")
+	}
 	return p.Describe(kubecfg, c, p.getInstanceNodes)
 }
 
@@ -301,15 +336,45 @@ func (p *Alibaba) generateClientSDK() error {
 	}
 	p.v = vpcClient
 
+	lbClient, err := slb.NewClientWithAccessKey(p.Region, p.AccessKey, p.AccessSecret)
+	if err != nil {
+		return err
+	}
+	p.lb = lbClient
+
 	return nil
 }
 
-func (p *Alibaba) runInstances(num int, master bool, password string) error {
+// runZoneInstances runs the instances round-robin across the zones of cluster, the zones which have fewer
+// existing nodes are preferred.
+func (p *Alibaba) runZoneInstances(num int, master bool, existing []types.Node, password string) error {
+	zones, vSwitches := p.zoneVSwitches()
+	for i, n := range putil.SpreadZones(zones, existing, num) {
+		if n == 0 {
+			continue
+		}
+		if err := p.runInstances(n, master, zones[i], vSwitches[i], password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zoneVSwitches returns the zones of cluster and the vSwitch of each zone. The single zone is used if the
+// vSwitch isn't the first one of the zones, e.g. it's overridden by the node pool.
+func (p *Alibaba) zoneVSwitches() ([]string, []string) {
+	if len(p.Zones) == 0 || len(p.VSwitches) != len(p.Zones) || p.Zone != p.Zones[0] || p.VSwitch != p.VSwitches[0] {
+		return []string{p.Zone}, []string{p.VSwitch}
+	}
+	return p.Zones, p.VSwitches
+}
+
+func (p *Alibaba) runInstances(num int, master bool, zone, vSwitch, password string) error {
 	request := ecs.CreateRunInstancesRequest()
 	request.Scheme = "https"
 	request.InstanceType = p.InstanceType
 	request.ImageId = p.Image
-	request.VSwitchId = p.VSwitch
+	request.VSwitchId = vSwitch
 	request.KeyPairName = p.KeyPair
 	request.SystemDiskCategory = p.DiskCategory
 	request.SystemDiskSize = p.DiskSize
@@ -331,8 +396,8 @@ func (p *Alibaba) runInstances(num int, master bool, password string) error {
 		request.InternetMaxBandwidthOut = requests.NewInteger(bandwidth)
 	}
 
-	if p.Zone != "" {
-		request.ZoneId = p.Zone
+	if zone != "" {
+		request.ZoneId = zone
 	}
 	if password != "" {
 		request.Password = password
@@ -366,10 +431,10 @@ func (p *Alibaba) runInstances(num int, master bool, password string) error {
 	response, err := p.c.RunInstances(request)
 	if err != nil || len(response.InstanceIdSets.InstanceIdSet) != num {
 		return fmt.Errorf("[%s] calling runInstances error. region: %s, zone: %s, "+"instanceName: %s, msg: [%v]",
-			p.GetProviderName(), p.Region, p.Zone, request.InstanceName, err)
+			p.GetProviderName(), p.Region, zone, request.InstanceName, err)
 	}
 	for _, id := range response.InstanceIdSets.InstanceIdSet {
-		p.M.Store(id, types.Node{Master: master, RollBack: true, InstanceID: id, InstanceStatus: alibaba.StatusPending})
+		p.M.Store(id, types.Node{Master: master, RollBack: true, InstanceID: id, InstanceStatus: alibaba.StatusPending, Zone: zone})
 	}
 
 	return nil
@@ -401,6 +466,11 @@ func (p *Alibaba) deleteInstance(f bool) (string, error) {
 			return "", err
 		}
 	}
+	if p.ControlPlaneEndpoint {
+		if err := p.deleteEndpoint(); err != nil && !f {
+			return "", err
+		}
+	}
 	p.releaseEipAddresses(false)
 	if err == nil && len(ids) > 0 {
 		p.Logger.Infof("[%s] cluster %s will be deleted", p.GetProviderName(), p.Name)
@@ -524,7 +594,8 @@ func (p *Alibaba) assembleInstanceStatus(ssh *types.SSH, uploadKeyPair bool, pub
 			InstanceStatus:    status.Status,
 			InternalIPAddress: status.VpcAttributes.PrivateIpAddress.IpAddress,
 			EipAllocationIds:  publicIPAddress,
-			PublicIPAddress:   eip})
+			PublicIPAddress:   eip,
+			Zone:              status.ZoneId})
 	}
 	return nil
 }
@@ -535,7 +606,8 @@ func (p *Alibaba) describeInstances() ([]ecs.Instance, error) {
 	pageSize := 20
 	request.PageSize = requests.NewInteger(pageSize)
 	request.Tag = &[]ecs.DescribeInstancesTag{{Key: "autok3s", Value: "true"}, {Key: "cluster", Value: common.TagClusterPrefix + p.ContextName}}
-	if p.Zone != "" {
+	// the instances spread across zones are listed in the region.
+	if p.Zone != "" && len(p.Zones) == 0 {
 		request.ZoneId = p.Zone
 	}
 	instanceList := make([]ecs.Instance, 0)
@@ -624,12 +696,36 @@ func (p *Alibaba) CreateCheck() error {
 		}
 	}
 
+	if len(p.Zones) > 0 || len(p.VSwitches) > 0 {
+		p.Zones = putil.ParseList(p.Zones)
+		p.VSwitches = putil.ParseList(p.VSwitches)
+		if len(p.Zones) == 0 {
+			return fmt.Errorf("[%s] calling preflight error: `--v-switches` must be set with `--zones`", p.GetProviderName())
+		}
+		if p.VSwitch != "" {
+			return fmt.Errorf("[%s] calling preflight error: `--v-switch` can't be set with `--zones`, please use `--v-switches` instead", p.GetProviderName())
+		}
+		if err := putil.ValidateZones(p.GetProviderName(), p.Zones, p.VSwitches, "v-switches"); err != nil {
+			return err
+		}
+		p.Zone = p.Zones[0]
+		if len(p.VSwitches) > 0 {
+			p.VSwitch = p.VSwitches[0]
+		}
+	}
+
 	return nil
 }
 
 // JoinCheck check join command and flags.
 func (p *Alibaba) JoinCheck() error {
-	return p.CheckJoinArgs(p.IsClusterExist)
+	if err := p.CheckJoinArgs(p.IsClusterExist); err != nil {
+		return err
+	}
+	if len(p.Zones) > 0 && len(p.VSwitches) != len(p.Zones) {
+		return fmt.Errorf("[%s] calling preflight error: `--zones` can only be set when creating the cluster", p.GetProviderName())
+	}
+	return p.ValidateNodePool(&p.Options, "zone", "zones", "v-switches")
 }
 
 func (p *Alibaba) describeEipAddresses(allocationIds []string) ([]vpc.EipAddress, error) {
@@ -911,7 +1007,12 @@ func (p *Alibaba) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 
 	p.Logger.Infof("[%s] %d masters and %d workers will be added in region %s", p.GetProviderName(), masterNum, workerNum, p.Region)
 
-	if p.VSwitch == "" {
+	if len(p.Zones) > 0 && len(p.VSwitches) != len(p.Zones) {
+		// get default vpc and vswitch of each zone.
+		if err := p.configZoneNetwork(); err != nil {
+			return nil, err
+		}
+	} else if p.VSwitch == "" {
 		// get default vpc and vswitch.
 		err := p.configNetwork()
 		if err != nil {
@@ -947,7 +1048,7 @@ func (p *Alibaba) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	// run ecs master instances.
 	if masterNum > 0 {
 		p.Logger.Infof("[%s] prepare for %d of master instances", p.GetProviderName(), masterNum)
-		if err := p.runInstances(masterNum, true, ssh.SSHPassword); err != nil {
+		if err := p.runZoneInstances(masterNum, true, p.Status.MasterNodes, ssh.SSHPassword); err != nil {
 			return nil, err
 		}
 		p.Logger.Infof("[%s] %d of master instances created successfully", p.GetProviderName(), masterNum)
@@ -956,7 +1057,7 @@ func (p *Alibaba) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	// run ecs worker instances.
 	if workerNum > 0 {
 		p.Logger.Infof("[%s] prepare for %d of worker instances", p.GetProviderName(), workerNum)
-		if err := p.runInstances(workerNum, false, ssh.SSHPassword); err != nil {
+		if err := p.runZoneInstances(workerNum, false, p.Status.WorkerNodes, ssh.SSHPassword); err != nil {
 			return nil, err
 		}
 		p.Logger.Infof("[%s] %d of worker instances created successfully", p.GetProviderName(), workerNum)
@@ -1017,6 +1118,10 @@ func (p *Alibaba) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	}
 	c.SSH = *ssh
 
+	if err = p.ConfigControlPlaneEndpoint(c, p.ensureEndpoint); err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
@@ -1077,6 +1182,7 @@ func (p *Alibaba) getInstanceNodes() ([]types.Node, error) {
 			InstanceStatus:    instance.Status,
 			InternalIPAddress: instance.VpcAttributes.PrivateIpAddress.IpAddress,
 			PublicIPAddress:   instance.PublicIpAddress.IpAddress,
+			Zone:              instance.ZoneId,
 		}
 		if p.EIP {
 			node.PublicIPAddress = []string{instance.EipAddress.IpAddress}
@@ -1274,6 +1380,26 @@ func (p *Alibaba) configNetwork() error {
 	return nil
 }
 
+// configZoneNetwork finds or generates the default vswitch of each zone when the nodes are spread across zones.
+func (p *Alibaba) configZoneNetwork() error {
+	zone := p.Zone
+	defer func() {
+		p.Zone = zone
+	}()
+	vSwitches := make([]string, 0, len(p.Zones))
+	for _, z := range p.Zones {
+		p.Zone = z
+		p.VSwitch = ""
+		if err := p.configNetwork(); err != nil {
+			return err
+		}
+		vSwitches = append(vSwitches, p.VSwitch)
+	}
+	p.VSwitches = vSwitches
+	p.VSwitch = vSwitches[0]
+	return nil
+}
+
 func (p *Alibaba) configSecurityGroup() error {
 	p.Logger.Infof("[%s] config default security group for %s in region %s", p.GetProviderName(), p.Vpc, p.Region)
 
@@ -1453,3 +1579,83 @@ func (p *Alibaba) uploadKeyPair(node types.Node, publicKey string) error {
 
 	return nil
 }
+
+func (p *Alibaba) stopInstances(ids []string) error {
+	ids, err := p.filterInstances(ids, alibaba.StatusRunning)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	p.Logger.Infof("[%s] stopping instances %s...", p.GetProviderName(), ids)
+	request := ecs.CreateStopInstancesRequest()
+	request.Scheme = "https"
+	request.RegionId = p.Region
+	request.InstanceId = &ids
+	request.StoppedMode = "StopCharging"
+	if _, err = p.c.StopInstances(request); err != nil {
+		return err
+	}
+	return p.waitInstances(ids, alibaba.StatusStopped)
+}
+
+func (p *Alibaba) startInstances(ids []string) error {
+	ids, err := p.filterInstances(ids, alibaba.StatusStopped)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	p.Logger.Infof("[%s] starting instances %s...", p.GetProviderName(), ids)
+	request := ecs.CreateStartInstancesRequest()
+	request.Scheme = "https"
+	request.RegionId = p.Region
+	request.InstanceId = &ids
+	if _, err = p.c.StartInstances(request); err != nil {
+		return err
+	}
+	return p.waitInstances(ids, alibaba.StatusRunning)
+}
+
+// filterInstances returns the instances which are in the status.
+func (p *Alibaba) filterInstances(ids []string, status string) ([]string, error) {
+	statuses, err := p.describeInstanceStatus(ids)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if statuses[id] == status {
+			rtn = append(rtn, id)
+		}
+	}
+	return rtn, nil
+}
+
+// waitInstances waits until all the instances are in the status.
+func (p *Alibaba) waitInstances(ids []string, status string) error {
+	return wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		statuses, err := p.describeInstanceStatus(ids)
+		if err != nil {
+			return false, nil
+		}
+		for _, id := range ids {
+			if statuses[id] != status {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+func (p *Alibaba) describeInstanceStatus(ids []string) (map[string]string, error) {
+	request := ecs.CreateDescribeInstanceStatusRequest()
+	request.Scheme = "https"
+	request.RegionId = p.Region
+	request.InstanceId = &ids
+	response, err := p.c.DescribeInstanceStatus(request)
+	if err != nil {
+		return nil, err
+	}
+	statuses := map[string]string{}
+	for _, status := range response.InstanceStatuses.InstanceStatus {
+		statuses[status.InstanceId] = status.Status
+	}
+	return statuses, nil
+}
@@ -0,0 +1,116 @@
+package alibaba
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/slb"
+)
+
+// ensureEndpoint creates the server load balancer of the control plane if not exist and adds the masters as backend.
+func (p *Alibaba) ensureEndpoint(masters []types.Node) (string, error) {
+	name := cluster.EndpointName(p.ContextName)
+	lb, err := p.getLoadBalancer(name)
+	if err != nil {
+		return "", err
+	}
+	id, address := "", ""
+	if lb != nil {
+		id, address = lb.LoadBalancerId, lb.Address
+	} else {
+		p.Logger.Infof("[%s] creating server load balancer %s", p.GetProviderName(), name)
+		request := slb.CreateCreateLoadBalancerRequest()
+		request.Scheme = "https"
+		request.RegionId = p.Region
+		request.LoadBalancerName = name
+		request.AddressType = "internet"
+		request.InternetChargeType = "paybytraffic"
+		response, err := p.lb.CreateLoadBalancer(request)
+		if err != nil {
+			return "", fmt.Errorf("failed to create server load balancer %s: %v", name, err)
+		}
+		id, address = response.LoadBalancerId, response.Address
+	}
+
+	listener := slb.CreateCreateLoadBalancerTCPListenerRequest()
+	listener.Scheme = "https"
+	listener.RegionId = p.Region
+	listener.LoadBalancerId = id
+	listener.ListenerPort = requests.NewInteger(cluster.EndpointPort)
+	listener.BackendServerPort = requests.NewInteger(cluster.EndpointPort)
+	listener.Bandwidth = requests.NewInteger(-1)
+	if _, err = p.lb.CreateLoadBalancerTCPListener(listener); err != nil {
+		if e, ok := err.(*errors.ServerError); !ok || e.ErrorCode() != "ListenerAlreadyExists" {
+			return "", fmt.Errorf("failed to create listener of server load balancer %s: %v", name, err)
+		}
+	}
+	start := slb.CreateStartLoadBalancerListenerRequest()
+	start.Scheme = "https"
+	start.RegionId = p.Region
+	start.LoadBalancerId = id
+	start.ListenerPort = requests.NewInteger(cluster.EndpointPort)
+	start.ListenerProtocol = "tcp"
+	if _, err = p.lb.StartLoadBalancerListener(start); err != nil {
+		return "", fmt.Errorf("failed to start listener of server load balancer %s: %v", name, err)
+	}
+
+	if len(masters) > 0 {
+		servers := make([]map[string]string, 0, len(masters))
+		for _, master := range masters {
+			servers = append(servers, map[string]string{"ServerId": master.InstanceID, "Weight": "100"})
+		}
+		data, err := json.Marshal(servers)
+		if err != nil {
+			return "", err
+		}
+		request := slb.CreateAddBackendServersRequest()
+		request.Scheme = "https"
+		request.RegionId = p.Region
+		request.LoadBalancerId = id
+		request.BackendServers = string(data)
+		if _, err = p.lb.AddBackendServers(request); err != nil {
+			return "", fmt.Errorf("failed to add masters to server load balancer %s: %v", name, err)
+		}
+	}
+	return address, nil
+}
+
+// deleteEndpoint removes the server load balancer of the control plane.
+func (p *Alibaba) deleteEndpoint() error {
+	name := cluster.EndpointName(p.ContextName)
+	lb, err := p.getLoadBalancer(name)
+	if err != nil || lb == nil {
+		return err
+	}
+	p.Logger.Infof("[%s] deleting server load balancer %s", p.GetProviderName(), name)
+	request := slb.CreateDeleteLoadBalancerRequest()
+	request.Scheme = "https"
+	request.RegionId = p.Region
+	request.LoadBalancerId = lb.LoadBalancerId
+	if _, err = p.lb.DeleteLoadBalancer(request); err != nil {
+		return fmt.Errorf("failed to delete server load balancer %s: %v", name, err)
+	}
+	return nil
+}
+
+func (p *Alibaba) getLoadBalancer(name string) (*slb.LoadBalancer, error) {
+	request := slb.CreateDescribeLoadBalancersRequest()
+	request.Scheme = "https"
+	request.RegionId = p.Region
+	request.LoadBalancerName = name
+	response, err := p.lb.DescribeLoadBalancers(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe server load balancer %s: %v", name, err)
+	}
+	for _, lb := range response.LoadBalancers.LoadBalancer {
+		if lb.LoadBalancerName == name {
+			return &lb, nil
+		}
+	}
+	return nil, nil
+}
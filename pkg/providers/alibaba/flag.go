@@ -116,6 +116,7 @@ func (p *Alibaba) MergeClusterOptions() error {
 func (p *Alibaba) GetJoinFlags() []types.Flag {
 	fs := p.sharedFlags()
 	fs = append(fs, p.GetClusterOptions()...)
+	fs = append(fs, p.GetNodePoolOptions()...)
 	return fs
 }
 
@@ -201,6 +202,12 @@ func (p *Alibaba) sharedFlags() []types.Flag {
 			Usage:  "ECS zone",
 			EnvVar: "ECS_ZONE",
 		},
+		{
+			Name:  "zones",
+			P:     &p.Zones,
+			V:     p.Zones,
+			Usage: "Spread the nodes across the ECS zones of region, which overrides --zone, i.e.(--zones cn-hangzhou-i,cn-hangzhou-j,cn-hangzhou-k)",
+		},
 		{
 			Name:   "key-pair",
 			P:      &p.KeyPair,
@@ -229,6 +236,12 @@ func (p *Alibaba) sharedFlags() []types.Flag {
 			Usage:  "Specify the vSwitch to be used by the instance, see: https://help.aliyun.com/document_detail/100380.html?spm=a2c4g.11186623.6.563.733b103bRTApHj",
 			EnvVar: "ECS_VSWITCH_ID",
 		},
+		{
+			Name:  "v-switches",
+			P:     &p.VSwitches,
+			V:     p.VSwitches,
+			Usage: "Specify the vSwitch for each zone of --zones in the same order, the default vSwitch of each zone is used by default",
+		},
 		{
 			Name:   "disk-category",
 			P:      &p.DiskCategory,
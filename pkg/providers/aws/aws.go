@@ -26,6 +26,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/sirupsen/logrus"
 )
 
@@ -78,6 +79,7 @@ type Amazon struct {
 	*cluster.ProviderBase `json:",inline"`
 	typesaws.Options      `json:",inline"`
 	client                *ec2.EC2
+	lb                    *elbv2.ELBV2
 }
 
 func init() {
@@ -157,7 +159,7 @@ func (p *Amazon) JoinK3sNode() (err error) {
 	if p.SSHUser == "" {
 		p.SSHUser = defaultUser
 	}
-	return p.JoinNodes(p.generateInstance, p.syncInstances, false, p.rollbackInstance)
+	return p.JoinNodes(p.NodePoolInstance(&p.Options, p.generateInstance), p.syncInstances, false, p.rollbackInstance)
 }
 
 // DeleteK3sCluster delete K3S cluster.
@@ -165,6 +167,24 @@ func (p *Amazon) DeleteK3sCluster(f bool) (err error) {
 	return p.DeleteCluster(f, p.deleteInstance)
 }
 
+// StopK3sCluster stops the instances of K3s cluster.
+func (p *Amazon) StopK3sCluster() error {
+	p.newClient()
+	return p.StopCluster(p.stopInstances)
+}
+
+// StartK3sCluster starts the instances of K3s cluster.
+func (p *Amazon) StartK3sCluster() error {
+	p.newClient()
+	return p.StartCluster(p.startInstances, p.getInstanceNodes)
+}
+
+// RemoveK3sNodes removes the worker nodes from K3s cluster and terminates the instances.
+func (p *Amazon) RemoveK3sNodes(ids []string) error {
+	p.newClient()
+	return p.RemoveNodes(ids, p.rollbackInstance)
+}
+
 // SSHK3sNode ssh K3s node.
 func (p *Amazon) SSHK3sNode(ip string) error {
 	c := &types.Cluster{
@@ -201,16 +221,23 @@ func (p *Amazon) IsClusterExist() (bool, []string, error) {
 // GenerateMasterExtraArgs generates K3S master extra args.
 func (p *Amazon) GenerateMasterExtraArgs(cluster *types.Cluster, master types.Node) string {
 	if option, ok := cluster.Options.(typesaws.Options); ok {
+		// the nodes created before spreading across zones are in the zone of cluster.
+		zone := master.Zone
+		if zone == "" {
+			zone = option.Zone
+		}
 		if option.CloudControllerManager {
-			return fmt.Sprintf(" --kubelet-arg=cloud-provider=external --kubelet-arg=provider-id=aws:///%s/%s --node-name='$(hostname -f)'", option.Zone, master.InstanceID)
+			return fmt.Sprintf(" --kubelet-arg=cloud-provider=external --kubelet-arg=provider-id=aws:///%s/%s --node-name='$(hostname -f)'", zone, master.InstanceID) +
+				putil.ZoneExtraArgs(zone)
 		}
+		return putil.ZoneExtraArgs(zone)
 	}
 	return ""
 }
 
 // GenerateWorkerExtraArgs generates K3S worker extra args.
 func (p *Amazon) GenerateWorkerExtraArgs(cluster *types.Cluster, worker types.Node) string {
-	return p.GenerateMasterExtraArgs(cluster, worker)
+	return p.GenerateMasterExtraArgs(p.NodePoolCluster(cluster, worker), worker) + p.NodePoolExtraArgs(cluster, worker)
 }
 
 // SetOptions set options.
@@ -246,6 +273,9 @@ func (p *Amazon) DescribeCluster(kubecfg string) *types.ClusterInfo {
 		Zone:     p.Zone,
 		Provider: p.GetProviderName(),
 	}
+	if len(p.Zones) > 0 {
+		c.Zone = strings.Join(p.Zones, ",")
+	}
 	return p.Describe(kubecfg, c, p.getInstanceNodes)
 }
 
@@ -312,7 +342,7 @@ func (p *Amazon) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	// run ecs master instances.
 	if masterNum > 0 {
 		p.Logger.Infof("[%s] prepare for %d of master instances", p.GetProviderName(), masterNum)
-		if err := p.runInstances(masterNum, true, ssh); err != nil {
+		if err := p.runZoneInstances(masterNum, true, p.Status.MasterNodes, ssh); err != nil {
 			return nil, err
 		}
 		p.Logger.Infof("[%s] %d of master instances created successfully", p.GetProviderName(), masterNum)
@@ -321,7 +351,7 @@ func (p *Amazon) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	// run ecs worker instances.
 	if workerNum > 0 {
 		p.Logger.Infof("[%s] prepare for %d of worker instances", p.GetProviderName(), workerNum)
-		if err := p.runInstances(workerNum, false, ssh); err != nil {
+		if err := p.runZoneInstances(workerNum, false, p.Status.WorkerNodes, ssh); err != nil {
 			return nil, err
 		}
 		p.Logger.Infof("[%s] %d of worker instances created successfully", p.GetProviderName(), workerNum)
@@ -349,6 +379,10 @@ func (p *Amazon) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	}
 	c.SSH = *ssh
 
+	if err := p.ConfigControlPlaneEndpoint(c, p.ensureEndpoint); err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
@@ -358,9 +392,42 @@ func (p *Amazon) newClient() {
 	config = config.WithCredentials(credentials.NewStaticCredentials(p.AccessKey, p.SecretKey, p.SessionToken))
 	sess := session.Must(session.NewSession(config))
 	p.client = ec2.New(sess)
+	p.lb = elbv2.New(sess)
 }
 
-func (p *Amazon) runInstances(num int, master bool, ssh *types.SSH) error {
+// runZoneInstances runs the instances round-robin across the zones of cluster, the zones which have fewer
+// existing nodes are preferred.
+func (p *Amazon) runZoneInstances(num int, master bool, existing []types.Node, ssh *types.SSH) error {
+	zones, subnets := p.zoneSubnets()
+	for i, n := range putil.SpreadZones(zones, existing, num) {
+		if n == 0 {
+			continue
+		}
+		if err := p.runInstances(n, master, zones[i], subnets[i], ssh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zoneSubnets returns the zones of cluster and the subnet of each zone. The single zone is used if the
+// zone or subnet isn't the first one of the zones, e.g. it's overridden by the node pool.
+func (p *Amazon) zoneSubnets() ([]string, []string) {
+	if len(p.Zones) == 0 || len(p.SubnetIDs) != len(p.Zones) || p.Zone != p.Zones[0] || p.SubnetID != p.SubnetIDs[0] {
+		return []string{p.Zone}, []string{p.SubnetID}
+	}
+	return p.Zones, p.SubnetIDs
+}
+
+// subnets returns all subnets of cluster.
+func (p *Amazon) subnets() []string {
+	if len(p.SubnetIDs) > 0 {
+		return p.SubnetIDs
+	}
+	return []string{p.SubnetID}
+}
+
+func (p *Amazon) runInstances(num int, master bool, zone, subnetID string, ssh *types.SSH) error {
 	rootSize, err := strconv.ParseInt(p.RootSize, 10, 64)
 	if err != nil {
 		return fmt.Errorf("[%s] --root-size is invalid %v, must be integer: %v", p.GetProviderName(), p.RootSize, err)
@@ -387,7 +454,7 @@ func (p *Amazon) runInstances(num int, master bool, ssh *types.SSH) error {
 	netSpecs := []*ec2.InstanceNetworkInterfaceSpecification{{
 		DeviceIndex:              aws.Int64(0), // eth0
 		Groups:                   aws.StringSlice([]string{p.SecurityGroup}),
-		SubnetId:                 &p.SubnetID,
+		SubnetId:                 aws.String(subnetID),
 		AssociatePublicIpAddress: aws.Bool(true),
 	}}
 
@@ -407,7 +474,7 @@ func (p *Amazon) runInstances(num int, master bool, ssh *types.SSH) error {
 		MinCount: aws.Int64(int64(num)),
 		MaxCount: aws.Int64(int64(num)),
 		Placement: &ec2.Placement{
-			AvailabilityZone: &p.Zone,
+			AvailabilityZone: aws.String(zone),
 		},
 		KeyName:             &p.KeypairName,
 		InstanceType:        &p.InstanceType,
@@ -431,7 +498,7 @@ func (p *Amazon) runInstances(num int, master bool, ssh *types.SSH) error {
 
 	if err != nil || len(inst.Instances) != num {
 		return fmt.Errorf("[%s] calling runInstances error. region: %s, zone: %s, msg: [%v]",
-			p.GetProviderName(), p.Region, p.Zone, err)
+			p.GetProviderName(), p.Region, zone, err)
 	}
 	instanceList := inst.Instances
 
@@ -444,6 +511,7 @@ func (p *Amazon) runInstances(num int, master bool, ssh *types.SSH) error {
 				RollBack:       true,
 				InstanceID:     aws.StringValue(ins.InstanceId),
 				InstanceStatus: aws.StringValue(ins.State.Name),
+				Zone:           zone,
 				SSH:            *ssh})
 	}
 
@@ -573,7 +641,8 @@ func (p *Amazon) getInstanceNodes() ([]types.Node, error) {
 			InstanceID:        aws.StringValue(instance.InstanceId),
 			InstanceStatus:    aws.StringValue(instance.State.Name),
 			InternalIPAddress: []string{aws.StringValue(instance.PrivateIpAddress)},
-			PublicIPAddress:   []string{aws.StringValue(instance.PublicIpAddress)}})
+			PublicIPAddress:   []string{aws.StringValue(instance.PublicIpAddress)},
+			Zone:              instanceZone(instance)})
 	}
 	return nodes, nil
 }
@@ -591,6 +660,7 @@ func (p *Amazon) syncInstances() error {
 			v := value.(types.Node)
 			v.InternalIPAddress = []string{aws.StringValue(instance.PrivateIpAddress)}
 			v.PublicIPAddress = []string{aws.StringValue(instance.PublicIpAddress)}
+			v.Zone = instanceZone(instance)
 			p.M.Store(aws.StringValue(instance.InstanceId), v)
 			continue
 		}
@@ -608,11 +678,19 @@ func (p *Amazon) syncInstances() error {
 			InstanceID:        aws.StringValue(instance.InstanceId),
 			InstanceStatus:    aws.StringValue(instance.State.Name),
 			InternalIPAddress: []string{aws.StringValue(instance.PrivateIpAddress)},
-			PublicIPAddress:   []string{aws.StringValue(instance.PublicIpAddress)}})
+			PublicIPAddress:   []string{aws.StringValue(instance.PublicIpAddress)},
+			Zone:              instanceZone(instance)})
 	}
 	return nil
 }
 
+func instanceZone(instance *ec2.Instance) string {
+	if instance.Placement == nil {
+		return ""
+	}
+	return aws.StringValue(instance.Placement.AvailabilityZone)
+}
+
 func (p *Amazon) describeInstances() ([]*ec2.Instance, error) {
 	describeInput := &ec2.DescribeInstancesInput{
 		Filters: []*ec2.Filter{
@@ -709,67 +787,8 @@ func (p *Amazon) CreateCheck() error {
 		return fmt.Errorf("[%s] calling preflight error: can't generate instance without vpc and subnet", p.GetProviderName())
 	}
 
-	if p.SubnetID != "" && p.VpcID != "" {
-		// check subnet is belongs to vpc.
-		subnetFilter := []*ec2.Filter{
-			{
-				Name:   aws.String("subnet-id"),
-				Values: []*string{&p.SubnetID},
-			},
-		}
-
-		subnets, err := p.client.DescribeSubnets(&ec2.DescribeSubnetsInput{
-			Filters: subnetFilter,
-		})
-		if err != nil {
-			return err
-		}
-
-		if subnets == nil || len(subnets.Subnets) == 0 {
-			return fmt.Errorf("[%s] there's not subnet found by id %s", p.GetProviderName(), p.SubnetID)
-		}
-
-		if *subnets.Subnets[0].VpcId != p.VpcID {
-			return fmt.Errorf("[%s] subnetId %s does not belong to VpcId: %s", p.GetProviderName(), p.SubnetID, p.VpcID)
-		}
-	}
-
-	if p.SubnetID == "" {
-		filters := []*ec2.Filter{
-			{
-				Name:   aws.String("availability-zone"),
-				Values: []*string{&p.Zone},
-			},
-			{
-				Name:   aws.String("vpc-id"),
-				Values: []*string{&p.VpcID},
-			},
-		}
-
-		subnets, err := p.client.DescribeSubnets(&ec2.DescribeSubnetsInput{
-			Filters: filters,
-		})
-		if err != nil {
-			return err
-		}
-
-		if len(subnets.Subnets) == 0 {
-			return fmt.Errorf("can't get subnets for vpc %s at zone %s", p.VpcID, p.Zone)
-		}
-
-		// find default subnet.
-		if len(subnets.Subnets) > 1 {
-			for _, subnet := range subnets.Subnets {
-				if subnet.DefaultForAz != nil && *subnet.DefaultForAz {
-					p.SubnetID = *subnet.SubnetId
-					break
-				}
-			}
-		}
-
-		if p.SubnetID == "" {
-			p.SubnetID = *subnets.Subnets[0].SubnetId
-		}
+	if err = p.configZones(); err != nil {
+		return err
 	}
 	// check user-data
 	if p.UserDataPath != "" {
@@ -786,6 +805,12 @@ func (p *Amazon) JoinCheck() error {
 	if err := p.CheckJoinArgs(p.IsClusterExist); err != nil {
 		return err
 	}
+	if err := p.ValidateNodePool(&p.Options, "zones", "subnet-ids"); err != nil {
+		return err
+	}
+	if len(p.Zones) > 0 && len(p.SubnetIDs) != len(p.Zones) {
+		return fmt.Errorf("[%s] calling preflight error: `--zones` can only be set when creating the cluster", p.GetProviderName())
+	}
 	masterNum, err := strconv.Atoi(p.Master)
 	if err != nil {
 		return fmt.Errorf("[%s] calling preflight error: `--master` must be number",
@@ -807,6 +832,116 @@ func (p *Amazon) JoinCheck() error {
 	return nil
 }
 
+// configZones validates the zones of cluster and finds the subnet of each zone.
+func (p *Amazon) configZones() error {
+	zones := []string{p.Zone}
+	subnets := []string{p.SubnetID}
+	if len(p.Zones) > 0 || len(p.SubnetIDs) > 0 {
+		p.Zones = putil.ParseList(p.Zones)
+		p.SubnetIDs = putil.ParseList(p.SubnetIDs)
+		if len(p.Zones) == 0 {
+			return fmt.Errorf("[%s] calling preflight error: `--subnet-ids` must be set with `--zones`", p.GetProviderName())
+		}
+		if p.SubnetID != "" {
+			return fmt.Errorf("[%s] calling preflight error: `--subnet-id` can't be set with `--zones`, please use `--subnet-ids` instead", p.GetProviderName())
+		}
+		if err := putil.ValidateZones(p.GetProviderName(), p.Zones, p.SubnetIDs, "subnet-ids"); err != nil {
+			return err
+		}
+		zones = p.Zones
+		subnets = make([]string, len(zones))
+		copy(subnets, p.SubnetIDs)
+	}
+
+	for i, zone := range zones {
+		if subnets[i] != "" {
+			if err := p.checkSubnet(subnets[i], zone, len(p.Zones) > 0); err != nil {
+				return err
+			}
+			continue
+		}
+		subnet, err := p.getDefaultSubnet(zone)
+		if err != nil {
+			return err
+		}
+		subnets[i] = subnet
+	}
+
+	if len(p.Zones) > 0 {
+		p.Zone = zones[0]
+		p.SubnetIDs = subnets
+	}
+	p.SubnetID = subnets[0]
+	return nil
+}
+
+// checkSubnet checks the subnet belongs to the vpc, and the zone of subnet if the nodes are spread across zones.
+func (p *Amazon) checkSubnet(subnetID, zone string, checkZone bool) error {
+	if p.VpcID == "" {
+		return nil
+	}
+	subnets, err := p.client.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("subnet-id"),
+				Values: []*string{aws.String(subnetID)},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if subnets == nil || len(subnets.Subnets) == 0 {
+		return fmt.Errorf("[%s] there's not subnet found by id %s", p.GetProviderName(), subnetID)
+	}
+
+	if *subnets.Subnets[0].VpcId != p.VpcID {
+		return fmt.Errorf("[%s] subnetId %s does not belong to VpcId: %s", p.GetProviderName(), subnetID, p.VpcID)
+	}
+
+	if checkZone && aws.StringValue(subnets.Subnets[0].AvailabilityZone) != zone {
+		return fmt.Errorf("[%s] subnetId %s does not belong to zone: %s", p.GetProviderName(), subnetID, zone)
+	}
+	return nil
+}
+
+// getDefaultSubnet returns the default subnet of the vpc at zone.
+func (p *Amazon) getDefaultSubnet(zone string) (string, error) {
+	filters := []*ec2.Filter{
+		{
+			Name:   aws.String("availability-zone"),
+			Values: []*string{aws.String(zone)},
+		},
+		{
+			Name:   aws.String("vpc-id"),
+			Values: []*string{&p.VpcID},
+		},
+	}
+
+	subnets, err := p.client.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		Filters: filters,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(subnets.Subnets) == 0 {
+		return "", fmt.Errorf("can't get subnets for vpc %s at zone %s", p.VpcID, zone)
+	}
+
+	// find default subnet.
+	if len(subnets.Subnets) > 1 {
+		for _, subnet := range subnets.Subnets {
+			if subnet.DefaultForAz != nil && *subnet.DefaultForAz {
+				return *subnet.SubnetId, nil
+			}
+		}
+	}
+
+	return *subnets.Subnets[0].SubnetId, nil
+}
+
 func (p *Amazon) createKeyPair(ssh *types.SSH) error {
 	if p.KeypairName != "" && ssh.SSHKeyPath == "" && p.KeypairName != p.ContextName {
 		return fmt.Errorf("[%s] calling preflight error: --ssh-key-path must set with --key-pair %s", p.GetProviderName(), p.KeypairName)
@@ -1011,22 +1146,26 @@ func (p *Amazon) configPermission(group *ec2.SecurityGroup) []*ec2.IpPermission
 	}
 
 	if p.Cluster && (!hasPorts["2379/tcp"] || !hasPorts["2380/tcp"]) {
-		cidr, err := p.getSubnetCIDR()
-		if err != nil || cidr == "" {
-			p.Logger.Errorf("[%s] failed to get subnet cidr with id %s, error: %v", p.GetProviderName(), p.SubnetID, err)
-			cidr = ipRange
+		cidrs, err := p.getSubnetCIDRs()
+		if err != nil || len(cidrs) == 0 {
+			p.Logger.Errorf("[%s] failed to get subnet cidr with id %s, error: %v", p.GetProviderName(), p.subnets(), err)
+			cidrs = []string{ipRange}
+		}
+		ipRanges := make([]*ec2.IpRange, 0, len(cidrs))
+		for _, cidr := range cidrs {
+			ipRanges = append(ipRanges, &ec2.IpRange{CidrIp: aws.String(cidr)})
 		}
 		perms = append(perms, &ec2.IpPermission{
 			IpProtocol: aws.String("tcp"),
 			FromPort:   aws.Int64(int64(2379)),
 			ToPort:     aws.Int64(int64(2379)),
-			IpRanges:   []*ec2.IpRange{{CidrIp: aws.String(cidr)}},
+			IpRanges:   ipRanges,
 		})
 		perms = append(perms, &ec2.IpPermission{
 			IpProtocol: aws.String("tcp"),
 			FromPort:   aws.Int64(int64(2380)),
 			ToPort:     aws.Int64(int64(2380)),
-			IpRanges:   []*ec2.IpRange{{CidrIp: aws.String(cidr)}},
+			IpRanges:   ipRanges,
 		})
 	}
 
@@ -1048,6 +1187,12 @@ func (p *Amazon) deleteInstance(f bool) (string, error) {
 		return p.ContextName, nil
 	}
 
+	if p.ControlPlaneEndpoint {
+		if err = p.deleteEndpoint(); err != nil && !f {
+			return "", err
+		}
+	}
+
 	// CCM will create elb for kubernetes service
 	if p.CloudControllerManager {
 		p.Logger.Warnf("[%s] Please ensure all services has released before remove the cluster, if not, please check the AWS ELB to ensure all ELB has been released.", p.GetProviderName())
@@ -1059,11 +1204,11 @@ func (p *Amazon) deleteInstance(f bool) (string, error) {
 	return p.ContextName, nil
 }
 
-func (p *Amazon) getSubnetCIDR() (string, error) {
+func (p *Amazon) getSubnetCIDRs() ([]string, error) {
 	subnetFilter := []*ec2.Filter{
 		{
 			Name:   aws.String("subnet-id"),
-			Values: []*string{&p.SubnetID},
+			Values: aws.StringSlice(p.subnets()),
 		},
 	}
 
@@ -1071,14 +1216,18 @@ func (p *Amazon) getSubnetCIDR() (string, error) {
 		Filters: subnetFilter,
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if subnets == nil || len(subnets.Subnets) == 0 {
-		return "", fmt.Errorf("[%s] there's not subnet found by id %s", p.GetProviderName(), p.SubnetID)
+		return nil, fmt.Errorf("[%s] there's not subnet found by id %s", p.GetProviderName(), p.subnets())
 	}
 
-	return aws.StringValue(subnets.Subnets[0].CidrBlock), nil
+	cidrs := make([]string, 0, len(subnets.Subnets))
+	for _, subnet := range subnets.Subnets {
+		cidrs = append(cidrs, aws.StringValue(subnet.CidrBlock))
+	}
+	return cidrs, nil
 }
 
 func (p *Amazon) addTagsForCCMResource() error {
@@ -1102,30 +1251,35 @@ func (p *Amazon) addTagsForCCMResource() error {
 		return err
 	}
 
-	// get subnet.
+	// get subnets.
 	subnetFilter := []*ec2.Filter{
 		{
 			Name:   aws.String("subnet-id"),
-			Values: []*string{&p.SubnetID},
+			Values: aws.StringSlice(p.subnets()),
 		},
 	}
 	subnets, err := p.client.DescribeSubnets(&ec2.DescribeSubnetsInput{
 		Filters: subnetFilter,
 	})
 	if err != nil || subnets == nil || len(subnets.Subnets) == 0 {
-		return fmt.Errorf("[%s] failed to get subnets %s, error: %v", p.GetProviderName(), p.SubnetID, err)
+		return fmt.Errorf("[%s] failed to get subnets %s, error: %v", p.GetProviderName(), p.subnets(), err)
+	}
+	for _, subnet := range subnets.Subnets {
+		subnetTags := subnet.Tags
+		subnetTags = append(subnetTags, &ec2.Tag{
+			Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", p.ContextName)),
+			Value: aws.String("shared"),
+		})
+		_, err = p.client.CreateTags(&ec2.CreateTagsInput{
+			Resources: []*string{subnet.SubnetId},
+			Tags:      subnetTags,
+		})
+		if err != nil {
+			return err
+		}
 	}
-	subnetTags := subnets.Subnets[0].Tags
-	subnetTags = append(subnetTags, &ec2.Tag{
-		Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", p.ContextName)),
-		Value: aws.String("shared"),
-	})
-	_, err = p.client.CreateTags(&ec2.CreateTagsInput{
-		Resources: aws.StringSlice([]string{p.SubnetID}),
-		Tags:      subnetTags,
-	})
 
-	return err
+	return nil
 }
 
 func (p *Amazon) removeTagsForCCMResource() error {
@@ -1143,7 +1297,7 @@ func (p *Amazon) removeTagsForCCMResource() error {
 		return err
 	}
 	_, err = p.client.DeleteTags(&ec2.DeleteTagsInput{
-		Resources: aws.StringSlice([]string{p.SubnetID}),
+		Resources: aws.StringSlice(p.subnets()),
 		Tags:      deletedTags,
 	})
 	return err
@@ -1247,3 +1401,19 @@ func getCCMExtraArgs(k3sversion string) ([]string, error) {
 	}
 	return []string{}, nil
 }
+
+func (p *Amazon) stopInstances(ids []string) error {
+	p.Logger.Infof("[%s] stopping instances %s...", p.GetProviderName(), ids)
+	if _, err := p.client.StopInstances(&ec2.StopInstancesInput{InstanceIds: aws.StringSlice(ids)}); err != nil {
+		return err
+	}
+	return p.client.WaitUntilInstanceStopped(&ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(ids)})
+}
+
+func (p *Amazon) startInstances(ids []string) error {
+	p.Logger.Infof("[%s] starting instances %s...", p.GetProviderName(), ids)
+	if _, err := p.client.StartInstances(&ec2.StartInstancesInput{InstanceIds: aws.StringSlice(ids)}); err != nil {
+		return err
+	}
+	return p.client.WaitUntilInstanceRunning(&ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(ids)})
+}
@@ -0,0 +1,169 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ensureEndpoint creates the network load balancer of the control plane if not exist and registers the masters.
+func (p *Amazon) ensureEndpoint(masters []types.Node) (string, error) {
+	name := cluster.EndpointName(p.ContextName)
+	lb, err := p.getLoadBalancer(name)
+	if err != nil {
+		return "", err
+	}
+	tags := []*elbv2.Tag{
+		{Key: aws.String("autok3s"), Value: aws.String("true")},
+		{Key: aws.String("cluster"), Value: aws.String(common.TagClusterPrefix + p.ContextName)},
+	}
+	if lb == nil {
+		p.Logger.Infof("[%s] creating network load balancer %s", p.GetProviderName(), name)
+		output, err := p.lb.CreateLoadBalancer(&elbv2.CreateLoadBalancerInput{
+			Name:    aws.String(name),
+			Type:    aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+			Scheme:  aws.String(elbv2.LoadBalancerSchemeEnumInternetFacing),
+			Subnets: aws.StringSlice(p.subnets()),
+			Tags:    tags,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create network load balancer %s: %v", name, err)
+		}
+		lb = output.LoadBalancers[0]
+	}
+
+	tg, err := p.getTargetGroup(name)
+	if err != nil {
+		return "", err
+	}
+	if tg == nil {
+		output, err := p.lb.CreateTargetGroup(&elbv2.CreateTargetGroupInput{
+			Name:                aws.String(name),
+			Protocol:            aws.String(elbv2.ProtocolEnumTcp),
+			Port:                aws.Int64(cluster.EndpointPort),
+			VpcId:               aws.String(p.VpcID),
+			TargetType:          aws.String(elbv2.TargetTypeEnumInstance),
+			HealthCheckProtocol: aws.String(elbv2.ProtocolEnumTcp),
+			Tags:                tags,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create target group %s: %v", name, err)
+		}
+		tg = output.TargetGroups[0]
+		// the masters connect to the endpoint as well, disable the client ip preservation to avoid the hairpin issue.
+		if _, err = p.lb.ModifyTargetGroupAttributes(&elbv2.ModifyTargetGroupAttributesInput{
+			TargetGroupArn: tg.TargetGroupArn,
+			Attributes: []*elbv2.TargetGroupAttribute{
+				{Key: aws.String("preserve_client_ip.enabled"), Value: aws.String("false")},
+			},
+		}); err != nil {
+			return "", fmt.Errorf("failed to modify attributes of target group %s: %v", name, err)
+		}
+	}
+
+	listeners, err := p.lb.DescribeListeners(&elbv2.DescribeListenersInput{LoadBalancerArn: lb.LoadBalancerArn})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe listeners of load balancer %s: %v", name, err)
+	}
+	if len(listeners.Listeners) == 0 {
+		if _, err = p.lb.CreateListener(&elbv2.CreateListenerInput{
+			LoadBalancerArn: lb.LoadBalancerArn,
+			Protocol:        aws.String(elbv2.ProtocolEnumTcp),
+			Port:            aws.Int64(cluster.EndpointPort),
+			DefaultActions: []*elbv2.Action{{
+				Type:           aws.String(elbv2.ActionTypeEnumForward),
+				TargetGroupArn: tg.TargetGroupArn,
+			}},
+		}); err != nil {
+			return "", fmt.Errorf("failed to create listener of load balancer %s: %v", name, err)
+		}
+	}
+
+	if len(masters) > 0 {
+		targets := make([]*elbv2.TargetDescription, 0, len(masters))
+		for _, master := range masters {
+			targets = append(targets, &elbv2.TargetDescription{Id: aws.String(master.InstanceID)})
+		}
+		if _, err = p.lb.RegisterTargets(&elbv2.RegisterTargetsInput{
+			TargetGroupArn: tg.TargetGroupArn,
+			Targets:        targets,
+		}); err != nil {
+			return "", fmt.Errorf("failed to register masters to target group %s: %v", name, err)
+		}
+	}
+
+	if err = p.lb.WaitUntilLoadBalancerAvailable(&elbv2.DescribeLoadBalancersInput{
+		LoadBalancerArns: []*string{lb.LoadBalancerArn},
+	}); err != nil {
+		return "", fmt.Errorf("failed to wait for load balancer %s to be active: %v", name, err)
+	}
+	return aws.StringValue(lb.DNSName), nil
+}
+
+// deleteEndpoint removes the network load balancer and the target group of the control plane.
+func (p *Amazon) deleteEndpoint() error {
+	name := cluster.EndpointName(p.ContextName)
+	lb, err := p.getLoadBalancer(name)
+	if err != nil {
+		return err
+	}
+	if lb != nil {
+		p.Logger.Infof("[%s] deleting network load balancer %s", p.GetProviderName(), name)
+		if _, err = p.lb.DeleteLoadBalancer(&elbv2.DeleteLoadBalancerInput{LoadBalancerArn: lb.LoadBalancerArn}); err != nil {
+			return fmt.Errorf("failed to delete load balancer %s: %v", name, err)
+		}
+		if err = p.lb.WaitUntilLoadBalancersDeleted(&elbv2.DescribeLoadBalancersInput{
+			LoadBalancerArns: []*string{lb.LoadBalancerArn},
+		}); err != nil {
+			return fmt.Errorf("failed to wait for load balancer %s to be deleted: %v", name, err)
+		}
+	}
+	tg, err := p.getTargetGroup(name)
+	if err != nil || tg == nil {
+		return err
+	}
+	// the target group is in use until the listeners of load balancer are removed.
+	var lastErr error
+	if err = wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		_, lastErr = p.lb.DeleteTargetGroup(&elbv2.DeleteTargetGroupInput{TargetGroupArn: tg.TargetGroupArn})
+		return lastErr == nil, nil
+	}); err != nil {
+		return fmt.Errorf("failed to delete target group %s: %v", name, lastErr)
+	}
+	return nil
+}
+
+func (p *Amazon) getLoadBalancer(name string) (*elbv2.LoadBalancer, error) {
+	output, err := p.lb.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{Names: aws.StringSlice([]string{name})})
+	if err != nil {
+		if ae, ok := err.(awserr.Error); ok && ae.Code() == elbv2.ErrCodeLoadBalancerNotFoundException {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to describe load balancer %s: %v", name, err)
+	}
+	if len(output.LoadBalancers) == 0 {
+		return nil, nil
+	}
+	return output.LoadBalancers[0], nil
+}
+
+func (p *Amazon) getTargetGroup(name string) (*elbv2.TargetGroup, error) {
+	output, err := p.lb.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{Names: aws.StringSlice([]string{name})})
+	if err != nil {
+		if ae, ok := err.(awserr.Error); ok && ae.Code() == elbv2.ErrCodeTargetGroupNotFoundException {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to describe target group %s: %v", name, err)
+	}
+	if len(output.TargetGroups) == 0 {
+		return nil, nil
+	}
+	return output.TargetGroups[0], nil
+}
@@ -94,6 +94,7 @@ func (p *Amazon) GetDeleteFlags() []types.Flag {
 func (p *Amazon) GetJoinFlags() []types.Flag {
 	fs := p.sharedFlags()
 	fs = append(fs, p.GetClusterOptions()...)
+	fs = append(fs, p.GetNodePoolOptions()...)
 	return fs
 }
 
@@ -211,6 +212,12 @@ func (p *Amazon) sharedFlags() []types.Flag {
 			Usage:  "AWS zone",
 			EnvVar: "AWS_ZONE",
 		},
+		{
+			Name:  "zones",
+			P:     &p.Zones,
+			V:     p.Zones,
+			Usage: "Spread the nodes across the AWS zones of region, which overrides --zone, i.e.(--zones ap-southeast-2a,ap-southeast-2b,ap-southeast-2c)",
+		},
 		{
 			Name:   "keypair-name",
 			P:      &p.KeypairName,
@@ -246,6 +253,12 @@ func (p *Amazon) sharedFlags() []types.Flag {
 			Usage:  "AWS VPC subnet id, see: https://docs.aws.amazon.com/vpc/latest/userguide/VPC_Subnets.html",
 			EnvVar: "AWS_SUBNET_ID",
 		},
+		{
+			Name:  "subnet-ids",
+			P:     &p.SubnetIDs,
+			V:     p.SubnetIDs,
+			Usage: "AWS VPC subnet ids for each zone of --zones in the same order, using the default subnet of each zone by default",
+		},
 		{
 			Name:   "volume-type",
 			P:      &p.VolumeType,
@@ -0,0 +1,120 @@
+package google
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	raw "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// ensureEndpoint creates the TCP load balancer of the control plane if not exist and adds the masters to its target pool.
+// The load balancer is composed of a regional address, a target pool and a forwarding rule with the same name.
+func (p *Google) ensureEndpoint(masters []types.Node) (string, error) {
+	name := cluster.EndpointName(p.ContextName)
+	address, err := p.client.Addresses.Get(p.Project, p.Region, name).Do()
+	if isNotFound(err) {
+		p.Logger.Infof("[%s] creating address %s for control-plane endpoint", p.GetProviderName(), name)
+		op, err := p.client.Addresses.Insert(p.Project, p.Region, &raw.Address{Name: name}).Do()
+		if err != nil {
+			return "", fmt.Errorf("failed to create address %s: %v", name, err)
+		}
+		if err = p.waitForRegionOp(op.Name); err != nil {
+			return "", err
+		}
+		address, err = p.client.Addresses.Get(p.Project, p.Region, name).Do()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get address %s: %v", name, err)
+	}
+
+	if _, err = p.client.TargetPools.Get(p.Project, p.Region, name).Do(); isNotFound(err) {
+		p.Logger.Infof("[%s] creating target pool %s for control-plane endpoint", p.GetProviderName(), name)
+		op, err := p.client.TargetPools.Insert(p.Project, p.Region, &raw.TargetPool{Name: name}).Do()
+		if err != nil {
+			return "", fmt.Errorf("failed to create target pool %s: %v", name, err)
+		}
+		if err = p.waitForRegionOp(op.Name); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get target pool %s: %v", name, err)
+	}
+
+	if len(masters) > 0 {
+		request := &raw.TargetPoolsAddInstanceRequest{}
+		for _, master := range masters {
+			request.Instances = append(request.Instances, &raw.InstanceReference{
+				Instance: fmt.Sprintf("%s/%s/zones/%s/instances/%s", apiURL, p.Project, p.zoneOf(master.InstanceID), master.InstanceID),
+			})
+		}
+		op, err := p.client.TargetPools.AddInstance(p.Project, p.Region, name, request).Do()
+		if err != nil {
+			return "", fmt.Errorf("failed to add masters to target pool %s: %v", name, err)
+		}
+		if err = p.waitForRegionOp(op.Name); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err = p.client.ForwardingRules.Get(p.Project, p.Region, name).Do(); isNotFound(err) {
+		p.Logger.Infof("[%s] creating forwarding rule %s for control-plane endpoint", p.GetProviderName(), name)
+		op, err := p.client.ForwardingRules.Insert(p.Project, p.Region, &raw.ForwardingRule{
+			Name:                name,
+			IPAddress:           address.Address,
+			IPProtocol:          "TCP",
+			PortRange:           strconv.Itoa(cluster.EndpointPort),
+			LoadBalancingScheme: "EXTERNAL",
+			Target:              fmt.Sprintf("%s/%s/regions/%s/targetPools/%s", apiURL, p.Project, p.Region, name),
+		}).Do()
+		if err != nil {
+			return "", fmt.Errorf("failed to create forwarding rule %s: %v", name, err)
+		}
+		if err = p.waitForRegionOp(op.Name); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get forwarding rule %s: %v", name, err)
+	}
+	return address.Address, nil
+}
+
+// deleteEndpoint removes the forwarding rule, the target pool and the address of the control plane.
+func (p *Google) deleteEndpoint() error {
+	name := cluster.EndpointName(p.ContextName)
+	p.Logger.Infof("[%s] deleting control-plane endpoint %s", p.GetProviderName(), name)
+	deletes := []func(...googleapi.CallOption) (*raw.Operation, error){
+		p.client.ForwardingRules.Delete(p.Project, p.Region, name).Do,
+		p.client.TargetPools.Delete(p.Project, p.Region, name).Do,
+		p.client.Addresses.Delete(p.Project, p.Region, name).Do,
+	}
+	for _, del := range deletes {
+		op, err := del()
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete control-plane endpoint %s: %v", name, err)
+		}
+		if err = p.waitForRegionOp(op.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForRegionOp waits for the operation of the regional resources, e.g. the load balancer.
+func (p *Google) waitForRegionOp(name string) error {
+	return p.waitForOp(func() (*raw.Operation, error) {
+		return p.client.RegionOperations.Get(p.Project, p.Region, name).Do()
+	})
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	return ok && e.Code == http.StatusNotFound
+}
@@ -96,6 +96,7 @@ func (p *Google) GetDeleteFlags() []types.Flag {
 func (p *Google) GetJoinFlags() []types.Flag {
 	fs := p.sharedFlags()
 	fs = append(fs, p.GetClusterOptions()...)
+	fs = append(fs, p.GetNodePoolOptions()...)
 	return fs
 }
 
@@ -200,6 +201,12 @@ func (p *Google) sharedFlags() []types.Flag {
 			Usage:  "GCE zone",
 			EnvVar: "GOOGLE_ZONE",
 		},
+		{
+			Name:  "zones",
+			P:     &p.Zones,
+			V:     p.Zones,
+			Usage: "Spread the nodes across the GCE zones of region, which overrides --zone, i.e.(--zones us-central1-a,us-central1-b,us-central1-c)",
+		},
 		{
 			Name:   "machine-type",
 			P:      &p.MachineType,
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"reflect"
 	"strconv"
 	"strings"
@@ -44,6 +45,8 @@ type Google struct {
 	typesgoogle.Options   `json:",inline"`
 	client                *raw.Service
 	globalURL             string
+	// instanceZones caches the zones of the instances by name, as the instances are managed by zonal APIs.
+	instanceZones map[string]string
 }
 
 func init() {
@@ -107,7 +110,7 @@ func (p *Google) JoinK3sNode() error {
 			return err
 		}
 	}
-	return p.JoinNodes(p.generateInstance, p.syncInstances, false, p.rollbackInstance)
+	return p.JoinNodes(p.NodePoolInstance(&p.Options, p.generateInstance), p.syncInstances, false, p.rollbackInstance)
 }
 
 // DeleteK3sCluster delete K3S cluster.
@@ -148,12 +151,42 @@ func (p *Google) remove(force bool) (string, error) {
 		}
 	}
 
+	if p.ControlPlaneEndpoint {
+		if err = p.deleteEndpoint(); err != nil && !force {
+			return "", err
+		}
+	}
+
 	_ = p.rollbackInstance(ids)
 	p.Logger.Infof("[%s] successfully terminate instances for cluster %s", p.GetProviderName(), p.Name)
 
 	return p.ContextName, nil
 }
 
+// StopK3sCluster stops the instances of K3s cluster.
+func (p *Google) StopK3sCluster() error {
+	if err := p.newClient(); err != nil {
+		return err
+	}
+	return p.StopCluster(p.stopInstances)
+}
+
+// StartK3sCluster starts the instances of K3s cluster.
+func (p *Google) StartK3sCluster() error {
+	if err := p.newClient(); err != nil {
+		return err
+	}
+	return p.StartCluster(p.startInstances, p.getInstanceNodes)
+}
+
+// RemoveK3sNodes removes the worker nodes from K3s cluster and deletes the instances.
+func (p *Google) RemoveK3sNodes(ids []string) error {
+	if err := p.newClient(); err != nil {
+		return err
+	}
+	return p.RemoveNodes(ids, p.rollbackInstance)
+}
+
 func (p *Google) SSHK3sNode(ip string) error {
 	c := &types.Cluster{
 		Metadata: p.Metadata,
@@ -187,15 +220,22 @@ func (p *Google) IsClusterExist() (bool, []string, error) {
 
 func (p *Google) GenerateMasterExtraArgs(cluster *types.Cluster, master types.Node) string {
 	if option, ok := cluster.Options.(typesgoogle.Options); ok {
+		// the nodes created before spreading across zones are in the zone of cluster.
+		zone := master.Zone
+		if zone == "" {
+			zone = option.Zone
+		}
 		if option.CloudControllerManager {
-			return fmt.Sprintf(" --kubelet-arg=cloud-provider=external --kubelet-arg=provider-id=gce://%s/%s/%s --node-name=%s", option.Project, option.Zone, master.InstanceID, master.InstanceID)
+			return fmt.Sprintf(" --kubelet-arg=cloud-provider=external --kubelet-arg=provider-id=gce://%s/%s/%s --node-name=%s", option.Project, zone, master.InstanceID, master.InstanceID) +
+				putil.ZoneExtraArgs(zone)
 		}
+		return putil.ZoneExtraArgs(zone)
 	}
 	return ""
 }
 
 func (p *Google) GenerateWorkerExtraArgs(cluster *types.Cluster, worker types.Node) string {
-	return p.GenerateMasterExtraArgs(cluster, worker)
+	return p.GenerateMasterExtraArgs(p.NodePoolCluster(cluster, worker), worker) + p.NodePoolExtraArgs(cluster, worker)
 }
 
 // SetOptions merge option struct for Google Cloud Provider
@@ -231,6 +271,9 @@ func (p *Google) DescribeCluster(kubecfg string) *types.ClusterInfo {
 		Zone:     p.Zone,
 		Provider: p.GetProviderName(),
 	}
+	if len(p.Zones) > 0 {
+		c.Zone = strings.Join(p.Zones, ",")
+	}
 	return p.Describe(kubecfg, c, p.getInstanceNodes)
 }
 
@@ -264,6 +307,14 @@ func (p *Google) CreateCheck() error {
 		}
 	}
 
+	p.Zones = putil.ParseList(p.Zones)
+	if len(p.Zones) > 0 {
+		if err := putil.ValidateZones(p.GetProviderName(), p.Zones, nil, ""); err != nil {
+			return err
+		}
+		p.Zone = p.Zones[0]
+	}
+
 	if err := p.CheckCreateArgs(p.IsClusterExist); err != nil {
 		return err
 	}
@@ -292,7 +343,35 @@ func (p *Google) JoinCheck() error {
 		}
 	}
 
-	return p.CheckJoinArgs(p.IsClusterExist)
+	if err := p.CheckJoinArgs(p.IsClusterExist); err != nil {
+		return err
+	}
+	if len(p.Zones) > 0 && p.Zones[0] != p.Zone {
+		return fmt.Errorf("[%s] calling preflight error: `--zones` can only be set when creating the cluster", p.GetProviderName())
+	}
+	return p.ValidateNodePool(&p.Options, "zone", "zones")
+}
+
+// zones returns the zones which the nodes of cluster are spread across.
+func (p *Google) zones() []string {
+	if len(p.Zones) == 0 || p.Zones[0] != p.Zone {
+		return []string{p.Zone}
+	}
+	return p.Zones
+}
+
+// zoneOf returns the zone of the instance, the zone of cluster is returned if it's not found.
+func (p *Google) zoneOf(name string) string {
+	if value, ok := p.M.Load(name); ok && value.(types.Node).Zone != "" {
+		return value.(types.Node).Zone
+	}
+	if _, ok := p.instanceZones[name]; !ok && len(p.zones()) > 1 {
+		_, _ = p.describeInstances()
+	}
+	if zone, ok := p.instanceZones[name]; ok {
+		return zone
+	}
+	return p.Zone
 }
 
 func (p *Google) newClient() error {
@@ -350,14 +429,14 @@ func (p *Google) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	// create instance
 	if masterNum > 0 {
 		p.Logger.Infof("[%s] prepare for %d master nodes", p.GetProviderName(), masterNum)
-		if err = p.startInstance(masterNum, true); err != nil {
+		if err = p.startZoneInstances(masterNum, true, p.Status.MasterNodes); err != nil {
 			return nil, err
 		}
 	}
 
 	if workerNum > 0 {
 		p.Logger.Infof("[%s] prepare for %d worker nodes", p.GetProviderName(), workerNum)
-		if err = p.startInstance(workerNum, false); err != nil {
+		if err = p.startZoneInstances(workerNum, false, p.Status.WorkerNodes); err != nil {
 			return nil, err
 		}
 	}
@@ -373,6 +452,10 @@ func (p *Google) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	}
 	c.SSH = *ssh
 
+	if err = p.ConfigControlPlaneEndpoint(c, p.ensureEndpoint); err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
@@ -388,6 +471,7 @@ func (p *Google) syncInstances() error {
 			v := value.(types.Node)
 			v.InternalIPAddress = []string{networkInterface.NetworkIP}
 			v.PublicIPAddress = []string{networkInterface.AccessConfigs[0].NatIP}
+			v.Zone = path.Base(instance.Zone)
 			p.M.Store(instance.Name, v)
 			continue
 		}
@@ -405,13 +489,29 @@ func (p *Google) syncInstances() error {
 			InstanceID:        instance.Name,
 			InstanceStatus:    instance.Status,
 			InternalIPAddress: []string{networkInterface.NetworkIP},
-			PublicIPAddress:   []string{networkInterface.AccessConfigs[0].NatIP}})
+			PublicIPAddress:   []string{networkInterface.AccessConfigs[0].NatIP},
+			Zone:              path.Base(instance.Zone)})
 	}
 
 	return nil
 }
 
-func (p *Google) startInstance(num int, master bool) error {
+// startZoneInstances starts the instances round-robin across the zones of cluster, the zones which have fewer
+// existing nodes are preferred.
+func (p *Google) startZoneInstances(num int, master bool, existing []types.Node) error {
+	zones := p.zones()
+	for i, n := range putil.SpreadZones(zones, existing, num) {
+		if n == 0 {
+			continue
+		}
+		if err := p.startInstance(n, master, zones[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Google) startInstance(num int, master bool, zone string) error {
 	var vmNet string
 	if strings.Contains(p.VMNetwork, "/networks/") {
 		vmNet = p.VMNetwork
@@ -421,7 +521,7 @@ func (p *Google) startInstance(num int, master bool) error {
 
 	instance := &raw.Instance{
 		Description: "AutoK3s managed VM",
-		MachineType: fmt.Sprintf("%s/%s/zones/%s/machineTypes/%s", apiURL, p.Project, p.Zone, p.MachineType),
+		MachineType: fmt.Sprintf("%s/%s/zones/%s/machineTypes/%s", apiURL, p.Project, zone, p.MachineType),
 		Disks: []*raw.AttachedDisk{
 			{
 				Boot:       true,
@@ -492,28 +592,28 @@ func (p *Google) startInstance(num int, master bool) error {
 		}
 		instance.Name = instanceName
 		diskName := fmt.Sprintf("%s-disk", instance.Name)
-		disk, err := p.getDisk(diskName)
+		disk, err := p.getDisk(zone, diskName)
 		if disk == nil || err != nil {
 			instance.Disks[0].InitializeParams = &raw.AttachedDiskInitializeParams{
 				DiskName:    diskName,
 				SourceImage: "https://www.googleapis.com/compute/v1/projects/" + p.MachineImage,
 				DiskSizeGb:  int64(p.DiskSize),
-				DiskType:    fmt.Sprintf("%s/%s/zones/%s/diskTypes/%s", apiURL, p.Project, p.Zone, p.DiskType),
+				DiskType:    fmt.Sprintf("%s/%s/zones/%s/diskTypes/%s", apiURL, p.Project, zone, p.DiskType),
 			}
 		} else {
-			instance.Disks[0].Source = fmt.Sprintf("%s/%s/zones/%s/disks/%s", apiURL, p.Project, p.Zone, diskName)
+			instance.Disks[0].Source = fmt.Sprintf("%s/%s/zones/%s/disks/%s", apiURL, p.Project, zone, diskName)
 		}
-		p.Logger.Infof("[%s] create instance %s", p.GetProviderName(), instanceName)
-		op, err := p.client.Instances.Insert(p.Project, p.Zone, instance).Do()
+		p.Logger.Infof("[%s] create instance %s in zone %s", p.GetProviderName(), instanceName, zone)
+		op, err := p.client.Instances.Insert(p.Project, zone, instance).Do()
 		if err != nil {
 			return err
 		}
 		p.Logger.Infof("[%s] waiting for instance %s", p.GetProviderName(), instanceName)
-		if err = p.waitForRegionalOp(op.Name); err != nil {
+		if err = p.waitForRegionalOp(zone, op.Name); err != nil {
 			return err
 		}
 
-		ins, err := p.instance(instanceName)
+		ins, err := p.instance(zone, instanceName)
 		if err != nil {
 			return err
 		}
@@ -531,6 +631,7 @@ func (p *Google) startInstance(num int, master bool) error {
 			PublicIPAddress:   []string{networkInterface.AccessConfigs[0].NatIP},
 			LocalHostname:     ins.Hostname,
 			SSH:               p.SSH,
+			Zone:              zone,
 		})
 	}
 	return nil
@@ -628,9 +729,9 @@ func (p *Google) waitForGlobalOp(name string) error {
 	})
 }
 
-func (p *Google) waitForRegionalOp(name string) error {
+func (p *Google) waitForRegionalOp(zone, name string) error {
 	return p.waitForOp(func() (*raw.Operation, error) {
-		return p.client.ZoneOperations.Get(p.Project, p.Zone, name).Do()
+		return p.client.ZoneOperations.Get(p.Project, zone, name).Do()
 	})
 }
 
@@ -673,12 +774,12 @@ func (p *Google) generateLabels(master bool) map[string]string {
 	return defaultLabels
 }
 
-func (p *Google) getDisk(diskName string) (*raw.Disk, error) {
-	return p.client.Disks.Get(p.Project, p.Zone, diskName).Do()
+func (p *Google) getDisk(zone, diskName string) (*raw.Disk, error) {
+	return p.client.Disks.Get(p.Project, zone, diskName).Do()
 }
 
-func (p *Google) instance(name string) (*raw.Instance, error) {
-	return p.client.Instances.Get(p.Project, p.Zone, name).Do()
+func (p *Google) instance(zone, name string) (*raw.Instance, error) {
+	return p.client.Instances.Get(p.Project, zone, name).Do()
 }
 
 func (p *Google) uploadKeyPair(instance *raw.Instance, sshKeyPath string) error {
@@ -699,56 +800,67 @@ func (p *Google) uploadKeyPair(instance *raw.Instance, sshKeyPath string) error
 		Value: &metaDataValue,
 	})
 
-	op, err := p.client.Instances.SetMetadata(p.Project, p.Zone, instance.Name, meta).Do()
+	zone := path.Base(instance.Zone)
+	op, err := p.client.Instances.SetMetadata(p.Project, zone, instance.Name, meta).Do()
 	if err != nil {
 		return err
 	}
 
-	return p.waitForRegionalOp(op.Name)
+	return p.waitForRegionalOp(zone, op.Name)
 }
 
 func (p *Google) deleteInstance(instanceName string) error {
 	p.Logger.Infof("[%s] remove instance...", p.GetProviderName())
-	op, err := p.client.Instances.Delete(p.Project, p.Zone, instanceName).Do()
+	zone := p.zoneOf(instanceName)
+	op, err := p.client.Instances.Delete(p.Project, zone, instanceName).Do()
 	if err != nil {
 		return err
 	}
 
 	p.Logger.Infof("[%s] waiting for instance %s to delete...", p.GetProviderName(), instanceName)
-	return p.waitForRegionalOp(op.Name)
+	return p.waitForRegionalOp(zone, op.Name)
 }
 
 func (p *Google) deleteDisk(instanceName string) error {
 	diskName := fmt.Sprintf("%s-disk", instanceName)
-	disk, _ := p.getDisk(diskName)
+	zone := p.zoneOf(instanceName)
+	disk, _ := p.getDisk(zone, diskName)
 	if disk == nil {
 		return nil
 	}
 
 	p.Logger.Infof("[%s] deleting disk for instance %s", p.GetProviderName(), instanceName)
-	op, err := p.client.Disks.Delete(p.Project, p.Zone, diskName).Do()
+	op, err := p.client.Disks.Delete(p.Project, zone, diskName).Do()
 	if err != nil {
 		return err
 	}
 
 	p.Logger.Infof("[%s] waiting for disk to delete", p.GetProviderName())
-	return p.waitForRegionalOp(op.Name)
+	return p.waitForRegionalOp(zone, op.Name)
 }
 
 func (p *Google) describeInstances() ([]*raw.Instance, error) {
-	insList, err := p.client.Instances.List(p.Project, p.Zone).Filter(fmt.Sprintf("labels.autok3s=true AND %s",
-		fmt.Sprintf("labels.cluster=%s", common.TagClusterPrefix+p.formatContextName()))).Do()
-	if err != nil {
-		return nil, err
-	}
 	// TODO: add paginating
 	instanceList := make([]*raw.Instance, 0)
-	if insList != nil {
-		if len(insList.Items) > 0 {
-			instanceList = append(instanceList, insList.Items...)
+	for _, zone := range p.zones() {
+		insList, err := p.client.Instances.List(p.Project, zone).Filter(fmt.Sprintf("labels.autok3s=true AND %s",
+			fmt.Sprintf("labels.cluster=%s", common.TagClusterPrefix+p.formatContextName()))).Do()
+		if err != nil {
+			return nil, err
+		}
+		if insList != nil {
+			if len(insList.Items) > 0 {
+				instanceList = append(instanceList, insList.Items...)
+			}
 		}
 	}
-	return instanceList, err
+	if p.instanceZones == nil {
+		p.instanceZones = map[string]string{}
+	}
+	for _, instance := range instanceList {
+		p.instanceZones[instance.Name] = path.Base(instance.Zone)
+	}
+	return instanceList, nil
 }
 
 func (p *Google) getInstanceNodes() ([]types.Node, error) {
@@ -776,7 +888,8 @@ func (p *Google) getInstanceNodes() ([]types.Node, error) {
 			InstanceID:        instance.Name,
 			InstanceStatus:    instance.Status,
 			InternalIPAddress: []string{networkInterface.NetworkIP},
-			PublicIPAddress:   []string{networkInterface.AccessConfigs[0].NatIP}})
+			PublicIPAddress:   []string{networkInterface.AccessConfigs[0].NatIP},
+			Zone:              path.Base(instance.Zone)})
 	}
 	return nodes, nil
 }
@@ -796,3 +909,33 @@ func splitPortProto(raw string) (port string, protocol string) {
 	}
 	return parts[0], parts[1]
 }
+
+func (p *Google) stopInstances(names []string) error {
+	for _, name := range names {
+		p.Logger.Infof("[%s] stopping instance %s...", p.GetProviderName(), name)
+		zone := p.zoneOf(name)
+		op, err := p.client.Instances.Stop(p.Project, zone, name).Do()
+		if err != nil {
+			return err
+		}
+		if err = p.waitForRegionalOp(zone, op.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Google) startInstances(names []string) error {
+	for _, name := range names {
+		p.Logger.Infof("[%s] starting instance %s...", p.GetProviderName(), name)
+		zone := p.zoneOf(name)
+		op, err := p.client.Instances.Start(p.Project, zone, name).Do()
+		if err != nil {
+			return err
+		}
+		if err = p.waitForRegionalOp(zone, op.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
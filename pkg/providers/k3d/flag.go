@@ -110,6 +110,13 @@ func (p *K3d) GetOptionFlags() []types.Flag {
 			Usage:    "Add label to node container, e.g.(--labels my.label@agent:0,1 --labels other.label=somevalue@server:0)",
 			Required: false,
 		},
+		{
+			Name:     "registry-use",
+			P:        &p.RegistryUse,
+			V:        p.RegistryUse,
+			Usage:    "Use the k3d-managed registry created by `autok3s k3d registry create`, which is added to the registry config of cluster, e.g.(--registry-use myregistry)",
+			Required: false,
+		},
 		{
 			Name:     "gpus",
 			P:        &p.GPUs,
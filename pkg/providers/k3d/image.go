@@ -0,0 +1,79 @@
+package k3d
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+
+	"github.com/k3d-io/k3d/v5/pkg/client"
+	"github.com/k3d-io/k3d/v5/pkg/runtimes"
+	k3d "github.com/k3d-io/k3d/v5/pkg/types"
+)
+
+// ImportClusterImages imports the images into the k3d cluster by the context name of cluster.
+func ImportClusterImages(contextName string, images []string, mode string) error {
+	state, err := common.DefaultDB.GetClusterByID(contextName)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("cluster %s is not found", contextName)
+	}
+	if state.Provider != providerName {
+		return fmt.Errorf("importing images is only supported by %s provider, cluster %s is provided by %s", providerName, contextName, state.Provider)
+	}
+	p := newProvider()
+	p.SetMetadata(&state.Metadata)
+	p.Logger = common.NewLogger(nil)
+	return p.ImportImages(images, mode)
+}
+
+// ImportImages imports the images from docker or the image tarballs into the nodes of k3d cluster.
+func (p *K3d) ImportImages(images []string, mode string) error {
+	importMode, err := parseImportMode(images, mode)
+	if err != nil {
+		return fmt.Errorf("[%s] %w", p.GetProviderName(), err)
+	}
+	c, err := client.ClusterGet(context.Background(), runtimes.SelectedRuntime, &k3d.Cluster{
+		Name: p.Name,
+		ServerLoadBalancer: &k3d.Loadbalancer{
+			Config: &k3d.LoadbalancerConfig{},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("[%s] cluster %s is not found: %w", p.GetProviderName(), p.Name, err)
+	}
+	p.SetLogLevelAndOutput()
+	if err = client.ImageImportIntoClusterMulti(context.Background(), runtimes.SelectedRuntime, images, c,
+		k3d.ImageImportOpts{Mode: importMode}); err != nil {
+		return fmt.Errorf("[%s] failed to import images to cluster %s: %w", p.GetProviderName(), p.Name, err)
+	}
+	p.Logger.Infof("[%s] successfully imported images %v to cluster %s", p.GetProviderName(), images, p.Name)
+	return nil
+}
+
+// ValidateImportImages validates the images and the import mode before importing.
+func ValidateImportImages(images []string, mode string) error {
+	_, err := parseImportMode(images, mode)
+	return err
+}
+
+func parseImportMode(images []string, mode string) (k3d.ImportMode, error) {
+	if len(images) == 0 {
+		return "", fmt.Errorf("no image is specified to import")
+	}
+	for _, image := range images {
+		if image == "-" {
+			return "", fmt.Errorf("importing image from stdin is not supported")
+		}
+	}
+	if mode == "" {
+		return k3d.ImportModeAutoDetect, nil
+	}
+	importMode, ok := k3d.ImportModes[mode]
+	if !ok {
+		return "", fmt.Errorf("invalid import mode %s, must be one of auto, direct and tools-node", mode)
+	}
+	return importMode, nil
+}
@@ -28,6 +28,7 @@ import (
 	k3d "github.com/k3d-io/k3d/v5/pkg/types"
 	k3dversion "github.com/k3d-io/k3d/v5/version"
 	"github.com/moby/term"
+	"github.com/rancher/wrangler/v2/pkg/slice"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -95,6 +96,21 @@ func (p *K3d) DeleteK3sCluster(f bool) (err error) {
 	return p.DeleteCluster(f, p.deleteK3d)
 }
 
+// StopK3sCluster stops the containers of K3s cluster.
+func (p *K3d) StopK3sCluster() error {
+	return p.StopCluster(p.stopK3d)
+}
+
+// StartK3sCluster starts the containers of K3s cluster.
+func (p *K3d) StartK3sCluster() error {
+	return p.StartCluster(p.startK3d, p.k3dStatus)
+}
+
+// RemoveK3sNodes removes the agent containers from K3s cluster.
+func (p *K3d) RemoveK3sNodes(ids []string) error {
+	return p.RemoveNodes(ids, p.removeK3dNodes)
+}
+
 // SSHK3sNode ssh K3s node.
 func (p *K3d) SSHK3sNode(ip string) error {
 	c := &types.Cluster{
@@ -231,6 +247,9 @@ func (p *K3d) JoinCheck() error {
 	if err := p.CheckJoinArgs(p.IsClusterExist); err != nil {
 		return err
 	}
+	if p.NodePool != "" {
+		return fmt.Errorf("[%s] calling preflight error: node pool is not supported", p.GetProviderName())
+	}
 	if p.MastersMemory != "" {
 		if _, err := dockerunits.RAMInBytes(p.MastersMemory); err != nil {
 			return fmt.Errorf("[%s] calling preflight error: provided `--masters-memory` limit value is invalid", p.GetProviderName())
@@ -269,6 +288,16 @@ func (p *K3d) rollbackK3d(ids []string) error {
 	return nil
 }
 
+func (p *K3d) removeK3dNodes(ids []string) error {
+	for _, id := range ids {
+		if err := client.NodeDelete(context.Background(), runtimes.SelectedRuntime, &k3d.Node{Name: id},
+			k3d.NodeDeleteOpts{SkipLBUpdate: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *K3d) syncK3d() error {
 	_, err := p.k3dStatus()
 	if err != nil {
@@ -517,6 +546,54 @@ func (p *K3d) deleteK3d(f bool) (string, error) {
 	return p.ContextName, nil
 }
 
+// stopK3d stops the node containers, the whole cluster including the load balancer is stopped with the servers.
+func (p *K3d) stopK3d(ids []string) error {
+	c, err := client.ClusterGet(context.Background(), runtimes.SelectedRuntime, &k3d.Cluster{
+		Name: p.Name,
+		ServerLoadBalancer: &k3d.Loadbalancer{
+			Config: &k3d.LoadbalancerConfig{},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	p.SetLogLevelAndOutput()
+	for _, n := range c.Nodes {
+		if n.Role == k3d.ServerRole && slice.ContainsString(ids, n.Name) {
+			return client.ClusterStop(context.Background(), runtimes.SelectedRuntime, c)
+		}
+	}
+	for _, n := range c.Nodes {
+		if n.State.Running && slice.ContainsString(ids, n.Name) {
+			p.Logger.Infof("[%s] stopping node %s...", p.GetProviderName(), n.Name)
+			if err = runtimes.SelectedRuntime.StopNode(context.Background(), n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// startK3d starts the whole cluster with the servers, k3d starts the servers before the agents.
+func (p *K3d) startK3d(ids []string) error {
+	c, err := client.ClusterGet(context.Background(), runtimes.SelectedRuntime, &k3d.Cluster{
+		Name: p.Name,
+		ServerLoadBalancer: &k3d.Loadbalancer{
+			Config: &k3d.LoadbalancerConfig{},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	p.SetLogLevelAndOutput()
+	for _, n := range c.Nodes {
+		if n.Role == k3d.ServerRole && slice.ContainsString(ids, n.Name) {
+			return client.ClusterStart(context.Background(), runtimes.SelectedRuntime, c, k3d.ClusterStartOpts{WaitForServer: true})
+		}
+	}
+	return nil
+}
+
 func (p *K3d) isNodeRunning(status string) bool {
 	return status == "running"
 }
@@ -686,12 +763,22 @@ func (p *K3d) wrapCliFlags(masters, workers int) (*k3dconf.ClusterConfig, error)
 		return nil, err
 	}
 
+	hosts, err := p.useRegistries(registry)
+	if err != nil {
+		return nil, err
+	}
+
 	content, err := utils.RegistryToString(registry)
 	if err != nil {
 		return nil, err
 	}
 
+	cfg.Registries.Use = hosts
 	cfg.Registries.Config = content
+	if len(hosts) > 0 {
+		// the registry content is saved with cluster, so that the used registries are shown in the cluster config.
+		p.RegistryContent = content
+	}
 
 	// volumeFilterMap will map volume mounts to applied node filters.
 	if len(p.Volumes) > 0 {
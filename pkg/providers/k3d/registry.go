@@ -0,0 +1,150 @@
+package k3d
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	k3dutil "github.com/k3d-io/k3d/v5/cmd/util"
+	"github.com/k3d-io/k3d/v5/pkg/client"
+	"github.com/k3d-io/k3d/v5/pkg/runtimes"
+	k3d "github.com/k3d-io/k3d/v5/pkg/types"
+	"github.com/rancher/wharfie/pkg/registries"
+)
+
+var registryImage = fmt.Sprintf("%s:%s", k3d.DefaultRegistryImageRepo, k3d.DefaultRegistryImageTag)
+
+// useRegistries merges the registries config of the k3d-managed registries used by the cluster into the registry
+// config of cluster, the k3d-managed registries are connected to the cluster network by k3d as well.
+func (p *K3d) useRegistries(registry *registries.Registry) ([]string, error) {
+	if len(p.RegistryUse) == 0 {
+		return nil, nil
+	}
+	hosts := make([]string, 0, len(p.RegistryUse))
+	used := make([]*k3d.Registry, 0, len(p.RegistryUse))
+	for _, name := range p.RegistryUse {
+		node, err := runtimes.SelectedRuntime.GetNode(context.Background(), &k3d.Node{Name: registryHost(name), Role: k3d.RegistryRole})
+		if err != nil {
+			return nil, fmt.Errorf("[%s] registry %s is not found, please create it by `autok3s k3d registry create %s`", p.GetProviderName(), name, name)
+		}
+		reg, err := client.RegistryFromNode(node)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, reg.Host)
+		used = append(used, reg)
+	}
+	generated, err := client.RegistryGenerateK3sConfig(context.Background(), used)
+	if err != nil {
+		return nil, err
+	}
+	// the registry config of cluster takes precedence over the generated config, the same as k3d.
+	if err = client.RegistryMergeConfig(context.Background(), generated, registry); err != nil {
+		return nil, err
+	}
+	*registry = *generated
+	return hosts, nil
+}
+
+// CreateRegistry creates and starts the k3d-managed registry, which can be used by the k3d clusters by `--registry-use`.
+func CreateRegistry(input *apis.K3dRegistry) (*apis.K3dRegistry, error) {
+	if input.Name == "" {
+		return nil, fmt.Errorf("[%s] registry name is required", providerName)
+	}
+	port := input.Port
+	if port == "" {
+		port = "random"
+	}
+	expose, err := k3dutil.ParsePortExposureSpec(port, k3d.DefaultRegistryPort)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] invalid registry port %s: %w", providerName, input.Port, err)
+	}
+	image := input.Image
+	if image == "" {
+		image = registryImage
+	}
+	reg := &k3d.Registry{
+		Host:         registryHost(input.Name),
+		Image:        image,
+		Network:      k3d.DefaultRuntimeNetwork,
+		ExposureOpts: *expose,
+	}
+	if input.ProxyRemoteURL != "" {
+		reg.Options.Proxy = k3d.RegistryProxy{
+			RemoteURL: input.ProxyRemoteURL,
+			Username:  input.ProxyUsername,
+			Password:  input.ProxyPassword,
+		}
+	}
+	if _, err = client.RegistryRun(context.Background(), runtimes.SelectedRuntime, reg); err != nil {
+		return nil, fmt.Errorf("[%s] failed to create registry %s: %w", providerName, input.Name, err)
+	}
+	return GetRegistry(input.Name)
+}
+
+// GetRegistry returns the k3d-managed registry by name.
+func GetRegistry(name string) (*apis.K3dRegistry, error) {
+	node, err := client.NodeGet(context.Background(), runtimes.SelectedRuntime, &k3d.Node{Name: registryHost(name), Role: k3d.RegistryRole})
+	if err != nil {
+		return nil, fmt.Errorf("[%s] registry %s is not found: %w", providerName, name, err)
+	}
+	return toAPIRegistry(node), nil
+}
+
+// ListRegistries returns all the k3d-managed registries.
+func ListRegistries() ([]*apis.K3dRegistry, error) {
+	nodes, err := client.NodeList(context.Background(), runtimes.SelectedRuntime)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] failed to list registries: %w", providerName, err)
+	}
+	nodes = client.NodeFilterByRoles(nodes, []k3d.Role{k3d.RegistryRole}, []k3d.Role{})
+	result := make([]*apis.K3dRegistry, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, toAPIRegistry(node))
+	}
+	return result, nil
+}
+
+// DeleteRegistry deletes the k3d-managed registry, the images pushed to the registry are removed with it.
+func DeleteRegistry(name string) error {
+	node, err := client.NodeGet(context.Background(), runtimes.SelectedRuntime, &k3d.Node{Name: registryHost(name), Role: k3d.RegistryRole})
+	if err != nil {
+		return fmt.Errorf("[%s] registry %s is not found: %w", providerName, name, err)
+	}
+	if err = client.NodeDelete(context.Background(), runtimes.SelectedRuntime, node, k3d.NodeDeleteOpts{SkipLBUpdate: true}); err != nil {
+		return fmt.Errorf("[%s] failed to delete registry %s: %w", providerName, name, err)
+	}
+	return nil
+}
+
+// registryHost returns the container name of the registry, k3d prefixes the registries with `k3d-` as other objects.
+func registryHost(name string) string {
+	prefix := k3d.DefaultObjectNamePrefix + "-"
+	if strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
+
+func toAPIRegistry(node *k3d.Node) *apis.K3dRegistry {
+	r := &apis.K3dRegistry{
+		Name:   strings.TrimPrefix(node.Name, k3d.DefaultObjectNamePrefix+"-"),
+		Host:   node.Name,
+		Image:  node.Image,
+		Status: node.State.Status,
+	}
+	if reg, err := client.RegistryFromNode(node); err == nil {
+		r.Port = reg.ExposureOpts.Binding.HostPort
+		if reg.ExposureOpts.Binding.HostIP != "" {
+			r.Port = fmt.Sprintf("%s:%s", reg.ExposureOpts.Binding.HostIP, reg.ExposureOpts.Binding.HostPort)
+		}
+	}
+	for _, env := range node.Env {
+		if v, ok := strings.CutPrefix(env, "REGISTRY_PROXY_REMOTEURL="); ok {
+			r.ProxyRemoteURL = v
+		}
+	}
+	return r
+}
@@ -0,0 +1,29 @@
+package k3d
+
+import (
+	"testing"
+
+	k3d "github.com/k3d-io/k3d/v5/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryHost(t *testing.T) {
+	assert.Equal(t, "k3d-myregistry", registryHost("myregistry"))
+	assert.Equal(t, "k3d-myregistry", registryHost("k3d-myregistry"))
+}
+
+func TestParseImportMode(t *testing.T) {
+	mode, err := parseImportMode([]string{"myapp:dev"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, k3d.ImportModeAutoDetect, mode)
+	mode, err = parseImportMode([]string{"myapp:dev", "./images.tar"}, "tools-node")
+	assert.NoError(t, err)
+	assert.Equal(t, k3d.ImportModeToolsNode, mode)
+
+	_, err = parseImportMode(nil, "")
+	assert.Error(t, err)
+	_, err = parseImportMode([]string{"-"}, "")
+	assert.Error(t, err)
+	_, err = parseImportMode([]string{"myapp:dev"}, "unknown")
+	assert.Error(t, err)
+}
@@ -27,6 +27,14 @@ const joinUsageExample = `  autok3s -d join \
     --worker-ips <worker-ips>
 `
 
+const importUsageExample = `  autok3s -d import \
+    --provider native \
+    --name <cluster name> \
+    --ssh-user <ssh-user> \
+    --ssh-key-path <ssh-key-path> \
+    --ips <node-ips>
+`
+
 const deleteUsageExample = `  autok3s -d delete \
     --provider native \
     --name <cluster name>
@@ -44,6 +52,8 @@ func (p *Native) GetUsageExample(action string) string {
 		return createUsageExample
 	case "join":
 		return joinUsageExample
+	case "import":
+		return importUsageExample
 	case "delete":
 		return deleteUsageExample
 	case "ssh":
@@ -71,6 +81,7 @@ func (p *Native) GetOptionFlags() []types.Flag {
 func (p *Native) GetJoinFlags() []types.Flag {
 	fs := p.sharedFlags()
 	fs = append(fs, p.GetClusterOptions()...)
+	fs = append(fs, p.GetNodePoolOptions()...)
 	fs = append(fs, types.Flag{
 		Name:  "ip",
 		P:     &p.IP,
@@ -80,6 +91,47 @@ func (p *Native) GetJoinFlags() []types.Flag {
 	return fs
 }
 
+// GetImportFlags returns native import flags.
+func (p *Native) GetImportFlags() []types.Flag {
+	fs := []types.Flag{
+		{
+			Name:      "name",
+			P:         &p.Name,
+			V:         p.Name,
+			Usage:     "Set the name of the kubeconfig context",
+			ShortHand: "n",
+			Required:  true,
+		},
+		{
+			Name:     "ips",
+			P:        &p.ImportIps,
+			V:        p.ImportIps,
+			Usage:    "Public IPs of the existing K3s nodes, the server/agent roles of nodes are discovered over SSH, e.g. 192.168.1.2,192.168.1.3",
+			Required: true,
+		},
+		{
+			Name:  "ip",
+			P:     &p.IP,
+			V:     p.IP,
+			Usage: "The server address of the cluster, e.g. the load balancer in front of servers, default to the address of the first server",
+		},
+		{
+			Name:  "k3s-install-script",
+			P:     &p.InstallScript,
+			V:     p.InstallScript,
+			Usage: "Change the default upstream k3s install script address, which is used to join nodes and upgrade cluster",
+		},
+		{
+			Name:  "k3s-install-mirror",
+			P:     &p.Mirror,
+			V:     p.Mirror,
+			Usage: "Enable mirror of the install script, e.g. INSTALL_K3S_MIRROR=cn",
+		},
+	}
+	fs = append(fs, p.GetSSHOptions()...)
+	return fs
+}
+
 // GetSSHFlags returns native ssh flags.
 func (p *Native) GetSSHFlags() []types.Flag {
 	fs := []types.Flag{
@@ -209,6 +261,18 @@ func (p *Native) sharedFlags() []types.Flag {
 			V:     p.WorkerIps,
 			Usage: "Public IPs of worker nodes on which to install agent, multiple IPs are separated by commas",
 		},
+		{
+			Name:  "vip",
+			P:     &p.VIP,
+			V:     p.VIP,
+			Usage: "Virtual IP announced by kube-vip on master nodes, it's used as the control-plane endpoint with `--control-plane-endpoint`",
+		},
+		{
+			Name:  "vip-interface",
+			P:     &p.VIPInterface,
+			V:     p.VIPInterface,
+			Usage: "Network interface of master nodes to announce the virtual IP, detected by kube-vip if not set",
+		},
 	}
 
 	return fs
@@ -1,13 +1,17 @@
 package native
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net"
 	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/cnrancher/autok3s/pkg/cluster"
@@ -19,6 +23,7 @@ import (
 	"github.com/cnrancher/autok3s/pkg/utils"
 
 	"github.com/rancher/wrangler/v2/pkg/slice"
+	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -27,14 +32,21 @@ import (
 const providerName = "native"
 
 var (
-	defaultUser       = "root"
-	defaultSSHKeyPath = "~/.ssh/id_rsa"
+	defaultUser          = "root"
+	defaultSSHKeyPath    = "~/.ssh/id_rsa"
+	kubeVipRegistry      = "ghcr.io"
+	kubeVipRepository    = "kube-vip/kube-vip"
+	kubeVipVersion       = "v0.8.9"
+	deployKubeVipCommand = "echo \"%s\" | base64 -d | tee \"%s/kube-vip.yaml\""
+	kubeVipTemplate      = template.Must(template.New("kube-vip").Parse(kubeVipTmpl))
 )
 
 // Native provider native struct.
 type Native struct {
 	*cluster.ProviderBase `json:",inline"`
 	native.Options        `json:",inline"`
+	// ImportIps are the nodes of the existing cluster to import, the roles of nodes are discovered.
+	ImportIps string `json:"-"`
 }
 
 func init() {
@@ -64,7 +76,10 @@ func (p *Native) GenerateClusterName() string {
 
 // GenerateManifest generates manifest deploy command.
 func (p *Native) GenerateManifest() []string {
-	// no need to support.
+	if p.ControlPlaneEndpoint && p.VIP != "" {
+		return []string{fmt.Sprintf(deployKubeVipCommand,
+			base64.StdEncoding.EncodeToString([]byte(getKubeVipManifest(p.VIP, p.VIPInterface, p.SystemDefaultRegistry))), common.K3sManifestsDir)}
+	}
 	return nil
 }
 
@@ -75,9 +90,8 @@ func (p *Native) GenerateMasterExtraArgs(_ *types.Cluster, _ types.Node) string
 }
 
 // GenerateWorkerExtraArgs generates K3S worker extra args.
-func (p *Native) GenerateWorkerExtraArgs(_ *types.Cluster, _ types.Node) string {
-	// no need to support.
-	return ""
+func (p *Native) GenerateWorkerExtraArgs(cluster *types.Cluster, worker types.Node) string {
+	return p.NodePoolExtraArgs(cluster, worker)
 }
 
 // CreateK3sCluster create K3S cluster.
@@ -122,9 +136,10 @@ func (p *Native) JoinK3sNode() (err error) {
 		}
 	}
 
-	return p.JoinNodes(func(_ *types.SSH) (*types.Cluster, error) {
+	// the hosts of native provider are not provisioned, so the pool options override is not supported.
+	return p.JoinNodes(p.NodePoolInstance(nil, func(_ *types.SSH) (*types.Cluster, error) {
 		return c, nil
-	}, p.syncNodes, false, p.rollbackInstance)
+	}), p.syncNodes, false, p.rollbackInstance)
 }
 
 func (p *Native) rollbackInstance(ids []string) error {
@@ -198,6 +213,15 @@ func (p *Native) CreateCheck() error {
 			p.Provider)
 	}
 
+	if p.ControlPlaneEndpoint {
+		if p.VIP == "" {
+			return fmt.Errorf("[%s] calling preflight error: need to set `--vip` for control-plane endpoint", p.Provider)
+		}
+		if net.ParseIP(p.VIP) == nil {
+			return fmt.Errorf("[%s] calling preflight error: `--vip` %s is not a valid IP", p.Provider, p.VIP)
+		}
+	}
+
 	return p.CheckCreateArgs(func() (bool, []string, error) {
 		return false, []string{}, nil
 	})
@@ -212,6 +236,12 @@ func (p *Native) JoinCheck() error {
 	if len(masterList) > 1 && !p.Cluster && p.DataStore == "" {
 		return fmt.Errorf("[%s] calling preflight error: can't join master nodes to single node cluster", p.GetProviderName())
 	}
+	if p.MasterIps != "" && p.NodePool != "" {
+		return fmt.Errorf("[%s] calling preflight error: only workers can be joined to node pool", p.GetProviderName())
+	}
+	if err := p.ValidateNodePool(nil); err != nil {
+		return err
+	}
 	// check --ip if cluster is not exist(for previous version)
 	state, err := common.DefaultDB.GetCluster(p.Name, p.Provider)
 	if err != nil {
@@ -307,6 +337,32 @@ func (p *Native) SetOptions(opt []byte) error {
 	return nil
 }
 
+// ImportK3sCluster imports the existing K3s cluster of the nodes.
+func (p *Native) ImportK3sCluster() error {
+	if p.SSHUser == "" {
+		p.SSHUser = defaultUser
+	}
+	if p.SSHKey == "" && p.SSHKeyName == "" && p.SSHPassword == "" && p.SSHKeyPath == "" && !p.SSHAgentAuth {
+		p.SSHKeyPath = defaultSSHKeyPath
+	}
+	nodes := []types.Node{}
+	for _, ip := range strings.Split(p.ImportIps, ",") {
+		if ip = strings.TrimSpace(ip); ip == "" {
+			continue
+		}
+		nodes = append(nodes, types.Node{
+			InstanceID:        strings.Replace(ip, ".", "-", -1),
+			InternalIPAddress: []string{ip},
+			PublicIPAddress:   []string{ip},
+		})
+	}
+	options := &native.Options{}
+	return p.ImportCluster(nodes, &p.SSH, options, func(c *types.Cluster) {
+		options.MasterIps = strings.Join(nodeIPs(c.MasterNodes), ",")
+		options.WorkerIps = strings.Join(nodeIPs(c.WorkerNodes), ",")
+	})
+}
+
 // GetProviderOptions get provider options.
 func (p *Native) GetProviderOptions(opt []byte) (interface{}, error) {
 	options := &native.Options{}
@@ -345,12 +401,19 @@ func (p *Native) assembleNodeStatus(ssh *types.SSH) (*types.Cluster, error) {
 		return true
 	})
 
-	return &types.Cluster{
+	c := &types.Cluster{
 		Metadata: p.Metadata,
 		Options:  p.Options,
 		Status:   p.Status,
 		SSH:      *ssh,
-	}, nil
+	}
+	// the virtual IP is announced by kube-vip which is deployed with the manifest.
+	if err := p.ConfigControlPlaneEndpoint(c, func(_ []types.Node) (string, error) {
+		return p.VIP, nil
+	}); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 func (p *Native) syncNodes() error {
@@ -485,3 +548,34 @@ func (p *Native) uninstallCluster(_ bool) (string, error) {
 func (p *Native) isInstanceRunning(_ string) bool {
 	return true
 }
+
+func nodeIPs(nodes []types.Node) []string {
+	ips := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ips = append(ips, node.PublicIPAddress[0])
+	}
+	return ips
+}
+
+// getKubeVipImage returns the kube-vip image, which is pulled from the system default registry if it's set,
+// the same as the K3s system images, so that the airgap clusters can mirror it in the private registry.
+func getKubeVipImage(systemDefaultRegistry string) string {
+	registry := kubeVipRegistry
+	if systemDefaultRegistry != "" {
+		registry = strings.TrimSuffix(systemDefaultRegistry, "/")
+	}
+	return fmt.Sprintf("%s/%s:%s", registry, kubeVipRepository, kubeVipVersion)
+}
+
+func getKubeVipManifest(address, iface, systemDefaultRegistry string) string {
+	rtn := bytes.NewBuffer([]byte{})
+	if err := kubeVipTemplate.Execute(rtn, map[string]interface{}{
+		"Image":     getKubeVipImage(systemDefaultRegistry),
+		"Address":   address,
+		"Interface": iface,
+		"Port":      cluster.EndpointPort,
+	}); err != nil {
+		logrus.Warnf("failed to execute kube-vip template, assuming no manifest, %v", err)
+	}
+	return rtn.String()
+}
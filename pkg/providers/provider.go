@@ -33,6 +33,8 @@ type Provider interface {
 	GetDeleteFlags() []types.Flag
 	// SSH command flags.
 	GetSSHFlags() []types.Flag
+	// Import command flags.
+	GetImportFlags() []types.Flag
 	// Credential flags.
 	GetCredentialFlags() []types.Flag
 	// Generate cluster name.
@@ -47,6 +49,14 @@ type Provider interface {
 	JoinK3sNode() error
 	// K3s delete cluster interface.
 	DeleteK3sCluster(f bool) error
+	// K3s stop cluster interface, the instances are stopped and can be started later.
+	StopK3sCluster() error
+	// K3s start cluster interface.
+	StartK3sCluster() error
+	// K3s remove worker nodes interface, the nodes are removed from the cluster and the instances are released.
+	RemoveK3sNodes(ids []string) error
+	// K3s import cluster interface, the existing K3s cluster is adopted into autok3s management.
+	ImportK3sCluster() error
 	// K3s ssh node interface.
 	SSHK3sNode(node string) error
 	// K3s check cluster exist.
@@ -81,6 +91,14 @@ type Provider interface {
 	RegisterCallbacks(name, event string, fn func(interface{}))
 	// UpgradeK3sCluster helps upgrade K3s cluster to specified version
 	UpgradeK3sCluster(clusterName, installScript, channel, version, packageName, packagePath string) error
+	// InstallAddon deploys the add-on to the existing K3s cluster.
+	InstallAddon(clusterName, addon string, values map[string]string) error
+	// UpgradeAddon re-renders the installed add-on with the merged values.
+	UpgradeAddon(clusterName, addon string, values map[string]string, unsetValues []string) error
+	// UninstallAddon removes the installed add-on from K3s cluster.
+	UninstallAddon(clusterName, addon string) error
+	// GetAddonStatus returns the installed add-ons with status.
+	GetAddonStatus(clusterName, addon string) ([]apis.ClusterAddonStatus, error)
 }
 
 // RegisterProvider registers a provider.Factory by name.
@@ -0,0 +1,134 @@
+package tencent
+
+import (
+	"fmt"
+
+	"github.com/cnrancher/autok3s/pkg/cluster"
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
+	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ensureEndpoint creates the cloud load balancer of the control plane if not exist and registers the masters.
+func (p *Tencent) ensureEndpoint(masters []types.Node) (string, error) {
+	name := cluster.EndpointName(p.ContextName)
+	lb, err := p.getLoadBalancer(name)
+	if err != nil {
+		return "", err
+	}
+	if lb == nil {
+		p.Logger.Infof("[%s] creating cloud load balancer %s", p.GetProviderName(), name)
+		request := clb.NewCreateLoadBalancerRequest()
+		request.LoadBalancerType = tencentCommon.StringPtr("OPEN")
+		request.LoadBalancerName = tencentCommon.StringPtr(name)
+		request.VpcId = tencentCommon.StringPtr(p.VpcID)
+		request.Tags = []*clb.TagInfo{
+			{TagKey: tencentCommon.StringPtr("autok3s"), TagValue: tencentCommon.StringPtr("true")},
+			{TagKey: tencentCommon.StringPtr("cluster"), TagValue: tencentCommon.StringPtr(common.TagClusterPrefix + p.ContextName)},
+		}
+		if _, err = p.lb.CreateLoadBalancer(request); err != nil {
+			return "", fmt.Errorf("failed to create cloud load balancer %s: %v", name, err)
+		}
+	}
+	// the vip is allocated asynchronously.
+	if err = wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		lb, err = p.getLoadBalancer(name)
+		if err != nil {
+			return false, err
+		}
+		return lb != nil && len(lb.LoadBalancerVips) > 0, nil
+	}); err != nil {
+		return "", fmt.Errorf("failed to wait for cloud load balancer %s to be ready: %v", name, err)
+	}
+
+	listenerID, err := p.getListener(lb.LoadBalancerId)
+	if err != nil {
+		return "", err
+	}
+	if listenerID == "" {
+		request := clb.NewCreateListenerRequest()
+		request.LoadBalancerId = lb.LoadBalancerId
+		request.Ports = []*int64{tencentCommon.Int64Ptr(cluster.EndpointPort)}
+		request.Protocol = tencentCommon.StringPtr("TCP")
+		request.ListenerNames = []*string{tencentCommon.StringPtr(name)}
+		response, err := p.lb.CreateListener(request)
+		if err != nil {
+			return "", fmt.Errorf("failed to create listener of cloud load balancer %s: %v", name, err)
+		}
+		if len(response.Response.ListenerIds) > 0 {
+			listenerID = *response.Response.ListenerIds[0]
+		}
+	}
+
+	if len(masters) > 0 {
+		request := clb.NewRegisterTargetsRequest()
+		request.LoadBalancerId = lb.LoadBalancerId
+		request.ListenerId = tencentCommon.StringPtr(listenerID)
+		for _, master := range masters {
+			request.Targets = append(request.Targets, &clb.Target{
+				InstanceId: tencentCommon.StringPtr(master.InstanceID),
+				Port:       tencentCommon.Int64Ptr(cluster.EndpointPort),
+			})
+		}
+		// the load balancer is locked by the listener task for a while.
+		var lastErr error
+		if err = wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+			_, lastErr = p.lb.RegisterTargets(request)
+			return lastErr == nil, nil
+		}); err != nil {
+			return "", fmt.Errorf("failed to register masters to cloud load balancer %s: %v", name, lastErr)
+		}
+	}
+	return *lb.LoadBalancerVips[0], nil
+}
+
+// deleteEndpoint removes the cloud load balancer of the control plane.
+func (p *Tencent) deleteEndpoint() error {
+	name := cluster.EndpointName(p.ContextName)
+	lb, err := p.getLoadBalancer(name)
+	if err != nil || lb == nil {
+		return err
+	}
+	p.Logger.Infof("[%s] deleting cloud load balancer %s", p.GetProviderName(), name)
+	request := clb.NewDeleteLoadBalancerRequest()
+	request.LoadBalancerIds = []*string{lb.LoadBalancerId}
+	if _, err = p.lb.DeleteLoadBalancer(request); err != nil {
+		return fmt.Errorf("failed to delete cloud load balancer %s: %v", name, err)
+	}
+	return nil
+}
+
+func (p *Tencent) getLoadBalancer(name string) (*clb.LoadBalancer, error) {
+	request := clb.NewDescribeLoadBalancersRequest()
+	request.LoadBalancerName = tencentCommon.StringPtr(name)
+	response, err := p.lb.DescribeLoadBalancers(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe cloud load balancer %s: %v", name, err)
+	}
+	for _, lb := range response.Response.LoadBalancerSet {
+		if lb.LoadBalancerName != nil && *lb.LoadBalancerName == name {
+			return lb, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *Tencent) getListener(loadBalancerID *string) (string, error) {
+	request := clb.NewDescribeListenersRequest()
+	request.LoadBalancerId = loadBalancerID
+	request.Protocol = tencentCommon.StringPtr("TCP")
+	request.Port = tencentCommon.Int64Ptr(cluster.EndpointPort)
+	response, err := p.lb.DescribeListeners(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe listeners of cloud load balancer %s: %v", *loadBalancerID, err)
+	}
+	for _, listener := range response.Response.Listeners {
+		if listener.ListenerId != nil {
+			return *listener.ListenerId, nil
+		}
+	}
+	return "", nil
+}
@@ -72,6 +72,7 @@ func (p *Tencent) GetOptionFlags() []types.Flag {
 func (p *Tencent) GetJoinFlags() []types.Flag {
 	fs := p.sharedFlags()
 	fs = append(fs, p.GetClusterOptions()...)
+	fs = append(fs, p.GetNodePoolOptions()...)
 	return fs
 }
 
@@ -201,6 +202,12 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			Usage:  "CVM zone",
 			EnvVar: "CVM_ZONE",
 		},
+		{
+			Name:  "zones",
+			P:     &p.Zones,
+			V:     p.Zones,
+			Usage: "Spread the nodes across the CVM zones of region, which overrides --zone, i.e.(--zones ap-guangzhou-3,ap-guangzhou-4,ap-guangzhou-6)",
+		},
 		{
 			Name:   "vpc",
 			P:      &p.VpcID,
@@ -215,6 +222,12 @@ func (p *Tencent) sharedFlags() []types.Flag {
 			Usage:  "Private network subnet id, see: https://cloud.tencent.com/document/product/215/20046#.E5.AD.90.E7.BD.91",
 			EnvVar: "CVM_SUBNET_ID",
 		},
+		{
+			Name:  "subnets",
+			P:     &p.SubnetIDs,
+			V:     p.SubnetIDs,
+			Usage: "Private network subnet id for each zone of --zones in the same order, must be set with --vpc",
+		},
 		{
 			Name:   "keypair-id",
 			P:      &p.KeypairID,
@@ -19,6 +19,7 @@ import (
 	"github.com/cnrancher/autok3s/pkg/types/tencent"
 	"github.com/cnrancher/autok3s/pkg/utils"
 
+	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
 	tencentCommon "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
@@ -57,10 +58,11 @@ type Tencent struct {
 	*cluster.ProviderBase `json:",inline"`
 	tencent.Options       `json:",inline"`
 
-	c *cvm.Client
-	v *vpc.Client
-	t *tag.Client
-	r *tke.Client
+	c  *cvm.Client
+	v  *vpc.Client
+	t  *tag.Client
+	r  *tke.Client
+	lb *clb.Client
 	// m *sync.Map
 }
 
@@ -129,7 +131,7 @@ func (p *Tencent) JoinK3sNode() (err error) {
 		p.SSHUser = defaultUser
 	}
 
-	return p.JoinNodes(p.generateInstance, func() error { return nil }, false, p.rollbackInstance)
+	return p.JoinNodes(p.NodePoolInstance(&p.Options, p.generateInstance), func() error { return nil }, false, p.rollbackInstance)
 }
 
 func (p *Tencent) rollbackInstance(ids []string) error {
@@ -189,6 +191,30 @@ func (p *Tencent) DeleteK3sCluster(f bool) error {
 	return p.DeleteCluster(f, p.deleteInstance)
 }
 
+// StopK3sCluster stops the instances of K3s cluster, the instances are not charged after stopped.
+func (p *Tencent) StopK3sCluster() error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	return p.StopCluster(p.stopInstances)
+}
+
+// StartK3sCluster starts the instances of K3s cluster.
+func (p *Tencent) StartK3sCluster() error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	return p.StartCluster(p.startInstances, p.getInstanceNodes)
+}
+
+// RemoveK3sNodes removes the worker nodes from K3s cluster and deletes the instances.
+func (p *Tencent) RemoveK3sNodes(ids []string) error {
+	if err := p.generateClientSDK(); err != nil {
+		return err
+	}
+	return p.RemoveNodes(ids, p.rollbackInstance)
+}
+
 // SSHK3sNode ssh K3s node.
 func (p *Tencent) SSHK3sNode(ip string) error {
 	c := &types.Cluster{
@@ -229,6 +255,7 @@ func (p *Tencent) getInstanceNodes() ([]types.Node, error) {
 			InstanceStatus:    instanceState,
 			InternalIPAddress: tencentCommon.StringValues(instance.PrivateIpAddresses),
 			PublicIPAddress:   tencentCommon.StringValues(instance.PublicIpAddresses),
+			Zone:              instanceZone(instance),
 		})
 	}
 	return nodes, nil
@@ -264,18 +291,24 @@ func (p *Tencent) IsClusterExist() (bool, []string, error) {
 // GenerateMasterExtraArgs generates K3S master extra args.
 func (p *Tencent) GenerateMasterExtraArgs(cluster *types.Cluster, master types.Node) string {
 	if option, ok := cluster.Options.(tencent.Options); ok {
+		// the nodes created before spreading across zones are in the zone of cluster.
+		zone := master.Zone
+		if zone == "" {
+			zone = option.Zone
+		}
 		if option.CloudControllerManager {
 			extraArgs := fmt.Sprintf(" --kubelet-arg=cloud-provider=external --kubelet-arg=node-status-update-frequency=30s --kubelet-arg=provider-id=tencentcloud:///%s/%s --node-name=%s",
-				option.Zone, master.InstanceID, master.InternalIPAddress[0])
-			return extraArgs
+				zone, master.InstanceID, master.InternalIPAddress[0])
+			return extraArgs + putil.ZoneExtraArgs(zone)
 		}
+		return putil.ZoneExtraArgs(zone)
 	}
 	return ""
 }
 
 // GenerateWorkerExtraArgs generates K3S worker extra args.
 func (p *Tencent) GenerateWorkerExtraArgs(cluster *types.Cluster, worker types.Node) string {
-	return p.GenerateMasterExtraArgs(cluster, worker)
+	return p.GenerateMasterExtraArgs(p.NodePoolCluster(cluster, worker), worker) + p.NodePoolExtraArgs(cluster, worker)
 }
 
 // GetCluster returns cluster status.
@@ -299,6 +332,9 @@ func (p *Tencent) DescribeCluster(kubecfg string) *types.ClusterInfo {
 		Zone:     p.Zone,
 		Provider: p.GetProviderName(),
 	}
+	if len(p.Zones) > 0 {
+		c.Zone = strings.Join(p.Zones, ",")
+	}
 	return p.Describe(kubecfg, c, p.getInstanceNodes)
 }
 
@@ -377,6 +413,12 @@ func (p *Tencent) generateClientSDK() error {
 	} else {
 		return err
 	}
+
+	if lbClient, err := clb.NewClient(credential, p.Region, cpf); err == nil {
+		p.lb = lbClient
+	} else {
+		return err
+	}
 	return nil
 }
 
@@ -399,7 +441,11 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 
 	if p.VpcID == "" {
 		// config default vpc and subnet.
-		err = p.configNetwork()
+		if len(p.Zones) > 0 {
+			err = p.configZoneNetwork()
+		} else {
+			err = p.configNetwork()
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -437,7 +483,7 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	// run ecs master instances.
 	if masterNum > 0 {
 		p.Logger.Infof("[%s] %d number of master instances will be created", p.GetProviderName(), masterNum)
-		if err := p.runInstances(masterNum, true, ssh.SSHPassword); err != nil {
+		if err := p.runZoneInstances(masterNum, true, p.Status.MasterNodes, ssh.SSHPassword); err != nil {
 			return nil, err
 		}
 		p.Logger.Infof("[%s] %d number of master instances successfully created", p.GetProviderName(), masterNum)
@@ -446,7 +492,7 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	// run ecs worker instances.
 	if workerNum > 0 {
 		p.Logger.Infof("[%s] %d number of worker instances will be created", p.GetProviderName(), workerNum)
-		if err := p.runInstances(workerNum, false, ssh.SSHPassword); err != nil {
+		if err := p.runZoneInstances(workerNum, false, p.Status.WorkerNodes, ssh.SSHPassword); err != nil {
 			return nil, err
 		}
 		p.Logger.Infof("[%s] %d number of worker instances successfully created", p.GetProviderName(), workerNum)
@@ -506,6 +552,10 @@ func (p *Tencent) generateInstance(ssh *types.SSH) (*types.Cluster, error) {
 	}
 	c.SSH = *ssh
 
+	if err = p.ConfigControlPlaneEndpoint(c, p.ensureEndpoint); err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
@@ -538,6 +588,12 @@ func (p *Tencent) deleteInstance(f bool) (string, error) {
 		}
 	}
 
+	if p.ControlPlaneEndpoint {
+		if err = p.deleteEndpoint(); err != nil && !f {
+			return "", err
+		}
+	}
+
 	taggedResource, err := p.describeResourcesByTags()
 	if err != nil {
 		p.Logger.Errorf("[%s] error when query tagged eip(s), message: %v", p.GetProviderName(), err)
@@ -614,12 +670,39 @@ func (p *Tencent) CreateCheck() error {
 			p.GetProviderName())
 	}
 
+	if len(p.Zones) > 0 || len(p.SubnetIDs) > 0 {
+		p.Zones = putil.ParseList(p.Zones)
+		p.SubnetIDs = putil.ParseList(p.SubnetIDs)
+		if len(p.Zones) == 0 {
+			return fmt.Errorf("[%s] calling preflight error: `--subnets` must be set with `--zones`", p.GetProviderName())
+		}
+		if p.SubnetID != "" {
+			return fmt.Errorf("[%s] calling preflight error: `--subnet` can't be set with `--zones`, please use `--subnets` instead", p.GetProviderName())
+		}
+		if p.VpcID != "" && len(p.SubnetIDs) == 0 {
+			return fmt.Errorf("[%s] calling preflight error: `--subnets` must be set with `--vpc` and `--zones`", p.GetProviderName())
+		}
+		if err := putil.ValidateZones(p.GetProviderName(), p.Zones, p.SubnetIDs, "subnets"); err != nil {
+			return err
+		}
+		p.Zone = p.Zones[0]
+		if len(p.SubnetIDs) > 0 {
+			p.SubnetID = p.SubnetIDs[0]
+		}
+	}
+
 	return nil
 }
 
 // JoinCheck check join command and flags.
 func (p *Tencent) JoinCheck() error {
-	return p.CheckJoinArgs(p.IsClusterExist)
+	if err := p.CheckJoinArgs(p.IsClusterExist); err != nil {
+		return err
+	}
+	if len(p.Zones) > 0 && len(p.SubnetIDs) != len(p.Zones) {
+		return fmt.Errorf("[%s] calling preflight error: `--zones` can only be set when creating the cluster", p.GetProviderName())
+	}
+	return p.ValidateNodePool(&p.Options, "zones", "subnets")
 }
 
 func (p *Tencent) assembleInstanceStatus(ssh *types.SSH, uploadKeyPair bool, publicKey string) error {
@@ -679,13 +762,38 @@ func (p *Tencent) assembleInstanceStatus(ssh *types.SSH, uploadKeyPair bool, pub
 			InstanceStatus:    tencent.StatusRunning,
 			InternalIPAddress: tencentCommon.StringValues(status.PrivateIpAddresses),
 			EipAllocationIds:  eip,
-			PublicIPAddress:   tencentCommon.StringValues(status.PublicIpAddresses)})
+			PublicIPAddress:   tencentCommon.StringValues(status.PublicIpAddresses),
+			Zone:              instanceZone(status)})
 
 	}
 	return nil
 }
 
-func (p *Tencent) runInstances(num int, master bool, password string) error {
+// runZoneInstances runs the instances round-robin across the zones of cluster, the zones which have fewer
+// existing nodes are preferred.
+func (p *Tencent) runZoneInstances(num int, master bool, existing []types.Node, password string) error {
+	zones, subnets := p.zoneSubnets()
+	for i, n := range putil.SpreadZones(zones, existing, num) {
+		if n == 0 {
+			continue
+		}
+		if err := p.runInstances(n, master, zones[i], subnets[i], password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zoneSubnets returns the zones of cluster and the subnet of each zone. The single zone is used if the
+// zone or subnet isn't the first one of the zones, e.g. it's overridden by the node pool.
+func (p *Tencent) zoneSubnets() ([]string, []string) {
+	if len(p.Zones) == 0 || len(p.SubnetIDs) != len(p.Zones) || p.Zone != p.Zones[0] || p.SubnetID != p.SubnetIDs[0] {
+		return []string{p.Zone}, []string{p.SubnetID}
+	}
+	return p.Zones, p.SubnetIDs
+}
+
+func (p *Tencent) runInstances(num int, master bool, zone, subnetID string, password string) error {
 	request := cvm.NewRunInstancesRequest()
 
 	diskSize, _ := strconv.ParseInt(p.SystemDiskSize, 10, 64)
@@ -696,12 +804,12 @@ func (p *Tencent) runInstances(num int, master bool, password string) error {
 	request.ImageId = tencentCommon.StringPtr(p.ImageID)
 	request.InstanceType = tencentCommon.StringPtr(p.InstanceType)
 	request.Placement = &cvm.Placement{
-		Zone: tencentCommon.StringPtr(p.Zone),
+		Zone: tencentCommon.StringPtr(zone),
 	}
 	request.InstanceChargeType = tencentCommon.StringPtr(p.InstanceChargeType)
 	request.SecurityGroupIds = tencentCommon.StringPtrs(strings.Split(p.SecurityGroupIds, ","))
 	request.VirtualPrivateCloud = &cvm.VirtualPrivateCloud{
-		SubnetId: tencentCommon.StringPtr(p.SubnetID),
+		SubnetId: tencentCommon.StringPtr(subnetID),
 		VpcId:    tencentCommon.StringPtr(p.VpcID),
 	}
 	request.SystemDisk = &cvm.SystemDisk{
@@ -749,15 +857,22 @@ func (p *Tencent) runInstances(num int, master bool, password string) error {
 	response, err := p.c.RunInstances(request)
 	if err != nil || len(response.Response.InstanceIdSet) != num {
 		return fmt.Errorf("[%s] calling runInstances error. region: %s, zone: %s, "+"instanceName: %s, msg: [%v]",
-			p.GetProviderName(), p.Region, p.Zone, *request.InstanceName, err)
+			p.GetProviderName(), p.Region, zone, *request.InstanceName, err)
 	}
 	for _, id := range response.Response.InstanceIdSet {
-		p.M.Store(*id, types.Node{Master: master, RollBack: true, InstanceID: *id, InstanceStatus: tencent.StatusPending})
+		p.M.Store(*id, types.Node{Master: master, RollBack: true, InstanceID: *id, InstanceStatus: tencent.StatusPending, Zone: zone})
 	}
 
 	return nil
 }
 
+func instanceZone(instance *cvm.Instance) string {
+	if instance.Placement == nil || instance.Placement.Zone == nil {
+		return ""
+	}
+	return *instance.Placement.Zone
+}
+
 func (p *Tencent) describeInstances() ([]*cvm.Instance, error) {
 	request := cvm.NewDescribeInstancesRequest()
 
@@ -1056,6 +1171,26 @@ func (p *Tencent) configNetwork() error {
 	return nil
 }
 
+// configZoneNetwork finds or generates the default subnet of each zone when the nodes are spread across zones.
+func (p *Tencent) configZoneNetwork() error {
+	zone := p.Zone
+	defer func() {
+		p.Zone = zone
+	}()
+	subnets := make([]string, 0, len(p.Zones))
+	for _, z := range p.Zones {
+		p.Zone = z
+		p.SubnetID = ""
+		if err := p.configNetwork(); err != nil {
+			return err
+		}
+		subnets = append(subnets, p.SubnetID)
+	}
+	p.SubnetIDs = subnets
+	p.SubnetID = subnets[0]
+	return nil
+}
+
 func (p *Tencent) generateDefaultVPC() error {
 	p.Logger.Infof("[%s] generate default vpc %s in region %s", p.GetProviderName(), vpcName, p.Region)
 	request := vpc.NewCreateVpcRequest()
@@ -1176,23 +1311,22 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 	if err != nil {
 		return err
 	}
-	// check subnet cidr.
-	var cidr string
+	// check subnet cidrs.
+	cidrs := []string{subnetCidrBlock}
 	if p.SubnetID != "" {
-		cidr, err = p.getSubnetCidr()
+		cidrs, err = p.getSubnetCidrs()
 		if err != nil {
 			return err
 		}
-	} else {
-		cidr = subnetCidrBlock
 	}
 	hasSSHPort := false
 	hasAPIServerPort := false
 	hasKubeletPort := false
 	hasVXlanPort := false
 	hasEgress := false
-	hasEtcdServerPort := false
-	hasEtcdPeerPort := false
+	// the etcd ports are allowed by the cidr blocks.
+	hasEtcdServerPort := map[string]bool{}
+	hasEtcdPeerPort := map[string]bool{}
 	if response != nil && response.Response != nil &&
 		response.Response.SecurityGroupPolicySet != nil && response.Response.SecurityGroupPolicySet.Ingress != nil {
 		rules := response.Response.SecurityGroupPolicySet.Ingress
@@ -1211,13 +1345,9 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 				case 8472:
 					hasVXlanPort = true
 				case 2379:
-					if *rule.CidrBlock == cidr || *rule.CidrBlock == ipRange {
-						hasEtcdServerPort = true
-					}
+					hasEtcdServerPort[*rule.CidrBlock] = true
 				case 2380:
-					if *rule.CidrBlock == cidr || *rule.CidrBlock == ipRange {
-						hasEtcdPeerPort = true
-					}
+					hasEtcdPeerPort[*rule.CidrBlock] = true
 				}
 			}
 
@@ -1273,7 +1403,10 @@ func (p *Tencent) configDefaultSecurityPermission() error {
 		})
 	}
 
-	if !hasEtcdServerPort || !hasEtcdPeerPort {
+	for _, cidr := range cidrs {
+		if (hasEtcdServerPort[cidr] || hasEtcdServerPort[ipRange]) && (hasEtcdPeerPort[cidr] || hasEtcdPeerPort[ipRange]) {
+			continue
+		}
 		perms = append(perms, &vpc.SecurityGroupPolicy{
 			Protocol:          tencentCommon.StringPtr("TCP"),
 			Port:              tencentCommon.StringPtr("2379"),
@@ -1388,16 +1521,100 @@ func (p *Tencent) uploadKeyPair(node types.Node, publicKey string) error {
 	return nil
 }
 
-func (p *Tencent) getSubnetCidr() (string, error) {
+func (p *Tencent) getSubnetCidrs() ([]string, error) {
+	subnets := []string{p.SubnetID}
+	if len(p.SubnetIDs) > 0 {
+		subnets = p.SubnetIDs
+	}
 	request := vpc.NewDescribeSubnetsRequest()
-	request.SubnetIds = tencentCommon.StringPtrs([]string{p.SubnetID})
+	request.SubnetIds = tencentCommon.StringPtrs(subnets)
 	response, err := p.v.DescribeSubnets(request)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	cidrs := make([]string, 0, len(subnets))
+	if response != nil && response.Response != nil {
+		for _, subnet := range response.Response.SubnetSet {
+			cidrs = append(cidrs, *subnet.CidrBlock)
+		}
+	}
+	return cidrs, nil
+}
+
+func (p *Tencent) stopInstances(ids []string) error {
+	ids, err := p.filterInstances(ids, tencent.StatusRunning)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	p.Logger.Infof("[%s] stopping instances %s...", p.GetProviderName(), ids)
+	request := cvm.NewStopInstancesRequest()
+	request.InstanceIds = tencentCommon.StringPtrs(ids)
+	request.StoppedMode = tencentCommon.StringPtr("STOP_CHARGING")
+	if _, err = p.c.StopInstances(request); err != nil {
+		return err
+	}
+	return p.waitInstances(ids, tencent.StatusStopped)
+}
+
+func (p *Tencent) startInstances(ids []string) error {
+	ids, err := p.filterInstances(ids, tencent.StatusStopped)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	p.Logger.Infof("[%s] starting instances %s...", p.GetProviderName(), ids)
+	request := cvm.NewStartInstancesRequest()
+	request.InstanceIds = tencentCommon.StringPtrs(ids)
+	if _, err = p.c.StartInstances(request); err != nil {
+		return err
+	}
+	return p.waitInstances(ids, tencent.StatusRunning)
+}
+
+// filterInstances returns the instances which are in the status.
+func (p *Tencent) filterInstances(ids []string, status string) ([]string, error) {
+	statuses, err := p.describeInstanceStatus(ids)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if statuses[id] == status {
+			rtn = append(rtn, id)
+		}
 	}
+	return rtn, nil
+}
 
-	if response != nil && response.Response != nil && response.Response.SubnetSet != nil {
-		return *response.Response.SubnetSet[0].CidrBlock, nil
+// waitInstances waits until all the instances are in the status.
+func (p *Tencent) waitInstances(ids []string, status string) error {
+	return wait.ExponentialBackoff(common.Backoff, func() (bool, error) {
+		statuses, err := p.describeInstanceStatus(ids)
+		if err != nil {
+			return false, nil
+		}
+		for _, id := range ids {
+			if statuses[id] != status {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+func (p *Tencent) describeInstanceStatus(ids []string) (map[string]string, error) {
+	request := cvm.NewDescribeInstancesStatusRequest()
+	request.InstanceIds = tencentCommon.StringPtrs(ids)
+	request.Limit = tencentCommon.Int64Ptr(100)
+	response, err := p.c.DescribeInstancesStatus(request)
+	if err != nil {
+		return nil, err
+	}
+	statuses := map[string]string{}
+	for _, status := range response.Response.InstanceStatusSet {
+		if status.InstanceId != nil && status.InstanceState != nil {
+			statuses[*status.InstanceId] = *status.InstanceState
+		}
 	}
-	return "", nil
+	return statuses, nil
 }
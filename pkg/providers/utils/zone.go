@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+// ZoneLabel is the well-known topology label of the node's zone.
+const ZoneLabel = "topology.kubernetes.io/zone"
+
+// ParseList splits the comma separated values of list flags, e.g. `--zones a,b --zones c`.
+func ParseList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				list = append(list, v)
+			}
+		}
+	}
+	return list
+}
+
+// ValidateZones validates the zones and the matching subnets of the provider, the subnets are optional
+// and must be one for each zone if they're set.
+func ValidateZones(providerName string, zones, subnets []string, subnetFlag string) error {
+	seen := map[string]bool{}
+	for _, zone := range zones {
+		if seen[zone] {
+			return fmt.Errorf("[%s] calling preflight error: zone %s is duplicated in `--zones`", providerName, zone)
+		}
+		seen[zone] = true
+	}
+	if len(subnets) > 0 && len(subnets) != len(zones) {
+		return fmt.Errorf("[%s] calling preflight error: `--%s` must be set for each zone of `--zones`", providerName, subnetFlag)
+	}
+	return nil
+}
+
+// SpreadZones returns the number of new nodes for each zone, the nodes are distributed round-robin across
+// the zones, starting from the zone which has the fewest existing nodes. The existing nodes without zone
+// are counted to the first zone, which is the zone of cluster before spreading.
+func SpreadZones(zones []string, nodes []types.Node, num int) []int {
+	counts := make([]int, len(zones))
+	for _, n := range nodes {
+		i := 0
+		for j, zone := range zones {
+			if n.Zone == zone {
+				i = j
+				break
+			}
+		}
+		counts[i]++
+	}
+	spread := make([]int, len(zones))
+	for ; num > 0; num-- {
+		i := 0
+		for j := range zones {
+			if counts[j] < counts[i] {
+				i = j
+			}
+		}
+		counts[i]++
+		spread[i]++
+	}
+	return spread
+}
+
+// ZoneExtraArgs generates the k3s args which label the node with its zone.
+func ZoneExtraArgs(zone string) string {
+	if zone == "" {
+		return ""
+	}
+	return fmt.Sprintf(" --node-label %s=%s", ZoneLabel, zone)
+}
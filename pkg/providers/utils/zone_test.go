@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseList(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, ParseList([]string{"a, b", "", "c,"}))
+	assert.Empty(t, ParseList(nil))
+}
+
+func TestValidateZones(t *testing.T) {
+	assert.NoError(t, ValidateZones("aws", []string{"a", "b"}, nil, "subnet-ids"))
+	assert.NoError(t, ValidateZones("aws", []string{"a", "b"}, []string{"s1", "s2"}, "subnet-ids"))
+	assert.Error(t, ValidateZones("aws", []string{"a", "b"}, []string{"s1"}, "subnet-ids"))
+	assert.Error(t, ValidateZones("aws", []string{"a", "a"}, nil, "subnet-ids"))
+}
+
+func TestSpreadZones(t *testing.T) {
+	zones := []string{"a", "b", "c"}
+	assert.Equal(t, []int{1, 1, 1}, SpreadZones(zones, nil, 3))
+	assert.Equal(t, []int{2, 2, 1}, SpreadZones(zones, nil, 5))
+	// the new nodes are spread to the zones which have fewer nodes.
+	nodes := []types.Node{{Zone: "a"}, {Zone: "b"}}
+	assert.Equal(t, []int{0, 0, 1}, SpreadZones(zones, nodes, 1))
+	assert.Equal(t, []int{1, 1, 2}, SpreadZones(zones, nodes, 4))
+	// the nodes without zone are counted to the first zone.
+	nodes = []types.Node{{}, {}}
+	assert.Equal(t, []int{0, 1, 1}, SpreadZones(zones, nodes, 2))
+	assert.Equal(t, []int{3}, SpreadZones([]string{"a"}, nodes, 3))
+}
+
+func TestZoneExtraArgs(t *testing.T) {
+	assert.Equal(t, " --node-label topology.kubernetes.io/zone=a", ZoneExtraArgs("a"))
+	assert.Empty(t, ZoneExtraArgs(""))
+}
@@ -8,9 +8,12 @@ import (
 	"github.com/cnrancher/autok3s/pkg/server/store/cluster"
 	"github.com/cnrancher/autok3s/pkg/server/store/credential"
 	"github.com/cnrancher/autok3s/pkg/server/store/explorer"
+	"github.com/cnrancher/autok3s/pkg/server/store/health"
 	"github.com/cnrancher/autok3s/pkg/server/store/kubectl"
 	"github.com/cnrancher/autok3s/pkg/server/store/pkg"
+	"github.com/cnrancher/autok3s/pkg/server/store/policy"
 	"github.com/cnrancher/autok3s/pkg/server/store/provider"
+	"github.com/cnrancher/autok3s/pkg/server/store/registry"
 	"github.com/cnrancher/autok3s/pkg/server/store/settings"
 	"github.com/cnrancher/autok3s/pkg/server/store/sshkey"
 	"github.com/cnrancher/autok3s/pkg/server/store/template"
@@ -35,6 +38,13 @@ func initCluster(s *types.APISchemas) {
 	s.MustImportAndCustomize(autok3stypes.KubeconfigOutput{}, nil)
 	s.MustImportAndCustomize(autok3stypes.EnableExplorerOutput{}, nil)
 	s.MustImportAndCustomize(autok3stypes.UpgradeInput{}, nil)
+	s.MustImportAndCustomize(autok3stypes.AddonInput{}, nil)
+	s.MustImportAndCustomize(autok3stypes.AddonStatusOutput{}, nil)
+	s.MustImportAndCustomize(autok3stypes.IssueKubeconfigInput{}, nil)
+	s.MustImportAndCustomize(autok3stypes.IssueKubeconfigOutput{}, nil)
+	s.MustImportAndCustomize(autok3stypes.RevokeKubeconfigInput{}, nil)
+	s.MustImportAndCustomize(autok3stypes.KubeconfigCredentialsOutput{}, nil)
+	s.MustImportAndCustomize(autok3stypes.ImportImageInput{}, nil)
 	s.MustImportAndCustomize(autok3stypes.Cluster{}, func(schema *types.APISchema) {
 		schema.Store = &cluster.Store{}
 		common.DefaultDB.Register()
@@ -53,12 +63,64 @@ func initCluster(s *types.APISchemas) {
 		schema.ResourceActions["upgrade"] = wranglertypes.Action{
 			Input: "upgradeInput",
 		}
+		schema.ResourceActions["install-addon"] = wranglertypes.Action{
+			Input: "addonInput",
+		}
+		schema.ResourceActions["upgrade-addon"] = wranglertypes.Action{
+			Input: "addonInput",
+		}
+		schema.ResourceActions["uninstall-addon"] = wranglertypes.Action{
+			Input: "addonInput",
+		}
+		schema.ResourceActions["addon-status"] = wranglertypes.Action{
+			Input:  "addonInput",
+			Output: "addonStatusOutput",
+		}
+		schema.ResourceActions["issue-kubeconfig"] = wranglertypes.Action{
+			Input:  "issueKubeconfigInput",
+			Output: "issueKubeconfigOutput",
+		}
+		schema.ResourceActions["revoke-kubeconfig"] = wranglertypes.Action{
+			Input: "revokeKubeconfigInput",
+		}
+		schema.ResourceActions["kubeconfig-credentials"] = wranglertypes.Action{
+			Output: "kubeconfigCredentialsOutput",
+		}
+		schema.ResourceActions["stop"] = wranglertypes.Action{}
+		schema.ResourceActions["start"] = wranglertypes.Action{}
+		schema.ResourceActions["import-image"] = wranglertypes.Action{
+			Input: "importImageInput",
+		}
 		schema.Formatter = cluster.Formatter
 		schema.ActionHandlers = cluster.HandleCluster()
 		schema.ByIDHandler = cluster.LinkCluster
 	})
 }
 
+func initClusterPolicy(s *types.APISchemas) {
+	s.MustImportAndCustomize(autok3stypes.ClusterPolicy{}, func(schema *types.APISchema) {
+		schema.Store = &policy.Store{}
+		schema.CollectionMethods = []string{http.MethodGet}
+		schema.ResourceMethods = []string{http.MethodGet, http.MethodPut, http.MethodDelete}
+	})
+}
+
+func initClusterHealth(s *types.APISchemas) {
+	s.MustImportAndCustomize(autok3stypes.ClusterHealth{}, func(schema *types.APISchema) {
+		schema.Store = &health.Store{}
+		schema.CollectionMethods = []string{http.MethodGet}
+		schema.ResourceMethods = []string{http.MethodGet}
+	})
+}
+
+func initK3dRegistry(s *types.APISchemas) {
+	s.MustImportAndCustomize(autok3stypes.K3dRegistry{}, func(schema *types.APISchema) {
+		schema.Store = &registry.Store{}
+		schema.CollectionMethods = []string{http.MethodGet, http.MethodPost}
+		schema.ResourceMethods = []string{http.MethodGet, http.MethodDelete}
+	})
+}
+
 func initCredential(s *types.APISchemas) {
 	s.MustImportAndCustomize(autok3stypes.Credential{}, func(schema *types.APISchema) {
 		schema.Store = &credential.Store{}
@@ -97,11 +159,17 @@ func initLogs(s *types.APISchemas) {
 }
 
 func initTemplates(s *types.APISchemas) {
+	s.MustImportAndCustomize(autok3stypes.TemplatePreviewInput{}, nil)
 	s.MustImportAndCustomize(autok3stypes.ClusterTemplate{}, func(schema *types.APISchema) {
 		schema.Store = &template.Store{}
 		schema.CollectionMethods = []string{http.MethodGet, http.MethodPost}
 		schema.ResourceMethods = []string{http.MethodGet, http.MethodDelete, http.MethodPut}
-
+		schema.ResourceActions["preview"] = wranglertypes.Action{
+			Input:  "templatePreviewInput",
+			Output: "clusterTemplate",
+		}
+		schema.Formatter = template.Formatter
+		schema.ActionHandlers = template.ActionHandlers()
 	})
 }
 
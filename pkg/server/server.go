@@ -22,6 +22,9 @@ func Start() http.Handler {
 	initMutual(s.Schemas)
 	initProvider(s.Schemas)
 	initCluster(s.Schemas)
+	initClusterPolicy(s.Schemas)
+	initClusterHealth(s.Schemas)
+	initK3dRegistry(s.Schemas)
 	initCredential(s.Schemas)
 	initKubeconfig(s.Schemas)
 	initLogs(s.Schemas)
@@ -30,12 +30,21 @@ func (a *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, data ty
 	if len(input.Manifest) == 0 {
 		return types.APIObject{}, errors.New("manifest file content cannot be empty")
 	}
+	if err := common.ValidateValuesSchema(input.ValuesSchema); err != nil {
+		return types.APIObject{}, err
+	}
+	if err := input.ReadinessChecks.Validate(); err != nil {
+		return types.APIObject{}, err
+	}
 
 	addon := &common.Addon{
-		Name:        input.Name,
-		Description: input.Description,
-		Manifest:    input.Manifest,
-		Values:      input.Values,
+		Name:            input.Name,
+		Description:     input.Description,
+		Manifest:        input.Manifest,
+		Values:          input.Values,
+		ValuesSchema:    input.ValuesSchema,
+		Dependencies:    input.Dependencies,
+		ReadinessChecks: input.ReadinessChecks,
 	}
 	err = common.DefaultDB.SaveAddon(addon)
 	if err != nil {
@@ -102,6 +111,27 @@ func (a *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data ty
 		isChanged = true
 	}
 
+	if len(input.ValuesSchema) != 0 && !bytes.Equal(input.ValuesSchema, addon.ValuesSchema) {
+		if err := common.ValidateValuesSchema(input.ValuesSchema); err != nil {
+			return types.APIObject{}, err
+		}
+		addon.ValuesSchema = input.ValuesSchema
+		isChanged = true
+	}
+
+	if input.Dependencies != nil && !reflect.DeepEqual(input.Dependencies, addon.Dependencies) {
+		addon.Dependencies = input.Dependencies
+		isChanged = true
+	}
+
+	if input.ReadinessChecks != nil && !reflect.DeepEqual(input.ReadinessChecks, addon.ReadinessChecks) {
+		if err := input.ReadinessChecks.Validate(); err != nil {
+			return types.APIObject{}, err
+		}
+		addon.ReadinessChecks = input.ReadinessChecks
+		isChanged = true
+	}
+
 	if !reflect.DeepEqual(input.Values, addon.Values) {
 		addon.Values = input.Values
 		isChanged = true
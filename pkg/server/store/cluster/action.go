@@ -29,15 +29,27 @@ import (
 const (
 	actionJoin               = "join"
 	linkNodes                = "nodes"
+	linkHealth               = "health"
 	actionEnableExplorer     = "enable-explorer"
 	actionDisableExplorer    = "disable-explorer"
 	actionDownloadKubeconfig = "download-kubeconfig"
 	actionUpgrade            = "upgrade"
+	actionInstallAddon       = "install-addon"
+	actionUpgradeAddon       = "upgrade-addon"
+	actionUninstallAddon     = "uninstall-addon"
+	actionAddonStatus        = "addon-status"
+	actionIssueKubeconfig    = "issue-kubeconfig"
+	actionRevokeKubeconfig   = "revoke-kubeconfig"
+	actionListCredentials    = "kubeconfig-credentials"
+	actionStop               = "stop"
+	actionStart              = "start"
+	actionImportImage        = "import-image"
 )
 
 // Formatter cluster's formatter.
 func Formatter(request *types.APIRequest, resource *types.RawResource) {
 	resource.Links[linkNodes] = request.URLBuilder.Link(resource.Schema, resource.ID, linkNodes)
+	resource.Links[linkHealth] = request.URLBuilder.Link(resource.Schema, resource.ID, linkHealth)
 	resource.AddAction(request, actionJoin)
 }
 
@@ -46,12 +58,26 @@ func HandleCluster() map[string]http.Handler {
 	kubeconfigAction := downloadKubeconfig{}
 	explorerAction := explorer{}
 	joinAction := join{}
+	addonAction := addon{}
+	credentialAction := kubeconfigCredential{}
+	powerAction := power{}
+	imageAction := importImage{}
 	return map[string]http.Handler{
 		actionJoin:               joinAction,
 		actionEnableExplorer:     explorerAction,
 		actionDisableExplorer:    explorerAction,
 		actionDownloadKubeconfig: kubeconfigAction,
 		actionUpgrade:            joinAction,
+		actionInstallAddon:       addonAction,
+		actionUpgradeAddon:       addonAction,
+		actionUninstallAddon:     addonAction,
+		actionAddonStatus:        addonAction,
+		actionIssueKubeconfig:    credentialAction,
+		actionRevokeKubeconfig:   credentialAction,
+		actionListCredentials:    credentialAction,
+		actionStop:               powerAction,
+		actionStart:              powerAction,
+		actionImportImage:        imageAction,
 	}
 }
 
@@ -60,6 +86,9 @@ func LinkCluster(request *types.APIRequest) (types.APIObject, error) {
 	if request.Link == linkNodes {
 		return nodesHandler(request, request.Schema, request.Name)
 	}
+	if request.Link == linkHealth {
+		return healthHandler(request, request.Name)
+	}
 
 	return request.Schema.Store.ByID(request, request.Schema, request.Name)
 }
@@ -141,6 +170,114 @@ func (j join) ServeHTTP(_ http.ResponseWriter, req *http.Request) {
 	apiRequest.WriteResponse(http.StatusOK, types.APIObject{})
 }
 
+type power struct{}
+
+func (p power) ServeHTTP(_ http.ResponseWriter, req *http.Request) {
+	apiRequest := types.GetAPIContext(req.Context())
+	clusterID := apiRequest.Name
+	if clusterID == "" {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, "clusterID cannot be empty"))
+		return
+	}
+	state, err := common.DefaultDB.GetClusterByID(clusterID)
+	if err != nil || state == nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.NotFound, fmt.Sprintf("cluster %s is not found", clusterID)))
+		return
+	}
+	// the failed cluster may be stopped or started partially, so the action can be taken again.
+	expected := common.StatusRunning
+	if apiRequest.Action == actionStart {
+		expected = common.StatusStopped
+	}
+	if state.Status != expected && state.Status != common.StatusFailed {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidState,
+			fmt.Sprintf("cluster %s is %s, action %s is not available", clusterID, state.Status, apiRequest.Action)))
+		return
+	}
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.NotFound, fmt.Sprintf("provider %s is not found", state.Provider)))
+		return
+	}
+	opt, err := provider.GetProviderOptions(state.Options)
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+	b, err := json.Marshal(&autok3stypes.Cluster{
+		Metadata: state.Metadata,
+		Options:  opt,
+	})
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+	if err = provider.SetConfig(b); err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+	if err = provider.MergeClusterOptions(); err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+	provider.GenerateClusterName()
+	provider.RegisterCallbacks(clusterID, "update", common.DefaultDB.BroadcastObject)
+
+	action := apiRequest.Action
+	go func() {
+		var err error
+		if action == actionStop {
+			err = provider.StopK3sCluster()
+		} else {
+			err = provider.StartK3sCluster()
+		}
+		if err != nil {
+			logrus.Errorf("failed to %s cluster %s: %v", action, clusterID, err)
+		}
+	}()
+	apiRequest.WriteResponse(http.StatusOK, types.APIObject{})
+}
+
+type importImage struct{}
+
+func (i importImage) ServeHTTP(_ http.ResponseWriter, req *http.Request) {
+	apiRequest := types.GetAPIContext(req.Context())
+	clusterID := apiRequest.Name
+	if clusterID == "" {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, "clusterID cannot be empty"))
+		return
+	}
+	state, err := common.DefaultDB.GetClusterByID(clusterID)
+	if err != nil || state == nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.NotFound, fmt.Sprintf("cluster %s is not found", clusterID)))
+		return
+	}
+	if state.Provider != "k3d" {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, fmt.Sprintf("action %s is only supported by K3d provider", apiRequest.Action)))
+		return
+	}
+	if state.Status != common.StatusRunning {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidState,
+			fmt.Sprintf("cluster %s is %s, action %s is not available", clusterID, state.Status, apiRequest.Action)))
+		return
+	}
+	input := &autok3stypes.ImportImageInput{}
+	if err = json.NewDecoder(req.Body).Decode(input); err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidBodyContent, err.Error()))
+		return
+	}
+	if err = k3d.ValidateImportImages(input.Images, input.Mode); err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, err.Error()))
+		return
+	}
+	go func() {
+		if err := k3d.ImportClusterImages(clusterID, input.Images, input.Mode); err != nil {
+			logrus.Errorf("failed to import images to cluster %s: %v", clusterID, err)
+		}
+	}()
+	apiRequest.WriteResponse(http.StatusOK, types.APIObject{})
+}
+
 func nodesHandler(_ *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
 	state, err := common.DefaultDB.GetClusterByID(id)
 	if err != nil || state == nil {
@@ -165,6 +302,15 @@ func nodesHandler(_ *types.APIRequest, schema *types.APISchema, id string) (type
 	}, nil
 }
 
+// healthHandler returns the health of cluster by the store of cluster health.
+func healthHandler(request *types.APIRequest, id string) (types.APIObject, error) {
+	schema := request.Schemas.LookupSchema("clusterHealth")
+	if schema == nil || schema.Store == nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, "cluster health is not supported")
+	}
+	return schema.Store.ByID(request, schema, id)
+}
+
 type explorer struct{}
 
 func (e explorer) ServeHTTP(_ http.ResponseWriter, req *http.Request) {
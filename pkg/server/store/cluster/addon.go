@@ -0,0 +1,89 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	autok3stypes "github.com/cnrancher/autok3s/pkg/types/apis"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v2/pkg/schemas/validation"
+)
+
+type addon struct{}
+
+func (a addon) ServeHTTP(_ http.ResponseWriter, req *http.Request) {
+	apiRequest := types.GetAPIContext(req.Context())
+	clusterID := apiRequest.Name
+	if clusterID == "" {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, "clusterID cannot be empty"))
+		return
+	}
+	state, err := common.DefaultDB.GetClusterByID(clusterID)
+	if err != nil || state == nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.NotFound, fmt.Sprintf("cluster %s is not found", clusterID)))
+		return
+	}
+	provider, err := providers.GetProvider(state.Provider)
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.NotFound, fmt.Sprintf("provider %s is not found", state.Provider)))
+		return
+	}
+
+	input := &autok3stypes.AddonInput{}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, input); err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, err.Error()))
+			return
+		}
+	}
+	action := apiRequest.Action
+	if action != actionAddonStatus && input.Name == "" {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, "add-on name cannot be empty"))
+		return
+	}
+
+	switch action {
+	case actionInstallAddon:
+		if _, _, err = common.DefaultDB.GetAddonByRef(input.Name); err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.NotFound, fmt.Sprintf("add-on %s is not found", input.Name)))
+			return
+		}
+		err = provider.InstallAddon(state.Name, input.Name, input.Values)
+	case actionUpgradeAddon:
+		err = provider.UpgradeAddon(state.Name, input.Name, input.Values, input.UnsetValues)
+	case actionUninstallAddon:
+		err = provider.UninstallAddon(state.Name, input.Name)
+	case actionAddonStatus:
+		addons, err := provider.GetAddonStatus(state.Name, input.Name)
+		if err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+			return
+		}
+		apiRequest.WriteResponse(http.StatusOK, types.APIObject{
+			Type: "addonStatusOutput",
+			Object: &autok3stypes.AddonStatusOutput{
+				Addons: addons,
+			},
+		})
+		return
+	default:
+		apiRequest.WriteError(apierror.NewAPIError(validation.ActionNotAvailable, fmt.Sprintf("invalid action %s", action)))
+		return
+	}
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+	apiRequest.WriteResponse(http.StatusOK, types.APIObject{})
+}
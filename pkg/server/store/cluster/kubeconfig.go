@@ -0,0 +1,129 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/kubeconfig"
+	autok3stypes "github.com/cnrancher/autok3s/pkg/types/apis"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v2/pkg/schemas/validation"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const defaultCredentialTTL = 24 * time.Hour
+
+type kubeconfigCredential struct{}
+
+func (k kubeconfigCredential) ServeHTTP(_ http.ResponseWriter, req *http.Request) {
+	apiRequest := types.GetAPIContext(req.Context())
+	clusterID := apiRequest.Name
+	if clusterID == "" {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, "clusterID cannot be empty"))
+		return
+	}
+	state, err := common.DefaultDB.GetClusterByID(clusterID)
+	if err != nil || state == nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.NotFound, fmt.Sprintf("cluster %s is not found", clusterID)))
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+
+	switch apiRequest.Action {
+	case actionIssueKubeconfig:
+		input := &autok3stypes.IssueKubeconfigInput{}
+		if err = json.Unmarshal(body, input); err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, err.Error()))
+			return
+		}
+		opts := kubeconfig.IssueOptions{
+			Name:        input.Name,
+			Kind:        input.Kind,
+			ClusterRole: input.ClusterRole,
+			Namespace:   input.Namespace,
+			TTL:         defaultCredentialTTL,
+		}
+		if opts.Kind == "" {
+			opts.Kind = common.KubeconfigCredentialServiceAccount
+		}
+		if input.TTL != "" {
+			if opts.TTL, err = time.ParseDuration(input.TTL); err != nil {
+				apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, fmt.Sprintf("invalid ttl %s: %v", input.TTL, err)))
+				return
+			}
+		}
+		config, credential, err := kubeconfig.Issue(req.Context(), state.ContextName, opts)
+		if err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+			return
+		}
+		result, err := clientcmd.Write(*config)
+		if err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+			return
+		}
+		apiRequest.WriteResponse(http.StatusOK, types.APIObject{
+			Type: "issueKubeconfigOutput",
+			Object: &autok3stypes.IssueKubeconfigOutput{
+				Config:     string(result),
+				Credential: toCredentialStatus(credential),
+			},
+		})
+	case actionRevokeKubeconfig:
+		input := &autok3stypes.RevokeKubeconfigInput{}
+		if err = json.Unmarshal(body, input); err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, err.Error()))
+			return
+		}
+		if input.Name == "" {
+			apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, "credential name cannot be empty"))
+			return
+		}
+		if err = kubeconfig.Revoke(req.Context(), state.ContextName, input.Name, input.Force); err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+			return
+		}
+		apiRequest.WriteResponse(http.StatusOK, types.APIObject{})
+	case actionListCredentials:
+		credentials, err := common.DefaultDB.ListKubeconfigCredentials(state.ContextName)
+		if err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+			return
+		}
+		output := &autok3stypes.KubeconfigCredentialsOutput{
+			Credentials: []autok3stypes.ClusterKubeconfigCredential{},
+		}
+		for _, credential := range credentials {
+			output.Credentials = append(output.Credentials, toCredentialStatus(credential))
+		}
+		apiRequest.WriteResponse(http.StatusOK, types.APIObject{
+			Type:   "kubeconfigCredentialsOutput",
+			Object: output,
+		})
+	default:
+		apiRequest.WriteError(apierror.NewAPIError(validation.ActionNotAvailable, fmt.Sprintf("invalid action %s", apiRequest.Action)))
+	}
+}
+
+func toCredentialStatus(credential *common.KubeconfigCredential) autok3stypes.ClusterKubeconfigCredential {
+	return autok3stypes.ClusterKubeconfigCredential{
+		Name:        credential.Name,
+		Kind:        credential.Kind,
+		ClusterRole: credential.ClusterRole,
+		Namespace:   credential.Namespace,
+		Subject:     credential.Subject,
+		Expired:     credential.Expired(),
+		ExpiresAt:   credential.ExpiresAt,
+		CreatedAt:   credential.CreatedAt,
+	}
+}
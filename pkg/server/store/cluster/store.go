@@ -73,7 +73,11 @@ func (c *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.AP
 	list := types.APIObjectList{}
 	queryParams := apiOp.Request.URL.Query()
 	provider := queryParams.Get("provider")
-	clusterList, err := cluster.ListClusters(provider)
+	listClusters := cluster.ListClusters
+	if queryParams.Get("refresh") == "true" {
+		listClusters = cluster.RefreshClusters
+	}
+	clusterList, err := listClusters(provider)
 	if err != nil {
 		return list, err
 	}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/health"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v2/pkg/schemas/validation"
+)
+
+// defaultHistoryLimit is the number of health records returned by default.
+const defaultHistoryLimit = 20
+
+// Store holds cluster health API state.
+type Store struct {
+	empty.Store
+}
+
+// ByID returns the latest health and health history of cluster by ID, the number of records is set by query limit.
+func (s *Store) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	limit := defaultHistoryLimit
+	if v := apiOp.Request.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			return types.APIObject{}, apierror.NewAPIError(validation.InvalidOption, fmt.Sprintf("invalid limit %s", v))
+		}
+	}
+	return getHealth(schema.ID, id, limit)
+}
+
+// List returns the latest health of all the clusters.
+func (s *Store) List(_ *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	states, err := common.DefaultDB.ListCluster("")
+	if err != nil {
+		return types.APIObjectList{}, err
+	}
+	result := types.APIObjectList{}
+	for _, state := range states {
+		obj, err := health.GetClusterHealth(state.ContextName, 1)
+		if err != nil {
+			return types.APIObjectList{}, err
+		}
+		obj.History = nil
+		result.Objects = append(result.Objects, types.APIObject{
+			Type:   schema.ID,
+			ID:     state.ContextName,
+			Object: obj,
+		})
+	}
+	return result, nil
+}
+
+// Watch watches the health of clusters.
+func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, _ types.WatchRequest) (chan types.APIEvent, error) {
+	return common.DefaultDB.Watch(apiOp, schema), nil
+}
+
+// getHealth returns the health API object of cluster.
+func getHealth(schemaID, id string, limit int) (types.APIObject, error) {
+	state, err := common.DefaultDB.GetClusterByID(id)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	if state == nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, fmt.Sprintf("cluster %s is not found", id))
+	}
+	obj, err := health.GetClusterHealth(id, limit)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	return types.APIObject{
+		Type:   schemaID,
+		ID:     id,
+		Object: obj,
+	}, nil
+}
@@ -86,6 +86,10 @@ func importHandler(_ http.ResponseWriter, r *http.Request) {
 		apiContext.WriteError(apierror.WrapAPIError(err, validation.InvalidFormat, "file content is not valid"))
 		return
 	}
+	if err = airgap.VerifyPackageSignature(path); err != nil {
+		apiContext.WriteError(apierror.WrapAPIError(err, validation.InvalidFormat, "package signature is not valid"))
+		return
+	}
 	targetPackage.Name = name
 	newPath := airgap.PackagePath(name)
 	targetPackage.FilePath = newPath
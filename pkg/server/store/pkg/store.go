@@ -7,6 +7,7 @@ import (
 
 	"github.com/cnrancher/autok3s/pkg/airgap"
 	"github.com/cnrancher/autok3s/pkg/common"
+	autok3stypes "github.com/cnrancher/autok3s/pkg/types"
 
 	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/store/empty"
@@ -95,6 +96,20 @@ func (e *Store) Update(_ *types.APIRequest, _ *types.APISchema, data types.APIOb
 		current.Archs = archs
 		changed = true
 	}
+	for key, target := range map[string]*autok3stypes.StringArray{
+		"images": &current.Images,
+		"charts": &current.Charts,
+	} {
+		// empty list is allowed to remove all the extra resources, so only the missing key is skipped.
+		if _, ok := updateData[key]; !ok {
+			continue
+		}
+		values := updateData.StringSlice(key)
+		if !reflect.DeepEqual(values, []string(*target)) {
+			*target = values
+			changed = true
+		}
+	}
 	if !changed {
 		apiObj := common.GetAPIObject(current)
 		return *apiObj, nil
@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/policy"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v2/pkg/data/convert"
+	"github.com/rancher/wrangler/v2/pkg/schemas/validation"
+)
+
+// Store holds cluster policy API state.
+type Store struct {
+	empty.Store
+}
+
+// ByID returns the policy of cluster by ID.
+func (s *Store) ByID(_ *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	state, err := getClusterState(id)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	return types.APIObject{
+		Type:   schema.ID,
+		ID:     id,
+		Object: policy.ToAPIPolicy(state, time.Now()),
+	}, nil
+}
+
+// List returns the policies of all the clusters.
+func (s *Store) List(_ *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	states, err := common.DefaultDB.ListCluster("")
+	if err != nil {
+		return types.APIObjectList{}, err
+	}
+	now := time.Now()
+	result := types.APIObjectList{}
+	for _, state := range states {
+		result.Objects = append(result.Objects, types.APIObject{
+			Type:   schema.ID,
+			ID:     state.ContextName,
+			Object: policy.ToAPIPolicy(state, now),
+		})
+	}
+	return result, nil
+}
+
+// Update replaces the policy of cluster, ttl and extend of the input are applied to the expiration time.
+func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject, id string) (types.APIObject, error) {
+	input := &apis.ClusterPolicy{}
+	if err := convert.ToObj(data.Data(), input); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	state, err := getClusterState(id)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	if err = policy.Apply(&state.ClusterPolicy, input, time.Now()); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidOption, err.Error())
+	}
+	if err = policy.ValidateProvider(&state.ClusterPolicy, state.Provider); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidOption, err.Error())
+	}
+	if err = common.DefaultDB.SaveClusterPolicy(state); err != nil {
+		return types.APIObject{}, err
+	}
+	return s.ByID(apiOp, schema, id)
+}
+
+// Delete removes the policy of cluster, the cluster itself is kept.
+func (s *Store) Delete(_ *types.APIRequest, _ *types.APISchema, id string) (types.APIObject, error) {
+	state, err := getClusterState(id)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	state.ClusterPolicy = common.ClusterPolicy{}
+	return types.APIObject{}, common.DefaultDB.SaveClusterPolicy(state)
+}
+
+// Watch watches the warnings of cluster policies.
+func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, _ types.WatchRequest) (chan types.APIEvent, error) {
+	return common.DefaultDB.Watch(apiOp, schema), nil
+}
+
+func getClusterState(id string) (*common.ClusterState, error) {
+	state, err := common.DefaultDB.GetClusterByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, apierror.NewAPIError(validation.NotFound, fmt.Sprintf("cluster %s is not found", id))
+	}
+	return state, nil
+}
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"github.com/cnrancher/autok3s/pkg/providers/k3d"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v2/pkg/data/convert"
+	"github.com/rancher/wrangler/v2/pkg/schemas/validation"
+)
+
+// Store holds the k3d-managed registry API state.
+type Store struct {
+	empty.Store
+}
+
+// Create creates and starts the k3d-managed registry.
+func (s *Store) Create(_ *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	input := &apis.K3dRegistry{}
+	if err := convert.ToObj(data.Data(), input); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	if input.Name == "" {
+		return types.APIObject{}, apierror.NewAPIError(validation.MissingRequired, "registry name is required")
+	}
+	reg, err := k3d.CreateRegistry(input)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.ServerError, err.Error())
+	}
+	return toAPIObject(schema, reg), nil
+}
+
+// ByID returns the k3d-managed registry by name.
+func (s *Store) ByID(_ *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	reg, err := k3d.GetRegistry(id)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, err.Error())
+	}
+	return toAPIObject(schema, reg), nil
+}
+
+// List returns all the k3d-managed registries.
+func (s *Store) List(_ *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	list, err := k3d.ListRegistries()
+	if err != nil {
+		return types.APIObjectList{}, err
+	}
+	result := types.APIObjectList{}
+	for _, reg := range list {
+		result.Objects = append(result.Objects, toAPIObject(schema, reg))
+	}
+	return result, nil
+}
+
+// Delete deletes the k3d-managed registry.
+func (s *Store) Delete(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	obj, err := s.ByID(apiOp, schema, id)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	if err = k3d.DeleteRegistry(id); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.ServerError, err.Error())
+	}
+	return obj, nil
+}
+
+func toAPIObject(schema *types.APISchema, reg *apis.K3dRegistry) types.APIObject {
+	return types.APIObject{
+		Type:   schema.ID,
+		ID:     reg.Name,
+		Object: reg,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/cnrancher/autok3s/pkg/airgap"
 	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/settings"
 
@@ -26,6 +27,11 @@ func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data ty
 	if err != nil {
 		return types.APIObject{}, err
 	}
+	if id == settings.PackageDownloadSource.Name {
+		if err := airgap.ValidateDownloadSource(setting.Value); err != nil {
+			return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+		}
+	}
 	if id == settings.HelmDashboardEnabled.Name {
 		if err := common.SwitchDashboard(context.TODO(), setting.Value); err != nil {
 			return types.APIObject{}, err
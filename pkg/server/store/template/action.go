@@ -0,0 +1,90 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	pkgtemplate "github.com/cnrancher/autok3s/pkg/template"
+	"github.com/cnrancher/autok3s/pkg/types/apis"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v2/pkg/schemas/validation"
+)
+
+const actionPreview = "preview"
+
+// ActionHandlers returns the action handlers of template.
+func ActionHandlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		actionPreview: http.HandlerFunc(previewHandler),
+	}
+}
+
+// Formatter adds the actions to template resource.
+func Formatter(request *types.APIRequest, resource *types.RawResource) {
+	resource.AddAction(request, actionPreview)
+}
+
+// previewHandler returns the template which is merged with the parents and the parameters are replaced with the input values.
+func previewHandler(_ http.ResponseWriter, req *http.Request) {
+	apiRequest := types.GetAPIContext(req.Context())
+	context := strings.Split(apiRequest.Name, ".")
+	if len(context) != 2 {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, fmt.Sprintf("invalid template id %s", apiRequest.Name)))
+		return
+	}
+	template, err := common.DefaultDB.GetTemplate(context[0], context[1])
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+	if template == nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.NotFound, fmt.Sprintf("template %s of provider %s is not exist", context[0], context[1])))
+		return
+	}
+
+	input := &apis.TemplatePreviewInput{}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, input); err != nil {
+			apiRequest.WriteError(apierror.NewAPIError(validation.InvalidOption, err.Error()))
+			return
+		}
+	}
+
+	resolved, err := pkgtemplate.Resolve(template, input.Values)
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.InvalidBodyContent, err.Error()))
+		return
+	}
+	provider, err := providers.GetProvider(resolved.Provider)
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.NotFound, err.Error()))
+		return
+	}
+	opt, err := provider.GetProviderOptions(resolved.Options)
+	if err != nil {
+		apiRequest.WriteError(apierror.NewAPIError(validation.ServerError, err.Error()))
+		return
+	}
+	apiRequest.WriteResponse(http.StatusOK, types.APIObject{
+		Type: apiRequest.Schema.ID,
+		ID:   resolved.ContextName,
+		Object: &apis.ClusterTemplate{
+			Metadata:  resolved.Metadata,
+			SSH:       resolved.SSH,
+			Options:   opt,
+			IsDefault: resolved.IsDefault,
+		},
+	})
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/cnrancher/autok3s/pkg/common"
 	"github.com/cnrancher/autok3s/pkg/providers"
+	pkgtemplate "github.com/cnrancher/autok3s/pkg/template"
+	autok3stypes "github.com/cnrancher/autok3s/pkg/types"
 	"github.com/cnrancher/autok3s/pkg/types/apis"
 
 	"github.com/rancher/apiserver/pkg/apierror"
@@ -42,10 +44,16 @@ func (t *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, data ty
 		return types.APIObject{}, err
 	}
 	temp = &common.Template{
-		Metadata:  template.Metadata,
-		SSH:       template.SSH,
-		Options:   opt,
-		IsDefault: template.IsDefault,
+		Metadata:   template.Metadata,
+		SSH:        template.SSH,
+		Options:    opt,
+		IsDefault:  template.IsDefault,
+		Parent:     template.Parent,
+		Parameters: template.Parameters,
+		Overrides:  templateOverrides(template, data),
+	}
+	if err := pkgtemplate.Validate(temp); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
 	}
 	err = common.DefaultDB.CreateTemplate(temp)
 	if err != nil {
@@ -64,9 +72,12 @@ func (t *Store) List(_ *types.APIRequest, schema *types.APISchema) (types.APIObj
 
 	for _, template := range templates {
 		temp := &apis.ClusterTemplate{
-			Metadata:  template.Metadata,
-			SSH:       template.SSH,
-			IsDefault: template.IsDefault,
+			Metadata:   template.Metadata,
+			SSH:        template.SSH,
+			IsDefault:  template.IsDefault,
+			Parent:     template.Parent,
+			Parameters: template.Parameters,
+			Overrides:  template.Overrides,
 		}
 		// TODO skip harvester for historical data, will remove here after harvester provider added back
 		if template.Provider == "harvester" {
@@ -77,7 +88,7 @@ func (t *Store) List(_ *types.APIRequest, schema *types.APISchema) (types.APIObj
 			logrus.Errorf("failed to get provider by name %s: %v", template.Provider, err)
 			continue
 		}
-		opt, err := provider.GetProviderOptions(template.Options)
+		opt, err := common.TemplateOptions(provider, template.Options)
 		if err != nil {
 			var status string
 			if e, ok := err.(*json.UnmarshalTypeError); ok {
@@ -124,15 +135,18 @@ func (t *Store) ByID(_ *types.APIRequest, schema *types.APISchema, id string) (t
 		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, fmt.Sprintf("template %s of provider %s is not exist", context[0], context[1]))
 	}
 	temp := &apis.ClusterTemplate{
-		Metadata:  template.Metadata,
-		SSH:       template.SSH,
-		IsDefault: template.IsDefault,
+		Metadata:   template.Metadata,
+		SSH:        template.SSH,
+		IsDefault:  template.IsDefault,
+		Parent:     template.Parent,
+		Parameters: template.Parameters,
+		Overrides:  template.Overrides,
 	}
 	provider, err := providers.GetProvider(template.Provider)
 	if err != nil {
 		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, err.Error())
 	}
-	opt, err := provider.GetProviderOptions(template.Options)
+	opt, err := common.TemplateOptions(provider, template.Options)
 	if err != nil {
 		return types.APIObject{}, err
 	}
@@ -164,9 +178,12 @@ func (t *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data ty
 		return types.APIObject{}, err
 	}
 	temp := &common.Template{
-		Metadata:  template.Metadata,
-		SSH:       template.SSH,
-		IsDefault: template.IsDefault,
+		Metadata:   template.Metadata,
+		SSH:        template.SSH,
+		IsDefault:  template.IsDefault,
+		Parent:     template.Parent,
+		Parameters: template.Parameters,
+		Overrides:  templateOverrides(template, data),
 	}
 	temp.ContextName = fmt.Sprintf("%s.%s", template.Name, template.Provider)
 	opt, err := json.Marshal(template.Options)
@@ -174,6 +191,9 @@ func (t *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data ty
 		return types.APIObject{}, err
 	}
 	temp.Options = opt
+	if err := pkgtemplate.Validate(temp); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
 	err = common.DefaultDB.UpdateTemplate(temp)
 	if err != nil {
 		return types.APIObject{}, err
@@ -187,8 +207,10 @@ func (t *Store) Delete(_ *types.APIRequest, _ *types.APISchema, id string) (type
 	if len(context) != 2 {
 		return types.APIObject{}, apierror.NewAPIError(validation.InvalidOption, fmt.Sprintf("invalid template id %s", id))
 	}
-	err := common.DefaultDB.DeleteTemplate(context[0], context[1])
-	return types.APIObject{}, err
+	if err := pkgtemplate.Delete(context[0], context[1]); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.Conflict, err.Error())
+	}
+	return types.APIObject{}, nil
 }
 
 // Watch watches template.
@@ -233,3 +255,11 @@ func (t *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, _ types.
 	}()
 	return result, nil
 }
+
+// templateOverrides returns the overrides of the request, the keys of request are used if it's not set.
+func templateOverrides(template *apis.ClusterTemplate, data types.APIObject) autok3stypes.StringArray {
+	if len(template.Overrides) > 0 {
+		return template.Overrides
+	}
+	return pkgtemplate.Overrides(data.Data())
+}
@@ -32,7 +32,16 @@ var (
 
 	InstallScript         = newSetting("install-script", "", "The k3s offline install script with base64 encode")
 	ScriptUpdateSource    = newSetting("install-script-source-repo", "https://rancher-mirror.rancher.cn/k3s/k3s-install.sh", "The install script auto update source, github or aliyun oss")
-	PackageDownloadSource = newSetting("package-download-source", "github", "The airgap package download source, github and aliyunoss are validated.")
+	PackageDownloadSource = newSetting("package-download-source", "github", "The airgap package download source, github, aliyunoss, http(s) base url, local directory or file:// url and s3://bucket/prefix url are validated.")
+	// PackageDownloadPathTemplate is only used by the custom download source, e.g. v1.28.5+k3s1 will be rendered with {{ .Version }}.
+	PackageDownloadPathTemplate = newSetting("package-download-path-template", "{{ .Version }}", "The path template of the k3s version resources under custom package download source, sprig functions are supported.")
+	PackageDownloadS3Endpoint   = newSetting("package-download-s3-endpoint", "", "The endpoint of S3-compatible package download source, use AWS S3 if empty.")
+	PackageDownloadS3Region     = newSetting("package-download-s3-region", "us-east-1", "The region of S3-compatible package download source.")
+	PackageTrustedKeys          = newSetting("package-trusted-keys", "", "The trusted ed25519 public keys with PEM format to verify airgap package signatures, signature verification is enforced if any key is configured.")
+
+	BuiltinRegistryImage = newSetting("builtin-registry-image", "docker.io/library/registry:2", "The image of the builtin registry deployed for airgap clusters, the image must be bundled in the airgap package.")
+
+	AddonReadinessTimeout = newSetting("addon-readiness-timeout", "10m", "The timeout of waiting for the readiness checks of each add-on, with duration format e.g. 10m.")
 
 	HelmDashboardEnabled = newSetting("helm-dashboard-enabled", "false", "The helm-dashboard is enabled or not")
 	HelmDashboardPort    = newSetting("helm-dashboard-port", "", "The helm-dashboard server port after enabled")
@@ -0,0 +1,315 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/providers"
+	"github.com/cnrancher/autok3s/pkg/types"
+)
+
+var (
+	parameterNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+	// parameterRefRegexp matches the parameter reference ${name}.
+	parameterRefRegexp = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_-]*)\}`)
+)
+
+// Resolve merges the template with its parents and replaces the parameter references with the values,
+// the default value is used for the parameter which is not set.
+// The returned template has no parent and parameters, and the options are validated by the provider.
+func Resolve(t *common.Template, values map[string]string) (*common.Template, error) {
+	chain, err := parentChain(t)
+	if err != nil {
+		return nil, err
+	}
+	// merge from the root template, the fields of child template take precedence.
+	merged := map[string]interface{}{}
+	options := map[string]interface{}{}
+	var parameters types.TemplateParameters
+	for i := len(chain) - 1; i >= 0; i-- {
+		fields, opt, err := templateFields(chain[i])
+		if err != nil {
+			return nil, err
+		}
+		overrides := map[string]bool{}
+		for _, k := range chain[i].Overrides {
+			overrides[k] = true
+		}
+		mergeFields(merged, fields, overrides)
+		mergeFields(options, opt, overrides)
+		parameters = mergeParameters(parameters, chain[i].Parameters)
+	}
+
+	resolvedValues, err := parameterValues(parameters, values)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %v", t.Name, err)
+	}
+	byName := map[string]types.TemplateParameter{}
+	for _, p := range parameters {
+		byName[p.Name] = p
+	}
+	fields := substitute(merged, byName, resolvedValues, false).(map[string]interface{})
+	options = substitute(options, byName, resolvedValues, true).(map[string]interface{})
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	rtn := &common.Template{IsDefault: t.IsDefault}
+	if err := json.Unmarshal(data, &rtn.Metadata); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &rtn.SSH); err != nil {
+		return nil, err
+	}
+	rtn.Name, rtn.Provider, rtn.ContextName = t.Name, t.Provider, t.ContextName
+	if rtn.Options, err = json.Marshal(options); err != nil {
+		return nil, err
+	}
+	p, err := providers.GetProvider(t.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.GetProviderOptions(rtn.Options); err != nil {
+		return nil, fmt.Errorf("invalid options of resolved template %s: %v", t.Name, err)
+	}
+	return rtn, nil
+}
+
+// Validate validates the parent and the parameters of the template, the options are validated by the provider
+// if the template doesn't refer to any parameter.
+func Validate(t *common.Template) error {
+	p, err := providers.GetProvider(t.Provider)
+	if err != nil {
+		return err
+	}
+	if len(t.Options) == 0 {
+		t.Options = []byte("{}")
+	}
+	if !bytes.Contains(t.Options, []byte("${")) {
+		if _, err := p.GetProviderOptions(t.Options); err != nil {
+			return fmt.Errorf("invalid options of template %s: %v", t.Name, err)
+		}
+	}
+	exists := map[string]bool{}
+	for _, param := range t.Parameters {
+		if !parameterNameRegexp.MatchString(param.Name) {
+			return fmt.Errorf("parameter name %q of template %s is not valid", param.Name, t.Name)
+		}
+		if exists[param.Name] {
+			return fmt.Errorf("parameter %s of template %s is duplicated", param.Name, t.Name)
+		}
+		exists[param.Name] = true
+		switch param.Type {
+		case "", types.TemplateParameterTypeString, types.TemplateParameterTypeInt, types.TemplateParameterTypeBool:
+		default:
+			return fmt.Errorf("type %s of parameter %s is not supported, only string, int and bool are supported", param.Type, param.Name)
+		}
+		if param.Pattern != "" {
+			if _, err := regexp.Compile(param.Pattern); err != nil {
+				return fmt.Errorf("pattern of parameter %s is not valid: %v", param.Name, err)
+			}
+		}
+		if param.Default != "" {
+			if err := validateParameterValue(param, param.Default); err != nil {
+				return fmt.Errorf("default value of parameter %s: %v", param.Name, err)
+			}
+		}
+	}
+	if t.Parent == "" {
+		return nil
+	}
+	if t.Parent == t.Name {
+		return fmt.Errorf("template %s cannot extend itself", t.Name)
+	}
+	_, err = parentChain(t)
+	return err
+}
+
+// parentChain returns the template and its parents, the template itself is the first one.
+func parentChain(t *common.Template) ([]*common.Template, error) {
+	chain := []*common.Template{t}
+	visited := map[string]bool{t.Name: true}
+	for current := t; current.Parent != ""; {
+		if visited[current.Parent] {
+			return nil, fmt.Errorf("template %s has circular parent %s", t.Name, current.Parent)
+		}
+		parent, err := common.DefaultDB.GetTemplate(current.Parent, t.Provider)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, fmt.Errorf("parent template %s of provider %s is not exist", current.Parent, t.Provider)
+		}
+		visited[parent.Name] = true
+		chain = append(chain, parent)
+		current = parent
+	}
+	return chain, nil
+}
+
+// templateFields returns the metadata and ssh fields and the options of template as maps.
+func templateFields(t *common.Template) (map[string]interface{}, map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	for _, v := range []interface{}{t.Metadata, t.SSH} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, nil, err
+		}
+	}
+	options := map[string]interface{}{}
+	if len(t.Options) > 0 {
+		if err := json.Unmarshal(t.Options, &options); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode options of template %s: %v", t.Name, err)
+		}
+	}
+	return fields, options, nil
+}
+
+// mergeFields overwrites the target with the non-zero fields of source and the overrides of source, so the template
+// can clear the fields of parent with the zero values, e.g. turning off cluster. The overrides which are omitted from
+// source for the zero values are removed from target.
+func mergeFields(target, source map[string]interface{}, overrides map[string]bool) {
+	for k := range overrides {
+		if _, ok := source[k]; !ok {
+			delete(target, k)
+		}
+	}
+	for k, v := range source {
+		if overrides[k] {
+			target[k] = v
+			continue
+		}
+		if v == nil || reflect.ValueOf(v).IsZero() {
+			continue
+		}
+		if rv := reflect.ValueOf(v); (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map) && rv.Len() == 0 {
+			continue
+		}
+		target[k] = v
+	}
+}
+
+// mergeParameters overwrites the parent parameters with the child parameters of the same name.
+func mergeParameters(parent, child types.TemplateParameters) types.TemplateParameters {
+	rtn := append(types.TemplateParameters{}, parent...)
+	for _, c := range child {
+		replaced := false
+		for i := range rtn {
+			if rtn[i].Name == c.Name {
+				rtn[i] = c
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			rtn = append(rtn, c)
+		}
+	}
+	return rtn
+}
+
+// parameterValues validates the values of parameters and fills the defaults.
+func parameterValues(parameters types.TemplateParameters, values map[string]string) (map[string]string, error) {
+	rtn := map[string]string{}
+	defined := map[string]bool{}
+	for _, param := range parameters {
+		defined[param.Name] = true
+		value, ok := values[param.Name]
+		if !ok {
+			value = param.Default
+		}
+		if value == "" {
+			if param.Required {
+				return nil, fmt.Errorf("parameter %s is required", param.Name)
+			}
+			rtn[param.Name] = value
+			continue
+		}
+		if err := validateParameterValue(param, value); err != nil {
+			return nil, fmt.Errorf("parameter %s: %v", param.Name, err)
+		}
+		rtn[param.Name] = value
+	}
+	for name := range values {
+		if !defined[name] {
+			return nil, fmt.Errorf("parameter %s is not defined", name)
+		}
+	}
+	return rtn, nil
+}
+
+func validateParameterValue(param types.TemplateParameter, value string) error {
+	switch param.Type {
+	case types.TemplateParameterTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("value %q is not an integer", value)
+		}
+	case types.TemplateParameterTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("value %q is not a boolean", value)
+		}
+	}
+	if param.Pattern != "" {
+		matched, err := regexp.MatchString("^(?:"+param.Pattern+")$", value)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return fmt.Errorf("value %q doesn't match pattern %s", value, param.Pattern)
+		}
+	}
+	return nil
+}
+
+// substitute replaces the references of the defined parameters in the string values, the references which are not
+// defined are kept, e.g. the shell variables in the scripts.
+// If typed is true, the string which is exactly one reference of int or bool parameter is replaced with the typed value.
+func substitute(v interface{}, parameters map[string]types.TemplateParameter, values map[string]string, typed bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		rtn := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			rtn[k] = substitute(item, parameters, values, typed)
+		}
+		return rtn
+	case []interface{}:
+		rtn := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			rtn = append(rtn, substitute(item, parameters, values, typed))
+		}
+		return rtn
+	case string:
+		if typed {
+			if match := parameterRefRegexp.FindStringSubmatch(value); match != nil && match[0] == value {
+				if param, ok := parameters[match[1]]; ok && values[param.Name] != "" {
+					switch param.Type {
+					case types.TemplateParameterTypeInt:
+						i, _ := strconv.Atoi(values[param.Name])
+						return i
+					case types.TemplateParameterTypeBool:
+						b, _ := strconv.ParseBool(values[param.Name])
+						return b
+					}
+				}
+			}
+		}
+		return parameterRefRegexp.ReplaceAllStringFunc(value, func(ref string) string {
+			name := parameterRefRegexp.FindStringSubmatch(ref)[1]
+			if _, ok := parameters[name]; !ok {
+				return ref
+			}
+			return values[name]
+		})
+	default:
+		return v
+	}
+}
@@ -0,0 +1,122 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/cnrancher/autok3s/pkg/common"
+	"github.com/cnrancher/autok3s/pkg/common/commontest"
+	"github.com/cnrancher/autok3s/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	commontest.InitStorage(t)
+	base := &common.Template{
+		Metadata: types.Metadata{
+			Name:            "base",
+			Provider:        "native",
+			K3sVersion:      "v1.28.1+k3s1",
+			Worker:          "${workers}",
+			MasterExtraArgs: "--node-label region=${region} --data-dir ${HOME}/k3s",
+		},
+		SSH:     types.SSH{SSHUser: "ubuntu", SSHPort: "22"},
+		Options: []byte(`{"master-ips":"${master}"}`),
+		Parameters: types.TemplateParameters{
+			{Name: "workers", Type: types.TemplateParameterTypeInt, Default: "1"},
+			{Name: "region", Pattern: "cn-[a-z]+", Required: true},
+			{Name: "master", Required: true},
+		},
+	}
+	assert.NoError(t, Create(base))
+	child := &common.Template{
+		Metadata:   types.Metadata{Name: "child", Provider: "native", K3sVersion: "v1.29.0+k3s1"},
+		Parent:     "base",
+		Parameters: types.TemplateParameters{{Name: "region", Pattern: "cn-[a-z]+", Default: "cn-hangzhou"}},
+	}
+	assert.NoError(t, Create(child))
+
+	resolved, err := Resolve(child, map[string]string{"master": "1.1.1.1", "workers": "3"})
+	assert.NoError(t, err)
+	assert.Equal(t, "child", resolved.Name)
+	assert.Equal(t, "v1.29.0+k3s1", resolved.K3sVersion)
+	assert.Equal(t, "3", resolved.Worker)
+	assert.Equal(t, "ubuntu", resolved.SSHUser)
+	assert.Equal(t, "--node-label region=cn-hangzhou --data-dir ${HOME}/k3s", resolved.MasterExtraArgs)
+	assert.JSONEq(t, `{"master-ips":"1.1.1.1"}`, string(resolved.Options))
+	assert.Empty(t, resolved.Parent)
+	assert.Empty(t, resolved.Parameters)
+
+	_, err = Resolve(child, nil)
+	assert.EqualError(t, err, "template child: parameter master is required")
+	_, err = Resolve(child, map[string]string{"master": "1.1.1.1", "workers": "three"})
+	assert.Error(t, err, "workers must be an integer")
+	_, err = Resolve(child, map[string]string{"master": "1.1.1.1", "region": "us-west"})
+	assert.Error(t, err, "region must match the pattern")
+	_, err = Resolve(child, map[string]string{"master": "1.1.1.1", "zone": "a"})
+	assert.Error(t, err, "zone is not defined")
+
+	assert.Error(t, Delete("base", "native"), "base is extended by child")
+	assert.Error(t, Create(&common.Template{
+		Metadata: types.Metadata{Name: "orphan", Provider: "native"},
+		Parent:   "missing",
+	}))
+	assert.Error(t, Create(&common.Template{
+		Metadata:   types.Metadata{Name: "invalid", Provider: "native"},
+		Parameters: types.TemplateParameters{{Name: "workers", Type: types.TemplateParameterTypeInt, Default: "many"}},
+	}))
+
+	// the circular parents are detected when the parent is updated.
+	base.Parent = "child"
+	assert.NoError(t, common.DefaultDB.UpdateTemplate(base))
+	_, err = Resolve(child, map[string]string{"master": "1.1.1.1"})
+	assert.Error(t, err)
+}
+
+func TestResolveZeroOverrides(t *testing.T) {
+	commontest.InitStorage(t)
+	assert.NoError(t, Create(&common.Template{
+		Metadata: types.Metadata{Name: "ha", Provider: "native", Cluster: true, Registry: "/etc/registries.yaml", Master: "3"},
+		Options:  []byte(`{"master-ips":"1.1.1.1"}`),
+	}))
+
+	// the child imported from YAML turns off cluster and clears the registry and the master ips of parent.
+	child, err := Import([]byte("name: single\nprovider: native\nparent: ha\ncluster: false\nregistry: \"\"\noptions:\n  master-ips: \"\"\n"), "")
+	assert.NoError(t, err)
+	assert.Equal(t, types.StringArray{"cluster", "master-ips", "registry"}, child.Overrides)
+	resolved, err := Resolve(child, nil)
+	assert.NoError(t, err)
+	assert.False(t, resolved.Cluster)
+	assert.Empty(t, resolved.Registry)
+	assert.Equal(t, "3", resolved.Master)
+	assert.JSONEq(t, `{"master-ips":""}`, string(resolved.Options))
+
+	// the zero values which are not overridden are inherited.
+	child = &common.Template{Metadata: types.Metadata{Name: "inherit", Provider: "native"}, Parent: "ha"}
+	assert.NoError(t, Create(child))
+	resolved, err = Resolve(child, nil)
+	assert.NoError(t, err)
+	assert.True(t, resolved.Cluster)
+	assert.Equal(t, "/etc/registries.yaml", resolved.Registry)
+	assert.JSONEq(t, `{"master-ips":"1.1.1.1"}`, string(resolved.Options))
+}
+
+func TestSubstituteTyped(t *testing.T) {
+	parameters := map[string]types.TemplateParameter{
+		"size":   {Name: "size", Type: types.TemplateParameterTypeInt},
+		"public": {Name: "public", Type: types.TemplateParameterTypeBool},
+	}
+	values := map[string]string{"size": "30", "public": "true"}
+	rtn := substitute(map[string]interface{}{
+		"disk-size": "${size}",
+		"name":      "disk-${size}",
+		"public-ip": "${public}",
+		"tags":      []interface{}{"${size}"},
+	}, parameters, values, true)
+	assert.Equal(t, map[string]interface{}{
+		"disk-size": 30,
+		"name":      "disk-30",
+		"public-ip": true,
+		"tags":      []interface{}{30},
+	}, rtn)
+}
@@ -5,6 +5,8 @@ var (
 	StatusPending = "Pending"
 	// StatusRunning alibaba instance running status.
 	StatusRunning = "Running"
+	// StatusStopped alibaba instance stopped status.
+	StatusStopped = "Stopped"
 )
 
 // Options alibaba provider's custom parameters.
@@ -18,8 +20,10 @@ type Options struct {
 	KeyPair                 string   `json:"key-pair,omitempty" yaml:"key-pair,omitempty"`
 	Region                  string   `json:"region,omitempty" yaml:"region,omitempty"`
 	Zone                    string   `json:"zone,omitempty" yaml:"zone,omitempty"`
+	Zones                   []string `json:"zones,omitempty" yaml:"zones,omitempty"`
 	Vpc                     string   `json:"vpc,omitempty" yaml:"vpc,omitempty"`
 	VSwitch                 string   `json:"v-switch,omitempty" yaml:"v-switch,omitempty"`
+	VSwitches               []string `json:"v-switches,omitempty" yaml:"v-switches,omitempty"`
 	SecurityGroup           string   `json:"security-group,omitempty" yaml:"security-group,omitempty"`
 	InternetMaxBandwidthOut string   `json:"internet-max-bandwidth-out,omitempty" yaml:"internet-max-bandwidth-out,omitempty"`
 	EIP                     bool     `json:"eip,omitempty" yaml:"eip,omitempty"`
@@ -1,6 +1,8 @@
 package apis
 
 import (
+	"time"
+
 	"github.com/cnrancher/autok3s/pkg/types"
 
 	"github.com/rancher/wrangler/v2/pkg/schemas"
@@ -59,6 +61,17 @@ type ClusterTemplate struct {
 	Status         string      `json:"status"`
 	IsHAMode       bool        `json:"is-ha-mode"`
 	DataStoreType  string      `json:"datastore-type,omitempty"`
+	// Parent is the name of the template of the same provider which this template extends.
+	Parent     string                   `json:"parent,omitempty"`
+	Parameters types.TemplateParameters `json:"parameters,omitempty"`
+	// Overrides are the fields and options set by the template, which override the parent even if they're zero values.
+	// The keys of the request are used if it's not set.
+	Overrides []string `json:"overrides,omitempty"`
+}
+
+// TemplatePreviewInput struct for template preview action, the values are set to the template parameters.
+type TemplatePreviewInput struct {
+	Values map[string]string `json:"values,omitempty"`
 }
 
 // KubeconfigOutput is specified cluster kubeconfig for user download
@@ -78,3 +91,164 @@ type UpgradeInput struct {
 	PackageName   string `json:"package-name,omitempty"`
 	PackagePath   string `json:"package-path,omitempty"`
 }
+
+// AddonInput struct for add-on actions of cluster.
+type AddonInput struct {
+	Name        string            `json:"name"`
+	Values      map[string]string `json:"values,omitempty"`
+	UnsetValues []string          `json:"unsetValues,omitempty"`
+}
+
+// ClusterAddonStatus struct for the add-on installed in cluster.
+type ClusterAddonStatus struct {
+	Name             string            `json:"name"`
+	Version          string            `json:"version,omitempty"`
+	Values           map[string]string `json:"values,omitempty"`
+	ManifestHash     string            `json:"manifestHash"`
+	Status           string            `json:"status"`
+	Readiness        string            `json:"readiness,omitempty"`
+	ReadinessMessage string            `json:"readinessMessage,omitempty"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	UpdatedAt        time.Time         `json:"updatedAt"`
+}
+
+// AddonStatusOutput struct for addon-status action.
+type AddonStatusOutput struct {
+	Addons []ClusterAddonStatus `json:"addons"`
+}
+
+// IssueKubeconfigInput struct for issue-kubeconfig action of cluster.
+type IssueKubeconfigInput struct {
+	Name string `json:"name"`
+	// Kind is serviceaccount or certificate, default to serviceaccount.
+	Kind        string `json:"kind,omitempty"`
+	ClusterRole string `json:"clusterRole"`
+	// Namespace is the scope of the role binding, the cluster role is bound to the whole cluster if it's empty.
+	Namespace string `json:"namespace,omitempty"`
+	// TTL is the duration of the credential, e.g. 24h, default to 24h.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// IssueKubeconfigOutput struct for issue-kubeconfig action of cluster.
+type IssueKubeconfigOutput struct {
+	Config     string                      `json:"config"`
+	Credential ClusterKubeconfigCredential `json:"credential"`
+}
+
+// RevokeKubeconfigInput struct for revoke-kubeconfig action of cluster.
+type RevokeKubeconfigInput struct {
+	Name  string `json:"name"`
+	Force bool   `json:"force,omitempty"`
+}
+
+// ClusterKubeconfigCredential struct for the credential issued for the scoped kubeconfig of cluster.
+type ClusterKubeconfigCredential struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	ClusterRole string    `json:"clusterRole"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Subject     string    `json:"subject"`
+	Expired     bool      `json:"expired"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// KubeconfigCredentialsOutput struct for kubeconfig-credentials action of cluster.
+type KubeconfigCredentialsOutput struct {
+	Credentials []ClusterKubeconfigCredential `json:"credentials"`
+}
+
+// ClusterPolicy struct for the scheduled start/stop window and TTL of cluster.
+type ClusterPolicy struct {
+	Cluster      string     `json:"cluster"`
+	StopAt       string     `json:"stopAt,omitempty"`
+	StartAt      string     `json:"startAt,omitempty"`
+	ScheduleDays string     `json:"scheduleDays,omitempty"`
+	Timezone     string     `json:"timezone,omitempty"`
+	ExpireAt     *time.Time `json:"expireAt,omitempty"`
+	// TTL resets the cluster to be deleted after the duration from now, e.g. 48h.
+	TTL string `json:"ttl,omitempty"`
+	// Extend postpones the deletion of the cluster by the duration, e.g. 24h.
+	Extend       string     `json:"extend,omitempty"`
+	NextAction   string     `json:"nextAction,omitempty"`
+	NextActionAt *time.Time `json:"nextActionAt,omitempty"`
+	// AutoHeal replaces the workers whose instances are gone or which are NotReady longer than the grace period.
+	AutoHeal            bool   `json:"autoHeal,omitempty"`
+	AutoHealGracePeriod string `json:"autoHealGracePeriod,omitempty"`
+	// AutoscaleMin and AutoscaleMax are the bounds of workers scaled by cluster-autoscaler.
+	AutoscaleMin int `json:"autoscaleMin,omitempty"`
+	AutoscaleMax int `json:"autoscaleMax,omitempty"`
+}
+
+// NodeHealth struct for the health of cluster node.
+type NodeHealth struct {
+	Name       string `json:"name"`
+	InstanceID string `json:"instanceID,omitempty"`
+	Address    string `json:"address,omitempty"`
+	Master     bool   `json:"master"`
+	// Ready is the status of node Ready condition, True, False or Unknown.
+	Ready string `json:"ready"`
+	// Pressures are the pressure conditions of node which are true, e.g. MemoryPressure and DiskPressure.
+	Pressures      []string `json:"pressures,omitempty"`
+	InstanceStatus string   `json:"instanceStatus,omitempty"`
+	SSHReachable   *bool    `json:"sshReachable,omitempty"`
+	Problems       []string `json:"problems,omitempty"`
+}
+
+// ClusterHealthCheck struct for the result of a health check of cluster.
+type ClusterHealthCheck struct {
+	Status string `json:"status"`
+	// APIServerLatency is the latency of apiserver /readyz in milliseconds.
+	APIServerLatency int64        `json:"apiServerLatency"`
+	Problems         []string     `json:"problems,omitempty"`
+	Nodes            []NodeHealth `json:"nodes,omitempty"`
+	CheckedAt        time.Time    `json:"checkedAt"`
+}
+
+// ClusterHealth struct for the latest health and health history of cluster.
+type ClusterHealth struct {
+	Cluster          string               `json:"cluster"`
+	Status           string               `json:"status"`
+	APIServerLatency int64                `json:"apiServerLatency"`
+	Problems         []string             `json:"problems,omitempty"`
+	Nodes            []NodeHealth         `json:"nodes,omitempty"`
+	CheckedAt        *time.Time           `json:"checkedAt,omitempty"`
+	History          []ClusterHealthCheck `json:"history,omitempty"`
+	// Repairs are the latest repairs of the failed workers by auto-heal.
+	Repairs []NodeRepair `json:"repairs,omitempty"`
+}
+
+// NodeRepair struct for the repair of a failed worker by auto-heal.
+type NodeRepair struct {
+	Cluster    string `json:"cluster"`
+	InstanceID string `json:"instanceID"`
+	Node       string `json:"node,omitempty"`
+	// Reason is why the worker is considered failed, e.g. the instance is terminated.
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	Message    string     `json:"message,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ImportImageInput struct for import-image action of k3d cluster.
+type ImportImageInput struct {
+	// Images are the names of the images in docker or the paths of the image tarballs.
+	Images []string `json:"images"`
+	// Mode is the import mode of k3d, auto, direct or tools-node, default to auto.
+	Mode string `json:"mode,omitempty"`
+}
+
+// K3dRegistry struct for the k3d-managed registry which can be shared by the k3d clusters.
+type K3dRegistry struct {
+	Name string `json:"name"`
+	// Host is the container name of the registry, which is the registry host inside the clusters.
+	Host string `json:"host,omitempty"`
+	// Port is the registry port exposed on the docker host in [HOST:]HOSTPORT, default to a random port.
+	Port           string `json:"port,omitempty"`
+	Image          string `json:"image,omitempty"`
+	ProxyRemoteURL string `json:"proxyRemoteURL,omitempty"`
+	ProxyUsername  string `json:"proxyUsername,omitempty"`
+	ProxyPassword  string `json:"proxyPassword,omitempty"`
+	Status         string `json:"status,omitempty"`
+}
@@ -65,6 +65,15 @@ type Metadata struct {
 	ServerConfigFile         string      `json:"server-config-file,omitempty" yaml:"server-config-file,omitempty"`
 	AgentConfigFileContent   string      `json:"agent-config-file-content,omitempty" yaml:"agent-config-file-content,omitempty"`
 	AgentConfigFile          string      `json:"agent-config-file,omitempty" yaml:"agent-config-file,omitempty"`
+	BuiltinRegistry          bool        `json:"builtin-registry" yaml:"builtin-registry" gorm:"type:bool"`
+	// ControlPlaneEndpoint provisions a load balancer or VIP in front of the masters of HA cluster, the address of the
+	// endpoint is set as the IP of cluster.
+	ControlPlaneEndpoint bool `json:"control-plane-endpoint" yaml:"control-plane-endpoint" gorm:"type:bool"`
+	// NodePools are the named worker pools of cluster, the workers joined to a pool are created with the options
+	// override of the pool and registered with its labels, taints and extra args.
+	NodePools NodePools `json:"node-pools,omitempty" yaml:"node-pools,omitempty" gorm:"type:nodePools"`
+	// NodePool is the pool of the workers to be joined.
+	NodePool string `json:"node-pool,omitempty" yaml:"node-pool,omitempty" gorm:"-"`
 }
 
 // Status struct for status.
@@ -88,6 +97,8 @@ type Node struct {
 	RollBack          bool     `json:"-" yaml:"-"`
 	Current           bool     `json:"-" yaml:"-"`
 	Standalone        bool     `json:"standalone"`
+	Pool              string   `json:"pool,omitempty" yaml:"pool,omitempty"`
+	Zone              string   `json:"zone,omitempty" yaml:"zone,omitempty"`
 
 	LocalHostname string `json:"local-hostname,omitempty" yaml:"local-hostname,omitempty"`
 }
@@ -143,6 +154,7 @@ type ClusterInfo struct {
 	Nodes         []ClusterNode `json:"nodes,omitempty"`
 	IsHAMode      bool          `json:"is-ha-mode,omitempty"`
 	DataStoreType string        `json:"datastore-type,omitempty"`
+	NodePools     NodePools     `json:"node-pools,omitempty"`
 }
 
 // ClusterNode struct for cluster node.
@@ -158,6 +170,8 @@ type ClusterNode struct {
 	Version                 string   `json:"version,omitempty"`
 	Master                  bool     `json:"-"`
 	Standalone              bool     `json:"standalone"`
+	Pool                    string   `json:"pool,omitempty"`
+	Zone                    string   `json:"zone,omitempty"`
 }
 
 // StringArray gorm custom string array flag type.
@@ -231,3 +245,54 @@ func (ss StringMap) Value() (driver.Value, error) {
 func (ss StringMap) GormDataType() string {
 	return "bytes"
 }
+
+// NodePool struct for the named worker pool of cluster.
+type NodePool struct {
+	Name string `json:"name" yaml:"name"`
+	// Options overrides the provider options by the option names, e.g. instance-type=t3.large.
+	Options   StringMap   `json:"options,omitempty" yaml:"options,omitempty"`
+	Labels    StringArray `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Taints    StringArray `json:"taints,omitempty" yaml:"taints,omitempty"`
+	ExtraArgs string      `json:"extra-args,omitempty" yaml:"extra-args,omitempty"`
+}
+
+// NodePools gorm custom node pool list type.
+type NodePools []NodePool
+
+// Get returns the pool of the name, nil if the pool is not exist.
+func (np NodePools) Get(name string) *NodePool {
+	for i := range np {
+		if np[i].Name == name {
+			return &np[i]
+		}
+	}
+	return nil
+}
+
+func (np *NodePools) Scan(value interface{}) (err error) {
+	var ba []byte
+	switch v := value.(type) {
+	case string:
+		ba = []byte(v)
+	case []byte:
+		ba = v
+	default:
+		return fmt.Errorf("failed to scan value %v", value)
+	}
+	t := NodePools{}
+	err = json.Unmarshal(ba, &t)
+	*np = t
+	return err
+}
+
+func (np NodePools) Value() (driver.Value, error) {
+	if len(np) == 0 {
+		return nil, nil
+	}
+	ba, err := json.Marshal(np)
+	return string(ba), err
+}
+
+func (np NodePools) GormDataType() string {
+	return "bytes"
+}
@@ -15,6 +15,8 @@ type Options struct {
 	VpcID                        string   `json:"vpc-id,omitempty" yaml:"vpc-id,omitempty"`
 	SubnetID                     string   `json:"subnet-id,omitempty" yaml:"subnet-id,omitempty"`
 	Zone                         string   `json:"zone,omitempty" yaml:"zone,omitempty"`
+	Zones                        []string `json:"zones,omitempty" yaml:"zones,omitempty"`
+	SubnetIDs                    []string `json:"subnet-ids,omitempty" yaml:"subnet-ids,omitempty"`
 	IamInstanceProfileForControl string   `json:"iam-instance-profile-control,omitempty" yaml:"iam-instance-profile-control,omitempty"`
 	IamInstanceProfileForWorker  string   `json:"iam-instance-profile-worker,omitempty" yaml:"iam-instance-profile-worker,omitempty"`
 	RequestSpotInstance          bool     `json:"request-spot-instance,omitempty" yaml:"request-spot-instance,omitempty"`
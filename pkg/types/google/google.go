@@ -3,6 +3,7 @@ package google
 type Options struct {
 	Region                 string   `json:"region,omitempty" yaml:"region,omitempty"`
 	Zone                   string   `json:"zone,omitempty" yaml:"zone,omitempty"`
+	Zones                  []string `json:"zones,omitempty" yaml:"zones,omitempty"`
 	MachineType            string   `json:"machine-type,omitempty" yaml:"machine-type,omitempty"`
 	MachineImage           string   `json:"machine-image,omitempty" yaml:"machine-image,omitempty"`
 	DiskType               string   `json:"disk-type,omitempty" yaml:"disk-type,omitempty"`
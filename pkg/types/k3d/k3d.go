@@ -11,6 +11,7 @@ type Options struct {
 	NoLB          bool     `json:"no-lb,omitempty" yaml:"no-lb,omitempty"`
 	NoImageVolume bool     `json:"no-image-volume,omitempty" yaml:"no-image-volume,omitempty"`
 	Ports         []string `json:"ports,omitempty" yaml:"ports,omitempty"`
+	RegistryUse   []string `json:"registry-use,omitempty" yaml:"registry-use,omitempty"`
 	Volumes       []string `json:"volumes,omitempty" yaml:"volumes,omitempty"`
 	WorkersMemory string   `json:"workers-memory,omitempty" yaml:"workers-memory,omitempty"`
 }
@@ -4,4 +4,7 @@ package native
 type Options struct {
 	MasterIps string `json:"master-ips,omitempty" yaml:"master-ips,omitempty"`
 	WorkerIps string `json:"worker-ips,omitempty" yaml:"worker-ips,omitempty"`
+	// VIP is the virtual IP announced by kube-vip on masters as the control-plane endpoint.
+	VIP          string `json:"vip,omitempty" yaml:"vip,omitempty"`
+	VIPInterface string `json:"vip-interface,omitempty" yaml:"vip-interface,omitempty"`
 }
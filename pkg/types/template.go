@@ -0,0 +1,60 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Parameter types of the cluster template.
+const (
+	TemplateParameterTypeString = "string"
+	TemplateParameterTypeInt    = "int"
+	TemplateParameterTypeBool   = "bool"
+)
+
+// TemplateParameter is the named parameter of cluster template, the fields of template refer to the parameter by ${name}.
+type TemplateParameter struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Type is one of string, int and bool, the default type is string.
+	Type     string `json:"type,omitempty" yaml:"type,omitempty"`
+	Default  string `json:"default,omitempty" yaml:"default,omitempty"`
+	Required bool   `json:"required,omitempty" yaml:"required,omitempty"`
+	// Pattern is the regular expression which the whole value must match.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+}
+
+// TemplateParameters gorm custom template parameter list type.
+type TemplateParameters []TemplateParameter
+
+// Scan gorm Scan implement.
+func (tp *TemplateParameters) Scan(value interface{}) (err error) {
+	var ba []byte
+	switch v := value.(type) {
+	case string:
+		ba = []byte(v)
+	case []byte:
+		ba = v
+	default:
+		return fmt.Errorf("failed to scan value %v", value)
+	}
+	t := []TemplateParameter{}
+	err = json.Unmarshal(ba, &t)
+	*tp = t
+	return err
+}
+
+// Value gorm Value implement.
+func (tp TemplateParameters) Value() (driver.Value, error) {
+	if len(tp) == 0 {
+		return nil, nil
+	}
+	ba, err := json.Marshal(tp)
+	return string(ba), err
+}
+
+// GormDataType returns gorm data type.
+func (tp TemplateParameters) GormDataType() string {
+	return "bytes"
+}
@@ -5,6 +5,8 @@ const (
 	StatusPending = "PENDING"
 	// StatusRunning tencent instance running status.
 	StatusRunning = "RUNNING"
+	// StatusStopped tencent instance stopped status.
+	StatusStopped = "STOPPED"
 
 	// Success tencent task success result.
 	Success = "SUCCESS"
@@ -25,11 +27,13 @@ type Options struct {
 	SecretKey               string   `json:"secret-key,omitempty" yaml:"secret-key,omitempty"`
 	Region                  string   `json:"region,omitempty" yaml:"region,omitempty"`
 	Zone                    string   `json:"zone,omitempty" yaml:"zone,omitempty"`
+	Zones                   []string `json:"zones,omitempty" yaml:"zones,omitempty"`
 	EndpointURL             string   `json:"endpoint-url,omitempty" yaml:"endpoint-url,omitempty"`
 	SecurityGroupIds        string   `json:"security-group,omitempty" yaml:"security-group,omitempty"`
 	KeypairID               string   `json:"keypair-id,omitempty" yaml:"keypair-id,omitempty"`
 	VpcID                   string   `json:"vpc,omitempty" yaml:"vpc,omitempty"`
 	SubnetID                string   `json:"subnet,omitempty" yaml:"subnet,omitempty"`
+	SubnetIDs               []string `json:"subnets,omitempty" yaml:"subnets,omitempty"`
 	ImageID                 string   `json:"image,omitempty" yaml:"image,omitempty"`
 	InstanceType            string   `json:"instance-type,omitempty" yaml:"instance-type,omitempty"`
 	InstanceChargeType      string   `json:"instance-charge-type,omitempty" yaml:"instance-charge-type,omitempty"`